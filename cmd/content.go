@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+)
+
+// contentCmd groups the commands moving items between databases.
+var contentCmd = &cobra.Command{
+	Use:   "content",
+	Short: "Export and import the items of a collection as JSON Lines or CSV.",
+	Long: `Move items between databases. Relations are exported as the unique attribute values of
+the related items and resolved against the target database on import, so related
+collections should be imported first.`,
+}
+
+var contentExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write every item of a collection to a file or stdout.",
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, _ := cmd.Flags().GetString("config")
+		name, _ := cmd.Flags().GetString("collection")
+		format, _ := cmd.Flags().GetString("format")
+		path, _ := cmd.Flags().GetString("file")
+		collection := initContentCommand(configPath, name)
+
+		out := io.Writer(os.Stdout)
+		if path != "-" {
+			file, err := os.Create(path)
+			if err != nil {
+				exitWithError(err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		written, err := storage.ExportItems(out, *collection, format)
+		if err != nil {
+			exitWithError(err)
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d item(s) of '%s'\n", written, collection.Name)
+	},
+}
+
+var contentImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create or update the items of a collection from a file or stdin.",
+	Long: `Validate every record like an API write and create it, or update the item with the same
+--upsert-on value. Invalid records are reported by line and skipped; the command exits
+with an error once done if any record failed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, _ := cmd.Flags().GetString("config")
+		name, _ := cmd.Flags().GetString("collection")
+		path, _ := cmd.Flags().GetString("file")
+		opts := storage.ImportOptions{Actor: models.SystemActor("content-import")}
+		opts.Format, _ = cmd.Flags().GetString("format")
+		opts.UpsertOn, _ = cmd.Flags().GetString("upsert-on")
+		collection := initContentCommand(configPath, name)
+
+		in := io.Reader(os.Stdin)
+		if path != "-" {
+			file, err := os.Open(path)
+			if err != nil {
+				exitWithError(err)
+			}
+			defer file.Close()
+			in = file
+		}
+
+		report, err := storage.ImportItems(in, *collection, opts)
+		if err != nil {
+			exitWithError(err)
+		}
+		fmt.Printf("Imported %d record(s) into '%s': %d created, %d updated, %d failed\n",
+			report.Total, collection.Name, report.Created, report.Updated, report.Failed)
+		for _, rowErr := range report.Errors {
+			fmt.Printf("  line %d: %s\n", rowErr.Line, rowErr.Error)
+		}
+		if report.Failed > len(report.Errors) {
+			fmt.Printf("  ... and %d more\n", report.Failed-len(report.Errors))
+		}
+		if report.Failed > 0 {
+			exitWithError(fmt.Errorf("%d record(s) failed to import", report.Failed))
+		}
+	},
+}
+
+func init() {
+	for _, command := range []*cobra.Command{contentExportCmd, contentImportCmd} {
+		command.Flags().StringP("config", "c", "config.yaml", "Path to the configuration file")
+		command.Flags().String("collection", "", "Name of the collection")
+		command.Flags().String("format", storage.TransferFormatJSONL, "Format of the items: jsonl or csv")
+		command.Flags().StringP("file", "f", "-", "Path of the file, - for stdin or stdout")
+		_ = command.MarkFlagRequired("collection")
+		contentCmd.AddCommand(command)
+	}
+	contentImportCmd.Flags().String("upsert-on", "", "Attribute identifying the existing item a record updates")
+	rootCmd.AddCommand(contentCmd)
+}
+
+// initContentCommand opens the database and loads the collection of a content command.
+// Logs go to stderr, so stdout only carries the exported items.
+func initContentCommand(cfgPath, name string) *models.Collection {
+	initCommandDatabase(cfgPath)
+	logger.Log.SetOutput(os.Stderr)
+
+	collection, err := storage.GetCollectionByName(name)
+	if err != nil {
+		exitWithError(err)
+	}
+	return collection
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 	"github.com/spf13/cobra"
 	gormlogger "gorm.io/gorm/logger"
 
@@ -20,7 +21,9 @@ var dispatcherCmd = &cobra.Command{
 	Short: "Starts the background dispatcher for processing collection events.",
 	Long: `The dispatcher connects to Redis and listens for generic collection events 
 (e.g., item created, updated, deleted). It then finds agents subscribed to these 
-events and enqueues specific jobs for the agent worker to process.`,
+events and enqueues specific jobs for the agent worker to process, as well as the
+deliveries of the webhooks subscribed to them. Every event is also broadcast to the
+API servers, which push it to their GraphQL subscriptions.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		configPath, _ := cmd.Flags().GetString("config")
 		runDispatcher(configPath)
@@ -74,6 +77,12 @@ func initializeDispatcher(cfgPath string) (*asynq.Server, *asynq.ServeMux, error
 
 	// Create the handler (dispatcher), passing the client it needs.
 	eventDispatcher := dispatcher.NewEventDispatcher(client)
+	eventDispatcher.EmbedItems = cfg.Embeddings.Provider != ""
+	eventDispatcher.Broadcast = redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
 
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(events.TaskTypeCollectionEvent, eventDispatcher.HandleCollectionEvent)
@@ -10,6 +10,7 @@ import (
 	"github.com/gohead-cms/gohead/pkg/config"
 	"github.com/gohead-cms/gohead/pkg/database"
 	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
 	"github.com/hibiken/asynq"
 	"github.com/spf13/cobra"
 	gormlogger "gorm.io/gorm/logger"
@@ -21,6 +22,8 @@ var schedulerCmd = &cobra.Command{
 	Short: "Starts the background scheduler for cron-based agent jobs.",
 	Long: `The scheduler is a long-running process that connects to the database to find
 agents with cron triggers and enqueues jobs for them at the specified times.
+It also enqueues the periodic purges of the items kept in the trash past their retention period
+and of the expired GraphQL persisted queries.
 It is recommended to run only one instance of the scheduler in a production environment.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		configPath, _ := cmd.Flags().GetString("config")
@@ -65,6 +68,32 @@ func runScheduler(cfgPath string) {
 	// Start the actual cron scheduler.
 	triggers.StartScheduler()
 
+	// Deleted items past the retention period, and the persisted queries registered by
+	// clients past their expiry, are purged every hour.
+	var periodicTasks []*asynq.Task
+	if cfg.Trash.RetentionDays > 0 {
+		task, err := storage.NewPurgeTrashTask(cfg.Trash.RetentionDays)
+		if err != nil {
+			logger.Log.WithError(err).Fatal("Failed to create trash purge task")
+		}
+		periodicTasks = append(periodicTasks, task)
+	}
+	if persisted := cfg.GraphQL.PersistedQueries; persisted.Store == "database" && persisted.TTLHours > 0 {
+		periodicTasks = append(periodicTasks, storage.NewPurgePersistedQueriesTask())
+	}
+	var periodic *asynq.Scheduler
+	if len(periodicTasks) > 0 {
+		periodic = asynq.NewScheduler(asynq.RedisClientOpt{Addr: cfg.Redis.Address}, nil)
+		for _, task := range periodicTasks {
+			if _, err := periodic.Register("@hourly", task); err != nil {
+				logger.Log.WithError(err).WithField("task", task.Type()).Fatal("Failed to schedule periodic task")
+			}
+		}
+		if err := periodic.Start(); err != nil {
+			logger.Log.WithError(err).Fatal("Failed to start periodic task scheduler")
+		}
+	}
+
 	logger.Log.Info("Scheduler started successfully. Waiting for jobs...")
 
 	// Wait indefinitely until a termination signal is received.
@@ -73,4 +102,7 @@ func runScheduler(cfgPath string) {
 	<-quit
 
 	logger.Log.Info("Shutting down scheduler...")
+	if periodic != nil {
+		periodic.Shutdown()
+	}
 }
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/gohead-cms/gohead/pkg/config"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/migrations"
+	"github.com/gohead-cms/gohead/pkg/schema"
+	"github.com/gohead-cms/gohead/pkg/storage"
+)
+
+// schemaCmd groups the commands managing the content model as code.
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Export and apply the content model as YAML files.",
+	Long: `Manage collections, singletons, components and agents as YAML files, one file per
+definition under the collections/, singletons/, components/ and agents/ subdirectories.
+Running servers pick up applied changes on their next schema reload or restart.`,
+}
+
+var schemaExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write every definition stored in the database to a directory.",
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, _ := cmd.Flags().GetString("config")
+		dir, _ := cmd.Flags().GetString("dir")
+		initCommandDatabase(configPath)
+
+		written, err := schema.Export(dir)
+		if err != nil {
+			exitWithError(err)
+		}
+		fmt.Printf("Exported %d definition(s) to %s\n", written, dir)
+	},
+}
+
+var schemaApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Reconcile the database against the definitions of a directory.",
+	Long: `Compare the definitions of a directory with the database, print the plan and apply it.
+Applying an unchanged directory again does nothing. Collection updates breaking existing
+items are refused unless --force is given, and definitions missing from the directory are
+only deleted with --prune.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, _ := cmd.Flags().GetString("config")
+		dir, _ := cmd.Flags().GetString("dir")
+		opts := schema.Options{}
+		opts.Prune, _ = cmd.Flags().GetBool("prune")
+		opts.Force, _ = cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		initCommandDatabase(configPath)
+
+		defs, err := schema.Load(dir)
+		if err != nil {
+			exitWithError(err)
+		}
+		plan, err := schema.Diff(defs, opts)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		if plan.Empty() {
+			fmt.Printf("Schema is up to date (%d definition(s) unchanged)\n", plan.Unchanged)
+			return
+		}
+		fmt.Printf("Plan: %d change(s), %d definition(s) unchanged\n", len(plan.Actions), plan.Unchanged)
+		for _, action := range plan.Actions {
+			fmt.Println("  " + action.String())
+			for _, change := range action.Changes {
+				fmt.Printf("      [%s] %s\n", change.Severity, change.Message)
+			}
+		}
+		if dryRun {
+			return
+		}
+
+		if err := schema.Apply(context.Background(), plan, opts); err != nil {
+			exitWithError(err)
+		}
+		fmt.Println("Schema applied")
+	},
+}
+
+func init() {
+	for _, command := range []*cobra.Command{schemaExportCmd, schemaApplyCmd} {
+		command.Flags().StringP("config", "c", "config.yaml", "Path to the configuration file")
+		command.Flags().StringP("dir", "d", "schema", "Directory of the definition files")
+		schemaCmd.AddCommand(command)
+	}
+	schemaApplyCmd.Flags().Bool("prune", false, "Delete definitions missing from the directory")
+	schemaApplyCmd.Flags().Bool("dry-run", false, "Print the plan without applying it")
+	schemaApplyCmd.Flags().Bool("force", false, "Apply collection updates that break existing items")
+	rootCmd.AddCommand(schemaCmd)
+}
+
+// initCommandDatabase loads the configuration and opens the migrated database.
+func initCommandDatabase(cfgPath string) {
+	cfg, err := config.LoadConfig(cfgPath)
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to load configuration: %w", err))
+	}
+	logger.InitLogger(cfg.LogLevel)
+
+	var gormLogLevel gormlogger.LogLevel
+	switch cfg.LogLevel {
+	case "debug":
+		gormLogLevel = gormlogger.Info
+	case "info", "warn", "warning":
+		gormLogLevel = gormlogger.Warn
+	case "error":
+		gormLogLevel = gormlogger.Error
+	default:
+		gormLogLevel = gormlogger.Silent
+	}
+
+	db, err := database.InitDatabase(cfg.DatabaseURL, gormLogLevel)
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to initialize database: %w", err))
+	}
+	if err := migrations.MigrateDatabase(db); err != nil {
+		exitWithError(fmt.Errorf("failed to migrate database: %w", err))
+	}
+	if err := storage.InitSearchIndex(db); err != nil {
+		exitWithError(fmt.Errorf("failed to initialize search index: %w", err))
+	}
+}
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	os.Exit(1)
+}
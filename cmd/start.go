@@ -2,17 +2,25 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/gohead-cms/gohead/internal/agent/events"
 	"github.com/gohead-cms/gohead/internal/agent/triggers"
 	"github.com/gohead-cms/gohead/internal/api/handlers"
 	"github.com/gohead-cms/gohead/internal/api/middleware"
 	"github.com/gohead-cms/gohead/internal/graphql"
+	"github.com/gohead-cms/gohead/internal/models"
 	"github.com/gohead-cms/gohead/pkg/auth"
 	"github.com/gohead-cms/gohead/pkg/config"
 	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/llm"
 	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/media"
 	"github.com/gohead-cms/gohead/pkg/metrics"
 	"github.com/gohead-cms/gohead/pkg/migrations"
 	"github.com/gohead-cms/gohead/pkg/seed"
@@ -22,6 +30,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/hibiken/asynq"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"github.com/spf13/cobra"
 	ginlogrus "github.com/toorop/gin-logrus"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
@@ -88,8 +97,21 @@ func InitializeServer(cfgPath string) (*gin.Engine, error) {
 	if err := migrations.MigrateDatabase(db); err != nil {
 		return nil, err
 	}
+	if err := storage.InitSearchIndex(db); err != nil {
+		return nil, err
+	}
+	if cfg.Embeddings.Provider != "" {
+		embedder, err := llm.NewEmbedder(cfg.Embeddings)
+		if err != nil {
+			return nil, err
+		}
+		if err := storage.InitSemanticSearch(db, embedder); err != nil {
+			return nil, err
+		}
+	}
 
 	// Initialize the GraphQL Schema
+	graphql.SetQueryLimits(cfg.GraphQL.MaxDepth, cfg.GraphQL.MaxComplexity)
 	if err := graphql.InitializeGraphQLSchema(); err != nil {
 		logger.Log.WithError(err).Error("Failed to initialize GraphQL schema")
 		return nil, err
@@ -105,6 +127,43 @@ func InitializeServer(cfgPath string) (*gin.Engine, error) {
 	asynqClient := asynq.NewClient(asynq.RedisClientOpt{Addr: cfg.Redis.Address})
 	storage.InitAsynqClient(asynqClient)
 	triggers.InitAsynqClient(asynqClient)
+	media.InitAsynqClient(asynqClient)
+	handlers.InitAsynqClient(asynqClient)
+
+	// --- Event Broadcast ---
+	// The dispatcher broadcasts collection events to every API server, which reloads
+	// its GraphQL schema and notifies the subscriptions of its clients.
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	go listenForEvents(redisClient)
+
+	// --- Persisted Queries ---
+	persisted := cfg.GraphQL.PersistedQueries
+	persistedTTL := time.Duration(persisted.TTLHours) * time.Hour
+	switch persisted.Store {
+	case "database":
+		graphql.SetPersistedQueries(graphql.DatabaseQueryStore{TTL: persistedTTL}, persisted.AllowList)
+	case "redis":
+		graphql.SetPersistedQueries(graphql.RedisQueryStore{Client: redisClient, TTL: persistedTTL}, persisted.AllowList)
+	case "none", "":
+		if persisted.AllowList {
+			return nil, errors.New("the persisted query allow-list requires a persisted query store")
+		}
+	default:
+		return nil, fmt.Errorf("unknown persisted query store '%s'", persisted.Store)
+	}
+
+	// --- Media Library ---
+	mediaDriver, err := media.NewDriver(cfg.Media)
+	if err != nil {
+		return nil, err
+	}
+	if err := media.Init(mediaDriver, cfg.Media); err != nil {
+		return nil, err
+	}
 
 	// --- Telemetry (Optional) ---
 	if cfg.TelemetryEnabled {
@@ -141,9 +200,28 @@ func InitializeServer(cfgPath string) (*gin.Engine, error) {
 	// --- Routes ---
 	setupRoutes(router)
 
+	// Files stored by the local media driver are served by the API itself
+	// unless they are published under an external base URL. They are served
+	// with headers preventing uploaded documents from running scripts.
+	if local := cfg.Media.Local; mediaDriver.Name() == "local" && strings.HasPrefix(local.BaseURL, "/") {
+		router.Group(local.BaseURL, middleware.MediaHeaders()).Static("/", local.Directory)
+	}
+
 	return router, nil
 }
 
+// listenForEvents handles the collection events broadcast by the dispatcher: schema
+// changes reload the GraphQL schema and item changes are pushed to subscriptions.
+func listenForEvents(client redis.UniversalClient) {
+	events.ListenCollectionEvents(context.Background(), client, func(payload events.CollectionEventPayload) {
+		if strings.HasPrefix(string(payload.EventType), "collection:") {
+			triggers.TriggerSchemaReload()
+			return
+		}
+		graphql.PublishEvent(payload)
+	})
+}
+
 func setupRoutes(router *gin.Engine) {
 	// Monitoring & Healthcheck
 	router.GET("/_metrics", gin.WrapH(promhttp.Handler()))
@@ -161,51 +239,120 @@ func setupRoutes(router *gin.Engine) {
 	// Agent Webhook Trigger (Public, authenticates with a token)
 	router.POST("/agents/webhook/:id", handlers.HandleWebhook)
 
+	// Media files, resized and converted on demand (Public, like the stored files)
+	router.GET("/media/:id", middleware.MediaHeaders(), handlers.GetMediaFile)
+
 	// ADMIN routes (schema/definition)
+	// Each route requires the 'manage' permission on its resource, e.g. collections.articles.manage.
 	admin := router.Group("/admin")
-	admin.Use(middleware.AuthMiddleware(), middleware.AdminOnly())
+	admin.Use(middleware.AuthMiddleware())
 	{
+		manage := func(resource, nameParam string) gin.HandlerFunc {
+			return middleware.RequirePermission(resource, models.ActionManage, nameParam)
+		}
+
 		// Collections
-		admin.POST("/collections", handlers.CreateCollection)
-		admin.GET("/collections", handlers.GetCollections)
-		admin.GET("/collections/:name", handlers.GetCollection)
-		admin.PUT("/collections/:name", handlers.UpdateCollection)
-		admin.DELETE("/collections/:name", handlers.DeleteCollection)
+		admin.POST("/collections", manage(models.ResourceCollections, ""), handlers.CreateCollection)
+		admin.GET("/collections", manage(models.ResourceCollections, ""), handlers.GetCollections)
+		admin.GET("/collections/:name", manage(models.ResourceCollections, "name"), handlers.GetCollection)
+		admin.PUT("/collections/:name", manage(models.ResourceCollections, "name"), handlers.UpdateCollection)
+		admin.DELETE("/collections/:name", manage(models.ResourceCollections, "name"), handlers.DeleteCollection)
 
 		// Singletons
-		admin.POST("/singleton", handlers.CreateOrUpdateSingleton)
-		admin.GET("/singleton/:name", handlers.GetSingleton)
-		admin.PUT("/singleton/:name", handlers.CreateOrUpdateSingleton)
-		admin.DELETE("/singleton/:name", handlers.DeleteSingleton)
+		admin.POST("/singleton", manage(models.ResourceSingletons, ""), handlers.CreateOrUpdateSingleton)
+		admin.GET("/singleton/:name", manage(models.ResourceSingletons, "name"), handlers.GetSingleton)
+		admin.PUT("/singleton/:name", manage(models.ResourceSingletons, "name"), handlers.CreateOrUpdateSingleton)
+		admin.DELETE("/singleton/:name", manage(models.ResourceSingletons, "name"), handlers.DeleteSingleton)
 
 		// Components
-		admin.POST("/components", handlers.CreateComponent)
-		admin.GET("/components/:name", handlers.GetComponent)
-		admin.PUT("/components/:name", handlers.UpdateComponent)
-		admin.DELETE("/components/:name", handlers.DeleteComponent)
+		admin.POST("/components", manage(models.ResourceComponents, ""), handlers.CreateComponent)
+		admin.GET("/components/:name", manage(models.ResourceComponents, "name"), handlers.GetComponent)
+		admin.PUT("/components/:name", manage(models.ResourceComponents, "name"), handlers.UpdateComponent)
+		admin.DELETE("/components/:name", manage(models.ResourceComponents, "name"), handlers.DeleteComponent)
 
 		// Agents
 		agents := admin.Group("/agents")
 		{
-			agents.POST("/", handlers.CreateAgent)
-			agents.GET("", handlers.GetAgents)
-			agents.GET("/:name", handlers.GetAgent)
-			agents.PUT("/:name", handlers.UpdateAgent)
-			agents.DELETE("/:name", handlers.DeleteAgent)
+			agents.POST("/", manage(models.ResourceAgents, ""), handlers.CreateAgent)
+			agents.GET("", manage(models.ResourceAgents, ""), handlers.GetAgents)
+			agents.GET("/:name", manage(models.ResourceAgents, "name"), handlers.GetAgent)
+			agents.PUT("/:name", manage(models.ResourceAgents, "name"), handlers.UpdateAgent)
+			agents.DELETE("/:name", manage(models.ResourceAgents, "name"), handlers.DeleteAgent)
+		}
+
+		// Roles
+		roles := admin.Group("/roles", manage(models.ResourceRoles, "name"))
+		{
+			roles.POST("", handlers.CreateRole)
+			roles.GET("", handlers.GetRoles)
+			roles.GET("/:name", handlers.GetRole)
+			roles.PUT("/:name", handlers.UpdateRole)
+			roles.DELETE("/:name", handlers.DeleteRole)
+		}
+
+		// GraphQL persisted query manifests
+		admin.POST("/graphql/queries", manage(models.ResourceGraphQL, ""), handlers.UploadPersistedQueries)
+
+		// Webhooks
+		webhooks := admin.Group("/webhooks")
+		{
+			webhooks.POST("", manage(models.ResourceWebhooks, ""), handlers.CreateWebhook)
+			webhooks.GET("", manage(models.ResourceWebhooks, ""), handlers.GetWebhooks)
+			webhooks.GET("/:name", manage(models.ResourceWebhooks, "name"), handlers.GetWebhook)
+			webhooks.PUT("/:name", manage(models.ResourceWebhooks, "name"), handlers.UpdateWebhook)
+			webhooks.DELETE("/:name", manage(models.ResourceWebhooks, "name"), handlers.DeleteWebhook)
+			webhooks.GET("/:name/deliveries", manage(models.ResourceWebhooks, "name"), handlers.GetWebhookDeliveries)
+			webhooks.GET("/:name/deliveries/:id", manage(models.ResourceWebhooks, "name"), handlers.GetWebhookDelivery)
+			webhooks.POST("/:name/deliveries/:id/redeliver", manage(models.ResourceWebhooks, "name"), handlers.RedeliverWebhook)
 		}
 	}
 
+	// GraphQL over WebSocket (authenticates in the connection_init message, since
+	// browsers cannot set headers on WebSockets)
+	router.GET("/api/graphql", handlers.GraphQLSubscriptionHandler)
+
 	// CONTENT routes (actual data/items)
 	content := router.Group("/api")
 	content.Use(middleware.AuthMiddleware())
 	{
 		content.POST("/graphql", handlers.GraphQLHandler)
+		content.GET("/search", handlers.SearchItems)
+		content.GET("/search/semantic", handlers.SemanticSearch)
 
 		// Dynamic Handlers
 		content.Any("/collections/:collection", handlers.DynamicCollectionHandler)
+		content.POST("/collections/:collection/bulk", handlers.BulkItems)
+		content.GET("/collections/:collection/trash", handlers.GetTrash)
+		content.DELETE("/collections/:collection/trash", handlers.EmptyTrash)
+		content.POST("/collections/:collection/trash/:id/restore", handlers.RestoreTrashItem)
+		content.DELETE("/collections/:collection/trash/:id", handlers.PurgeTrashItem)
 		content.Any("/collections/:collection/:id", handlers.DynamicCollectionHandler)
+		content.POST("/collections/:collection/:id/publish", handlers.PublishItem)
+		content.POST("/collections/:collection/:id/unpublish", handlers.UnpublishItem)
+		content.PUT("/collections/:collection/:id/schedule", handlers.ScheduleItem)
+		content.GET("/collections/:collection/:id/locales", handlers.GetItemLocales)
+		content.GET("/collections/:collection/:id/references", handlers.GetItemReferences)
+		content.GET("/collections/:collection/:id/revisions", handlers.GetItemRevisions)
+		content.GET("/collections/:collection/:id/revisions/diff", handlers.DiffItemRevisions)
+		content.GET("/collections/:collection/:id/revisions/:version", handlers.GetItemRevision)
+		content.POST("/collections/:collection/:id/revisions/:version/restore", handlers.RestoreItemRevision)
 		content.GET("/singleton/:name", handlers.GetSingleItem)
 		content.POST("/singleton/:name", handlers.CreateOrUpdateSingletonItem)
 		content.PUT("/singleton/:name", handlers.CreateOrUpdateSingletonItem)
+		content.PATCH("/singleton/:name", handlers.PatchSingleItem)
+		content.POST("/singleton/:name/publish", handlers.PublishSingleItem)
+		content.POST("/singleton/:name/unpublish", handlers.UnpublishSingleItem)
+		content.PUT("/singleton/:name/schedule", handlers.ScheduleSingleItem)
+		content.GET("/schedule", handlers.GetScheduledTransitions)
+
+		// Media library
+		mediaAccess := func(action string) gin.HandlerFunc {
+			return middleware.RequirePermission(models.ResourceMedia, action, "")
+		}
+		content.POST("/media", mediaAccess(models.ActionCreate), handlers.UploadAsset)
+		content.GET("/media", mediaAccess(models.ActionRead), handlers.GetAssets)
+		content.GET("/media/:id", mediaAccess(models.ActionRead), handlers.GetAsset)
+		content.PUT("/media/:id", mediaAccess(models.ActionUpdate), handlers.UpdateAsset)
+		content.DELETE("/media/:id", mediaAccess(models.ActionDelete), handlers.DeleteAsset)
 	}
 }
@@ -10,14 +10,18 @@ import (
 	runner "github.com/gohead-cms/gohead/internal/agent/runners"
 	"github.com/gohead-cms/gohead/pkg/config"
 	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/llm"
 	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/media"
+	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/gohead-cms/gohead/pkg/webhooks"
 )
 
 // workerCmd represents the worker command
 var workerCmd = &cobra.Command{
 	Use:   "worker",
-	Short: "Starts the background worker for processing agent jobs.",
-	Long:  `The worker connects to Redis and listens for asynchronous jobs, such as running agents based on cron schedules or webhooks.`,
+	Short: "Starts the background worker for processing agent, media, schema migration, embedding, webhook delivery and scheduled publishing jobs.",
+	Long:  `The worker connects to Redis and listens for asynchronous jobs, such as running agents based on cron schedules or webhooks, generating image variants, rewriting items after schema changes, computing item embeddings, delivering webhooks and publishing items at their scheduled time.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Use the default config path, similar to your start command
 		configPath, _ := cmd.Flags().GetString("config")
@@ -53,9 +57,38 @@ func runWorker(cfgPath string) {
 		gormLogLevel = gormlogger.Silent
 	}
 
-	if _, err := database.InitDatabase(cfg.DatabaseURL, gormLogLevel); err != nil {
+	db, err := database.InitDatabase(cfg.DatabaseURL, gormLogLevel)
+	if err != nil {
 		logger.Log.WithError(err).Fatal("Failed to initialize database")
 	}
+	// Agent tools and schema migrations write items, which the search index follows.
+	if err := storage.InitSearchIndex(db); err != nil {
+		logger.Log.WithError(err).Fatal("Failed to initialize search index")
+	}
+	if cfg.Embeddings.Provider != "" {
+		embedder, err := llm.NewEmbedder(cfg.Embeddings)
+		if err != nil {
+			logger.Log.WithError(err).Fatal("Failed to initialize embedding provider")
+		}
+		if err := storage.InitSemanticSearch(db, embedder); err != nil {
+			logger.Log.WithError(err).Fatal("Failed to initialize semantic search")
+		}
+	}
+
+	// Media processing tasks read and write files through the media driver.
+	mediaDriver, err := media.NewDriver(cfg.Media)
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Failed to initialize media storage")
+	}
+	if err := media.Init(mediaDriver, cfg.Media); err != nil {
+		logger.Log.WithError(err).Fatal("Invalid media configuration")
+	}
+
+	// Webhook deliveries only reach public addresses unless configured otherwise.
+	webhooks.AllowPrivateNetworks(cfg.Webhooks.AllowPrivateNetworks)
+
+	// Scheduled transitions publish items, which emits collection events.
+	storage.InitAsynqClient(asynq.NewClient(asynq.RedisClientOpt{Addr: cfg.Redis.Address}))
 
 	logger.Log.Info("Starting agent worker...")
 
@@ -65,7 +98,15 @@ func runWorker(cfgPath string) {
 		asynq.Config{
 			Concurrency: 10,
 			Logger:      &logger.AsynqLoggerAdapter{},
-			Queues:      map[string]int{"agents": 10},
+			Queues: map[string]int{
+				"agents":                    10,
+				media.QueueName:             5,
+				webhooks.QueueName:          5,
+				storage.ScheduleQueueName:   5,
+				storage.SchemaQueueName:     2,
+				storage.EmbeddingsQueueName: 2,
+			},
+			RetryDelayFunc: webhooks.RetryDelay,
 		},
 	)
 
@@ -75,6 +116,13 @@ func runWorker(cfgPath string) {
 	// 5. Create a new ServeMux to map task types to handlers
 	mux := asynq.NewServeMux()
 	mux.HandleFunc("agent:run", agentRunner.HandleAgentJob)
+	mux.HandleFunc(media.TaskTypeGenerateVariants, media.HandleVariantsTask)
+	mux.HandleFunc(storage.TaskTypeSchemaMigration, storage.HandleSchemaMigrationTask)
+	mux.HandleFunc(storage.TaskTypeEmbeddings, storage.HandleEmbeddingsTask)
+	mux.HandleFunc(webhooks.TaskTypeDeliver, webhooks.HandleDeliveryTask)
+	mux.HandleFunc(storage.TaskTypeScheduledTransition, storage.HandleScheduledTransitionTask)
+	mux.HandleFunc(storage.TaskTypePurgeTrash, storage.HandlePurgeTrashTask)
+	mux.HandleFunc(storage.TaskTypePurgePersistedQueries, storage.HandlePurgePersistedQueriesTask)
 
 	// 6. Start the server
 	logger.Log.Info("Worker is ready and listening for jobs...")
@@ -13,13 +13,21 @@ import (
 	"github.com/gohead-cms/gohead/internal/agent/triggers"
 	"github.com/gohead-cms/gohead/pkg/logger"
 	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/gohead-cms/gohead/pkg/webhooks"
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 )
 
 // EventDispatcher listens for generic collection events and dispatches
-// specific agent jobs to the appropriate queue.
+// specific agent jobs and webhook deliveries to the appropriate queue.
 type EventDispatcher struct {
 	asynqClient *asynq.Client
+	// EmbedItems enqueues the update of item embeddings on item and collection
+	// events, when an embedding provider is configured.
+	EmbedItems bool
+	// Broadcast, when set, receives every event on events.BroadcastChannel, from which
+	// the API servers reload their schema and notify their GraphQL subscriptions.
+	Broadcast redis.UniversalClient
 }
 
 // NewEventDispatcher creates a new dispatcher instance.
@@ -43,6 +51,16 @@ func (d *EventDispatcher) HandleCollectionEvent(ctx context.Context, t *asynq.Ta
 		go triggers.TriggerSchemaReload()
 	}
 
+	if d.EmbedItems {
+		d.enqueueEmbeddings(ctx, payload)
+	}
+
+	if d.Broadcast != nil {
+		if err := events.BroadcastCollectionEvent(ctx, d.Broadcast, payload); err != nil {
+			logger.Log.WithError(err).WithField("event_type", payload.EventType).Error("Failed to broadcast event to the API servers")
+		}
+	}
+
 	logger.Log.
 		WithField("event_type", payload.EventType).
 		WithField("collection", payload.CollectionName).
@@ -56,6 +74,12 @@ func (d *EventDispatcher) HandleCollectionEvent(ctx context.Context, t *asynq.Ta
 		return err // Return the error so Asynq can retry the job.
 	}
 
+	// Notify the webhooks subscribed to this event. This comes once nothing can fail
+	// the job any longer, as a retry would record and send their deliveries again.
+	if err := webhooks.Dispatch(ctx, d.asynqClient, payload); err != nil {
+		logger.Log.WithError(err).WithField("event_type", payload.EventType).Error("Failed to dispatch event to webhooks")
+	}
+
 	if len(subscribedAgents) == 0 {
 		logger.Log.
 			WithField("event_type", payload.EventType).
@@ -108,3 +132,31 @@ func (d *EventDispatcher) HandleCollectionEvent(ctx context.Context, t *asynq.Ta
 
 	return nil
 }
+
+// enqueueEmbeddings enqueues the update of the embeddings affected by an event: those
+// of the item, or of every item when the attributes of the collection changed.
+func (d *EventDispatcher) enqueueEmbeddings(ctx context.Context, payload events.CollectionEventPayload) {
+	var embeddings storage.EmbeddingsPayload
+	switch payload.EventType {
+	case events.EventTypeItemCreated, events.EventTypeItemUpdated, events.EventTypeItemDeleted, events.EventTypeItemRestored:
+		embeddings.ItemIDs = []uint{payload.ItemID}
+	case events.EventTypeCollectionCreated, events.EventTypeCollectionUpdated:
+	default:
+		return
+	}
+
+	collection, err := storage.GetCollectionByName(payload.CollectionName)
+	if err != nil {
+		logger.Log.WithError(err).WithField("collection", payload.CollectionName).Warn("Failed to load collection for embeddings")
+		return
+	}
+	if !collection.HasSemanticAttributes() {
+		return
+	}
+	if embeddings.ItemIDs == nil {
+		embeddings.CollectionID = collection.ID
+	}
+	if err := storage.EnqueueEmbeddings(ctx, d.asynqClient, embeddings); err != nil {
+		logger.Log.WithError(err).WithField("collection", payload.CollectionName).Error("Failed to enqueue embeddings from dispatcher")
+	}
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// BroadcastChannel is the Redis pub/sub channel the dispatcher broadcasts collection
+// events on. Every API server listens to it, so the clients of all of them are notified.
+const BroadcastChannel = "gohead:events"
+
+// BroadcastCollectionEvent publishes a collection event to the API servers listening
+// on BroadcastChannel. Servers which are not listening at that time never receive it.
+func BroadcastCollectionEvent(ctx context.Context, client redis.UniversalClient, payload CollectionEventPayload) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal event payload: %w", err)
+	}
+	if err := client.Publish(ctx, BroadcastChannel, payloadBytes).Err(); err != nil {
+		return fmt.Errorf("could not broadcast event: %w", err)
+	}
+	return nil
+}
+
+// ListenCollectionEvents calls handle with every collection event broadcast on
+// BroadcastChannel until ctx is done. The Redis client subscribes again when the
+// connection is lost; the events broadcast in the meantime are missed.
+func ListenCollectionEvents(ctx context.Context, client redis.UniversalClient, handle func(CollectionEventPayload)) {
+	pubsub := client.Subscribe(ctx, BroadcastChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			var payload CollectionEventPayload
+			if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+				logger.Log.WithError(err).Error("Failed to unmarshal broadcast collection event")
+				continue
+			}
+			handle(payload)
+		}
+	}
+}
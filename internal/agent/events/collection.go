@@ -22,6 +22,9 @@ const (
 	EventTypeItemCreated       EventType = "item:created"
 	EventTypeItemUpdated       EventType = "item:updated"
 	EventTypeItemDeleted       EventType = "item:deleted"
+	EventTypeItemRestored      EventType = "item:restored"
+	EventTypeItemPublished     EventType = "item:published"
+	EventTypeItemUnpublished   EventType = "item:unpublished"
 )
 
 // CollectionEventPayload is the generic data structure for a collection event.
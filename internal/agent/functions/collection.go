@@ -21,6 +21,7 @@ func init() {
 	StaticFunctionMap["collections.upsert_item"] = upsertCollectionItem
 	StaticFunctionMap["collections.list_items"] = listCollectionItems // Renamed from query_items
 	StaticFunctionMap["collections.delete_item"] = deleteCollectionItem
+	StaticFunctionMap["collections.semantic_search"] = semanticSearchItems
 }
 
 // listCollections lists all available collection names.
@@ -56,7 +57,9 @@ func getCollectionSchema(ctx context.Context, args any) (string, error) {
 		return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
 	}
 
-	formattedSchema := utils.FormatCollectionSchema(collection)
+	// Agents act without a role, so attributes restricted to some roles are not shown.
+	readable := collection.ReadableBy(nil)
+	formattedSchema := utils.FormatCollectionSchema(&readable)
 	resultBytes, _ := json.Marshal(formattedSchema)
 	return string(resultBytes), nil
 }
@@ -109,7 +112,7 @@ func upsertCollectionItem(ctx context.Context, args any) (string, error) {
 			return fmt.Sprintf(`{"status": "error", "message": "error checking for item: %s"}`, err.Error()), nil
 		}
 
-		err = storage.UpdateItem(uint(itemID), data)
+		err = storage.UpdateItem(uint(itemID), data, actorFromContext(ctx))
 		if err != nil {
 			return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
 		}
@@ -124,7 +127,7 @@ func upsertCollectionItem(ctx context.Context, args any) (string, error) {
 			CollectionID: collection.ID,
 			Data:         data,
 		}
-		newItem, err := storage.SaveItem(*collection, newItem.Data)
+		newItem, err := storage.SaveItem(*collection, newItem.Data, actorFromContext(ctx))
 		if err != nil {
 			return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
 		}
@@ -135,7 +138,8 @@ func upsertCollectionItem(ctx context.Context, args any) (string, error) {
 	}
 }
 
-// listCollectionItems finds and retrieves items from a collection with pagination.
+// listCollectionItems finds and retrieves items from a collection, a page at a time by
+// page number or from the cursor of the previous page.
 func listCollectionItems(ctx context.Context, args any) (string, error) {
 	argMap, ok := args.(map[string]any)
 	if !ok {
@@ -163,22 +167,58 @@ func listCollectionItems(ctx context.Context, args any) (string, error) {
 		return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
 	}
 
-	// 2. Call storage layer
-	items, total, err := storage.GetItems(collection.ID, int(page), int(pageSize))
-	if err != nil {
-		return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
+	// 2. Call storage layer. With 'after', items are read from the cursor of the previous
+	// page instead of a page number. Either way, they are only counted when 'withCount'
+	// is set.
+	var items []models.Item
+	pagination := map[string]any{"pageSize": int(pageSize)}
+	withCount, _ := argMap["withCount"].(bool)
+	if after, cursorMode := argMap["after"].(string); cursorMode {
+		itemPage, err := storage.QueryItemsAfter(collection.ID, models.ItemQuery{}, after, int(pageSize))
+		if err != nil {
+			return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
+		}
+		items = itemPage.Items
+		pagination["hasNextPage"] = itemPage.HasNextPage
+		pagination["endCursor"] = itemPage.EndCursor()
+		if withCount {
+			total, err := storage.CountItems(collection.ID, models.ItemQuery{})
+			if err != nil {
+				return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
+			}
+			pagination["total"] = total
+		}
+	} else if withCount {
+		var total int
+		items, total, err = storage.GetItems(collection.ID, int(page), int(pageSize))
+		if err != nil {
+			return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
+		}
+		pagination["page"] = int(page)
+		pagination["total"] = total
+		pagination["pageCount"] = (total + int(pageSize) - 1) / int(pageSize)
+	} else {
+		// One more item than asked for tells whether there is a next page.
+		items, err = storage.QueryItemsOffset(collection.ID, models.ItemQuery{}, int(page-1)*int(pageSize), int(pageSize)+1)
+		if err != nil {
+			return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
+		}
+		pagination["page"] = int(page)
+		pagination["hasNextPage"] = len(items) > int(pageSize)
+		if len(items) > int(pageSize) {
+			items = items[:int(pageSize)]
+		}
+	}
+
+	// Agents act without a role, so values of attributes restricted to some roles are removed.
+	for i := range items {
+		items[i].Data = models.StripUnreadableFields(collection.Attributes, items[i].Data, nil)
 	}
 
 	// 3. Format a rich response for the LLM
-	pageCount := (total + int(pageSize) - 1) / int(pageSize)
 	response := map[string]any{
-		"items": items,
-		"pagination": map[string]any{
-			"page":      int(page),
-			"pageSize":  int(pageSize),
-			"total":     total,
-			"pageCount": pageCount,
-		},
+		"items":      items,
+		"pagination": pagination,
 	}
 
 	resultBytes, _ := json.Marshal(response)
@@ -202,7 +242,7 @@ func deleteCollectionItem(ctx context.Context, args any) (string, error) {
 		return `{"status": "error", "message": "invalid item_id format"}`, nil
 	}
 
-	err = storage.DeleteItem(uint(itemID))
+	err = storage.DeleteItem(uint(itemID), actorFromContext(ctx))
 	if err != nil {
 		return fmt.Sprintf(`{"status": "error", "message": "failed to delete item: %s"}`, err.Error()), nil
 	}
@@ -214,3 +254,64 @@ func deleteCollectionItem(ctx context.Context, args any) (string, error) {
 	resultBytes, _ := json.Marshal(result)
 	return string(resultBytes), nil
 }
+
+// semanticSearchItems finds the items closest in meaning to a query, across the given
+// collections or every collection with semantic attributes.
+func semanticSearchItems(ctx context.Context, args any) (string, error) {
+	argMap, ok := args.(map[string]any)
+	if !ok {
+		return `{"status": "error", "message": "invalid arguments format"}`, nil
+	}
+
+	query, _ := argMap["query"].(string)
+	if query == "" {
+		return `{"status": "error", "message": "missing required parameter: query"}`, nil
+	}
+	var names []string
+	if requested, ok := argMap["collections"].([]any); ok {
+		for _, value := range requested {
+			if name, ok := value.(string); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	limit, _ := argMap["limit"].(float64)
+	if limit < 1 {
+		limit = 10
+	}
+
+	results, err := storage.SemanticSearch(ctx, storage.SemanticQuery{
+		Text:        query,
+		Collections: names,
+		Limit:       int(limit),
+	})
+	if err != nil {
+		return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
+	}
+
+	collections := make(map[string]*models.Collection)
+	matches := make([]map[string]any, 0, len(results))
+	for _, result := range results {
+		collection, loaded := collections[result.Collection]
+		if !loaded {
+			if collection, err = storage.GetCollectionByName(result.Collection); err != nil {
+				return fmt.Sprintf(`{"status": "error", "message": "%s"}`, err.Error()), nil
+			}
+			collections[result.Collection] = collection
+		}
+		item, err := storage.GetItemByID(collection.ID, result.ItemID)
+		if err != nil {
+			continue
+		}
+		// Agents act without a role, so values of attributes restricted to some roles are removed.
+		item.Data = models.StripUnreadableFields(collection.Attributes, item.Data, nil)
+		matches = append(matches, map[string]any{
+			"collection": result.Collection,
+			"score":      result.Score,
+			"item":       item,
+		})
+	}
+
+	resultBytes, _ := json.Marshal(map[string]any{"results": matches})
+	return string(resultBytes), nil
+}
@@ -3,6 +3,7 @@ package functions
 import (
 	"context"
 
+	"github.com/gohead-cms/gohead/internal/models"
 	agentModels "github.com/gohead-cms/gohead/internal/models/agents"
 )
 
@@ -35,3 +36,17 @@ type RegistryTool struct {
 	registry    *Registry
 	toolName    string
 }
+
+type agentIDKey struct{}
+
+// WithAgentID returns a copy of ctx identifying the agent executing tools,
+// so content changes made by tools are attributed to it.
+func WithAgentID(ctx context.Context, agentID uint) context.Context {
+	return context.WithValue(ctx, agentIDKey{}, agentID)
+}
+
+// actorFromContext returns the agent actor stored in ctx.
+func actorFromContext(ctx context.Context) models.Actor {
+	agentID, _ := ctx.Value(agentIDKey{}).(uint)
+	return models.AgentActor(agentID)
+}
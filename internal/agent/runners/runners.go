@@ -38,6 +38,8 @@ func (r *AgentRunner) HandleAgentJob(ctx context.Context, t *asynq.Task) error {
 		return err
 	}
 
+	// Content changes made by tools are attributed to this agent.
+	ctx = functions.WithAgentID(ctx, agent.ID)
 	if err := r.runConversation(ctx, agent, payload); err != nil {
 		logger.Log.WithError(err).WithField("agent_id", payload.AgentID).Error("Agent conversation loop failed")
 		return err
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// bulkActions maps the operations of a bulk write to the permissions they require.
+var bulkActions = map[string][]string{
+	storage.BulkOpCreate: {models.ActionCreate},
+	storage.BulkOpUpdate: {models.ActionUpdate},
+	storage.BulkOpUpsert: {models.ActionCreate, models.ActionUpdate},
+	storage.BulkOpDelete: {models.ActionDelete},
+}
+
+// bulkErrorStatus returns the HTTP status of the error of a failed bulk operation.
+func bulkErrorStatus(err error) int {
+	var referenced *models.ItemReferencedError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &referenced):
+		return http.StatusConflict
+	}
+	return writeErrorStatus(err)
+}
+
+// formatBulkResults formats the outcome of every operation of a bulk write, with the
+// item written by the operations that succeeded.
+func formatBulkResults(c *gin.Context, ct *models.Collection, results []storage.BulkResult) []gin.H {
+	formatted := make([]gin.H, 0, len(results))
+	for _, result := range results {
+		entry := gin.H{"index": result.Index, "op": result.Op, "status": result.Status}
+		if result.ID != 0 {
+			entry["id"] = result.ID
+		}
+		if result.Error != "" {
+			entry["error"] = result.Error
+		}
+		if result.Item != nil {
+			entry["item"] = formatItem(c, result.Item, ct)
+		}
+		formatted = append(formatted, entry)
+	}
+	return formatted
+}
+
+// BulkItems runs a list of create, update, upsert and delete operations on the items of
+// a collection, given as {"operations": [...]}. By default the operations are atomic:
+// when one fails, none is applied. With 'atomic=false' failing operations are skipped
+// and the others applied. The response holds the outcome of every operation.
+func BulkItems(c *gin.Context) {
+	ct, err := storage.GetCollectionByName(c.Param("collection"))
+	if err != nil {
+		c.Set("response", "Collection not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+	atomic, err := strconv.ParseBool(c.DefaultQuery("atomic", "true"))
+	if err != nil {
+		c.Set("response", "Invalid atomic parameter, expected 'true' or 'false'")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		Operations []storage.BulkOperation `json:"operations"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Set("response", "Invalid request body")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if err := storage.ValidateBulkOperations(*ct, input.Operations); err != nil {
+		c.Set("response", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	for i, op := range input.Operations {
+		for _, action := range bulkActions[op.Op] {
+			if !hasPermission(c, models.ResourceCollections, ct.Name, action) {
+				c.Set("response", "Access denied")
+				c.Set("details", fmt.Sprintf("operation %d requires the '%s' permission", i, action))
+				c.Set("status", http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	results, err := storage.BulkWriteItems(*ct, input.Operations, storage.BulkOptions{
+		Atomic: atomic,
+		Locale: c.Query("locale"),
+		Actor:  requestActor(c),
+	})
+	var bulkErr *storage.BulkError
+	if errors.As(err, &bulkErr) {
+		c.Set("response", fmt.Sprintf("Operation %d failed, no operation was applied: %v", bulkErr.Index, bulkErr.Err))
+		c.Set("details", formatBulkResults(c, ct, results))
+		c.Set("status", bulkErrorStatus(bulkErr.Err))
+		return
+	}
+	if err != nil {
+		c.Set("response", "Failed to run bulk operations")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == storage.BulkStatusFailed {
+			failed++
+		}
+	}
+	c.Set("response", formatBulkResults(c, ct, results))
+	c.Set("meta", gin.H{"atomic": atomic, "succeeded": len(results) - failed, "failed": failed})
+	c.Set("status", http.StatusOK)
+}
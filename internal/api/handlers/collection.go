@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -158,6 +159,10 @@ func CreateCollection(c *gin.Context) {
 }
 
 // UpdateCollection handles updating an existing collection.
+// The update is planned first: with ?dry_run=true the plan and the number of items it would
+// break are returned without applying it, and updates breaking items require ?force=true.
+// Optional 'directives' rename, convert and default attributes of existing items, which are
+// rewritten in a background job.
 func UpdateCollection(c *gin.Context) {
 	name := c.Param("name") // Collection name (not ID)
 
@@ -175,6 +180,13 @@ func UpdateCollection(c *gin.Context) {
 		return
 	}
 
+	directives, err := models.ParseSchemaDirectives(input["directives"])
+	if err != nil {
+		c.Set("response", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
 	if err := models.ValidateCollectionSchema(collection); err != nil {
 		logger.Log.WithError(err).Warn("UpdateCollection: Validation failed")
 		c.Set("response", err.Error())
@@ -191,6 +203,33 @@ func UpdateCollection(c *gin.Context) {
 		return
 	}
 
+	plan, err := storage.PlanCollectionUpdate(existing.ID, collection, directives)
+	if err != nil {
+		logger.Log.WithError(err).Warn("UpdateCollection: Invalid schema change")
+		c.Set("response", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	report, err := storage.DryRunSchemaPlan(existing.ID, *plan)
+	if err != nil {
+		logger.Log.WithError(err).Error("UpdateCollection: Failed to check items against the new schema")
+		c.Set("response", "Failed to check items against the new schema")
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		c.Set("response", gin.H{"plan": plan, "report": report})
+		c.Set("status", http.StatusOK)
+		return
+	}
+	if report.BrokenItems > 0 && c.Query("force") != "true" {
+		c.Set("response", fmt.Sprintf("The schema change would break %d item(s); review the plan with ?dry_run=true and apply it with ?force=true", report.BrokenItems))
+		c.Set("meta", gin.H{"plan": plan, "report": report})
+		c.Set("status", http.StatusConflict)
+		return
+	}
+
 	// Update in DB
 	if err := storage.UpdateCollection(existing.ID, &collection); err != nil {
 		logger.Log.WithError(err).Error("UpdateCollection: Failed to update collection")
@@ -199,6 +238,18 @@ func UpdateCollection(c *gin.Context) {
 		return
 	}
 
+	meta := gin.H{"plan": plan, "report": report}
+	if plan.RewritesData() {
+		jobID, err := storage.EnqueueSchemaMigration(c.Request.Context(), existing.ID, *plan)
+		if err != nil {
+			logger.Log.WithError(err).Error("UpdateCollection: Failed to migrate items")
+			c.Set("response", "Collection updated but its items could not be migrated")
+			c.Set("status", http.StatusInternalServerError)
+			return
+		}
+		meta["migration_job"] = jobID
+	}
+
 	// Fetch updated collection
 	updated, err := storage.GetCollectionByID(existing.ID)
 	if err != nil {
@@ -211,6 +262,7 @@ func UpdateCollection(c *gin.Context) {
 	c.Set("response",
 		utils.FormatCollectionSchema(updated),
 	)
+	c.Set("meta", meta)
 	c.Set("status", http.StatusOK)
 }
 
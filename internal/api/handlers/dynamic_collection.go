@@ -3,14 +3,13 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gohead-cms/gohead/internal/models"
-	"github.com/gohead-cms/gohead/pkg/database"
 	"github.com/gohead-cms/gohead/pkg/logger"
 	"github.com/gohead-cms/gohead/pkg/storage"
-	"github.com/gohead-cms/gohead/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -57,7 +56,7 @@ func DynamicCollectionHandler(c *gin.Context) {
 	case http.MethodGet:
 		handleRead(c, userRole, ct, id)
 
-	case http.MethodPut:
+	case http.MethodPut, http.MethodPatch:
 		handleUpdate(c, userRole, ct, id)
 
 	case http.MethodDelete:
@@ -77,7 +76,7 @@ func DynamicCollectionHandler(c *gin.Context) {
 // handleCreate handles the creation of a single item or a batch of items.
 func handleCreate(c *gin.Context, userRole string, ct *models.Collection) {
 	// 1. Permission Check
-	if !hasPermission(userRole, "create") {
+	if !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionCreate) {
 		logger.Log.WithFields(logrus.Fields{
 			"user_role":  userRole,
 			"collection": ct.Name,
@@ -101,7 +100,6 @@ func handleCreate(c *gin.Context, userRole string, ct *models.Collection) {
 	// ---------------------------------
 	//  CASE 1: Bulk Creation (Array)
 	// ---------------------------------
-	tx := database.DB.Begin()
 	if len(body) > 0 && body[0] == '[' {
 		var inputs []struct {
 			Data map[string]any `json:"data"`
@@ -112,36 +110,40 @@ func handleCreate(c *gin.Context, userRole string, ct *models.Collection) {
 			return
 		}
 
-		var createdItems []map[string]any
-		// IMPORTANT: For bulk operations, you should use a database transaction.
-
+		// The items are created atomically, like a bulk write of create operations.
+		ops := make([]storage.BulkOperation, len(inputs))
 		for i, input := range inputs {
-			if _, err := models.ValidateItemValues(*ct, input.Data, tx); err != nil {
-				// tx.Rollback() // Rollback on the first validation error
-				c.Set("response", gin.H{
-					"error":      "Validation failed for an item in the batch",
-					"details":    err.Error(),
-					"item_index": i,
-				})
-				c.Set("status", http.StatusBadRequest)
-				return
-			}
-
-			item := models.Item{
-				CollectionID: ct.ID,
-				Data:         input.Data,
+			ops[i] = storage.BulkOperation{Op: storage.BulkOpCreate, Data: models.JSONMap(input.Data)}
+			if ops[i].Data == nil {
+				ops[i].Data = models.JSONMap{}
 			}
-
-			if err := storage.SaveItemWithTransaction(tx, &item); err != nil { // Should be storage.SaveItemInTransaction(&item, tx)
-				tx.Rollback()
-				c.Set("response", "Failed to save an item during bulk operation")
-				c.Set("status", http.StatusInternalServerError)
-				return
-			}
-			createdItems = append(createdItems, utils.FormatCollectionItem(&item, ct))
+		}
+		results, err := storage.BulkWriteItems(*ct, ops, storage.BulkOptions{
+			Atomic: true,
+			Locale: c.Query("locale"),
+			Actor:  requestActor(c),
+		})
+		var bulkErr *storage.BulkError
+		if errors.As(err, &bulkErr) {
+			c.Set("response", gin.H{
+				"error":      "Validation failed for an item in the batch",
+				"details":    bulkErr.Err.Error(),
+				"item_index": bulkErr.Index,
+			})
+			c.Set("status", writeErrorStatus(bulkErr.Err))
+			return
+		}
+		if err != nil {
+			c.Set("response", "Failed to save items during bulk operation")
+			c.Set("details", err.Error())
+			c.Set("status", http.StatusBadRequest)
+			return
 		}
 
-		tx.Commit()
+		createdItems := make([]map[string]any, 0, len(results))
+		for _, result := range results {
+			createdItems = append(createdItems, formatItem(c, result.Item, ct))
+		}
 		c.Set("response", createdItems)
 		c.Set("meta", gin.H{"created_count": len(createdItems)})
 		c.Set("status", http.StatusCreated)
@@ -159,21 +161,20 @@ func handleCreate(c *gin.Context, userRole string, ct *models.Collection) {
 			return
 		}
 
-		// This logic is directly from your original CreateItem function
-		if _, err := models.ValidateItemValues(*ct, input.Data, tx); err != nil {
-			c.Set("response", err.Error())
-			c.Set("status", http.StatusBadRequest)
+		data, ok := localizeInput(c, ct, input.Data, nil)
+		if !ok {
 			return
 		}
 
-		newItem, err := storage.SaveItem(*ct, input.Data)
+		// The item is validated within the transaction creating it.
+		newItem, err := storage.SaveItem(*ct, data, requestActor(c))
 		if err != nil {
-			c.Set("response", "Failed to save item: "+err.Error())
-			c.Set("status", http.StatusInternalServerError)
+			c.Set("response", err.Error())
+			c.Set("status", writeErrorStatus(err))
 			return
 		}
 
-		c.Set("response", utils.FormatCollectionItem(newItem, ct))
+		c.Set("response", formatItem(c, newItem, ct))
 		c.Set("meta", gin.H{})
 		c.Set("status", http.StatusCreated)
 
@@ -185,7 +186,7 @@ func handleCreate(c *gin.Context, userRole string, ct *models.Collection) {
 
 // handleRead handles fetching items or a single item by ID
 func handleRead(c *gin.Context, userRole string, ct *models.Collection, id string) {
-	if !hasPermission(userRole, "read") {
+	if !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionRead) {
 		logger.Log.WithFields(logrus.Fields{
 			"user_role":  userRole,
 			"collection": ct.Name,
@@ -208,9 +209,29 @@ func handleRead(c *gin.Context, userRole string, ct *models.Collection, id strin
 		level = parsedLevel
 	}
 
+	// Readers only see published items; previewing drafts requires update rights.
+	status := c.DefaultQuery("status", models.ItemStatusPublished)
+	switch status {
+	case models.ItemStatusPublished:
+	case models.ItemStatusDraft:
+		if !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionUpdate) {
+			logger.Log.WithFields(logrus.Fields{
+				"user_role":  userRole,
+				"collection": ct.Name,
+			}).Warn("Draft preview permission denied")
+			c.Set("response", "Access denied")
+			c.Set("status", http.StatusForbidden)
+			return
+		}
+	default:
+		c.Set("response", "Invalid status parameter, expected 'published' or 'draft'")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
 	// Handle fetching all items or a single item by ID
 	if id == "" {
-		GetItems(*ct, uint(level))(c)
+		GetItems(*ct, uint(level), status)(c)
 	} else {
 		itemID, err := strconv.Atoi(id)
 		if err != nil {
@@ -218,13 +239,14 @@ func handleRead(c *gin.Context, userRole string, ct *models.Collection, id strin
 			c.Set("status", http.StatusBadRequest)
 			return
 		}
-		GetItemByID(*ct, uint(itemID), uint(level))(c)
+		GetItemByID(*ct, uint(itemID), uint(level), status)(c)
 	}
 }
 
-// handleUpdate handles updating an item by ID.
+// handleUpdate handles updating an item by ID, replacing its data with PUT or the
+// attributes given with PATCH.
 func handleUpdate(c *gin.Context, userRole string, ct *models.Collection, id string) {
-	if !hasPermission(userRole, "update") {
+	if !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionUpdate) {
 		logger.Log.WithFields(logrus.Fields{
 			"user_role":  userRole,
 			"collection": ct.Name,
@@ -234,7 +256,9 @@ func handleUpdate(c *gin.Context, userRole string, ct *models.Collection, id str
 		c.Set("status", http.StatusForbidden)
 		return
 	}
-	if id != "" {
+	if id != "" && c.Request.Method == http.MethodPatch {
+		PatchItem(*ct)(c)
+	} else if id != "" {
 		UpdateItem(*ct)(c)
 	} else {
 		logger.Log.Warn("Update operation requires a valid ID")
@@ -245,7 +269,7 @@ func handleUpdate(c *gin.Context, userRole string, ct *models.Collection, id str
 
 // handleDelete handles deleting an item by ID.
 func handleDelete(c *gin.Context, userRole string, ct *models.Collection, id string) {
-	if !hasPermission(userRole, "delete") {
+	if !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionDelete) {
 		logger.Log.WithFields(logrus.Fields{
 			"user_role":  userRole,
 			"collection": ct.Name,
@@ -33,7 +33,7 @@ func TestDynamicContentHandler(t *testing.T) {
 	router.Use(middleware.ResponseWrapper())
 	defer testutils.CleanupTestDB()
 
-	assert.NoError(t, db.AutoMigrate(&models.User{}, &models.UserRole{}, &models.Collection{}, &models.Attribute{}, &models.Item{}))
+	assert.NoError(t, db.AutoMigrate(&models.User{}, &models.UserRole{}, &models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRevision{}, &models.ItemRelation{}))
 
 	// Define a test collection to work with
 	collection := models.Collection{
@@ -48,7 +48,7 @@ func TestDynamicContentHandler(t *testing.T) {
 	// Define a user role with permission to create content
 	adminRole := models.UserRole{
 		Name:        "admin",
-		Permissions: models.JSONMap{"create": true},
+		Permissions: models.JSONMap{"collections.*.create": true},
 	}
 	assert.NoError(t, db.Create(&adminRole).Error)
 
@@ -142,12 +142,12 @@ func TestRetrieveContentItem(t *testing.T) {
 	router.Use(middleware.ResponseWrapper())
 	defer testutils.CleanupTestDB()
 
-	assert.NoError(t, db.AutoMigrate(&models.User{}, &models.UserRole{}, &models.Collection{}, &models.Attribute{}, &models.Item{}))
+	assert.NoError(t, db.AutoMigrate(&models.User{}, &models.UserRole{}, &models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRevision{}, &models.ItemRelation{}))
 
 	// Seed roles with permissions
 	viewerRole := models.UserRole{
 		Name:        "viewer",
-		Permissions: models.JSONMap{"read": true},
+		Permissions: models.JSONMap{"collections.*.read": true},
 	}
 	assert.NoError(t, db.Create(&viewerRole).Error)
 
@@ -167,8 +167,12 @@ func TestRetrieveContentItem(t *testing.T) {
 		Data:         models.JSONMap{"title": "Existing Article", "content": "This is an existing article."},
 	}
 
-	_, err := storage.SaveItem(*&collection, contentItem.Data)
+	savedItem, err := storage.SaveItem(*&collection, contentItem.Data, models.UserActor("tester"))
 	assert.NoError(t, err)
+	// Viewers only see published items
+	_, err = storage.PublishItem(collection, savedItem.ID)
+	assert.NoError(t, err)
+	contentItem.ID = savedItem.ID
 	// Apply AuthMiddleware to protected routes
 	protected := router.Group("/")
 	protected.Use(middleware.AuthMiddleware())
@@ -198,5 +202,12 @@ func TestRetrieveContentItem(t *testing.T) {
 	data, ok := response["data"].(map[string]any)
 	assert.True(t, ok, "Response should have a 'data' key")
 
-	assert.Equal(t, "Existing Article", data["title"])
+	// The values of the item are under 'attributes', its publication state in 'meta'.
+	attributes, ok := data["attributes"].(map[string]any)
+	assert.True(t, ok, "Item should have an 'attributes' key")
+	assert.Equal(t, "Existing Article", attributes["title"])
+	assert.Equal(t, float64(contentItem.ID), data["id"])
+	meta, ok := response["meta"].(map[string]any)
+	assert.True(t, ok, "Response should have a 'meta' key")
+	assert.Equal(t, models.ItemStatusPublished, meta["status"])
 }
@@ -1,30 +1,74 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/graphql-go/graphql"
 
+	"github.com/gohead-cms/gohead/internal/api/middleware"
 	schema "github.com/gohead-cms/gohead/internal/graphql"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
 )
 
+// graphQLRequest is the body of a GraphQL request. The query may be left out in favour
+// of its hash, in the persistedQuery extension.
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	Extensions    struct {
+		PersistedQuery *schema.PersistedQueryExtension `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
 // GraphQLHandler handles GraphQL queries
 func GraphQLHandler(c *gin.Context) {
-	var request struct {
-		Query string `json:"query"`
-	}
-
+	var request graphQLRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.Set("response", "Invalid input format")
 		c.Set("status", http.StatusBadRequest)
 		return
 	}
 
-	// Execute GraphQL query
-	result := graphql.Do(graphql.Params{
-		Schema:        schema.Schema,
-		RequestString: request.Query,
+	// Resolvers check permissions against the caller's role.
+	ctx := schema.WithViewer(c.Request.Context(), schema.Viewer{
+		Username: c.GetString("username"),
+		Role:     middleware.CurrentRole(c),
+	})
+
+	query, err := schema.ResolvePersistedQuery(ctx, request.Query, request.Extensions.PersistedQuery)
+	switch {
+	case errors.Is(err, schema.ErrPersistedQueryNotFound), errors.Is(err, schema.ErrPersistedQueryNotSupported):
+		// Answered in the format APQ clients expect, so they send the query in full.
+		code := "PERSISTED_QUERY_NOT_FOUND"
+		if errors.Is(err, schema.ErrPersistedQueryNotSupported) {
+			code = "PERSISTED_QUERY_NOT_SUPPORTED"
+		}
+		c.JSON(http.StatusOK, gin.H{"errors": []gin.H{{"message": err.Error(), "extensions": gin.H{"code": code}}}})
+		return
+	case errors.Is(err, schema.ErrQueryNotAllowed):
+		c.Set("response", "Query not allowed")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusForbidden)
+		return
+	case err != nil:
+		c.Set("response", "Invalid persisted query")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	// Execute GraphQL query within the depth and complexity limits
+	result := schema.Execute(graphql.Params{
+		Schema:         schema.Schema,
+		RequestString:  query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        ctx,
 	})
 
 	if len(result.Errors) > 0 {
@@ -37,3 +81,38 @@ func GraphQLHandler(c *gin.Context) {
 	c.Set("response", result.Data)
 	c.Set("status", http.StatusOK)
 }
+
+// UploadPersistedQueries persists the queries of a manifest produced when a frontend is
+// built, either an Apollo persisted query manifest or an object of queries by hash.
+func UploadPersistedQueries(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.Set("response", "Invalid input format")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	queries, err := models.ParsePersistedQueryManifest(body)
+	if err != nil {
+		c.Set("response", "Invalid persisted query manifest")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	if err := schema.SavePersistedQueries(c.Request.Context(), queries); err != nil {
+		if errors.Is(err, schema.ErrPersistedQueryNotSupported) {
+			c.Set("response", "Persisted queries are disabled")
+			c.Set("status", http.StatusNotImplemented)
+			return
+		}
+		logger.Log.WithError(err).Error("Failed to save persisted query manifest")
+		c.Set("response", "Failed to save persisted queries")
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Log.WithField("count", len(queries)).Info("Persisted query manifest uploaded")
+	c.Set("response", gin.H{"message": "Persisted queries saved"})
+	c.Set("meta", gin.H{"total": len(queries)})
+	c.Set("status", http.StatusOK)
+}
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+
+	"github.com/gohead-cms/gohead/internal/api/middleware"
+	schema "github.com/gohead-cms/gohead/internal/graphql"
+	"github.com/gohead-cms/gohead/pkg/logger"
+)
+
+// graphqlWSProtocol is the WebSocket subprotocol of GraphQL over WebSocket, described at
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+const graphqlWSProtocol = "graphql-transport-ws"
+
+const (
+	// wsInitTimeout is the time a client has to send connection_init after connecting.
+	wsInitTimeout = 10 * time.Second
+	// wsPingInterval is the interval between the pings keeping idle connections open.
+	wsPingInterval = 30 * time.Second
+	// wsPongWait is the time a client has to answer a ping before it is disconnected.
+	wsPongWait   = wsPingInterval + 10*time.Second
+	wsWriteWait  = 10 * time.Second
+	wsMaxMessage = 1 << 20
+)
+
+// Close codes of the graphql-transport-ws protocol.
+const (
+	wsCloseBadRequest          = 4400
+	wsCloseUnauthorized        = 4401
+	wsCloseForbidden           = 4403
+	wsCloseSubprotocol         = 4406
+	wsCloseInitTimeout         = 4408
+	wsCloseSubscriberExists    = 4409
+	wsCloseTooManyInitRequests = 4429
+)
+
+var wsUpgrader = websocket.Upgrader{
+	Subprotocols: []string{graphqlWSProtocol},
+	// Clients authenticate with a token in connection_init rather than with cookies,
+	// so a page of another origin cannot act on behalf of a user.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsMessage is a message of the graphql-transport-ws protocol.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsSubscribePayload is the operation of a 'subscribe' message.
+type wsSubscribePayload struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	Extensions    struct {
+		PersistedQuery *schema.PersistedQueryExtension `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// GraphQLSubscriptionHandler serves GraphQL operations, subscriptions in particular,
+// over a WebSocket speaking the graphql-transport-ws protocol. Clients authenticate
+// with the same JWT as the other routes, either in the Authorization header of the
+// upgrade request or, since browsers cannot set it, in the 'Authorization' field of
+// the connection_init payload.
+func GraphQLSubscriptionHandler(c *gin.Context) {
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader already replied with the error.
+		logger.Log.WithError(err).Debug("Failed to upgrade GraphQL WebSocket connection")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session := &wsSession{
+		conn:          conn,
+		authorization: c.GetHeader("Authorization"),
+		operations:    make(map[string]context.CancelFunc),
+	}
+	session.serve(ctx)
+}
+
+// wsSession is a graphql-transport-ws connection.
+type wsSession struct {
+	conn          *websocket.Conn
+	authorization string
+	writeMu       sync.Mutex
+
+	mu         sync.Mutex
+	initiated  bool
+	viewer     *schema.Viewer
+	expiry     *time.Timer
+	operations map[string]context.CancelFunc
+}
+
+func (s *wsSession) serve(ctx context.Context) {
+	defer s.conn.Close()
+	if s.conn.Subprotocol() != graphqlWSProtocol {
+		s.close(wsCloseSubprotocol, "Subprotocol not acceptable")
+		return
+	}
+
+	initTimer := time.AfterFunc(wsInitTimeout, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.viewer == nil {
+			s.close(wsCloseInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+	go s.keepAlive(ctx)
+
+	s.conn.SetReadLimit(wsMaxMessage)
+	_ = s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, cancel := range s.operations {
+			cancel()
+		}
+		if s.expiry != nil {
+			s.expiry.Stop()
+		}
+	}()
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var message wsMessage
+		if err := json.Unmarshal(data, &message); err != nil || message.Type == "" {
+			s.close(wsCloseBadRequest, "Invalid message received")
+			return
+		}
+		if !s.handle(ctx, message) {
+			return
+		}
+	}
+}
+
+// handle processes a message and reports whether the connection remains open.
+func (s *wsSession) handle(ctx context.Context, message wsMessage) bool {
+	switch message.Type {
+	case "connection_init":
+		return s.initialize(message.Payload)
+	case "ping":
+		s.send(wsMessage{Type: "pong"})
+	case "pong":
+	case "subscribe":
+		return s.subscribe(ctx, message)
+	case "complete":
+		s.mu.Lock()
+		if cancel, exists := s.operations[message.ID]; exists {
+			cancel()
+			delete(s.operations, message.ID)
+		}
+		s.mu.Unlock()
+	default:
+		s.close(wsCloseBadRequest, "Invalid message received")
+		return false
+	}
+	return true
+}
+
+// initialize authenticates the client of the connection with its token.
+func (s *wsSession) initialize(payload json.RawMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.initiated {
+		s.close(wsCloseTooManyInitRequests, "Too many initialisation requests")
+		return false
+	}
+	s.initiated = true
+
+	authorization := s.authorization
+	if authorization == "" {
+		var params struct {
+			Authorization string `json:"Authorization"`
+		}
+		_ = json.Unmarshal(payload, &params)
+		authorization = params.Authorization
+	}
+	tokenString := strings.TrimPrefix(authorization, "Bearer ")
+	if tokenString == "" || tokenString == authorization {
+		s.close(wsCloseForbidden, "Bearer token required")
+		return false
+	}
+	claims, role, err := middleware.Authenticate(tokenString)
+	if err != nil {
+		s.close(wsCloseForbidden, "Forbidden")
+		return false
+	}
+
+	s.viewer = &schema.Viewer{Username: claims.Username, Role: role}
+	// Operations end with the validity of the token they were authorized with.
+	if claims.ExpiresAt > 0 {
+		s.expiry = time.AfterFunc(time.Until(time.Unix(claims.ExpiresAt, 0)), func() {
+			s.close(wsCloseForbidden, "Token expired")
+		})
+	}
+	s.send(wsMessage{Type: "connection_ack"})
+	return true
+}
+
+// subscribe starts executing the operation of a 'subscribe' message.
+func (s *wsSession) subscribe(ctx context.Context, message wsMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.viewer == nil {
+		s.close(wsCloseUnauthorized, "Unauthorized")
+		return false
+	}
+	var payload wsSubscribePayload
+	if message.ID == "" || json.Unmarshal(message.Payload, &payload) != nil {
+		s.close(wsCloseBadRequest, "Invalid message received")
+		return false
+	}
+	if _, exists := s.operations[message.ID]; exists {
+		s.close(wsCloseSubscriberExists, "Subscriber for "+message.ID+" already exists")
+		return false
+	}
+
+	opCtx, cancel := context.WithCancel(schema.WithViewer(ctx, *s.viewer))
+	s.operations[message.ID] = cancel
+	go s.execute(opCtx, message.ID, payload)
+	return true
+}
+
+// execute runs an operation and sends its results until it completes, the client
+// completes it or the connection closes. Queries and mutations have a single result.
+func (s *wsSession) execute(ctx context.Context, id string, payload wsSubscribePayload) {
+	query, err := schema.ResolvePersistedQuery(ctx, payload.Query, payload.Extensions.PersistedQuery)
+	params := graphql.Params{
+		Schema:         schema.GetSchema(),
+		RequestString:  query,
+		VariableValues: payload.Variables,
+		OperationName:  payload.OperationName,
+		Context:        ctx,
+	}
+	var results chan *graphql.Result
+	if err != nil {
+		results = make(chan *graphql.Result, 1)
+		results <- &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+		close(results)
+	} else if isSubscription(query, payload.OperationName) {
+		results = schema.Subscribe(params)
+	} else {
+		results = make(chan *graphql.Result, 1)
+		results <- schema.Execute(params)
+		close(results)
+	}
+
+	failed := false
+	for result := range results {
+		// Results are read until the channel closes so the executor never blocks.
+		if ctx.Err() != nil || failed {
+			continue
+		}
+		if len(result.Errors) > 0 && result.Data == nil {
+			errorsPayload, _ := json.Marshal(result.Errors)
+			s.send(wsMessage{ID: id, Type: "error", Payload: errorsPayload})
+			failed = true
+			continue
+		}
+		resultPayload, _ := json.Marshal(result)
+		s.send(wsMessage{ID: id, Type: "next", Payload: resultPayload})
+	}
+
+	s.mu.Lock()
+	_, active := s.operations[id]
+	delete(s.operations, id)
+	s.mu.Unlock()
+	// Operations completed by the client or failed are not completed again.
+	if active && ctx.Err() == nil && !failed {
+		s.send(wsMessage{ID: id, Type: "complete"})
+	}
+}
+
+// keepAlive pings the client until ctx is done, so idle connections stay open and
+// dead ones are detected.
+func (s *wsSession) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *wsSession) send(message wsMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err := s.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		logger.Log.WithError(err).Debug("Failed to write to GraphQL WebSocket connection")
+	}
+}
+
+// close closes the connection with a close code of the protocol.
+func (s *wsSession) close(code int, reason string) {
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteWait))
+	_ = s.conn.Close()
+}
+
+// isSubscription reports whether the operation of a document to execute is a
+// subscription. Invalid documents are not, so their errors are reported by schema.Execute.
+func isSubscription(query, operationName string) bool {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return false
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (operation.Name != nil && operation.Name.Value == operationName) {
+			return operation.Operation == ast.OperationTypeSubscription
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/agent/events"
+	schema "github.com/gohead-cms/gohead/internal/graphql"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/auth"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQLSubscriptionHandler(t *testing.T) {
+	router, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.UserRole{}, &models.Collection{}, &models.Attribute{}, &models.Item{}))
+	require.NoError(t, db.Create(&models.UserRole{Name: "reader", Permissions: models.JSONMap{"collections.articles.read": true}}).Error)
+	require.NoError(t, db.Create(&models.Collection{Name: "articles", Attributes: []models.Attribute{{Name: "title", Type: "text"}}}).Error)
+	require.NoError(t, schema.InitializeGraphQLSchema())
+
+	auth.InitializeJWT("test-secret")
+	token, err := auth.GenerateJWT("reader", "reader")
+	require.NoError(t, err)
+
+	router.GET("/graphql", GraphQLSubscriptionHandler)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	connect := func(t *testing.T) *websocket.Conn {
+		dialer := websocket.Dialer{Subprotocols: []string{graphqlWSProtocol}}
+		conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/graphql", nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+	read := func(t *testing.T, conn *websocket.Conn) map[string]any {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		var message map[string]any
+		require.NoError(t, conn.ReadJSON(&message))
+		return message
+	}
+	closeCode := func(t *testing.T, conn *websocket.Conn) int {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		_, _, err := conn.ReadMessage()
+		closeErr, ok := err.(*websocket.CloseError)
+		require.True(t, ok, "expected the connection to be closed, got %v", err)
+		return closeErr.Code
+	}
+
+	t.Run("Invalid token", func(t *testing.T) {
+		conn := connect(t)
+		require.NoError(t, conn.WriteJSON(map[string]any{"type": "connection_init", "payload": map[string]any{"Authorization": "Bearer invalid"}}))
+		assert.Equal(t, wsCloseForbidden, closeCode(t, conn))
+	})
+
+	t.Run("Subscribe before initialisation", func(t *testing.T) {
+		conn := connect(t)
+		require.NoError(t, conn.WriteJSON(map[string]any{"id": "1", "type": "subscribe", "payload": map[string]any{"query": "subscription { articleDeleted }"}}))
+		assert.Equal(t, wsCloseUnauthorized, closeCode(t, conn))
+	})
+
+	t.Run("Subscriptions and queries", func(t *testing.T) {
+		conn := connect(t)
+		require.NoError(t, conn.WriteJSON(map[string]any{"type": "connection_init", "payload": map[string]any{"Authorization": "Bearer " + token}}))
+		assert.Equal(t, "connection_ack", read(t, conn)["type"])
+
+		require.NoError(t, conn.WriteJSON(map[string]any{"id": "q", "type": "subscribe", "payload": map[string]any{"query": "{ articles { title } }"}}))
+		message := read(t, conn)
+		assert.Equal(t, "next", message["type"])
+		assert.Equal(t, map[string]any{"data": map[string]any{"articles": []any{}}}, message["payload"])
+		assert.Equal(t, map[string]any{"id": "q", "type": "complete"}, read(t, conn))
+
+		require.NoError(t, conn.WriteJSON(map[string]any{"id": "s", "type": "subscribe", "payload": map[string]any{"query": "subscription { articleDeleted }"}}))
+		// The subscription is registered asynchronously, so events are published until
+		// one is received.
+		received := make(chan map[string]any, 1)
+		go func() {
+			var message map[string]any
+			_ = conn.ReadJSON(&message)
+			received <- message
+		}()
+		var next map[string]any
+		require.Eventually(t, func() bool {
+			schema.PublishEvent(events.CollectionEventPayload{EventType: events.EventTypeItemDeleted, CollectionName: "articles", ItemID: 7})
+			select {
+			case next = <-received:
+				return true
+			default:
+				return false
+			}
+		}, 2*time.Second, 20*time.Millisecond)
+		assert.Equal(t, "s", next["id"])
+		assert.Equal(t, map[string]any{"data": map[string]any{"articleDeleted": "7"}}, next["payload"])
+
+		// Events published before the first one was received may follow.
+		require.NoError(t, conn.WriteJSON(map[string]any{"type": "ping"}))
+		for message = read(t, conn); message["type"] == "next"; message = read(t, conn) {
+		}
+		assert.Equal(t, "pong", message["type"])
+	})
+}
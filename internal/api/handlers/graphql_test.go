@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/api/middleware"
+	schema "github.com/gohead-cms/gohead/internal/graphql"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/auth"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQLPersistedQueries(t *testing.T) {
+	router, db := testutils.SetupTestServer()
+	router.Use(middleware.ResponseWrapper())
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.UserRole{}, &models.Collection{}, &models.Attribute{}, &models.Item{}, &models.PersistedQuery{}))
+	require.NoError(t, db.Create(&models.UserRole{Name: "reader", Permissions: models.JSONMap{"collections.articles.read": true}}).Error)
+	require.NoError(t, db.Create(&models.UserRole{Name: "frontend", Permissions: models.JSONMap{"graphql.*.manage": true}}).Error)
+	require.NoError(t, db.Create(&models.Collection{Name: "articles", Attributes: []models.Attribute{{Name: "title", Type: "text"}}}).Error)
+	require.NoError(t, schema.InitializeGraphQLSchema())
+	schema.SetPersistedQueries(schema.DatabaseQueryStore{}, false)
+	defer schema.SetPersistedQueries(nil, false)
+
+	auth.InitializeJWT("test-secret")
+	readerToken, err := auth.GenerateJWT("reader", "reader")
+	require.NoError(t, err)
+	frontendToken, err := auth.GenerateJWT("frontend", "frontend")
+	require.NoError(t, err)
+
+	router.POST("/graphql", middleware.AuthMiddleware(), GraphQLHandler)
+	router.POST("/admin/graphql/queries", middleware.AuthMiddleware(), middleware.RequirePermission(models.ResourceGraphQL, models.ActionManage, ""), UploadPersistedQueries)
+	post := func(path, token string, body any) (int, map[string]any) {
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	const (
+		registered = "{ articles { title } }"
+		uploaded   = "query Titles { articles { id title } }"
+	)
+	byHash := func(query string) map[string]any {
+		return map[string]any{"persistedQuery": map[string]any{"version": 1, "sha256Hash": models.HashQuery(query)}}
+	}
+
+	t.Run("Automatic persisted queries", func(t *testing.T) {
+		code, response := post("/graphql", readerToken, map[string]any{"extensions": byHash(registered)})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []any{map[string]any{"message": "PersistedQueryNotFound", "extensions": map[string]any{"code": "PERSISTED_QUERY_NOT_FOUND"}}}, response["errors"])
+
+		code, response = post("/graphql", readerToken, map[string]any{"query": registered, "extensions": byHash(registered)})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]any{"articles": []any{}}, response["data"])
+
+		code, response = post("/graphql", readerToken, map[string]any{"extensions": byHash(registered)})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]any{"articles": []any{}}, response["data"])
+
+		code, _ = post("/graphql", readerToken, map[string]any{"query": uploaded, "extensions": byHash(registered)})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Manifest upload", func(t *testing.T) {
+		manifest := map[string]any{
+			"format":     "apollo-persisted-query-manifest",
+			"version":    1,
+			"operations": []any{map[string]any{"id": models.HashQuery(uploaded), "name": "Titles", "type": "query", "body": uploaded}},
+		}
+		code, _ := post("/admin/graphql/queries", readerToken, manifest)
+		assert.Equal(t, http.StatusForbidden, code)
+		code, _ = post("/admin/graphql/queries", frontendToken, map[string]any{models.HashQuery(registered): uploaded})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, response := post("/admin/graphql/queries", frontendToken, manifest)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]any{"total": float64(1)}, response["meta"])
+	})
+
+	t.Run("Allow-list", func(t *testing.T) {
+		schema.SetPersistedQueries(schema.DatabaseQueryStore{}, true)
+		defer schema.SetPersistedQueries(schema.DatabaseQueryStore{}, false)
+
+		code, response := post("/graphql", readerToken, map[string]any{"operationName": "Titles", "extensions": byHash(uploaded)})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]any{"articles": []any{}}, response["data"])
+
+		code, _ = post("/graphql", readerToken, map[string]any{"extensions": byHash(registered)})
+		assert.Equal(t, http.StatusForbidden, code)
+		code, _ = post("/graphql", readerToken, map[string]any{"query": "{ articles { id } }"})
+		assert.Equal(t, http.StatusForbidden, code)
+
+		code, _ = post("/graphql", frontendToken, map[string]any{"query": "{ __typename }"})
+		assert.Equal(t, http.StatusOK, code)
+	})
+}
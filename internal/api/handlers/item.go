@@ -1,23 +1,70 @@
 package handlers
 
 import (
+	"errors"
+	"maps"
 	"net/http"
 	"strconv"
 
+	"github.com/gohead-cms/gohead/internal/api/middleware"
 	"github.com/gohead-cms/gohead/internal/models"
 	"github.com/gohead-cms/gohead/pkg/database"
 	"github.com/gohead-cms/gohead/pkg/logger"
 	"github.com/gohead-cms/gohead/pkg/storage"
 	"github.com/gohead-cms/gohead/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/gin-gonic/gin"
 )
 
+// requestActor identifies the authenticated user making the request.
+func requestActor(c *gin.Context) models.Actor {
+	return models.UserActor(c.GetString("username")).WithRole(middleware.CurrentRole(c))
+}
+
+// formatItem formats an item for a response: attributes the user's role may not read
+// are hidden, localized attributes are translated and media attributes are expanded
+// into assets.
+func formatItem(c *gin.Context, item *models.Item, ct *models.Collection) map[string]any {
+	readable := readableCollection(c, ct)
+	formatted := *item
+	formatted.Data = ct.LocalizeData(item.Data, c.Query("locale"))
+	expanded, err := storage.ExpandMedia(readable.Attributes, formatted.Data)
+	if err != nil {
+		logger.Log.WithField("item_id", item.ID).WithError(err).Warn("Failed to expand media attributes")
+		return utils.FormatCollectionItem(&formatted, readable)
+	}
+	formatted.Data = expanded
+	return utils.FormatCollectionItem(&formatted, readable)
+}
+
+// localizeInput merges the item data of a write request into the stored translations,
+// in the locale of the 'locale' query parameter. It sets the error response and
+// returns false when the locale is not supported.
+func localizeInput(c *gin.Context, ct *models.Collection, data, current models.JSONMap) (models.JSONMap, bool) {
+	localized, err := ct.LocalizeInput(data, current, c.Query("locale"))
+	if err != nil {
+		c.Set("response", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return nil, false
+	}
+	return localized, true
+}
+
+// responseLocale returns the locale localized attributes are read in, for the response
+// metadata: the requested locale or the first supported one of its fallback chain.
+func responseLocale(c *gin.Context, ct *models.Collection) string {
+	if locale := c.Query("locale"); locale == models.LocaleAll {
+		return locale
+	}
+	return ct.ResolveLocale(c.Query("locale"))
+}
+
 // CreateItem handles nested creations
 func CreateItem(collection models.Collection) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !hasPermission(c.GetString("role"), "create") {
+		if !hasPermission(c, models.ResourceCollections, collection.Name, models.ActionCreate) {
 			c.Set("response", "Access denied")
 			c.Set("status", http.StatusForbidden)
 			return
@@ -32,25 +79,36 @@ func CreateItem(collection models.Collection) gin.HandlerFunc {
 			return
 		}
 
-		newItem, err := storage.SaveItem(collection, input.Data)
+		data, ok := localizeInput(c, &collection, input.Data, nil)
+		if !ok {
+			return
+		}
+		newItem, err := storage.SaveItem(collection, data, requestActor(c))
 		if err != nil {
 			c.Set("response", err.Error())
-			c.Set("status", http.StatusBadRequest) // Validation errors are Bad Request
+			c.Set("status", writeErrorStatus(err)) // Validation errors are Bad Request
 			return
 		}
 
 		// Format the response
-		newItem.Data["id"] = newItem.ID
+		attributes := models.StripUnreadableFields(collection.Attributes, collection.LocalizeData(newItem.Data, c.Query("locale")), middleware.CurrentRole(c))
+		if expanded, err := storage.ExpandMedia(collection.Attributes, attributes); err == nil {
+			attributes = expanded
+		}
+		attributes["id"] = newItem.ID
 		c.Set("response", gin.H{
 			"id":         newItem.ID,
-			"attributes": newItem.Data,
+			"attributes": attributes,
 		})
 		c.Set("status", http.StatusCreated)
 	}
 }
 
-// GetItems handles pagination and relation hydrating.
-func GetItems(collection models.Collection, level uint) gin.HandlerFunc {
+// GetItems handles filtering, sorting, field selection, pagination and relation hydrating.
+// Pages are read by number ('page') or from the cursor of the previous one ('after'); the
+// matching items are only counted, for 'total' and 'pageCount', when 'withCount' is set.
+// status is either models.ItemStatusPublished or models.ItemStatusDraft (preview).
+func GetItems(collection models.Collection, level uint, status string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Handle pagination from query params
 		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -62,43 +120,128 @@ func GetItems(collection models.Collection, level uint) gin.HandlerFunc {
 			pageSize = 10
 		}
 
-		items, total, err := storage.GetItems(collection.ID, page, pageSize)
+		// Filters, sort and field selection are validated against the collection's attributes.
+		// Hidden attributes can be neither filtered nor sorted on.
+		query, err := models.ParseItemQuery(collection.ReadableBy(middleware.CurrentRole(c)), c.Request.URL.Query())
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch items"})
+			c.Set("response", err.Error())
+			c.Set("status", http.StatusBadRequest)
 			return
 		}
+		query.Status = status
+		opts := readOptions(c, status == models.ItemStatusPublished)
 
-		var results []models.JSONMap
-		for _, item := range items {
-			logger.Log.WithField("item_id", item).Debug("Fetch relation")
-			item.Data["id"] = item.ID
-			// Use the storage layer to fetch relations
-			hydratedData, err := storage.FetchNestedRelations(collection, item.Data, level)
+		// With 'after', items are read from a cursor instead of a page number. Either way,
+		// they are only counted when 'withCount' is set.
+		after, cursorMode := c.GetQuery("after")
+		var items []models.Item
+		var pagination gin.H
+		if cursorMode {
+			items, pagination, err = cursorPage(c, collection.ID, query, after, pageSize)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to populate relations"})
+				var queryErr *models.QueryError
+				if errors.As(err, &queryErr) {
+					c.Set("response", err.Error())
+					c.Set("status", http.StatusBadRequest)
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch items"})
 				return
 			}
-			logger.Log.WithField("hydratedData", hydratedData).Debug("Hydrated")
-			results = append(results, hydratedData)
+		} else {
+			items, pagination, err = numberedPage(c, collection.ID, query, page, pageSize)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch items"})
+				return
+			}
+		}
+
+		data := make([]models.JSONMap, len(items))
+		for i, item := range items {
+			item.Data["id"] = item.ID
+			item.Data["published_at"] = item.PublishedAt
+			data[i] = item.Data
+		}
+		// Use the storage layer to fetch the relations of the whole page at once
+		hydrated, err := storage.FetchItemsRelations(collection, data, level, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to populate relations"})
+			return
+		}
+		var results []models.JSONMap
+		for _, hydratedData := range hydrated {
+			results = append(results, query.SelectFields(hydratedData))
 		}
 
 		// Construct response with pagination metadata
+		meta := gin.H{"pagination": pagination}
+		if collection.IsLocalized() {
+			meta["locale"] = responseLocale(c, &collection)
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"data": results,
-			"meta": gin.H{
-				"pagination": gin.H{
-					"page":      page,
-					"pageSize":  pageSize,
-					"total":     total,
-					"pageCount": (int(total) + pageSize - 1) / pageSize, // Ceiling division
-				},
-			},
+			"meta": meta,
 		})
 	}
 }
 
+// numberedPage reads the items of a page by number, with the pagination metadata of the
+// response: whether there is a next page and, when 'withCount' is set, the number of
+// matching items and pages.
+func numberedPage(c *gin.Context, collectionID uint, query models.ItemQuery, page, pageSize int) ([]models.Item, gin.H, error) {
+	pagination := gin.H{
+		"page":     page,
+		"pageSize": pageSize,
+	}
+	if withCount, _ := strconv.ParseBool(c.Query("withCount")); withCount {
+		items, total, err := storage.QueryItems(collectionID, query, page, pageSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		pagination["hasNextPage"] = page*pageSize < total
+		pagination["total"] = total
+		pagination["pageCount"] = (total + pageSize - 1) / pageSize // Ceiling division
+		return items, pagination, nil
+	}
+
+	// One more item than asked for tells whether there is a next page.
+	items, err := storage.QueryItemsOffset(collectionID, query, (page-1)*pageSize, pageSize+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	pagination["hasNextPage"] = len(items) > pageSize
+	if len(items) > pageSize {
+		items = items[:pageSize]
+	}
+	return items, pagination, nil
+}
+
+// cursorPage reads the items following the cursor after, with the pagination metadata
+// of the response: the cursor to read the next page from and, when 'withCount' is set,
+// the number of matching items.
+func cursorPage(c *gin.Context, collectionID uint, query models.ItemQuery, after string, pageSize int) ([]models.Item, gin.H, error) {
+	page, err := storage.QueryItemsAfter(collectionID, query, after, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	pagination := gin.H{
+		"pageSize":    pageSize,
+		"hasNextPage": page.HasNextPage,
+		"endCursor":   page.EndCursor(),
+	}
+	if withCount, _ := strconv.ParseBool(c.Query("withCount")); withCount {
+		total, err := storage.CountItems(collectionID, query)
+		if err != nil {
+			return nil, nil, err
+		}
+		pagination["total"] = total
+	}
+	return page.Items, pagination, nil
+}
+
 // GetItemByID retrieves a single item by ID.
-func GetItemByID(ct models.Collection, id uint, level uint) gin.HandlerFunc {
+// Drafts are only returned in preview mode (status models.ItemStatusDraft).
+func GetItemByID(ct models.Collection, id uint, level uint, status string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
 		id, err := strconv.Atoi(idStr)
@@ -114,82 +257,146 @@ func GetItemByID(ct models.Collection, id uint, level uint) gin.HandlerFunc {
 			c.Set("status", http.StatusNotFound)
 			return
 		}
+		publishedOnly := status == models.ItemStatusPublished
+		if publishedOnly && !item.IsPublished() {
+			c.Set("response", "Item not found")
+			c.Set("status", http.StatusNotFound)
+			return
+		}
 
-		data, err := storage.FetchNestedRelations(ct, item.Data, level)
+		item.Data["id"] = item.ID
+		data, err := storage.FetchNestedRelations(ct, item.Data, level, readOptions(c, publishedOnly))
 		if err != nil {
 			c.Set("response", "Failed to fetch item relations")
 			c.Set("status", http.StatusInternalServerError)
 			return
 		}
 
-		c.Set("response", utils.FormatNestedItems(item.ID, data, &ct))
+		meta := itemStatusMeta(item.Status, item.PublishedAt, item.PublishAt, item.UnpublishAt)
+		meta["version"] = item.Version
+		if ct.IsLocalized() {
+			meta["locale"] = responseLocale(c, &ct)
+		}
+		setETag(c, item.Version)
+		c.Set("response", utils.FormatNestedItems(item.ID, data, readableCollection(c, &ct)))
 		c.Set("status", http.StatusOK)
-		c.Set("meta", gin.H{})
+		c.Set("meta", meta)
 	}
 }
 
 // UpdateItem now handles nested creations and uses the c.Set response pattern.
 func UpdateItem(collection models.Collection) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		itemID, err := strconv.Atoi(c.Param("id"))
+		updateItem(c, collection, false)
+	}
+}
+
+// PatchItem updates the attributes given in the request body, the others keep their
+// stored values.
+func PatchItem(collection models.Collection) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		updateItem(c, collection, true)
+	}
+}
+
+// updateItem writes the data of the request body to an item, replacing all its data or,
+// when partial, the attributes given only. With an If-Match header the write fails with
+// a conflict unless the item is still at the expected version.
+func updateItem(c *gin.Context, collection models.Collection, partial bool) {
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Set("response", "Invalid ID format")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Data models.JSONMap `json:"data"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Set("response", "Invalid request body")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	var updatedItem models.Item
+	txErr := database.DB.Transaction(func(tx *gorm.DB) error {
+		var itemToUpdate models.Item
+		// The item is locked until the transaction ends, so its version cannot change
+		// between the check and the write.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND collection_id = ?", itemID, collection.ID).
+			First(&itemToUpdate).Error
 		if err != nil {
-			c.Set("response", "Invalid ID format")
-			c.Set("status", http.StatusBadRequest)
-			return
+			return gorm.ErrRecordNotFound
 		}
-
-		var input struct {
-			Data models.JSONMap `json:"data"`
+		if err := models.CheckVersion(expectedVersion, itemToUpdate.Version); err != nil {
+			return err
 		}
-		if err := c.ShouldBindJSON(&input); err != nil {
-			c.Set("response", "Invalid request body")
-			c.Set("status", http.StatusBadRequest)
-			return
+		// Only the translations of the requested locale are replaced.
+		data, err := collection.LocalizeInput(input.Data, itemToUpdate.Data, c.Query("locale"))
+		if err != nil {
+			return err
 		}
+		if partial {
+			merged := models.JSONMap{}
+			maps.Copy(merged, itemToUpdate.Data)
+			maps.Copy(merged, data)
+			data = merged
+		}
+		access := models.WriteAccess{Role: middleware.CurrentRole(c), Current: itemToUpdate.Data}
+		processedData, err := models.ValidateItemValues(collection, data, tx, access)
+		if err != nil {
+			return err
+		}
+		itemToUpdate.Data = processedData
+		if err := tx.Save(&itemToUpdate).Error; err != nil {
+			return err
+		}
+		if err := models.SyncItemRelations(tx, collection, &itemToUpdate); err != nil {
+			return err
+		}
+		if err := storage.RecordItemRevision(tx, &itemToUpdate, models.RevisionActionUpdate, requestActor(c)); err != nil {
+			return err
+		}
+		updatedItem = itemToUpdate
+		return nil
+	})
 
-		var updatedItem models.Item
-		txErr := database.DB.Transaction(func(tx *gorm.DB) error {
-			processedData, err := models.ValidateItemValues(collection, input.Data, tx)
-			if err != nil {
-				return err
-			}
-			var itemToUpdate models.Item
-			if err := tx.Where("id = ? AND collection_id = ?", itemID, collection.ID).First(&itemToUpdate).Error; err != nil {
-				return gorm.ErrRecordNotFound
-			}
-			itemToUpdate.Data = processedData
-			if err := tx.Save(&itemToUpdate).Error; err != nil {
-				return err
-			}
-			updatedItem = itemToUpdate
-			return nil
-		})
-
-		if txErr != nil {
-			if txErr == gorm.ErrRecordNotFound {
-				c.Set("response", "Item not found")
-				c.Set("status", http.StatusNotFound)
-				return
-			}
-			c.Set("response", txErr.Error())
-			c.Set("status", http.StatusBadRequest)
+	if txErr != nil {
+		if txErr == gorm.ErrRecordNotFound {
+			c.Set("response", "Item not found")
+			c.Set("status", http.StatusNotFound)
 			return
 		}
-
-		level, _ := strconv.Atoi(c.DefaultQuery("level", "2"))
-		hydratedData, err := storage.FetchNestedRelations(collection, updatedItem.Data, uint(level))
-		if err != nil {
-			c.Set("response", "Failed to populate relations for response")
-			c.Set("status", http.StatusInternalServerError)
+		if versionConflict(c, txErr) {
 			return
 		}
+		c.Set("response", txErr.Error())
+		c.Set("status", writeErrorStatus(txErr))
+		return
+	}
 
-		c.Set("response", gin.H{
-			"id":         updatedItem.ID,
-			"attributes": hydratedData,
-		})
-		c.Set("status", http.StatusOK)
+	level, _ := strconv.Atoi(c.DefaultQuery("level", "2"))
+	updatedItem.Data["id"] = updatedItem.ID
+	hydratedData, err := storage.FetchNestedRelations(collection, updatedItem.Data, uint(level), readOptions(c, false))
+	if err != nil {
+		c.Set("response", "Failed to populate relations for response")
+		c.Set("status", http.StatusInternalServerError)
+		return
 	}
+
+	setETag(c, updatedItem.Version)
+	c.Set("response", gin.H{
+		"id":         updatedItem.ID,
+		"attributes": hydratedData,
+	})
+	c.Set("meta", gin.H{"version": updatedItem.Version})
+	c.Set("status", http.StatusOK)
 }
 
 func DeleteItem(ct models.Collection) gin.HandlerFunc {
@@ -213,7 +420,14 @@ func DeleteItem(ct models.Collection) gin.HandlerFunc {
 		}
 
 		// Now it is safe to delete!
-		if err := storage.DeleteItem(uint(id)); err != nil {
+		if err := storage.DeleteItem(uint(id), requestActor(c)); err != nil {
+			var referenced *models.ItemReferencedError
+			if errors.As(err, &referenced) {
+				c.Set("response", "Item is referenced by another item")
+				c.Set("details", err.Error())
+				c.Set("status", http.StatusConflict)
+				return
+			}
 			c.Set("response", "Failed to delete item")
 			c.Set("details", err.Error())
 			c.Set("status", http.StatusInternalServerError)
@@ -226,3 +440,143 @@ func DeleteItem(ct models.Collection) gin.HandlerFunc {
 		c.Set("status", http.StatusOK)
 	}
 }
+
+// PublishItem makes an item visible to readers.
+func PublishItem(c *gin.Context) {
+	changeItemStatus(c, storage.PublishItem)
+}
+
+// UnpublishItem moves an item back to draft.
+func UnpublishItem(c *gin.Context) {
+	changeItemStatus(c, storage.UnpublishItem)
+}
+
+func changeItemStatus(c *gin.Context, change func(models.Collection, uint) (*models.Item, error)) {
+	ct, err := storage.GetCollectionByName(c.Param("collection"))
+	if err != nil {
+		c.Set("response", "Collection not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	if !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionPublish) {
+		c.Set("response", "Access denied")
+		c.Set("status", http.StatusForbidden)
+		return
+	}
+
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Set("response", "Invalid ID format")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	item, err := change(*ct, uint(itemID))
+	if err != nil {
+		c.Set("response", "Item not found")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	c.Set("response", formatItem(c, item, ct))
+	c.Set("meta", itemStatusMeta(item.Status, item.PublishedAt, item.PublishAt, item.UnpublishAt))
+	c.Set("status", http.StatusOK)
+}
+
+// readableItem loads the item identified by the ':collection' and ':id' path parameters
+// for a reader. Draft items are only visible to roles with update rights. It sets the
+// error response and returns false when the request cannot proceed.
+func readableItem(c *gin.Context) (*models.Collection, *models.Item, bool) {
+	ct, err := storage.GetCollectionByName(c.Param("collection"))
+	if err != nil {
+		c.Set("response", "Collection not found")
+		c.Set("status", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	if !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionRead) {
+		c.Set("response", "Access denied")
+		c.Set("status", http.StatusForbidden)
+		return nil, nil, false
+	}
+
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || itemID <= 0 {
+		c.Set("response", "Invalid ID format")
+		c.Set("status", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	item, err := storage.GetItemByID(ct.ID, uint(itemID))
+	if err == nil && !item.IsPublished() && !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionUpdate) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		c.Set("response", "Item not found")
+		c.Set("status", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return ct, item, true
+}
+
+// GetItemLocales reports the translation status of an item: for each locale of the
+// collection, the localized attributes that have a value in another locale but not in
+// this one. Draft items are only visible to roles with update rights.
+func GetItemLocales(c *gin.Context) {
+	ct, item, ok := readableItem(c)
+	if !ok {
+		return
+	}
+
+	missing := ct.MissingTranslations(item.Data, readableCollection(c, ct).Attributes)
+	complete := []string{}
+	for _, locale := range ct.Locales {
+		if len(missing[locale]) == 0 {
+			complete = append(complete, locale)
+		}
+	}
+
+	c.Set("response", gin.H{
+		"default_locale": ct.DefaultLocale,
+		"locales":        ct.Locales,
+		"missing":        missing,
+		"complete":       complete,
+	})
+	c.Set("status", http.StatusOK)
+}
+
+// GetItemReferences lists the items referencing an item through their relations, e.g.
+// the articles whose author is a given author. The 'source' and 'attribute' query
+// parameters restrict the list to a collection and a relation attribute. Referencing
+// items the user may not see are left out.
+func GetItemReferences(c *gin.Context) {
+	_, item, ok := readableItem(c)
+	if !ok {
+		return
+	}
+
+	references, err := storage.GetItemReferences(item.ID, c.Query("source"), c.Query("attribute"))
+	if err != nil {
+		c.Set("response", "Failed to fetch item references")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	visible := []storage.ItemReference{}
+	for _, reference := range references {
+		if !hasPermission(c, models.ResourceCollections, reference.Collection, models.ActionRead) {
+			continue
+		}
+		if reference.Status != models.ItemStatusPublished && !hasPermission(c, models.ResourceCollections, reference.Collection, models.ActionUpdate) {
+			continue
+		}
+		visible = append(visible, reference)
+	}
+
+	c.Set("response", visible)
+	c.Set("meta", gin.H{"total": len(visible)})
+	c.Set("status", http.StatusOK)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,10 +12,12 @@ import (
 	"github.com/gohead-cms/gohead/internal/models"
 	"github.com/gohead-cms/gohead/pkg/auth"
 	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
 	"github.com/gohead-cms/gohead/pkg/testutils"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Initialize logger for testing
@@ -39,7 +42,7 @@ func TestCreateItemIntegration(t *testing.T) {
 	assert.NoError(t, db.AutoMigrate(&models.User{}, &models.UserRole{}, &models.Collection{}, &models.Attribute{}, &models.Item{}))
 
 	// Seed roles with correct permissions for the test
-	adminRole := models.UserRole{Name: "admin", Description: "Administrator", Permissions: models.JSONMap{"create": true}}
+	adminRole := models.UserRole{Name: "admin", Description: "Administrator", Permissions: models.JSONMap{"collections.*.create": true}}
 	assert.NoError(t, db.Create(&adminRole).Error)
 
 	// Create a test collection
@@ -134,3 +137,123 @@ func TestCreateItemIntegration(t *testing.T) {
 		assert.Contains(t, errorData["message"], "missing required attribute")
 	})
 }
+
+func TestUpdateItemIntegration(t *testing.T) {
+	router, db := testutils.SetupTestServer()
+	router.Use(middleware.ResponseWrapper())
+	protected := router.Group("/")
+	protected.Use(middleware.AuthMiddleware())
+	defer testutils.CleanupTestDB()
+
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.UserRole{}, &models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRevision{}, &models.ItemRelation{}))
+	editorRole := models.UserRole{Name: "editor", Permissions: models.JSONMap{"collections.*.update": true, "collections.*.read": true}}
+	require.NoError(t, db.Create(&editorRole).Error)
+
+	collection := models.Collection{
+		Name: "articles",
+		Attributes: []models.Attribute{
+			{Name: "title", Type: "string", Required: true},
+			{Name: "content", Type: "richtext", Required: true},
+		},
+	}
+	require.NoError(t, storage.SaveCollection(&collection))
+	item, err := storage.SaveItem(collection, models.JSONMap{"title": "Draft", "content": "Kept as is."}, models.UserActor("tester"))
+	require.NoError(t, err)
+	protected.PUT("/:collection/:id", DynamicCollectionHandler)
+	protected.PATCH("/:collection/:id", DynamicCollectionHandler)
+
+	token, err := auth.GenerateJWT("test_editor", "editor")
+	require.NoError(t, err)
+	send := func(method, ifMatch string, data map[string]any) (*httptest.ResponseRecorder, map[string]any) {
+		body, _ := json.Marshal(map[string]any{"data": data})
+		req, _ := http.NewRequest(method, fmt.Sprintf("/articles/%d", item.ID), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return rr, response
+	}
+
+	t.Run("Patch keeps the attributes left out", func(t *testing.T) {
+		rr, response := send(http.MethodPatch, `"1"`, map[string]any{"title": "Patched"})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+		data, ok := response["data"].(map[string]any)
+		require.True(t, ok, "Response should have a 'data' key")
+		attributes, ok := data["attributes"].(map[string]any)
+		require.True(t, ok, "Item should have an 'attributes' key")
+		assert.Equal(t, "Patched", attributes["title"])
+		assert.Equal(t, "Kept as is.", attributes["content"])
+	})
+
+	t.Run("Stale If-Match is a conflict", func(t *testing.T) {
+		rr, response := send(http.MethodPut, `"1"`, map[string]any{"title": "Overwritten", "content": "Lost update."})
+		require.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+		assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+		errorData, ok := response["error"].(map[string]any)
+		require.True(t, ok, "Response should have an 'error' key")
+		details, ok := errorData["details"].(map[string]any)
+		require.True(t, ok, "Error should have 'details'")
+		assert.Equal(t, float64(2), details["current_version"])
+		assert.Equal(t, float64(1), details["expected_version"])
+
+		stored, err := storage.GetItemByID(collection.ID, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Patched", stored.Data["title"])
+	})
+
+	t.Run("Current If-Match replaces the item", func(t *testing.T) {
+		rr, _ := send(http.MethodPut, `"2"`, map[string]any{"title": "Replaced", "content": "New content."})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+	})
+}
+
+func TestGetItemsPagination(t *testing.T) {
+	router, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRevision{}, &models.ItemRelation{}))
+
+	collection := models.Collection{Name: "articles", Attributes: []models.Attribute{{Name: "title", Type: "string"}}}
+	require.NoError(t, storage.SaveCollection(&collection))
+	for _, title := range []string{"first", "second", "third"} {
+		item, err := storage.SaveItem(collection, models.JSONMap{"title": title}, models.UserActor("tester"))
+		require.NoError(t, err)
+		_, err = storage.PublishItem(collection, item.ID)
+		require.NoError(t, err)
+	}
+	router.GET("/articles", GetItems(collection, 0, models.ItemStatusPublished))
+
+	pagination := func(query string) map[string]any {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/articles?pageSize=2&"+query, nil)
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var response struct {
+			Meta struct {
+				Pagination map[string]any `json:"pagination"`
+			} `json:"meta"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response.Meta.Pagination
+	}
+
+	// Items are only counted when asked to.
+	assert.Equal(t, map[string]any{"page": float64(1), "pageSize": float64(2), "hasNextPage": true}, pagination("page=1"))
+	assert.Equal(t, map[string]any{"page": float64(2), "pageSize": float64(2), "hasNextPage": false}, pagination("page=2"))
+	assert.Equal(t, map[string]any{
+		"page": float64(2), "pageSize": float64(2), "hasNextPage": false, "total": float64(3), "pageCount": float64(2),
+	}, pagination("page=2&withCount=true"))
+
+	cursor := pagination("after=")
+	assert.NotContains(t, cursor, "total")
+	assert.Equal(t, true, cursor["hasNextPage"])
+	assert.Equal(t, float64(3), pagination("after=&withCount=true")["total"])
+}
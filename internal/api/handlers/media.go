@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/media"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// UploadAsset stores a file sent as the 'file' field of a multipart form and adds it
+// to the media library. An optional 'alt_text' field sets its alternative text.
+func UploadAsset(c *gin.Context) {
+	driver := media.CurrentDriver()
+	if driver == nil {
+		c.Set("response", "Media storage is not configured")
+		c.Set("status", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := media.MaxUploadSize()
+	if limit > 0 {
+		// Leave room for the multipart envelope and the other form fields.
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+1<<20)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.Set("response", "File exceeds the maximum upload size of "+strconv.FormatInt(limit, 10)+" bytes")
+		c.Set("status", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		c.Set("response", "Missing 'file' in multipart form")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if limit > 0 && fileHeader.Size > limit {
+		c.Set("response", "File exceeds the maximum upload size of "+strconv.FormatInt(limit, 10)+" bytes")
+		c.Set("status", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Set("response", "Failed to read uploaded file")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	info, err := media.Inspect(file)
+	if err != nil {
+		c.Set("response", "Failed to read uploaded file")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	key := media.ObjectKey(info, fileHeader.Filename)
+	if err := driver.Put(c.Request.Context(), key, file, info.Size, info.MimeType); err != nil {
+		logger.Log.WithField("key", key).WithError(err).Error("Failed to store uploaded file")
+		c.Set("response", "Failed to store uploaded file")
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	asset := models.Asset{
+		Name:       filepath.Base(fileHeader.Filename),
+		MimeType:   info.MimeType,
+		Size:       info.Size,
+		Checksum:   info.Checksum,
+		Width:      info.Width,
+		Height:     info.Height,
+		AltText:    c.PostForm("alt_text"),
+		Driver:     driver.Name(),
+		StorageKey: key,
+		URL:        driver.URL(key),
+	}
+	if err := storage.CreateAsset(&asset); err != nil {
+		c.Set("response", "Failed to save asset")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	if err := media.EnqueueVariants(c.Request.Context(), &asset); err != nil {
+		// The upload itself succeeded; the asset is served without variants.
+		logger.Log.WithField("asset_id", asset.ID).WithError(err).Warn("Failed to schedule image variants")
+	}
+
+	logger.Log.WithFields(logrus.Fields{
+		"asset_id":  asset.ID,
+		"mime_type": asset.MimeType,
+		"size":      asset.Size,
+	}).Info("Asset uploaded")
+	c.Set("response", asset)
+	c.Set("status", http.StatusCreated)
+}
+
+// GetAssets lists the media library, newest first. The 'mime' query parameter
+// filters on a mime type prefix, e.g. ?mime=image/.
+func GetAssets(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "25"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 25
+	}
+
+	assets, total, err := storage.GetAssets(c.Query("mime"), page, pageSize)
+	if err != nil {
+		c.Set("response", "Failed to fetch assets")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", assets)
+	c.Set("meta", gin.H{
+		"pagination": gin.H{
+			"page":      page,
+			"pageSize":  pageSize,
+			"total":     total,
+			"pageCount": (total + pageSize - 1) / pageSize,
+		},
+	})
+	c.Set("status", http.StatusOK)
+}
+
+// assetFromParam loads the asset identified by the ':id' path parameter.
+// It sets the error response and returns nil when the asset cannot be found.
+func assetFromParam(c *gin.Context) *models.Asset {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.Set("response", "Invalid asset ID")
+		c.Set("status", http.StatusBadRequest)
+		return nil
+	}
+	asset, err := storage.GetAssetByID(uint(id))
+	if err != nil {
+		c.Set("response", "Asset not found")
+		c.Set("status", http.StatusNotFound)
+		return nil
+	}
+	return asset
+}
+
+// GetAsset retrieves an asset of the media library. When any of the 'w', 'h', 'fit' or
+// 'fmt' query parameters is set, it returns the image resized and converted instead,
+// e.g. ?w=640&fmt=webp. Results are cached by the storage driver.
+func GetAsset(c *gin.Context) {
+	asset := assetFromParam(c)
+	if asset == nil {
+		return
+	}
+
+	transform, requested, err := transformFromQuery(c)
+	if err != nil {
+		c.Set("response", "Invalid image transform")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if !requested {
+		c.Set("response", asset)
+		c.Set("status", http.StatusOK)
+		return
+	}
+	serveTransformedImage(c, asset, transform, "private, max-age=86400")
+}
+
+// GetMediaFile serves the file of an asset to anyone, like the files of the storage
+// driver: the image resized and converted when any of the 'w', 'h', 'fit' or 'fmt' query
+// parameters is set, e.g. /media/12?w=640&fmt=webp, or else a redirect to its URL.
+func GetMediaFile(c *gin.Context) {
+	asset := assetFromParam(c)
+	if asset == nil {
+		return
+	}
+
+	transform, requested, err := transformFromQuery(c)
+	if err != nil {
+		c.Set("response", "Invalid image transform")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if !requested {
+		c.Redirect(http.StatusFound, asset.URL)
+		return
+	}
+	serveTransformedImage(c, asset, transform, "public, max-age=86400")
+}
+
+// serveTransformedImage writes an image asset resized and converted by transform,
+// caching the result in the storage driver and, with cacheControl, in HTTP caches.
+func serveTransformedImage(c *gin.Context, asset *models.Asset, transform media.Transform, cacheControl string) {
+	driver := media.CurrentDriver()
+	if driver == nil || driver.Name() != asset.Driver {
+		c.Set("response", "The asset file is not available")
+		c.Set("status", http.StatusServiceUnavailable)
+		return
+	}
+	if !asset.IsImage() {
+		c.Set("response", "Only images can be transformed")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if transform.Width == 0 && transform.Height == 0 {
+		// Format conversion only: the image keeps its size, within the largest one
+		// rendered, and its proportions.
+		transform.Width = min(*asset.Width, media.MaxTransformDimension)
+		transform.Height = min(*asset.Height, media.MaxTransformDimension)
+		transform.Fit = media.FitInside
+	}
+	if err := transform.Validate(); err != nil {
+		c.Set("response", "Invalid image transform")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	file, mimeType, err := media.OpenVariant(c.Request.Context(), driver, asset, transform)
+	if err != nil {
+		logger.Log.WithField("asset_id", asset.ID).WithError(err).Warn("Failed to transform image")
+		c.Set("response", "Failed to transform image")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusUnprocessableEntity)
+		return
+	}
+	defer file.Close()
+
+	c.Header("Cache-Control", cacheControl)
+	c.DataFromReader(http.StatusOK, -1, mimeType, file, nil)
+}
+
+// transformFromQuery reads an image transform from the 'w', 'h', 'fit' and 'fmt' query
+// parameters. It reports whether any of them is set.
+func transformFromQuery(c *gin.Context) (media.Transform, bool, error) {
+	var t media.Transform
+	requested := false
+	for _, param := range []struct {
+		name   string
+		target *int
+	}{{"w", &t.Width}, {"h", &t.Height}} {
+		value, ok := c.GetQuery(param.name)
+		if !ok {
+			continue
+		}
+		requested = true
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return t, true, fmt.Errorf("'%s' must be a positive integer", param.name)
+		}
+		*param.target = n
+	}
+	if fit, ok := c.GetQuery("fit"); ok {
+		requested, t.Fit = true, fit
+	}
+	if format, ok := c.GetQuery("fmt"); ok {
+		requested, t.Format = true, format
+	}
+	return t, requested, nil
+}
+
+// UpdateAsset changes the alternative text of an asset.
+func UpdateAsset(c *gin.Context) {
+	asset := assetFromParam(c)
+	if asset == nil {
+		return
+	}
+
+	var input struct {
+		AltText *string `json:"alt_text"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.AltText == nil {
+		c.Set("response", "Invalid input, expected 'alt_text'")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := storage.UpdateAssetAltText(asset.ID, *input.AltText)
+	if err != nil {
+		c.Set("response", "Failed to update asset")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+	c.Set("response", updated)
+	c.Set("status", http.StatusOK)
+}
+
+// DeleteAsset removes an asset from the media library, and its file when no other
+// asset shares it. Items referencing the asset no longer show it.
+func DeleteAsset(c *gin.Context) {
+	asset := assetFromParam(c)
+	if asset == nil {
+		return
+	}
+
+	unused, err := storage.DeleteAsset(asset)
+	if err != nil {
+		c.Set("response", "Failed to delete asset")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	if driver := media.CurrentDriver(); unused && driver != nil && driver.Name() == asset.Driver {
+		if err := driver.Delete(c.Request.Context(), asset.StorageKey); err != nil {
+			// The record is gone; a leftover file is only wasted space.
+			logger.Log.WithField("asset_id", asset.ID).WithError(err).Warn("Failed to delete asset file")
+		}
+		if err := driver.DeletePrefix(c.Request.Context(), media.VariantPrefix(asset.StorageKey)); err != nil {
+			logger.Log.WithField("asset_id", asset.ID).WithError(err).Warn("Failed to delete asset variants")
+		}
+	}
+
+	c.Set("response", "Asset deleted")
+	c.Set("status", http.StatusOK)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/api/middleware"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/config"
+	"github.com/gohead-cms/gohead/pkg/media"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMediaFile(t *testing.T) {
+	router, db := testutils.SetupTestServer()
+	router.Use(middleware.ResponseWrapper())
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Asset{}))
+
+	driver, err := media.NewLocalDriver(t.TempDir(), "/uploads")
+	require.NoError(t, err)
+	require.NoError(t, media.Init(driver, config.MediaConfig{}))
+	defer func() { _ = media.Init(nil, config.MediaConfig{}) }()
+
+	// An image wider than the largest transform, to convert without resizing.
+	width, height := media.MaxTransformDimension+4, 8
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{uint8(x), 0, 128, 255})
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, img))
+	require.NoError(t, driver.Put(context.Background(), "banner.png", bytes.NewReader(encoded.Bytes()), int64(encoded.Len()), "image/png"))
+	asset := models.Asset{
+		MimeType: "image/png", Size: int64(encoded.Len()), Width: &width, Height: &height,
+		Driver: driver.Name(), StorageKey: "banner.png", URL: driver.URL("banner.png"),
+	}
+	require.NoError(t, db.Create(&asset).Error)
+	router.GET("/media/:id", middleware.MediaHeaders(), GetMediaFile)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/media/%d%s", asset.ID, query), nil)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Without a transform, redirects to the file", func(t *testing.T) {
+		rr := get("")
+		assert.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, asset.URL, rr.Header().Get("Location"))
+	})
+
+	t.Run("Format conversion of a large image", func(t *testing.T) {
+		rr := get("?fmt=webp")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, "image/webp", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+		decoded, err := media.DecodeImage(rr.Body)
+		require.NoError(t, err)
+		assert.Equal(t, media.MaxTransformDimension, decoded.Bounds().Dx())
+	})
+
+	t.Run("Resize", func(t *testing.T) {
+		rr := get("?w=100&fmt=png")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		decoded, err := media.DecodeImage(rr.Body)
+		require.NoError(t, err)
+		assert.Equal(t, 100, decoded.Bounds().Dx())
+	})
+
+	t.Run("Invalid transform", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("?w=wide").Code)
+		assert.Equal(t, http.StatusBadRequest, get(fmt.Sprintf("?w=%d", media.MaxTransformDimension+1)).Code)
+	})
+}
@@ -1,26 +1,52 @@
 package handlers
 
-func hasPermission(role, action string) bool {
-	permissions := map[string]map[string]bool{
-		"admin": {
-			"create": true,
-			"read":   true,
-			"update": true,
-			"delete": true,
-		},
-		"editor": {
-			"create": true,
-			"read":   true,
-			"update": true,
-			"delete": true,
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gohead-cms/gohead/internal/api/middleware"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hasPermission reports whether the authenticated user's role grants action on the
+// named resource, e.g. hasPermission(c, models.ResourceCollections, "articles", models.ActionUpdate).
+func hasPermission(c *gin.Context, resource, name, action string) bool {
+	role := middleware.CurrentRole(c)
+	return role != nil && role.Can(models.Permission(resource, name, action))
+}
+
+// readOptions returns the storage read options for the user's role: related items of
+// collections the role may not read are hidden, as are attributes it may not read.
+// Localized attributes are read in the locale of the 'locale' query parameter.
+func readOptions(c *gin.Context, publishedOnly bool) storage.ReadOptions {
+	role := middleware.CurrentRole(c)
+	return storage.ReadOptions{
+		PublishedOnly: publishedOnly,
+		Locale:        c.Query("locale"),
+		CanRead: func(collectionName string) bool {
+			return hasPermission(c, models.ResourceCollections, collectionName, models.ActionRead)
 		},
-		"viewer": {
-			"read": true,
+		CanReadField: func(attribute models.Attribute) bool {
+			return attribute.ReadableBy(role)
 		},
 	}
+}
+
+// readableCollection returns ct limited to the attributes the user's role may read,
+// for formatting responses.
+func readableCollection(c *gin.Context, ct *models.Collection) *models.Collection {
+	readable := ct.ReadableBy(middleware.CurrentRole(c))
+	return &readable
+}
 
-	if rolePermissions, exists := permissions[role]; exists {
-		return rolePermissions[action]
+// writeErrorStatus returns the HTTP status for an error rejecting an item write.
+func writeErrorStatus(err error) int {
+	var accessErr *models.FieldAccessError
+	if errors.As(err, &accessErr) {
+		return http.StatusForbidden
 	}
-	return false
+	return http.StatusBadRequest
 }
@@ -37,8 +37,8 @@ func TestProtectedHandlerWithPermissions(t *testing.T) {
 	assert.NoError(t, db.AutoMigrate(&models.User{}, &models.UserRole{}, &models.Collection{}, &models.Attribute{}))
 
 	// Seed roles with the required permissions for the test cases.
-	adminRole := models.UserRole{Name: "admin", Permissions: models.JSONMap{"create": true}}
-	userRole := models.UserRole{Name: "user", Permissions: models.JSONMap{"read": true}}
+	adminRole := models.UserRole{Name: "admin", Permissions: models.JSONMap{"*": true}}
+	userRole := models.UserRole{Name: "user", Permissions: models.JSONMap{"collections.*.read": true}}
 	assert.NoError(t, db.Create(&adminRole).Error)
 	assert.NoError(t, db.Create(&userRole).Error)
 
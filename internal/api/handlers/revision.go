@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gohead-cms/gohead/internal/api/middleware"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// revisionTarget resolves the collection and item ID of a revision request.
+// Revisions may hold draft content, so they are limited to roles with update rights.
+// It sets the error response and returns false when the request cannot proceed.
+func revisionTarget(c *gin.Context) (*models.Collection, uint, bool) {
+	ct, err := storage.GetCollectionByName(c.Param("collection"))
+	if err != nil {
+		c.Set("response", "Collection not found")
+		c.Set("status", http.StatusNotFound)
+		return nil, 0, false
+	}
+
+	if !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionUpdate) {
+		c.Set("response", "Access denied")
+		c.Set("status", http.StatusForbidden)
+		return nil, 0, false
+	}
+
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || itemID <= 0 {
+		c.Set("response", "Invalid ID format")
+		c.Set("status", http.StatusBadRequest)
+		return nil, 0, false
+	}
+	return ct, uint(itemID), true
+}
+
+// hideRevisionFields removes from revisions the attributes the user's role may not read.
+func hideRevisionFields(c *gin.Context, ct *models.Collection, revisions ...*models.ItemRevision) {
+	role := middleware.CurrentRole(c)
+	for _, revision := range revisions {
+		revision.Data = models.StripUnreadableFields(ct.Attributes, revision.Data, role)
+	}
+}
+
+// parseVersion reads a revision version number from a path or query value.
+func parseVersion(c *gin.Context, raw, name string) (int, bool) {
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 1 {
+		c.Set("response", "Invalid revision version for '"+name+"'")
+		c.Set("status", http.StatusBadRequest)
+		return 0, false
+	}
+	return version, true
+}
+
+// GetItemRevisions lists the revisions of an item, newest first.
+func GetItemRevisions(c *gin.Context) {
+	ct, itemID, ok := revisionTarget(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := storage.GetItemRevisions(ct.ID, itemID)
+	if err != nil {
+		c.Set("response", "Failed to fetch revisions")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range revisions {
+		hideRevisionFields(c, ct, &revisions[i])
+	}
+
+	c.Set("response", revisions)
+	c.Set("meta", gin.H{"total": len(revisions)})
+	c.Set("status", http.StatusOK)
+}
+
+// GetItemRevision retrieves a single revision of an item.
+func GetItemRevision(c *gin.Context) {
+	ct, itemID, ok := revisionTarget(c)
+	if !ok {
+		return
+	}
+	version, ok := parseVersion(c, c.Param("version"), "version")
+	if !ok {
+		return
+	}
+
+	revision, err := storage.GetItemRevision(ct.ID, itemID, version)
+	if err != nil {
+		c.Set("response", "Revision not found")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	hideRevisionFields(c, ct, revision)
+
+	c.Set("response", revision)
+	c.Set("status", http.StatusOK)
+}
+
+// DiffItemRevisions compares two revisions of an item field by field.
+// The revisions are given by the 'from' and 'to' query parameters.
+func DiffItemRevisions(c *gin.Context) {
+	ct, itemID, ok := revisionTarget(c)
+	if !ok {
+		return
+	}
+	fromVersion, ok := parseVersion(c, c.Query("from"), "from")
+	if !ok {
+		return
+	}
+	toVersion, ok := parseVersion(c, c.Query("to"), "to")
+	if !ok {
+		return
+	}
+
+	from, err := storage.GetItemRevision(ct.ID, itemID, fromVersion)
+	if err != nil {
+		c.Set("response", "Revision not found")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+	to, err := storage.GetItemRevision(ct.ID, itemID, toVersion)
+	if err != nil {
+		c.Set("response", "Revision not found")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	hideRevisionFields(c, ct, from, to)
+
+	c.Set("response", models.DiffRevisions(*from, *to))
+	c.Set("meta", gin.H{"from": fromVersion, "to": toVersion})
+	c.Set("status", http.StatusOK)
+}
+
+// RestoreItemRevision writes an old revision back as a new update of the item.
+func RestoreItemRevision(c *gin.Context) {
+	ct, itemID, ok := revisionTarget(c)
+	if !ok {
+		return
+	}
+	version, ok := parseVersion(c, c.Param("version"), "version")
+	if !ok {
+		return
+	}
+
+	if _, err := storage.GetItemRevision(ct.ID, itemID, version); err != nil {
+		c.Set("response", "Revision not found")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	item, err := storage.RestoreItemRevision(*ct, itemID, version, requestActor(c))
+	if err != nil {
+		logger.Log.WithFields(logrus.Fields{
+			"collection": ct.Name,
+			"item_id":    itemID,
+			"version":    version,
+		}).WithError(err).Warn("Failed to restore item revision")
+		c.Set("response", "Failed to restore revision")
+		c.Set("details", err.Error())
+		c.Set("status", writeErrorStatus(err))
+		return
+	}
+
+	c.Set("response", formatItem(c, item, ct))
+	c.Set("meta", gin.H{"restored_from": version})
+	c.Set("status", http.StatusOK)
+}
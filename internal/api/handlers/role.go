@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gohead-cms/gohead/internal/api/middleware"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// protectedRole cannot have its permissions changed or be deleted, so that
+// at least one role can always manage the others.
+const protectedRole = "admin"
+
+// ungrantedPermissions returns the permissions granted in permissions that the role of
+// the caller does not hold itself, so that no role can grant more than it has.
+func ungrantedPermissions(c *gin.Context, permissions models.JSONMap) []string {
+	caller := middleware.CurrentRole(c)
+	var ungranted []string
+	for permission, value := range permissions {
+		if granted, _ := value.(bool); granted && (caller == nil || !caller.Can(permission)) {
+			ungranted = append(ungranted, permission)
+		}
+	}
+	sort.Strings(ungranted)
+	return ungranted
+}
+
+// GetRoles lists all roles with their permission sets.
+func GetRoles(c *gin.Context) {
+	roles, err := storage.GetAllRoles()
+	if err != nil {
+		c.Set("response", "Failed to fetch roles")
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", roles)
+	c.Set("meta", gin.H{"total": len(roles)})
+	c.Set("status", http.StatusOK)
+}
+
+// GetRole retrieves a role by name.
+func GetRole(c *gin.Context) {
+	role, err := storage.GetRoleByName(c.Param("name"))
+	if err != nil {
+		c.Set("response", "Role not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	c.Set("response", role)
+	c.Set("status", http.StatusOK)
+}
+
+// CreateRole creates a custom role with its permission set.
+func CreateRole(c *gin.Context) {
+	var input struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		Permissions models.JSONMap `json:"permissions"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Set("response", "Invalid input format")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	role := models.UserRole{Name: input.Name, Description: input.Description, Permissions: input.Permissions}
+	if err := models.ValidateUserRole(role); err != nil {
+		c.Set("response", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if err := models.ValidateRolePermissions(role.Permissions); err != nil {
+		c.Set("response", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if ungranted := ungrantedPermissions(c, role.Permissions); len(ungranted) > 0 {
+		c.Set("response", "Cannot grant permissions the caller does not hold")
+		c.Set("details", gin.H{"permissions": ungranted})
+		c.Set("status", http.StatusForbidden)
+		return
+	}
+	if _, err := storage.GetRoleByName(role.Name); err == nil {
+		c.Set("response", "Role '"+role.Name+"' already exists")
+		c.Set("status", http.StatusConflict)
+		return
+	}
+
+	if err := storage.SaveRole(&role); err != nil {
+		c.Set("response", "Failed to create role")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", role)
+	c.Set("status", http.StatusCreated)
+}
+
+// UpdateRole replaces the description and/or the permission set of a role.
+func UpdateRole(c *gin.Context) {
+	role, err := storage.GetRoleByName(c.Param("name"))
+	if err != nil {
+		c.Set("response", "Role not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	var input struct {
+		Description *string        `json:"description"`
+		Permissions models.JSONMap `json:"permissions"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Set("response", "Invalid input format")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	updates := map[string]any{}
+	if input.Description != nil {
+		updates["description"] = *input.Description
+	}
+	if input.Permissions != nil {
+		if role.Name == protectedRole {
+			c.Set("response", "The permissions of the '"+protectedRole+"' role cannot be changed")
+			c.Set("status", http.StatusForbidden)
+			return
+		}
+		if len(input.Permissions) == 0 {
+			c.Set("response", "at least one permission is required for the role")
+			c.Set("status", http.StatusBadRequest)
+			return
+		}
+		if err := models.ValidateRolePermissions(input.Permissions); err != nil {
+			c.Set("response", err.Error())
+			c.Set("status", http.StatusBadRequest)
+			return
+		}
+		if ungranted := ungrantedPermissions(c, input.Permissions); len(ungranted) > 0 {
+			c.Set("response", "Cannot grant permissions the caller does not hold")
+			c.Set("details", gin.H{"permissions": ungranted})
+			c.Set("status", http.StatusForbidden)
+			return
+		}
+		updates["permissions"] = input.Permissions
+	}
+	if len(updates) == 0 {
+		c.Set("response", "Nothing to update")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	if err := storage.UpdateRole(role.ID, updates); err != nil {
+		c.Set("response", "Failed to update role")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	updated, err := storage.GetRoleByID(role.ID)
+	if err != nil {
+		c.Set("response", "Failed to reload role")
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+	c.Set("response", updated)
+	c.Set("status", http.StatusOK)
+}
+
+// DeleteRole deletes a role that is no longer assigned to any user.
+func DeleteRole(c *gin.Context) {
+	role, err := storage.GetRoleByName(c.Param("name"))
+	if err != nil {
+		c.Set("response", "Role not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+	if role.Name == protectedRole {
+		c.Set("response", "The '"+protectedRole+"' role cannot be deleted")
+		c.Set("status", http.StatusForbidden)
+		return
+	}
+
+	users, err := storage.CountUsersWithRole(role.ID)
+	if err != nil {
+		c.Set("response", "Failed to check role assignments")
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+	if users > 0 {
+		c.Set("response", "Role is still assigned to users")
+		c.Set("details", gin.H{"users": users})
+		c.Set("status", http.StatusConflict)
+		return
+	}
+
+	if err := storage.DeleteRole(role.ID); err != nil {
+		c.Set("response", "Failed to delete role")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Log.WithField("role", role.Name).Info("Role deleted successfully")
+	c.Set("response", nil)
+	c.Set("status", http.StatusOK)
+}
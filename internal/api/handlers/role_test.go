@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/api/middleware"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/auth"
+	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleGrants(t *testing.T) {
+	router, db := testutils.SetupTestServer()
+	router.Use(middleware.ResponseWrapper())
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.UserRole{}, &models.User{}))
+	require.NoError(t, db.Create(&models.UserRole{Name: "team-lead", Permissions: models.JSONMap{
+		"roles.team-lead.manage": true,
+		"roles.writer.manage":    true,
+		"collections.*.read":     true,
+	}}).Error)
+	require.NoError(t, db.Create(&models.UserRole{Name: "writer", Permissions: models.JSONMap{"collections.articles.read": true}}).Error)
+
+	auth.InitializeJWT("test-secret")
+	token, err := auth.GenerateJWT("lead", "team-lead")
+	require.NoError(t, err)
+
+	roles := router.Group("/admin/roles", middleware.AuthMiddleware(), middleware.RequirePermission(models.ResourceRoles, models.ActionManage, "name"))
+	roles.PUT("/:name", UpdateRole)
+	put := func(name string, permissions models.JSONMap) int {
+		body, _ := json.Marshal(map[string]any{"permissions": permissions})
+		req, _ := http.NewRequest(http.MethodPut, "/admin/roles/"+name, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// A role managing itself cannot grant itself more than it holds.
+	assert.Equal(t, http.StatusForbidden, put("team-lead", models.JSONMap{"*": true}))
+	assert.Equal(t, http.StatusForbidden, put("team-lead", models.JSONMap{"roles.team-lead.manage": true, "collections.*.update": true}))
+	role, err := storage.GetRoleByName("team-lead")
+	require.NoError(t, err)
+	assert.False(t, role.Can("collections.articles.update"))
+
+	// Permissions it holds can be granted, permissions listed as not granted are ignored.
+	assert.Equal(t, http.StatusOK, put("writer", models.JSONMap{"collections.*.read": true, "collections.*.delete": false}))
+	assert.Equal(t, http.StatusForbidden, put("writer", models.JSONMap{"collections.articles.update": true}))
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// itemStatusMeta describes the publication state of an item or single item, with its
+// schedule when one is set.
+func itemStatusMeta(status string, publishedAt, publishAt, unpublishAt *time.Time) gin.H {
+	meta := gin.H{"status": status, "published_at": publishedAt}
+	if publishAt != nil {
+		meta["publish_at"] = publishAt
+	}
+	if unpublishAt != nil {
+		meta["unpublish_at"] = unpublishAt
+	}
+	return meta
+}
+
+// bindSchedule reads and validates the schedule in the request body. It sets the error
+// response and returns false when the schedule is invalid.
+func bindSchedule(c *gin.Context) (models.ItemSchedule, bool) {
+	var schedule models.ItemSchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		c.Set("response", "Invalid request body")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return schedule, false
+	}
+	if err := models.ValidateItemSchedule(schedule, time.Now()); err != nil {
+		c.Set("response", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return schedule, false
+	}
+	return schedule, true
+}
+
+// ScheduleItem replaces the publication schedule of an item with the 'publish_at' and
+// 'unpublish_at' times of the request body. A missing or null time cancels the
+// corresponding transition.
+func ScheduleItem(c *gin.Context) {
+	ct, err := storage.GetCollectionByName(c.Param("collection"))
+	if err != nil {
+		c.Set("response", "Collection not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+	if !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionPublish) {
+		c.Set("response", "Access denied")
+		c.Set("status", http.StatusForbidden)
+		return
+	}
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Set("response", "Invalid ID format")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	schedule, ok := bindSchedule(c)
+	if !ok {
+		return
+	}
+
+	item, err := storage.ScheduleItem(*ct, uint(itemID), schedule)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Set("response", "Item not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		c.Set("response", "Failed to schedule item")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", formatItem(c, item, ct))
+	c.Set("meta", itemStatusMeta(item.Status, item.PublishedAt, item.PublishAt, item.UnpublishAt))
+	c.Set("status", http.StatusOK)
+}
+
+// ScheduleSingleItem replaces the publication schedule of the single item of a
+// singleton, like ScheduleItem.
+func ScheduleSingleItem(c *gin.Context) {
+	singletonName := c.Param("name")
+	if !hasPermission(c, models.ResourceSingletons, singletonName, models.ActionPublish) {
+		c.Set("response", "Access denied")
+		c.Set("status", http.StatusForbidden)
+		return
+	}
+	schedule, ok := bindSchedule(c)
+	if !ok {
+		return
+	}
+
+	item, err := storage.ScheduleSingleItem(singletonName, schedule)
+	if err != nil {
+		c.Set("response", "Single item not found")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]any{"id": item.ID, "attributes": item.Data}
+	maps.Copy(response, itemStatusMeta(item.Status, item.PublishedAt, item.PublishAt, item.UnpublishAt))
+	c.Set("response", response)
+	c.Set("status", http.StatusOK)
+}
+
+// GetScheduledTransitions lists the upcoming publications and unpublications the user
+// may apply, soonest first. The 'until' query parameter, an RFC 3339 time, limits the
+// list to the transitions scheduled before it.
+func GetScheduledTransitions(c *gin.Context) {
+	var until time.Time
+	if param := c.Query("until"); param != "" {
+		parsed, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			c.Set("response", "Invalid until parameter, expected an RFC 3339 time")
+			c.Set("status", http.StatusBadRequest)
+			return
+		}
+		until = parsed
+	}
+
+	transitions, err := storage.GetScheduledTransitions(until)
+	if err != nil {
+		c.Set("response", "Failed to fetch scheduled transitions")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	visible := []storage.ScheduledTransition{}
+	for _, transition := range transitions {
+		resource := models.ResourceCollections
+		if transition.Target == storage.TransitionTargetSingleItem {
+			resource = models.ResourceSingletons
+		}
+		if hasPermission(c, resource, transition.Collection, models.ActionPublish) {
+			visible = append(visible, transition)
+		}
+	}
+
+	c.Set("response", visible)
+	c.Set("meta", gin.H{"total": len(visible)})
+	c.Set("status", http.StatusOK)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchParams are the parameters shared by the full-text and semantic searches.
+type searchParams struct {
+	text          string
+	collections   []string
+	publishedOnly bool
+	limit         int
+}
+
+// parseSearchParams reads the search parameters of the request. Without a
+// 'collections' parameter, every collection the user may search and for which
+// indexed reports attributes to search is searched. It sets the error response
+// and returns false when the parameters are invalid.
+func parseSearchParams(c *gin.Context, indexed func(*models.Collection) bool) (searchParams, bool) {
+	params := searchParams{text: strings.TrimSpace(c.Query("q")), limit: storage.DefaultSearchLimit}
+	if params.text == "" {
+		c.Set("response", "Missing search parameter 'q'")
+		c.Set("status", http.StatusBadRequest)
+		return params, false
+	}
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed < 1 || parsed > storage.MaxSearchLimit {
+			c.Set("response", fmt.Sprintf("Invalid limit parameter, expected 1 to %d", storage.MaxSearchLimit))
+			c.Set("status", http.StatusBadRequest)
+			return params, false
+		}
+		params.limit = parsed
+	}
+
+	status := c.DefaultQuery("status", models.ItemStatusPublished)
+	if status != models.ItemStatusPublished && status != models.ItemStatusDraft {
+		c.Set("response", "Invalid status parameter, expected 'published' or 'draft'")
+		c.Set("status", http.StatusBadRequest)
+		return params, false
+	}
+	params.publishedOnly = status == models.ItemStatusPublished
+	canSearch := func(name string) bool {
+		return hasPermission(c, models.ResourceCollections, name, models.ActionRead) &&
+			(params.publishedOnly || hasPermission(c, models.ResourceCollections, name, models.ActionUpdate))
+	}
+
+	if param := c.Query("collections"); param != "" {
+		for _, name := range strings.Split(param, ",") {
+			name = strings.TrimSpace(name)
+			if _, err := storage.GetCollectionByName(name); err != nil {
+				c.Set("response", fmt.Sprintf("Collection '%s' not found", name))
+				c.Set("status", http.StatusNotFound)
+				return params, false
+			}
+			if !canSearch(name) {
+				c.Set("response", "Access denied")
+				c.Set("status", http.StatusForbidden)
+				return params, false
+			}
+			params.collections = append(params.collections, name)
+		}
+		return params, true
+	}
+
+	collections, _, err := storage.GetAllCollections(nil, nil, nil)
+	if err != nil {
+		c.Set("response", "Failed to fetch collections")
+		c.Set("status", http.StatusInternalServerError)
+		return params, false
+	}
+	for _, ct := range collections {
+		if indexed(&ct) && canSearch(ct.Name) {
+			params.collections = append(params.collections, ct.Name)
+		}
+	}
+	return params, true
+}
+
+// SearchItems runs a full-text search over the searchable attributes of items.
+// Query parameters: 'q' the words to search, 'collections' a comma-separated list of
+// collections to search (by default every collection the user may read), 'status'
+// 'published' (default) or 'draft' to include drafts, which requires update rights,
+// and 'limit' the maximum number of results.
+func SearchItems(c *gin.Context) {
+	params, ok := parseSearchParams(c, (*models.Collection).HasSearchableAttributes)
+	if !ok {
+		return
+	}
+	if len(params.collections) == 0 {
+		c.Set("response", []storage.SearchResult{})
+		c.Set("meta", gin.H{"total": 0})
+		c.Set("status", http.StatusOK)
+		return
+	}
+
+	results, err := storage.SearchItems(storage.SearchQuery{
+		Text:          params.text,
+		Collections:   params.collections,
+		PublishedOnly: params.publishedOnly,
+		Limit:         params.limit,
+	})
+	if err != nil {
+		logger.Log.WithError(err).WithField("query", params.text).Warn("Search failed")
+		c.Set("response", "Failed to search items")
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", results)
+	c.Set("meta", gin.H{"total": len(results)})
+	c.Set("status", http.StatusOK)
+}
+
+// SemanticSearch returns the items whose semantic attributes are the closest in
+// meaning to 'q', most similar first. It takes the parameters of SearchItems and
+// requires an embedding provider to be configured.
+func SemanticSearch(c *gin.Context) {
+	if !storage.SemanticSearchEnabled() {
+		c.Set("response", "Semantic search is not enabled")
+		c.Set("status", http.StatusServiceUnavailable)
+		return
+	}
+	params, ok := parseSearchParams(c, (*models.Collection).HasSemanticAttributes)
+	if !ok {
+		return
+	}
+	if len(params.collections) == 0 {
+		c.Set("response", []storage.SemanticResult{})
+		c.Set("meta", gin.H{"total": 0})
+		c.Set("status", http.StatusOK)
+		return
+	}
+
+	results, err := storage.SemanticSearch(c.Request.Context(), storage.SemanticQuery{
+		Text:          params.text,
+		Collections:   params.collections,
+		PublishedOnly: params.publishedOnly,
+		Limit:         params.limit,
+	})
+	if err != nil {
+		logger.Log.WithError(err).WithField("query", params.text).Warn("Semantic search failed")
+		c.Set("response", "Failed to search items")
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", results)
+	c.Set("meta", gin.H{"total": len(results)})
+	c.Set("status", http.StatusOK)
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"fmt"
+	"maps"
 	"net/http"
 
 	"github.com/gohead-cms/gohead/internal/models"
@@ -16,8 +18,33 @@ func GetSingleItem(c *gin.Context) {
 	SingletonName := c.Param("name")
 	logger.Log.Debugf("Fetching single item for type: %s", SingletonName)
 
+	if !hasPermission(c, models.ResourceSingletons, SingletonName, models.ActionRead) {
+		c.Set("response", "Access denied")
+		c.Set("status", http.StatusForbidden)
+		return
+	}
+
+	// Readers only see a published item; previewing a draft requires update rights.
+	status := c.DefaultQuery("status", models.ItemStatusPublished)
+	switch status {
+	case models.ItemStatusPublished:
+	case models.ItemStatusDraft:
+		if !hasPermission(c, models.ResourceSingletons, SingletonName, models.ActionUpdate) {
+			c.Set("response", "Access denied")
+			c.Set("status", http.StatusForbidden)
+			return
+		}
+	default:
+		c.Set("response", "Invalid status parameter, expected 'published' or 'draft'")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
 	// Attempt to get the SingleItem corresponding to this SingletonName
 	item, err := storage.GetSingleItemByType(SingletonName)
+	if err == nil && status == models.ItemStatusPublished && !item.IsPublished() {
+		err = fmt.Errorf("no published single item found for single type '%s'", SingletonName)
+	}
 	if err != nil {
 		c.Set("response", gin.H{
 			"data": nil,
@@ -32,11 +59,19 @@ func GetSingleItem(c *gin.Context) {
 		return
 	}
 
-	response := map[string]any{
-		"id":         item.ID,
-		"attributes": item.Data,
+	data := item.Data
+	if st, err := storage.GetSingletonByName(SingletonName); err == nil {
+		if expanded, err := storage.ExpandMedia(st.Attributes, data); err == nil {
+			data = expanded
+		} else {
+			logger.Log.WithField("name", SingletonName).WithError(err).Warn("GetSingleItem: failed to expand media")
+		}
 	}
 
+	response := map[string]any{"id": item.ID, "attributes": data, "version": item.Version}
+	maps.Copy(response, itemStatusMeta(item.Status, item.PublishedAt, item.PublishAt, item.UnpublishAt))
+
+	setETag(c, item.Version)
 	c.Set("response", response)
 	c.Set("status", http.StatusOK)
 }
@@ -150,6 +185,12 @@ func CreateOrUpdateSingletonItem(c *gin.Context) {
 	// The single type name from URL (e.g. /single-types/:name)
 	SingletonName := c.Param("name")
 
+	if !hasPermission(c, models.ResourceSingletons, SingletonName, models.ActionUpdate) {
+		c.Set("response", "Access denied")
+		c.Set("status", http.StatusForbidden)
+		return
+	}
+
 	// Fetch the single type schema
 	st, err := storage.GetSingletonByName(SingletonName)
 	if err != nil {
@@ -160,6 +201,10 @@ func CreateOrUpdateSingletonItem(c *gin.Context) {
 		return
 	}
 	logger.Log.WithField("single_type", st).Debug("handler:CreateOrUpdateSingletonItem")
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
 	// Parse the input JSON -> { "data": { ... } }
 	var input struct {
@@ -191,7 +236,10 @@ func CreateOrUpdateSingletonItem(c *gin.Context) {
 
 	if existingItem != nil {
 		// Update the existing single item
-		updatedItem, updateErr := storage.UpdateSingleItem(SingletonName, valueData)
+		updatedItem, updateErr := storage.UpdateSingleItem(SingletonName, valueData, storage.UpdateOptions{ExpectedVersion: expectedVersion})
+		if versionConflict(c, updateErr) {
+			return
+		}
 		if updateErr != nil {
 			logger.Log.WithError(updateErr).WithField("Singleton", SingletonName).
 				Error("Failed to update single type item")
@@ -200,6 +248,7 @@ func CreateOrUpdateSingletonItem(c *gin.Context) {
 			return
 		}
 
+		setETag(c, updatedItem.Version)
 		c.Set("response", gin.H{"message": "single type updated successfully", "single_type": updatedItem})
 		c.Set("detail", updatedItem)
 		c.Set("status", http.StatusOK)
@@ -214,7 +263,94 @@ func CreateOrUpdateSingletonItem(c *gin.Context) {
 			return
 		}
 
+		setETag(c, newItem.Version)
 		c.Set("response", gin.H{"message": "single type updated successfully", "single_type": newItem})
 		c.Set("status", http.StatusCreated)
 	}
 }
+
+// PatchSingleItem updates the attributes of the single item of a single type given in
+// the request body, the others keep their stored values. Like updates, it honors the
+// If-Match header.
+func PatchSingleItem(c *gin.Context) {
+	SingletonName := c.Param("name")
+	if !hasPermission(c, models.ResourceSingletons, SingletonName, models.ActionUpdate) {
+		c.Set("response", "Access denied")
+		c.Set("status", http.StatusForbidden)
+		return
+	}
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Set("response", "Invalid input format")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if _, err := storage.GetSingleItemByType(SingletonName); err != nil {
+		c.Set("response", "Single item not found")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	item, err := storage.UpdateSingleItem(SingletonName, input.Data, storage.UpdateOptions{
+		ExpectedVersion: expectedVersion,
+		Partial:         true,
+	})
+	if versionConflict(c, err) {
+		return
+	}
+	if err != nil {
+		c.Set("response", "Failed to update single item")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]any{"id": item.ID, "attributes": item.Data, "version": item.Version}
+	maps.Copy(response, itemStatusMeta(item.Status, item.PublishedAt, item.PublishAt, item.UnpublishAt))
+	setETag(c, item.Version)
+	c.Set("response", response)
+	c.Set("status", http.StatusOK)
+}
+
+// PublishSingleItem makes the single item of a single type visible to readers.
+func PublishSingleItem(c *gin.Context) {
+	changeSingleItemStatus(c, storage.PublishSingleItem)
+}
+
+// UnpublishSingleItem moves the single item of a single type back to draft.
+func UnpublishSingleItem(c *gin.Context) {
+	changeSingleItemStatus(c, storage.UnpublishSingleItem)
+}
+
+func changeSingleItemStatus(c *gin.Context, change func(string) (*models.SingleItem, error)) {
+	SingletonName := c.Param("name")
+	if !hasPermission(c, models.ResourceSingletons, SingletonName, models.ActionPublish) {
+		c.Set("response", "Access denied")
+		c.Set("status", http.StatusForbidden)
+		return
+	}
+
+	item, err := change(SingletonName)
+	if err != nil {
+		logger.Log.WithError(err).WithField("Singleton", SingletonName).
+			Warn("Failed to change single item status")
+		c.Set("response", "Single item not found")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]any{"id": item.ID, "attributes": item.Data}
+	maps.Copy(response, itemStatusMeta(item.Status, item.PublishedAt, item.PublishAt, item.UnpublishAt))
+	c.Set("response", response)
+	c.Set("status", http.StatusOK)
+}
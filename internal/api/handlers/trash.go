@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// trashCollection resolves the collection of a trash request. The trash is limited to
+// roles allowed to delete items of the collection.
+// It sets the error response and returns nil when the request cannot proceed.
+func trashCollection(c *gin.Context) *models.Collection {
+	ct, err := storage.GetCollectionByName(c.Param("collection"))
+	if err != nil {
+		c.Set("response", "Collection not found")
+		c.Set("status", http.StatusNotFound)
+		return nil
+	}
+
+	if !hasPermission(c, models.ResourceCollections, ct.Name, models.ActionDelete) {
+		c.Set("response", "Access denied")
+		c.Set("status", http.StatusForbidden)
+		return nil
+	}
+	return ct
+}
+
+// trashItemID reads the item ID of a trash request. It sets the error response and
+// returns false when the ID is invalid.
+func trashItemID(c *gin.Context) (uint, bool) {
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || itemID <= 0 {
+		c.Set("response", "Invalid ID format")
+		c.Set("status", http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(itemID), true
+}
+
+// GetTrash lists the deleted items of a collection, most recently deleted first.
+func GetTrash(c *gin.Context) {
+	ct := trashCollection(c)
+	if ct == nil {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "25"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 25
+	}
+
+	items, total, err := storage.GetDeletedItems(ct.ID, page, pageSize)
+	if err != nil {
+		c.Set("response", "Failed to fetch deleted items")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]map[string]any, 0, len(items))
+	for i := range items {
+		formatted := formatItem(c, &items[i], ct)
+		formatted["deleted_at"] = items[i].DeletedAt.Time
+		response = append(response, formatted)
+	}
+
+	c.Set("response", response)
+	c.Set("meta", gin.H{
+		"pagination": gin.H{
+			"page":      page,
+			"pageSize":  pageSize,
+			"total":     total,
+			"pageCount": (total + pageSize - 1) / pageSize,
+		},
+	})
+	c.Set("status", http.StatusOK)
+}
+
+// RestoreTrashItem takes a deleted item out of the trash. It fails with a conflict when
+// one of its unique values has been taken by another item since.
+func RestoreTrashItem(c *gin.Context) {
+	ct := trashCollection(c)
+	if ct == nil {
+		return
+	}
+	itemID, ok := trashItemID(c)
+	if !ok {
+		return
+	}
+
+	item, err := storage.RestoreItem(*ct, itemID, requestActor(c))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Set("response", "Item not found in the trash")
+		c.Set("status", http.StatusNotFound)
+		return
+	case errors.Is(err, storage.ErrRestoreConflict):
+		c.Set("response", "Item conflicts with an existing item")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusConflict)
+		return
+	case err != nil:
+		c.Set("response", "Failed to restore item")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", formatItem(c, item, ct))
+	c.Set("status", http.StatusOK)
+}
+
+// PurgeTrashItem permanently deletes an item of the trash.
+func PurgeTrashItem(c *gin.Context) {
+	ct := trashCollection(c)
+	if ct == nil {
+		return
+	}
+	itemID, ok := trashItemID(c)
+	if !ok {
+		return
+	}
+
+	purged, err := storage.PurgeItems(ct.ID, []uint{itemID})
+	if err != nil {
+		c.Set("response", "Failed to purge item")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+	if purged == 0 {
+		c.Set("response", "Item not found in the trash")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	c.Set("response", nil)
+	c.Set("status", http.StatusOK)
+}
+
+// EmptyTrash permanently deletes every item in the trash of a collection.
+func EmptyTrash(c *gin.Context) {
+	ct := trashCollection(c)
+	if ct == nil {
+		return
+	}
+
+	purged, err := storage.PurgeItems(ct.ID, nil)
+	if err != nil {
+		c.Set("response", "Failed to empty trash")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", nil)
+	c.Set("meta", gin.H{"purged": purged})
+	c.Set("status", http.StatusOK)
+}
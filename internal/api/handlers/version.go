@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gohead-cms/gohead/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag sets the ETag header of a response to the version of an item or single item.
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion reads the version a write expects from the If-Match header: nil when
+// the header is missing or '*'. It sets the error response and returns false when the
+// header does not hold a version.
+func ifMatchVersion(c *gin.Context) (*int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+	tag := strings.TrimPrefix(header, "W/")
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		tag = unquoted
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		c.Set("response", "Invalid If-Match header, expected the ETag of the item")
+		c.Set("status", http.StatusBadRequest)
+		return nil, false
+	}
+	return &version, true
+}
+
+// versionConflict sets the conflict response, with the current version for the client
+// to merge its changes with, and returns true when err is a *models.VersionConflictError.
+func versionConflict(c *gin.Context, err error) bool {
+	var conflict *models.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	setETag(c, conflict.Current)
+	c.Set("response", "The item was changed since it was read")
+	c.Set("details", gin.H{"expected_version": conflict.Expected, "current_version": conflict.Current})
+	c.Set("status", http.StatusConflict)
+	return true
+}
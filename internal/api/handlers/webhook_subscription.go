@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/gohead-cms/gohead/pkg/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webhookInput is the body of the webhook creation and update requests. Fields left
+// out of an update are unchanged.
+type webhookInput struct {
+	Name        *string            `json:"name"`
+	URL         *string            `json:"url"`
+	Collections *[]string          `json:"collections"`
+	Events      *[]string          `json:"events"`
+	Secret      *string            `json:"secret"`
+	Headers     *map[string]string `json:"headers"`
+	Enabled     *bool              `json:"enabled"`
+}
+
+// apply copies the fields set in the input to the webhook.
+func (input webhookInput) apply(webhook *models.Webhook) {
+	if input.Name != nil {
+		webhook.Name = *input.Name
+	}
+	if input.URL != nil {
+		webhook.URL = *input.URL
+	}
+	if input.Collections != nil {
+		webhook.Collections = *input.Collections
+	}
+	if input.Events != nil {
+		webhook.Events = *input.Events
+	}
+	if input.Secret != nil {
+		webhook.Secret = *input.Secret
+	}
+	if input.Headers != nil {
+		webhook.Headers = *input.Headers
+	}
+	if input.Enabled != nil {
+		webhook.Enabled = *input.Enabled
+	}
+}
+
+// redactWebhook hides the secret of a webhook, which is only returned on creation.
+func redactWebhook(webhook models.Webhook) models.Webhook {
+	webhook.Secret = ""
+	return webhook
+}
+
+// GetWebhooks lists all webhooks.
+func GetWebhooks(c *gin.Context) {
+	list, err := storage.GetAllWebhooks()
+	if err != nil {
+		c.Set("response", "Failed to fetch webhooks")
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+	for i := range list {
+		list[i] = redactWebhook(list[i])
+	}
+
+	c.Set("response", list)
+	c.Set("meta", gin.H{"total": len(list)})
+	c.Set("status", http.StatusOK)
+}
+
+// GetWebhook retrieves a webhook by name.
+func GetWebhook(c *gin.Context) {
+	webhook, err := storage.GetWebhookByName(c.Param("name"))
+	if err != nil {
+		c.Set("response", "Webhook not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	c.Set("response", redactWebhook(*webhook))
+	c.Set("status", http.StatusOK)
+}
+
+// CreateWebhook subscribes an endpoint to content events. A secret is generated when
+// none is given; it is returned in the response and never again.
+func CreateWebhook(c *gin.Context) {
+	var input webhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Set("response", "Invalid input format")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+
+	webhook := models.Webhook{Enabled: true}
+	input.apply(&webhook)
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			c.Set("response", "Failed to generate webhook secret")
+			c.Set("status", http.StatusInternalServerError)
+			return
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	if err := models.ValidateWebhook(webhook); err != nil {
+		c.Set("response", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if _, err := storage.GetWebhookByName(webhook.Name); err == nil {
+		c.Set("response", "Webhook '"+webhook.Name+"' already exists")
+		c.Set("status", http.StatusConflict)
+		return
+	}
+
+	if err := storage.SaveWebhook(&webhook); err != nil {
+		c.Set("response", "Failed to create webhook")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", webhook)
+	c.Set("status", http.StatusCreated)
+}
+
+// UpdateWebhook changes the fields of a webhook given in the request body.
+func UpdateWebhook(c *gin.Context) {
+	webhook, err := storage.GetWebhookByName(c.Param("name"))
+	if err != nil {
+		c.Set("response", "Webhook not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	var input webhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Set("response", "Invalid input format")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if input.Secret != nil && *input.Secret == "" {
+		c.Set("response", "The webhook secret cannot be empty")
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	name := webhook.Name
+	input.apply(webhook)
+	if err := models.ValidateWebhook(*webhook); err != nil {
+		c.Set("response", err.Error())
+		c.Set("status", http.StatusBadRequest)
+		return
+	}
+	if webhook.Name != name {
+		if _, err := storage.GetWebhookByName(webhook.Name); err == nil {
+			c.Set("response", "Webhook '"+webhook.Name+"' already exists")
+			c.Set("status", http.StatusConflict)
+			return
+		}
+	}
+
+	if err := storage.UpdateWebhook(webhook); err != nil {
+		c.Set("response", "Failed to update webhook")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", redactWebhook(*webhook))
+	c.Set("status", http.StatusOK)
+}
+
+// DeleteWebhook deletes a webhook and its delivery log.
+func DeleteWebhook(c *gin.Context) {
+	webhook, err := storage.GetWebhookByName(c.Param("name"))
+	if err != nil {
+		c.Set("response", "Webhook not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	if err := storage.DeleteWebhook(webhook.ID); err != nil {
+		c.Set("response", "Failed to delete webhook")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Log.WithField("webhook", webhook.Name).Info("Webhook deleted successfully")
+	c.Set("response", nil)
+	c.Set("status", http.StatusOK)
+}
+
+// GetWebhookDeliveries lists the deliveries of a webhook, newest first. The 'status'
+// query parameter filters on 'pending', 'succeeded' or 'failed' deliveries.
+func GetWebhookDeliveries(c *gin.Context) {
+	webhook, err := storage.GetWebhookByName(c.Param("name"))
+	if err != nil {
+		c.Set("response", "Webhook not found")
+		c.Set("status", http.StatusNotFound)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "25"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 25
+	}
+
+	deliveries, total, err := storage.GetWebhookDeliveries(webhook.ID, c.Query("status"), page, pageSize)
+	if err != nil {
+		c.Set("response", "Failed to fetch webhook deliveries")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", deliveries)
+	c.Set("meta", gin.H{
+		"pagination": gin.H{
+			"page":      page,
+			"pageSize":  pageSize,
+			"total":     total,
+			"pageCount": (total + pageSize - 1) / pageSize,
+		},
+	})
+	c.Set("status", http.StatusOK)
+}
+
+// webhookDeliveryFromParams loads the delivery identified by the ':id' path parameter
+// of the webhook identified by ':name'. It sets the error response and returns nil
+// when either cannot be found.
+func webhookDeliveryFromParams(c *gin.Context) *models.WebhookDelivery {
+	webhook, err := storage.GetWebhookByName(c.Param("name"))
+	if err != nil {
+		c.Set("response", "Webhook not found")
+		c.Set("status", http.StatusNotFound)
+		return nil
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.Set("response", "Invalid delivery ID")
+		c.Set("status", http.StatusBadRequest)
+		return nil
+	}
+	delivery, err := storage.GetWebhookDelivery(webhook.ID, uint(id))
+	if err != nil {
+		c.Set("response", "Delivery not found")
+		c.Set("status", http.StatusNotFound)
+		return nil
+	}
+	return delivery
+}
+
+// GetWebhookDelivery retrieves a delivery of a webhook, with the payload sent and the
+// response received.
+func GetWebhookDelivery(c *gin.Context) {
+	delivery := webhookDeliveryFromParams(c)
+	if delivery == nil {
+		return
+	}
+
+	c.Set("response", delivery)
+	c.Set("status", http.StatusOK)
+}
+
+// RedeliverWebhook sends the payload of a delivery again, as a new delivery.
+func RedeliverWebhook(c *gin.Context) {
+	delivery := webhookDeliveryFromParams(c)
+	if delivery == nil {
+		return
+	}
+	if asynqClient == nil {
+		c.Set("response", "Background jobs are not available")
+		c.Set("status", http.StatusServiceUnavailable)
+		return
+	}
+
+	redelivery, err := webhooks.Redeliver(c.Request.Context(), asynqClient, delivery)
+	if err != nil {
+		c.Set("response", "Failed to redeliver webhook")
+		c.Set("details", err.Error())
+		c.Set("status", http.StatusInternalServerError)
+		return
+	}
+
+	c.Set("response", redelivery)
+	c.Set("status", http.StatusAccepted)
+}
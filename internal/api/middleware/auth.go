@@ -2,9 +2,11 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/gohead-cms/gohead/internal/models"
 	"github.com/gohead-cms/gohead/pkg/auth"
 	"github.com/gohead-cms/gohead/pkg/logger"
 	"github.com/gohead-cms/gohead/pkg/storage"
@@ -28,28 +30,46 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Parse JWT and extract claims
-		claims, err := auth.ParseJWT(tokenString)
-		if err != nil {
-			abortWithError(c, http.StatusUnauthorized, "InvalidTokenError", "Invalid token")
+		claims, role, err := Authenticate(tokenString)
+		if errors.Is(err, ErrInvalidRole) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid role"})
 			return
 		}
-
-		// Retrieve the role using the storage abstraction
-		role, err := storage.GetRoleByName(claims.Role)
 		if err != nil {
-			logger.Log.Warnf("Role '%s' not found", claims.Role)
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid role"})
+			abortWithError(c, http.StatusUnauthorized, "InvalidTokenError", "Invalid token")
 			return
 		}
 
 		// Attach user details to the context
 		c.Set("username", claims.Username)
 		c.Set("role", role.Name)
+		c.Set("user_role", role)
 		c.Next()
 	}
 }
 
+// ErrInvalidRole is returned by Authenticate for a token granting a role which does
+// not exist.
+var ErrInvalidRole = errors.New("invalid role")
+
+// Authenticate parses a JWT and returns its claims along with the role they grant. It
+// checks tokens the same way for every transport, HTTP requests and WebSockets alike.
+func Authenticate(tokenString string) (*auth.Claims, *models.UserRole, error) {
+	// Parse JWT and extract claims
+	claims, err := auth.ParseJWT(tokenString)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Retrieve the role using the storage abstraction
+	role, err := storage.GetRoleByName(claims.Role)
+	if err != nil {
+		logger.Log.Warnf("Role '%s' not found", claims.Role)
+		return nil, nil, ErrInvalidRole
+	}
+	return claims, role, nil
+}
+
 // Helper function to abort with a standardized error
 func abortWithError(c *gin.Context, status int, name, message string) {
 	c.AbortWithStatusJSON(status, gin.H{
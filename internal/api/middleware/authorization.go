@@ -4,7 +4,9 @@ package middleware
 import (
 	"net/http"
 
+	"github.com/gohead-cms/gohead/internal/models"
 	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -42,3 +44,46 @@ func AdminOnly() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// CurrentRole returns the role of the authenticated user, as loaded by AuthMiddleware.
+// It returns nil when the request is not authenticated or the role no longer exists.
+func CurrentRole(c *gin.Context) *models.UserRole {
+	if role, ok := c.Get("user_role"); ok {
+		if userRole, ok := role.(*models.UserRole); ok {
+			return userRole
+		}
+	}
+	name := c.GetString("role")
+	if name == "" {
+		return nil
+	}
+	role, err := storage.GetRoleByName(name)
+	if err != nil {
+		return nil
+	}
+	c.Set("user_role", role)
+	return role
+}
+
+// RequirePermission aborts the request unless the user's role grants action on resource.
+// The resource name is read from the nameParam path parameter; when nameParam is empty or
+// the parameter is missing, the permission is checked for every name ("*").
+func RequirePermission(resource, action, nameParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := models.PermissionWildcard
+		if nameParam != "" && c.Param(nameParam) != "" {
+			name = c.Param(nameParam)
+		}
+		permission := models.Permission(resource, name, action)
+
+		role := CurrentRole(c)
+		if role == nil || !role.Can(permission) {
+			logger.Log.WithField("role", c.GetString("role")).
+				WithField("permission", permission).
+				Warn("Permission denied")
+			abortWithError(c, http.StatusForbidden, "ForbiddenError", "Access denied: missing permission '"+permission+"'")
+			return
+		}
+		c.Next()
+	}
+}
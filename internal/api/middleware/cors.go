@@ -27,6 +27,8 @@ func CORSMiddleware(cfg config.Config) gin.HandlerFunc {
 			c.Header("Access-Control-Allow-Origin", origin)
 			c.Header("Access-Control-Allow-Methods", strings.Join(cfg.CORS.AllowedMethods, ", "))
 			c.Header("Access-Control-Allow-Headers", strings.Join(cfg.CORS.AllowedHeaders, ", "))
+			// Clients read the version of items from the ETag for their next write.
+			c.Header("Access-Control-Expose-Headers", "ETag")
 
 			if cfg.CORS.AllowCredentials {
 				c.Header("Access-Control-Allow-Credentials", "true")
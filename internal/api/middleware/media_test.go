@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaHeaders(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"photo.png":  "\x89PNG\r\n\x1a\n",
+		"page.html":  "<script>alert(1)</script>",
+		"logo.svg":   `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`,
+		"clip.mp4":   "video",
+		"unknown.xy": "<html><script>alert(1)</script></html>",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Group("/uploads", MediaHeaders()).Static("/", dir)
+
+	cases := map[string]bool{
+		"photo.png":  true,
+		"clip.mp4":   true,
+		"page.html":  false,
+		"logo.svg":   false,
+		"unknown.xy": false,
+	}
+	for name, inline := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/uploads/"+name, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			assert.Contains(t, w.Header().Get("Content-Security-Policy"), "sandbox")
+			if inline {
+				assert.Empty(t, w.Header().Get("Content-Disposition"))
+			} else {
+				assert.Equal(t, "attachment", w.Header().Get("Content-Disposition"))
+			}
+		})
+	}
+}
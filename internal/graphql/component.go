@@ -0,0 +1,225 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+var (
+	componentTypeRegistry  = make(map[string]*graphql.Object)
+	componentInputRegistry = make(map[string]*graphql.InputObject)
+	dynamicZoneRegistry    = make(map[string]*graphql.Union)
+)
+
+// jsonScalar carries arbitrary JSON values. Dynamic zones use it as their input type,
+// since GraphQL has no input unions.
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:         "JSON",
+	Description:  "An arbitrary JSON value.",
+	Serialize:    func(value any) any { return value },
+	ParseValue:   func(value any) any { return value },
+	ParseLiteral: parseJSONLiteral,
+})
+
+// parseJSONLiteral converts an inline GraphQL value into its JSON representation.
+func parseJSONLiteral(valueAST ast.Value) any {
+	switch value := valueAST.(type) {
+	case *ast.ObjectValue:
+		result := make(map[string]any, len(value.Fields))
+		for _, field := range value.Fields {
+			result[field.Name.Value] = parseJSONLiteral(field.Value)
+		}
+		return result
+	case *ast.ListValue:
+		result := make([]any, 0, len(value.Values))
+		for _, element := range value.Values {
+			result = append(result, parseJSONLiteral(element))
+		}
+		return result
+	case *ast.IntValue:
+		return graphql.Int.ParseLiteral(value)
+	case *ast.FloatValue:
+		return graphql.Float.ParseLiteral(value)
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.StringValue:
+		return value.Value
+	case *ast.EnumValue:
+		return value.Value
+	default:
+		return nil
+	}
+}
+
+// componentTypeName returns the name of the GraphQL type of a component, kept apart from
+// the types of collections which are named after the collection.
+func componentTypeName(name string) string {
+	return name + "Component"
+}
+
+// upperFirst capitalizes the first letter of a name.
+func upperFirst(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// GetOrCreateComponentType retrieves the GraphQL object type of a component from the cache
+// or creates it from the component definition.
+func GetOrCreateComponentType(name string) (*graphql.Object, error) {
+	if gqlType, exists := componentTypeRegistry[name]; exists {
+		return gqlType, nil
+	}
+
+	component, err := storage.GetComponentByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	typeName := componentTypeName(component.Name)
+	gqlType := graphql.NewObject(graphql.ObjectConfig{
+		Name: typeName,
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			fields := graphql.Fields{}
+			for _, componentAttr := range component.Attributes {
+				field, err := attributeField(typeName, 0, componentAttr.AsAttribute())
+				if err != nil {
+					panic(fmt.Sprintf("schema error in component '%s': %v", component.Name, err))
+				}
+				fields[componentAttr.Name] = field
+			}
+			return fields
+		}),
+	})
+	componentTypeRegistry[name] = gqlType
+
+	logger.Log.WithField("component_name", component.Name).Info("GraphQL component type created successfully")
+	return gqlType, nil
+}
+
+// componentFieldType returns the GraphQL type of a "component" attribute, a list of them
+// when repeatable, or of a "dynamiczone" attribute: a list of a union of its components.
+func componentFieldType(parent string, attr models.Attribute) (graphql.Output, error) {
+	if attr.Type == "component" {
+		gqlType, err := GetOrCreateComponentType(attr.ComponentRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve component '%s': %w", attr.Name, err)
+		}
+		if attr.Repeatable {
+			return graphql.NewList(gqlType), nil
+		}
+		return gqlType, nil
+	}
+
+	unionName := parent + upperFirst(attr.Name) + "Zone"
+	if union, exists := dynamicZoneRegistry[unionName]; exists {
+		return graphql.NewList(union), nil
+	}
+
+	members := make([]*graphql.Object, 0, len(attr.Components))
+	for _, name := range attr.Components {
+		gqlType, err := GetOrCreateComponentType(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve dynamic zone '%s': %w", attr.Name, err)
+		}
+		members = append(members, gqlType)
+	}
+	union := graphql.NewUnion(graphql.UnionConfig{
+		Name:  unionName,
+		Types: members,
+		ResolveType: func(p graphql.ResolveTypeParams) *graphql.Object {
+			entry, _ := p.Value.(map[string]any)
+			name, _ := entry[models.ComponentDiscriminator].(string)
+			return componentTypeRegistry[name]
+		},
+	})
+	dynamicZoneRegistry[unionName] = union
+	return graphql.NewList(union), nil
+}
+
+// componentInputType returns the GraphQL input type of a "component" or "dynamiczone"
+// attribute. Entries of dynamic zones are JSON objects naming their component.
+func componentInputType(attr models.Attribute) (graphql.Input, error) {
+	if attr.Type == "dynamiczone" {
+		return graphql.NewList(jsonScalar), nil
+	}
+
+	inputType, exists := componentInputRegistry[attr.ComponentRef]
+	if !exists {
+		component, err := storage.GetComponentByName(attr.ComponentRef)
+		if err != nil {
+			return nil, err
+		}
+		inputType = graphql.NewInputObject(graphql.InputObjectConfig{
+			Name: componentTypeName(component.Name) + "Input",
+			Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {
+				fields := graphql.InputObjectConfigFieldMap{}
+				for _, componentAttr := range component.Attributes {
+					gqlType, err := attributeInputType(componentAttr.AsAttribute())
+					if err != nil {
+						logger.Log.Warnf("Skipping attribute '%s' in component '%s' input: %v", componentAttr.Name, component.Name, err)
+						continue
+					}
+					if componentAttr.Required {
+						gqlType = graphql.NewNonNull(gqlType)
+					}
+					fields[componentAttr.Name] = &graphql.InputObjectFieldConfig{Type: gqlType}
+				}
+				return fields
+			}),
+		})
+		componentInputRegistry[attr.ComponentRef] = inputType
+	}
+
+	if attr.Repeatable {
+		return graphql.NewList(inputType), nil
+	}
+	return inputType, nil
+}
+
+// ResolveComponent returns the instances stored in a "component" or "dynamiczone" attribute.
+// Instances are read in the same preview mode and locale as the item holding them, which
+// their relations depend on.
+func ResolveComponent(p graphql.ResolveParams, attr models.Attribute) (any, error) {
+	sourceMap, ok := p.Source.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid source type for component '%s', expected map[string]any", attr.Name)
+	}
+	value, exists := sourceMap[attr.Name]
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	withContext := func(element any) any {
+		instance, ok := element.(map[string]any)
+		if !ok {
+			return nil
+		}
+		result := make(map[string]any, len(instance)+2)
+		for key, val := range instance {
+			result[key] = val
+		}
+		result[previewKey] = sourceMap[previewKey]
+		result[localeKey] = sourceMap[localeKey]
+		return result
+	}
+
+	list, isList := value.([]any)
+	if !isList {
+		return withContext(value), nil
+	}
+	results := make([]any, 0, len(list))
+	for _, element := range list {
+		if instance := withContext(element); instance != nil {
+			results = append(results, instance)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,147 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/graphql-go/graphql"
+)
+
+// maxConnectionSize caps the number of items of a connection page.
+const maxConnectionSize = 100
+
+// countKey holds the function counting the items of a connection, so they are only
+// counted when 'totalCount' is selected. It is not exposed as a GraphQL field.
+const countKey = "__count"
+
+// pageInfoType describes the position of a connection page, following the Relay
+// cursor connections specification.
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"startCursor":     &graphql.Field{Type: graphql.String},
+		"endCursor":       &graphql.Field{Type: graphql.String},
+	},
+})
+
+var connectionRegistry = make(map[string]*graphql.Object)
+
+// connectionType returns the Relay connection type of the items of a collection type.
+func connectionType(gqlType *graphql.Object) *graphql.Object {
+	name := gqlType.Name() + "Connection"
+	if connection, exists := connectionRegistry[name]; exists {
+		return connection
+	}
+
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: gqlType.Name() + "Edge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: gqlType},
+		},
+	})
+	connection := graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		Fields: graphql.Fields{
+			"edges":    &graphql.Field{Type: graphql.NewList(edgeType)},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "Number of items matching the query. They are only counted when selected.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					source, _ := p.Source.(map[string]any)
+					count, ok := source[countKey].(func() (int, error))
+					if !ok {
+						return nil, nil
+					}
+					return count()
+				},
+			},
+		},
+	})
+	connectionRegistry[name] = connection
+	return connection
+}
+
+// connectionField returns the query reading the items of a collection as a Relay
+// connection, a page at a time from the cursor of the previous page.
+func connectionField(coll models.Collection, gqlType *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type:        connectionType(gqlType),
+		Description: fmt.Sprintf("Items of '%s', paginated with cursors.", coll.Name),
+		Args: withArgs(filterArgs(coll), graphql.FieldConfigArgument{
+			"first": &graphql.ArgumentConfig{
+				Type:         graphql.Int,
+				DefaultValue: 25,
+				Description:  fmt.Sprintf("The number of items to return, at most %d.", maxConnectionSize),
+			},
+			"after": &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "Cursor of the item to read the items following, the 'endCursor' of the previous page.",
+			},
+			"status": statusArgument,
+			"locale": readLocaleArgument,
+		}),
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			status, err := readStatusArg(p, coll)
+			if err != nil {
+				return nil, err
+			}
+			locale, err := localeArg(p)
+			if err != nil {
+				return nil, err
+			}
+			first, _ := p.Args["first"].(int)
+			if first < 1 || first > maxConnectionSize {
+				return nil, fmt.Errorf("invalid 'first' argument, expected 1 to %d", maxConnectionSize)
+			}
+			after, _ := p.Args["after"].(string)
+
+			query, err := itemQueryArgs(p, coll, locale)
+			if err != nil {
+				return nil, err
+			}
+			query.Status = status
+			page, err := storage.QueryItemsAfter(coll.ID, query, after, first)
+			if err != nil {
+				var queryErr *models.QueryError
+				if errors.As(err, &queryErr) {
+					return nil, fmt.Errorf("invalid 'after' argument: %w", err)
+				}
+				logger.Log.WithError(err).Warn("Failed to fetch items for collection", coll.Name)
+				return nil, fmt.Errorf("failed to fetch items")
+			}
+
+			attributes := viewerAttributes(p.Context, coll.Attributes)
+			edges := make([]map[string]any, len(page.Items))
+			for i, item := range page.Items {
+				edges[i] = map[string]any{
+					"cursor": page.Cursors[i],
+					"node":   mapItemToGraphQLResult(item, coll, attributes, status == models.ItemStatusDraft, locale),
+				}
+			}
+			pageInfo := map[string]any{
+				"hasNextPage":     page.HasNextPage,
+				"hasPreviousPage": after != "",
+				"startCursor":     nil,
+				"endCursor":       nil,
+			}
+			if len(page.Cursors) > 0 {
+				pageInfo["startCursor"] = page.Cursors[0]
+				pageInfo["endCursor"] = page.EndCursor()
+			}
+			return map[string]any{
+				"edges":    edges,
+				"pageInfo": pageInfo,
+				countKey: func() (int, error) {
+					return storage.CountItems(coll.ID, query)
+				},
+			}, nil
+		},
+	}
+}
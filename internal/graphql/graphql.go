@@ -2,9 +2,9 @@ package graphql
 
 import (
 	"fmt"
-	"sync"
-
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/gohead-cms/gohead/internal/models"
 	"github.com/gohead-cms/gohead/pkg/database"
@@ -19,24 +19,42 @@ import (
 var (
 	Schema      graphql.Schema
 	schemaMutex sync.RWMutex
+	buildMutex  sync.Mutex
 )
 
 // InitializeGraphQLSchema dynamically generates the GraphQL schema and protects it with a write lock.
 // This function can be called again at runtime to "hot-reload" the schema if collections change.
 func InitializeGraphQLSchema() error {
-	rootQuery, err := GenerateGraphQLQueries()
+	// Every reload builds its types from scratch, from a single read of the collections,
+	// so the schema follows the changes made since the previous one. Reloads run one at
+	// a time since they share the type registries.
+	buildMutex.Lock()
+	defer buildMutex.Unlock()
+	resetTypeRegistries()
+
+	collections, err := loadCollections()
+	if err != nil {
+		return err
+	}
+	rootQuery, err := generateQueries(collections)
 	if err != nil {
 		return err
 	}
 
-	mutation, err := GenerateGraphQLMutations()
+	mutation, err := generateMutations(collections)
 	if err != nil {
 		return fmt.Errorf("failed to generate GraphQL mutations: %w", err)
 	}
 
+	subscription, err := generateSubscriptions(collections)
+	if err != nil {
+		return fmt.Errorf("failed to generate GraphQL subscriptions: %w", err)
+	}
+
 	schemaConfig := graphql.SchemaConfig{
-		Query:    rootQuery,
-		Mutation: mutation,
+		Query:        rootQuery,
+		Mutation:     mutation,
+		Subscription: subscription,
 	}
 
 	newSchema, err := graphql.NewSchema(schemaConfig)
@@ -53,6 +71,17 @@ func InitializeGraphQLSchema() error {
 	return nil
 }
 
+// resetTypeRegistries forgets the GraphQL types built for the previous schema.
+func resetTypeRegistries() {
+	typeRegistry = make(map[string]*graphql.Object)
+	connectionRegistry = make(map[string]*graphql.Object)
+	whereInputRegistry = make(map[string]*graphql.InputObject)
+	orderByRegistry = make(map[string]*graphql.Enum)
+	componentTypeRegistry = make(map[string]*graphql.Object)
+	componentInputRegistry = make(map[string]*graphql.InputObject)
+	dynamicZoneRegistry = make(map[string]*graphql.Union)
+}
+
 // GetSchema safely returns the current GraphQL schema using a read lock.
 // All GraphQL handlers should use this function to get the schema.
 func GetSchema() graphql.Schema {
@@ -63,17 +92,31 @@ func GetSchema() graphql.Schema {
 
 // GenerateGraphQLQueries dynamically creates GraphQL queries for each collection.
 func GenerateGraphQLQueries() (*graphql.Object, error) {
-	logger.Log.Debug("Generating GraphQL queries...")
-
-	fields := graphql.Fields{}
+	collections, err := loadCollections()
+	if err != nil {
+		return nil, err
+	}
+	return generateQueries(collections)
+}
 
+// loadCollections fetches the collections the schema is generated from.
+func loadCollections() ([]models.Collection, error) {
 	var collections []models.Collection
-	if err := database.DB.Preload("Attributes").Find(&collections).Error; err != nil {
+	if err := database.DB.Preload("Attributes").Order("name").Find(&collections).Error; err != nil {
 		logger.Log.WithError(err).Error("Failed to fetch collections from database")
 		return nil, err
 	}
-
 	logger.Log.WithField("collections_count", len(collections)).Info("Collections retrieved for schema generation")
+	return collections, nil
+}
+
+// generateQueries creates the queries of each collection: a plural field listing its
+// items, a singular one reading an item by ID and a connection paginated with cursors.
+func generateQueries(collections []models.Collection) (*graphql.Object, error) {
+	logger.Log.Debug("Generating GraphQL queries...")
+
+	fields := graphql.Fields{}
+	gqlTypes := make(map[string]*graphql.Object, len(collections))
 
 	for _, collection := range collections {
 		coll := collection
@@ -88,15 +131,12 @@ func GenerateGraphQLQueries() (*graphql.Object, error) {
 			}).Error("Failed to convert collection to GraphQL type")
 			return nil, err
 		}
+		gqlTypes[coll.Name] = gqlType
 
 		fields[coll.Name] = &graphql.Field{
 			// Use graphql.NewList to signify that this query can return multiple items.
 			Type: graphql.NewList(gqlType),
-			Args: graphql.FieldConfigArgument{
-				"id": &graphql.ArgumentConfig{
-					Type:        graphql.ID,
-					Description: "Fetch a single item by its ID.",
-				},
+			Args: withArgs(filterArgs(coll), graphql.FieldConfigArgument{
 				"limit": &graphql.ArgumentConfig{
 					Type:         graphql.Int,
 					DefaultValue: 25,
@@ -107,47 +147,63 @@ func GenerateGraphQLQueries() (*graphql.Object, error) {
 					DefaultValue: 0,
 					Description:  "The number of items to skip for pagination.",
 				},
-			},
+				"status": statusArgument,
+				"locale": readLocaleArgument,
+			}),
 			Resolve: func(p graphql.ResolveParams) (any, error) {
 				logger.Log.WithField("collection", coll.Name).Debug("Resolver triggered")
 
-				// Case 1: Fetch a single item by ID
-				if idArg, ok := p.Args["id"].(string); ok && idArg != "" {
-					parsedID, err := strconv.ParseUint(idArg, 10, 32)
-					if err != nil {
-						logger.Log.WithError(err).Warn("Failed to parse 'id' argument to uint")
-						return nil, fmt.Errorf("invalid 'id' argument")
-					}
-
-					item, err := storage.GetItemByID(coll.ID, uint(parsedID))
-					if err != nil {
-						// For "not found", GraphQL typically returns null data and no error.
-						return nil, nil
-					}
-
-					return []any{mapItemToGraphQLResult(*item, coll.Attributes)}, nil
+				status, err := readStatusArg(p, coll)
+				if err != nil {
+					return nil, err
+				}
+				locale, err := localeArg(p)
+				if err != nil {
+					return nil, err
 				}
+				query, err := itemQueryArgs(p, coll, locale)
+				if err != nil {
+					return nil, err
+				}
+				query.Status = status
 
-				// Case 2: Fetch a list of items with pagination
 				limit := p.Args["limit"].(int)
 				offset := p.Args["offset"].(int)
-
-				items, _, err := storage.GetItems(coll.ID, offset, limit)
+				items, err := storage.QueryItemsOffset(coll.ID, query, offset, limit)
 				if err != nil {
 					logger.Log.WithError(err).Warn("Failed to fetch items for collection", coll.Name)
 					return nil, fmt.Errorf("failed to fetch items")
 				}
 
+				attributes := viewerAttributes(p.Context, coll.Attributes)
 				results := make([]map[string]any, 0, len(items))
 				for _, item := range items {
-					results = append(results, mapItemToGraphQLResult(item, coll.Attributes))
+					results = append(results, mapItemToGraphQLResult(item, coll, attributes, status == models.ItemStatusDraft, locale))
 				}
-
 				return results, nil
 			},
 		}
 	}
 
+	// Singular and connection fields are added once every plural field is known, so
+	// that a collection cannot hide the plural field of another one.
+	for _, coll := range collections {
+		if name := singularFieldName(coll.Name); fieldAvailable(fields, name, coll.Name) {
+			fields[name] = singularField(coll, gqlTypes[coll.Name])
+		}
+		if name := coll.Name + "Connection"; fieldAvailable(fields, name, coll.Name) {
+			fields[name] = connectionField(coll, gqlTypes[coll.Name])
+		}
+	}
+
+	if len(collections) > 0 {
+		if _, taken := fields["search"]; taken {
+			logger.Log.Warn("A collection is named 'search', the search query is not available in GraphQL")
+		} else {
+			fields["search"] = searchField(collections)
+		}
+	}
+
 	if len(fields) == 0 {
 		fields["_placeholder"] = &graphql.Field{
 			Type:        graphql.String,
@@ -164,12 +220,140 @@ func GenerateGraphQLQueries() (*graphql.Object, error) {
 	}), nil
 }
 
+// fieldAvailable reports whether a query of a collection can be named name, and logs
+// why it cannot when another query already is.
+func fieldAvailable(fields graphql.Fields, name, collection string) bool {
+	if _, taken := fields[name]; taken {
+		logger.Log.WithField("collection", collection).Warnf("The query '%s' of '%s' is taken by another collection and not available in GraphQL", name, collection)
+		return false
+	}
+	return true
+}
+
+// singularFieldName returns the name of the query reading an item of a collection: the
+// singular of the collection name, or the name followed by 'Item' when it does not end
+// like an English plural.
+func singularFieldName(name string) string {
+	switch {
+	case len(name) > 3 && strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"),
+		strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return strings.TrimSuffix(name, "es")
+	case len(name) > 1 && strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name + "Item"
+}
+
+// singularField returns the query reading an item of a collection by ID.
+func singularField(coll models.Collection, gqlType *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type:        gqlType,
+		Description: fmt.Sprintf("An item of '%s', null when it does not exist.", coll.Name),
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "ID of the item.",
+			},
+			"status": statusArgument,
+			"locale": readLocaleArgument,
+		},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			status, err := readStatusArg(p, coll)
+			if err != nil {
+				return nil, err
+			}
+			preview := status == models.ItemStatusDraft
+			locale, err := localeArg(p)
+			if err != nil {
+				return nil, err
+			}
+
+			idArg, _ := p.Args["id"].(string)
+			parsedID, err := strconv.ParseUint(idArg, 10, 32)
+			if err != nil {
+				logger.Log.WithError(err).Warn("Failed to parse 'id' argument to uint")
+				return nil, fmt.Errorf("invalid 'id' argument")
+			}
+
+			item, err := storage.GetItemByID(coll.ID, uint(parsedID))
+			if err != nil || (!preview && !item.IsPublished()) {
+				// For "not found", GraphQL typically returns null data and no error.
+				return nil, nil
+			}
+			return mapItemToGraphQLResult(*item, coll, viewerAttributes(p.Context, coll.Attributes), preview, locale), nil
+		},
+	}
+}
+
+// previewKey marks results read in draft preview mode so their relations are not
+// restricted to published items. It is not exposed as a GraphQL field.
+const previewKey = "__preview"
+
+// localeKey holds the locale results were read in, so related items are read in the
+// same locale. It is not exposed as a GraphQL field.
+const localeKey = "__locale"
+
+// statusArgument is the 'status' argument of the queries reading items.
+var statusArgument = &graphql.ArgumentConfig{
+	Type:         graphql.String,
+	DefaultValue: models.ItemStatusPublished,
+	Description:  "Publication state to read: 'published' (default) or 'draft' to preview drafts.",
+}
+
+// readLocaleArgument is the 'locale' argument of the queries reading items.
+var readLocaleArgument = &graphql.ArgumentConfig{
+	Type:        graphql.String,
+	Description: "Locale to read localized fields in, falling back to the default locale.",
+}
+
+// readStatusArg checks the viewer may read the items of a collection and returns the
+// publication state of the 'status' argument. Previewing drafts requires update rights.
+func readStatusArg(p graphql.ResolveParams, coll models.Collection) (string, error) {
+	if !viewerCan(p.Context, models.ResourceCollections, coll.Name, models.ActionRead) {
+		return "", fmt.Errorf("access denied: missing permission to read '%s'", coll.Name)
+	}
+	status, _ := p.Args["status"].(string)
+	switch status {
+	case "", models.ItemStatusPublished:
+		return models.ItemStatusPublished, nil
+	case models.ItemStatusDraft:
+		if !viewerCan(p.Context, models.ResourceCollections, coll.Name, models.ActionUpdate) {
+			return "", fmt.Errorf("access denied: previewing drafts requires update rights")
+		}
+		return status, nil
+	}
+	return "", fmt.Errorf("invalid 'status' argument, expected 'published' or 'draft'")
+}
+
+// localeArg reads the 'locale' argument of a field. Every translation at once cannot be
+// represented by the schema's field types, so models.LocaleAll is refused.
+func localeArg(p graphql.ResolveParams) (string, error) {
+	locale, _ := p.Args["locale"].(string)
+	if locale == models.LocaleAll {
+		return "", fmt.Errorf("invalid 'locale' argument, a single locale is expected")
+	}
+	return locale, nil
+}
+
 // mapItemToGraphQLResult is a helper function to convert a storage item
 // into a map suitable for a GraphQL response, reducing code duplication.
-func mapItemToGraphQLResult(item models.Item, attributes []models.Attribute) map[string]any {
-	result := map[string]any{"id": item.ID}
+// Only the values of the given attributes are included, localized ones in locale.
+func mapItemToGraphQLResult(item models.Item, collection models.Collection, attributes []models.Attribute, preview bool, locale string) map[string]any {
+	result := map[string]any{
+		"id":           item.ID,
+		"status":       item.Status,
+		"published_at": item.PublishedAt,
+		"publish_at":   item.PublishAt,
+		"unpublish_at": item.UnpublishAt,
+		"version":      item.Version,
+		previewKey:     preview,
+		localeKey:      locale,
+	}
+	data := collection.LocalizeData(item.Data, locale)
 	for _, attr := range attributes {
-		if value, exists := item.Data[attr.Name]; exists {
+		if value, exists := data[attr.Name]; exists {
 			result[attr.Name] = value
 		}
 	}
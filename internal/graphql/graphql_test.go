@@ -2,6 +2,7 @@ package graphql
 
 import (
 	"bytes"
+	"context"
 	"testing"
 
 	"github.com/gohead-cms/gohead/internal/models"
@@ -76,15 +77,182 @@ func TestGenerateGraphQLQueries(t *testing.T) {
 	// Execute a GraphQL query
 	query := `
 		{
-			authors(id: "1") {
+			author(id: "1") {
 				name
 				email
 			}
 		}`
-	params := graphql.Params{Schema: schema, RequestString: query}
+	reader := &models.UserRole{Name: "reader", Permissions: models.JSONMap{"collections.authors.read": true}}
+	params := graphql.Params{
+		Schema:        schema,
+		RequestString: query,
+		Context:       WithViewer(context.Background(), Viewer{Username: "reader", Role: reader}),
+	}
 	result := graphql.Do(params)
 
 	// Validate response
 	assert.Empty(t, result.Errors, "Expected no errors in GraphQL query execution")
 	assert.NotNil(t, result.Data, "Expected valid data in GraphQL response")
+
+	// Without a role granting read access the query is refused
+	denied := graphql.Do(graphql.Params{Schema: schema, RequestString: query})
+	assert.NotEmpty(t, denied.Errors, "Expected the query to be refused without a viewer")
+}
+
+// TestCollectionConnection pages through the items of a collection with cursors.
+func TestCollectionConnection(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	err := db.AutoMigrate(&models.Attribute{}, &models.Collection{}, &models.Item{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	collection := models.Collection{
+		Name:       "tags",
+		Attributes: []models.Attribute{{Name: "label", Type: "text"}},
+	}
+	if err := db.Create(&collection).Error; err != nil {
+		t.Fatalf("Failed to insert test collection: %v", err)
+	}
+	for _, label := range []string{"go", "rust", "zig"} {
+		item := models.Item{CollectionID: collection.ID, Status: models.ItemStatusPublished, Data: models.JSONMap{"label": label}}
+		if err := db.Create(&item).Error; err != nil {
+			t.Fatalf("Failed to insert test item: %v", err)
+		}
+	}
+
+	queryObject, err := GenerateGraphQLQueries()
+	assert.NoError(t, err)
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: queryObject})
+	assert.NoError(t, err)
+
+	reader := &models.UserRole{Name: "reader", Permissions: models.JSONMap{"collections.tags.read": true}}
+	read := func(after string) map[string]any {
+		result := graphql.Do(graphql.Params{
+			Schema: schema,
+			RequestString: `query($after: String) {
+				tagsConnection(first: 2, after: $after) {
+					edges { cursor node { label } }
+					pageInfo { hasNextPage hasPreviousPage endCursor }
+					totalCount
+				}
+			}`,
+			VariableValues: map[string]any{"after": after},
+			Context:        WithViewer(context.Background(), Viewer{Username: "reader", Role: reader}),
+		})
+		assert.Empty(t, result.Errors)
+		return result.Data.(map[string]any)["tagsConnection"].(map[string]any)
+	}
+	labels := func(connection map[string]any) []string {
+		var result []string
+		for _, edge := range connection["edges"].([]any) {
+			node := edge.(map[string]any)["node"].(map[string]any)
+			result = append(result, node["label"].(string))
+		}
+		return result
+	}
+
+	first := read("")
+	assert.Equal(t, []string{"go", "rust"}, labels(first))
+	assert.Equal(t, 3, first["totalCount"])
+	pageInfo := first["pageInfo"].(map[string]any)
+	assert.Equal(t, true, pageInfo["hasNextPage"])
+	assert.Equal(t, false, pageInfo["hasPreviousPage"])
+
+	second := read(pageInfo["endCursor"].(string))
+	assert.Equal(t, []string{"zig"}, labels(second))
+	assert.Equal(t, false, second["pageInfo"].(map[string]any)["hasNextPage"])
+}
+
+// TestCollectionQueryArguments filters and sorts items and their relations.
+func TestCollectionQueryArguments(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	err := db.AutoMigrate(&models.Attribute{}, &models.Collection{}, &models.Item{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	tags := models.Collection{Name: "tags", Attributes: []models.Attribute{{Name: "label", Type: "text"}}}
+	products := models.Collection{Name: "products", Attributes: []models.Attribute{
+		{Name: "title", Type: "text"},
+		{Name: "price", Type: "float"},
+		{Name: "tags", Type: "relation", Target: "tags", Relation: "manyToMany"},
+	}}
+	for _, collection := range []*models.Collection{&tags, &products} {
+		if err := db.Create(collection).Error; err != nil {
+			t.Fatalf("Failed to insert test collection: %v", err)
+		}
+	}
+	create := func(collection models.Collection, data models.JSONMap) uint {
+		item := models.Item{CollectionID: collection.ID, Status: models.ItemStatusPublished, Data: data}
+		if err := db.Create(&item).Error; err != nil {
+			t.Fatalf("Failed to insert test item: %v", err)
+		}
+		return item.ID
+	}
+	sale := create(tags, models.JSONMap{"label": "sale"})
+	fresh := create(tags, models.JSONMap{"label": "new"})
+	create(products, models.JSONMap{"title": "Go mug", "price": 12.5, "tags": []any{sale, fresh}})
+	create(products, models.JSONMap{"title": "Rust mug", "price": 9})
+	create(products, models.JSONMap{"title": "Go poster", "price": 30})
+
+	assert.NoError(t, InitializeGraphQLSchema())
+	reader := &models.UserRole{Name: "reader", Permissions: models.JSONMap{
+		"collections.products.read": true,
+		"collections.tags.read":     true,
+	}}
+	run := func(query string) map[string]any {
+		result := graphql.Do(graphql.Params{
+			Schema:        GetSchema(),
+			RequestString: query,
+			Context:       WithViewer(context.Background(), Viewer{Username: "reader", Role: reader}),
+		})
+		assert.Empty(t, result.Errors)
+		data, _ := result.Data.(map[string]any)
+		return data
+	}
+	titles := func(list any) []string {
+		var result []string
+		for _, entry := range list.([]any) {
+			result = append(result, entry.(map[string]any)["title"].(string))
+		}
+		return result
+	}
+
+	data := run(`{ products(where: {title_contains: "go", price_lt: 20}) { title } }`)
+	assert.Equal(t, []string{"Go mug"}, titles(data["products"]))
+
+	data = run(`{ products(where: {OR: [{price_gt: 20}, {title: "Rust mug"}]}, orderBy: [price_DESC]) { title } }`)
+	assert.Equal(t, []string{"Go poster", "Rust mug"}, titles(data["products"]))
+
+	data = run(`{ productsConnection(first: 2, orderBy: [title_DESC]) { edges { node { title } } } }`)
+	var connectionTitles []string
+	for _, edge := range data["productsConnection"].(map[string]any)["edges"].([]any) {
+		connectionTitles = append(connectionTitles, edge.(map[string]any)["node"].(map[string]any)["title"].(string))
+	}
+	assert.Equal(t, []string{"Rust mug", "Go poster"}, connectionTitles)
+
+	data = run(`{ product(id: "3") { title tags(where: {label_in: ["sale"]}) { label } } }`)
+	product := data["product"].(map[string]any)
+	assert.Equal(t, []any{map[string]any{"label": "sale"}}, product["tags"])
+
+	// The schema follows the attributes added to a collection on the next reload.
+	if err := db.Create(&models.Attribute{CollectionID: &products.ID, Name: "stock", Type: "int"}).Error; err != nil {
+		t.Fatalf("Failed to insert attribute: %v", err)
+	}
+	assert.NoError(t, InitializeGraphQLSchema())
+	data = run(`{ products(where: {stock_null: true}, orderBy: [stock_ASC]) { title } }`)
+	assert.Len(t, data["products"], 3)
+}
+
+func TestSingularFieldName(t *testing.T) {
+	assert.Equal(t, "article", singularFieldName("articles"))
+	assert.Equal(t, "category", singularFieldName("categories"))
+	assert.Equal(t, "box", singularFieldName("boxes"))
+	assert.Equal(t, "address", singularFieldName("addresses"))
+	assert.Equal(t, "TestCollectionItem", singularFieldName("TestCollection"))
 }
@@ -24,44 +24,21 @@ func ConvertCollectionToGraphQLType(collection models.Collection) (*graphql.Obje
 		Fields: graphql.FieldsThunk(func() graphql.Fields {
 			// 1. Create the map of fields inside the thunk.
 			fields := graphql.Fields{
-				"id": &graphql.Field{Type: graphql.ID},
+				"id":           &graphql.Field{Type: graphql.ID},
+				"status":       &graphql.Field{Type: graphql.String},
+				"published_at": &graphql.Field{Type: graphql.DateTime},
+				"publish_at":   &graphql.Field{Type: graphql.DateTime, Description: "Time the item is scheduled to be published at."},
+				"unpublish_at": &graphql.Field{Type: graphql.DateTime, Description: "Time the item is scheduled to be unpublished at."},
+				"version":      &graphql.Field{Type: graphql.Int, Description: "Version of the item, increased on every write."},
 			}
 
 			// 2. Loop through attributes and build all fields.
 			for _, attr := range collection.Attributes {
-				localAttr := attr
-
-				var gqlFieldType graphql.Output
-				var resolveFunc graphql.FieldResolveFn
-				var err error
-
-				if localAttr.Type == "relation" {
-					gqlFieldType, err = GetOrCreateGraphQLType(localAttr.Target)
-					if err != nil {
-						panic(fmt.Sprintf("schema error: failed to resolve relation '%s': %v", localAttr.Name, err))
-					}
-					resolveFunc = func(p graphql.ResolveParams) (any, error) {
-						return ResolveRelation(p, collection.ID, localAttr)
-					}
-				} else {
-					gqlFieldType, err = types.GetGraphQLType(localAttr.Type)
-					if err != nil {
-						panic(fmt.Sprintf("schema error: bad type for attribute '%s': %v", localAttr.Name, err))
-					}
-					resolveFunc = func(p graphql.ResolveParams) (any, error) {
-						if sourceMap, ok := p.Source.(map[string]any); ok {
-							if value, exists := sourceMap[localAttr.Name]; exists {
-								return value, nil
-							}
-						}
-						return nil, nil // Return null if the key doesn't exist.
-					}
-				}
-
-				fields[localAttr.Name] = &graphql.Field{
-					Type:    gqlFieldType,
-					Resolve: resolveFunc,
+				field, err := attributeField(collection.Name, collection.ID, attr)
+				if err != nil {
+					panic(fmt.Sprintf("schema error: %v", err))
 				}
+				fields[attr.Name] = field
 			}
 			// 3. Return the completed map.
 			return fields
@@ -88,3 +65,64 @@ func GetOrCreateGraphQLType(collectionName string) (*graphql.Object, error) {
 
 	return ConvertCollectionToGraphQLType(*collection)
 }
+
+// attributeField builds the GraphQL field of an attribute of a collection or component
+// named parent. collectionID is zero for the attributes of components.
+func attributeField(parent string, collectionID uint, attr models.Attribute) (*graphql.Field, error) {
+	switch attr.Type {
+	case "relation":
+		gqlType, err := GetOrCreateGraphQLType(attr.Target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve relation '%s': %w", attr.Name, err)
+		}
+		field := &graphql.Field{
+			Type: gqlType,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return ResolveRelation(p, collectionID, attr)
+			},
+		}
+		if attr.IsToMany() {
+			target, err := storage.GetCollectionByName(attr.Target)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve relation '%s': %w", attr.Name, err)
+			}
+			field.Type = graphql.NewList(gqlType)
+			field.Args = relationArgs(*target)
+		}
+		return field, nil
+	case "media":
+		return &graphql.Field{
+			Type: mediaFieldType(attr),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return ResolveMedia(p, attr)
+			},
+		}, nil
+	case "component", "dynamiczone":
+		fieldType, err := componentFieldType(parent, attr)
+		if err != nil {
+			return nil, err
+		}
+		return &graphql.Field{
+			Type: fieldType,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return ResolveComponent(p, attr)
+			},
+		}, nil
+	default:
+		gqlType, err := types.GetGraphQLType(attr.Type)
+		if err != nil {
+			return nil, fmt.Errorf("bad type for attribute '%s': %w", attr.Name, err)
+		}
+		return &graphql.Field{
+			Type: gqlType,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				if sourceMap, ok := p.Source.(map[string]any); ok {
+					if value, exists := sourceMap[attr.Name]; exists {
+						return value, nil
+					}
+				}
+				return nil, nil // Return null if the key doesn't exist.
+			},
+		}, nil
+	}
+}
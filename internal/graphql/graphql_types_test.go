@@ -146,3 +146,51 @@ func TestTypeRegistryCache(t *testing.T) {
 	// Ensure they point to the same object (cached)
 	assert.Equal(t, gqlType1, gqlType2, "Expected cached GraphQL type to be returned")
 }
+
+// TestComponentGraphQLTypes ensures components map to object types and dynamic zones to unions.
+func TestComponentGraphQLTypes(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	err := db.AutoMigrate(&models.Attribute{}, &models.Collection{}, &models.Component{}, &models.ComponentAttribute{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	for _, component := range []models.Component{
+		{Name: "hero", Attributes: []models.ComponentAttribute{{BaseAttribute: models.BaseAttribute{Name: "headline", Type: "text"}}}},
+		{Name: "cta", Attributes: []models.ComponentAttribute{{BaseAttribute: models.BaseAttribute{Name: "label", Type: "text"}}}},
+	} {
+		if err := db.Create(&component).Error; err != nil {
+			t.Fatalf("Failed to insert test component: %v", err)
+		}
+	}
+
+	collection := models.Collection{
+		Name: "landings",
+		Attributes: []models.Attribute{
+			{Name: "hero", Type: "component", ComponentRef: "hero"},
+			{Name: "ctas", Type: "component", ComponentRef: "cta", Repeatable: true},
+			{Name: "blocks", Type: "dynamiczone", Components: []string{"hero", "cta"}},
+		},
+	}
+	gqlType, err := ConvertCollectionToGraphQLType(collection)
+	assert.NoError(t, err)
+
+	fields := gqlType.Fields()
+	hero, isObject := fields["hero"].Type.(*graphql.Object)
+	assert.True(t, isObject, "Expected 'hero' to be a GraphQL Object type")
+	assert.Equal(t, "heroComponent", hero.Name())
+	assert.NotNil(t, hero.Fields()["headline"])
+
+	ctas, isList := fields["ctas"].Type.(*graphql.List)
+	assert.True(t, isList, "Expected a repeatable component to be a list")
+	assert.Equal(t, "ctaComponent", ctas.OfType.Name())
+
+	blocks, isList := fields["blocks"].Type.(*graphql.List)
+	assert.True(t, isList, "Expected a dynamic zone to be a list")
+	union, isUnion := blocks.OfType.(*graphql.Union)
+	assert.True(t, isUnion, "Expected the entries of a dynamic zone to be a union")
+	assert.Len(t, union.Types(), 2)
+	resolved := union.ResolveType(graphql.ResolveTypeParams{Value: map[string]any{models.ComponentDiscriminator: "cta"}})
+	assert.Equal(t, "ctaComponent", resolved.Name())
+}
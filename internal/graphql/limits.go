@@ -0,0 +1,246 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// defaultListSize is the number of items a list field is expected to return when no
+// 'first' or 'limit' argument bounds it, such as the items of a relation.
+const defaultListSize = 25
+
+// Limits of the operations executed, set by SetQueryLimits. Zero disables a limit.
+var (
+	maxQueryDepth      = 10
+	maxQueryComplexity = 5000
+)
+
+// SetQueryLimits sets the maximum depth and complexity of the operations executed. The
+// depth is the number of nested selection sets; the complexity counts every field
+// selected once per item the list fields above it are expected to return.
+func SetQueryLimits(maxDepth, maxComplexity int) {
+	maxQueryDepth = maxDepth
+	maxQueryComplexity = maxComplexity
+}
+
+// Execute runs a query or mutation within the depth and complexity limits. The related
+// items read by its resolvers are batched for the whole operation.
+func Execute(params graphql.Params) *graphql.Result {
+	if err := checkQueryLimits(params); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	ctx := params.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	params.Context = withLoaders(ctx)
+	return graphql.Do(params)
+}
+
+// Subscribe starts a subscription within the depth and complexity limits. Its events
+// are read afresh every time, without the cache of the loaders.
+func Subscribe(params graphql.Params) chan *graphql.Result {
+	if err := checkQueryLimits(params); err != nil {
+		results := make(chan *graphql.Result, 1)
+		results <- &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+		close(results)
+		return results
+	}
+	return graphql.Subscribe(params)
+}
+
+// checkQueryLimits rejects an operation deeper or more complex than allowed. Documents
+// which cannot be parsed are left to the executor to report.
+func checkQueryLimits(params graphql.Params) error {
+	if maxQueryDepth <= 0 && maxQueryComplexity <= 0 {
+		return nil
+	}
+	document, err := parser.Parse(parser.ParseParams{Source: params.RequestString})
+	if err != nil {
+		return nil
+	}
+
+	walker := &limitWalker{
+		schema:    params.Schema,
+		variables: params.VariableValues,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		visiting:  make(map[string]bool),
+	}
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			walker.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operation == nil && (params.OperationName == "" || (definition.Name != nil && definition.Name.Value == params.OperationName)) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+
+	var root graphql.Type
+	switch operation.Operation {
+	case ast.OperationTypeMutation:
+		if mutation := params.Schema.MutationType(); mutation != nil {
+			root = mutation
+		}
+	case ast.OperationTypeSubscription:
+		if subscription := params.Schema.SubscriptionType(); subscription != nil {
+			root = subscription
+		}
+	default:
+		root = params.Schema.QueryType()
+	}
+	depth, complexity := walker.selectionSet(operation.SelectionSet, root, 1, 0)
+	if maxQueryDepth > 0 && depth > maxQueryDepth {
+		return fmt.Errorf("query is too deep: %d levels, at most %d allowed", depth, maxQueryDepth)
+	}
+	if maxQueryComplexity > 0 && complexity > maxQueryComplexity {
+		return fmt.Errorf("query is too complex: complexity of %d, at most %d allowed", complexity, maxQueryComplexity)
+	}
+	return nil
+}
+
+// limitWalker measures the depth and complexity of the selection sets of an operation.
+type limitWalker struct {
+	schema    graphql.Schema
+	variables map[string]any
+	fragments map[string]*ast.FragmentDefinition
+	// visiting holds the fragments being walked, so cycles are not followed.
+	visiting map[string]bool
+}
+
+// selectionSet returns the depth and complexity of a selection set on parent, a level
+// nested in the operation. parent is nil for abstract types, whose fields are only
+// measured within fragments. size, when above zero, is the number of items requested of
+// a connection, which the first list below it returns in place of defaultListSize.
+func (w *limitWalker) selectionSet(set *ast.SelectionSet, parent graphql.Type, level, size int) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+	depth, complexity := level, 0
+	for _, selection := range set.Selections {
+		var d, c int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d, c = w.field(selection, parent, level, size)
+		case *ast.InlineFragment:
+			fragmentType := parent
+			if selection.TypeCondition != nil {
+				fragmentType = w.schema.Type(selection.TypeCondition.Name.Value)
+			}
+			d, c = w.selectionSet(selection.SelectionSet, fragmentType, level, size)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, exists := w.fragments[name]
+			if !exists || w.visiting[name] {
+				continue
+			}
+			w.visiting[name] = true
+			d, c = w.selectionSet(fragment.SelectionSet, w.schema.Type(fragment.TypeCondition.Name.Value), level, size)
+			delete(w.visiting, name)
+		}
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity
+}
+
+// field returns the depth and complexity of a field: one, plus the complexity of its
+// selections for every item it is expected to return. Introspection is not limited.
+func (w *limitWalker) field(field *ast.Field, parent graphql.Type, level, size int) (int, int) {
+	name := field.Name.Value
+	if strings.HasPrefix(name, "__") {
+		return level, 0
+	}
+	if field.SelectionSet == nil {
+		return level, 1
+	}
+
+	var definition *graphql.FieldDefinition
+	if object, ok := parent.(*graphql.Object); ok {
+		definition = object.Fields()[name]
+	}
+	if definition == nil {
+		depth, complexity := w.selectionSet(field.SelectionSet, nil, level+1, 0)
+		return depth, 1 + complexity
+	}
+
+	fieldType := definition.Type
+	if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+		fieldType = nonNull.OfType
+	}
+	_, isList := fieldType.(*graphql.List)
+	requested, hasSize := w.sizeArgument(field, definition)
+
+	multiplier := 1
+	switch {
+	case isList && hasSize:
+		multiplier, size = requested, 0
+	case isList && size > 0:
+		multiplier, size = size, 0
+	case isList:
+		multiplier = defaultListSize
+	case hasSize:
+		// A connection: its edges hold the items requested.
+		size = requested
+	}
+
+	depth, complexity := w.selectionSet(field.SelectionSet, namedType(fieldType), level+1, size)
+	return depth, 1 + multiplier*complexity
+}
+
+// sizeArgument returns the number of items a field is asked for with its 'first' or
+// 'limit' argument, given in the query, in a variable or by default.
+func (w *limitWalker) sizeArgument(field *ast.Field, definition *graphql.FieldDefinition) (int, bool) {
+	for _, name := range []string{"first", "limit"} {
+		for _, argument := range field.Arguments {
+			if argument.Name.Value != name {
+				continue
+			}
+			switch value := argument.Value.(type) {
+			case *ast.IntValue:
+				if size, err := strconv.Atoi(value.Value); err == nil && size >= 0 {
+					return size, true
+				}
+			case *ast.Variable:
+				switch size := w.variables[value.Name.Value].(type) {
+				case int:
+					return size, true
+				case float64:
+					return int(size), true
+				}
+			}
+		}
+		for _, argument := range definition.Args {
+			if size, ok := argument.DefaultValue.(int); ok && argument.Name() == name {
+				return size, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// namedType returns the type wrapped in lists and non-null types.
+func namedType(t graphql.Type) graphql.Type {
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.List:
+			t = wrapped.OfType
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		default:
+			return t
+		}
+	}
+}
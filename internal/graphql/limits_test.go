@@ -0,0 +1,61 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQueryLimits rejects the operations deeper or more complex than allowed.
+func TestQueryLimits(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Attribute{}, &models.Collection{}, &models.Item{}))
+	require.NoError(t, db.Create(&models.Collection{Name: "articles", Attributes: []models.Attribute{
+		{Name: "title", Type: "text"},
+		{Name: "related", Type: "relation", Target: "articles", Relation: "manyToMany"},
+	}}).Error)
+	require.NoError(t, InitializeGraphQLSchema())
+
+	defer SetQueryLimits(maxQueryDepth, maxQueryComplexity)
+	reader := &models.UserRole{Name: "reader", Permissions: models.JSONMap{"collections.articles.read": true}}
+	run := func(query string, variables map[string]any) *graphql.Result {
+		return Execute(graphql.Params{
+			Schema:         GetSchema(),
+			RequestString:  query,
+			VariableValues: variables,
+			Context:        WithViewer(context.Background(), Viewer{Username: "reader", Role: reader}),
+		})
+	}
+	errorMessage := func(result *graphql.Result) string {
+		require.Len(t, result.Errors, 1)
+		return result.Errors[0].Message
+	}
+
+	t.Run("Depth", func(t *testing.T) {
+		SetQueryLimits(3, 0)
+		assert.Empty(t, run(`{ articles { related { title } } }`, nil).Errors)
+		assert.Contains(t, errorMessage(run(`{ articles { related { related { title } } } }`, nil)), "query is too deep: 4 levels, at most 3 allowed")
+		// Fragments count at the level they are spread, and introspection is not limited.
+		assert.Contains(t, errorMessage(run(`{ articles { ...Related } } fragment Related on articles { related { related { title } } }`, nil)), "query is too deep")
+		assert.Empty(t, run(`{ __schema { types { fields { type { name } } } } }`, nil).Errors)
+	})
+
+	t.Run("Complexity", func(t *testing.T) {
+		SetQueryLimits(0, 100)
+		// 1 + 10 × (1 + 1 + 25 × 1) = 271
+		assert.Contains(t, errorMessage(run(`{ articles(limit: 10) { title related { title } } }`, nil)), "query is too complex: complexity of 271, at most 100 allowed")
+		// 1 + 3 × (1 + 1 + 25 × 1) = 82
+		assert.Empty(t, run(`query($limit: Int) { articles(limit: $limit) { title related { title } } }`, map[string]any{"limit": 3}).Errors)
+		// The items requested of a connection are counted once, on its edges:
+		// 1 + (1 + 4 × (1 + 1 + 1) + 1 + 1) = 16
+		SetQueryLimits(0, 15)
+		assert.Contains(t, errorMessage(run(`{ articlesConnection(first: 4) { edges { cursor node { title } } pageInfo { hasNextPage } } }`, nil)), "complexity of 16")
+	})
+}
@@ -0,0 +1,149 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/storage"
+)
+
+type loadersKey struct{}
+
+// loaders batch the reads of the relation resolvers of a request. graphql-go resolves
+// the fields of a level of the query before the thunks they return, so the collections
+// and items requested for all the rows of a level are queued first, then read with one
+// query for the collections and one per collection for the items. What they read is
+// cached until the end of the request.
+type loaders struct {
+	mu sync.Mutex
+	// collections holds the collections read by name, nil for names of no collection.
+	collections        map[string]*models.Collection
+	pendingCollections map[string]struct{}
+	// items holds the items read by collection ID and item ID, nil for missing items.
+	items map[uint]map[uint]*models.Item
+	// pendingItems holds the IDs of the items to read by collection name, as the ID of
+	// the collection may not be read yet.
+	pendingItems map[string]map[uint]struct{}
+}
+
+func newLoaders() *loaders {
+	return &loaders{
+		collections:        make(map[string]*models.Collection),
+		pendingCollections: make(map[string]struct{}),
+		items:              make(map[uint]map[uint]*models.Item),
+		pendingItems:       make(map[string]map[uint]struct{}),
+	}
+}
+
+// withLoaders returns a copy of ctx carrying new loaders, shared by the resolvers of an
+// operation.
+func withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, newLoaders())
+}
+
+// loadersFromContext returns the loaders of the operation executed with ctx. Without
+// any, as for the events of subscriptions which must be read afresh every time, the
+// reads of each resolver are batched on their own.
+func loadersFromContext(ctx context.Context) *loaders {
+	if ctx != nil {
+		if l, ok := ctx.Value(loadersKey{}).(*loaders); ok {
+			return l
+		}
+	}
+	return newLoaders()
+}
+
+// loadRelated queues the read of a collection and of its items with the given IDs. The
+// function returned reads them along with everything queued so far, and returns the
+// items found in the order of ids.
+func (l *loaders) loadRelated(collectionName string, ids []uint) func() (*models.Collection, []models.Item, error) {
+	l.mu.Lock()
+	collection, loaded := l.collections[collectionName]
+	if !loaded {
+		l.pendingCollections[collectionName] = struct{}{}
+	}
+	for _, id := range ids {
+		if collection != nil {
+			if _, cached := l.items[collection.ID][id]; cached {
+				continue
+			}
+		}
+		if l.pendingItems[collectionName] == nil {
+			l.pendingItems[collectionName] = make(map[uint]struct{})
+		}
+		l.pendingItems[collectionName][id] = struct{}{}
+	}
+	l.mu.Unlock()
+
+	return func() (*models.Collection, []models.Item, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if err := l.flush(); err != nil {
+			return nil, nil, err
+		}
+		collection := l.collections[collectionName]
+		if collection == nil {
+			return nil, nil, fmt.Errorf("collection '%s' not found", collectionName)
+		}
+		items := make([]models.Item, 0, len(ids))
+		for _, id := range ids {
+			if item := l.items[collection.ID][id]; item != nil {
+				items = append(items, *item)
+			}
+		}
+		return collection, items, nil
+	}
+}
+
+// flush reads the queued collections, then the queued items. l.mu must be held.
+func (l *loaders) flush() error {
+	if len(l.pendingCollections) > 0 {
+		names := make([]string, 0, len(l.pendingCollections))
+		for name := range l.pendingCollections {
+			names = append(names, name)
+			l.collections[name] = nil
+		}
+		clear(l.pendingCollections)
+
+		collections, err := storage.GetCollectionsByNames(names)
+		if err != nil {
+			return err
+		}
+		for i := range collections {
+			l.collections[collections[i].Name] = &collections[i]
+		}
+	}
+
+	for name, pending := range l.pendingItems {
+		delete(l.pendingItems, name)
+		collection := l.collections[name]
+		if collection == nil {
+			continue
+		}
+		ids := make([]uint, 0, len(pending))
+		for id := range pending {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+
+		items, err := storage.QueryItemsOffset(collection.ID, models.ItemQuery{IDs: ids}, 0, -1)
+		if err != nil {
+			return err
+		}
+		cached := l.items[collection.ID]
+		if cached == nil {
+			cached = make(map[uint]*models.Item, len(ids))
+			l.items[collection.ID] = cached
+		}
+		for _, id := range ids {
+			cached[id] = nil
+		}
+		for i := range items {
+			cached[items[i].ID] = &items[i]
+		}
+	}
+	return nil
+}
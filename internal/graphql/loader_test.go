@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestRelationLoaders resolves the relations of a list of items with one query per
+// related collection.
+func TestRelationLoaders(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Attribute{}, &models.Collection{}, &models.Item{}))
+
+	categories := models.Collection{Name: "categories", Attributes: []models.Attribute{{Name: "name", Type: "text"}}}
+	tags := models.Collection{Name: "tags", Attributes: []models.Attribute{{Name: "label", Type: "text"}}}
+	products := models.Collection{Name: "products", Attributes: []models.Attribute{
+		{Name: "title", Type: "text"},
+		{Name: "category", Type: "relation", Target: "categories", Relation: "manyToOne"},
+		{Name: "tags", Type: "relation", Target: "tags", Relation: "manyToMany"},
+	}}
+	for _, collection := range []*models.Collection{&categories, &tags, &products} {
+		require.NoError(t, db.Create(collection).Error)
+	}
+	create := func(collection models.Collection, status string, data models.JSONMap) uint {
+		item := models.Item{CollectionID: collection.ID, Status: status, Data: data}
+		require.NoError(t, db.Create(&item).Error)
+		return item.ID
+	}
+	mugs := create(categories, models.ItemStatusPublished, models.JSONMap{"name": "Mugs"})
+	posters := create(categories, models.ItemStatusPublished, models.JSONMap{"name": "Posters"})
+	sale := create(tags, models.ItemStatusPublished, models.JSONMap{"label": "sale"})
+	hidden := create(tags, models.ItemStatusDraft, models.JSONMap{"label": "hidden"})
+	create(products, models.ItemStatusPublished, models.JSONMap{"title": "Go mug", "category": mugs, "tags": []any{sale, hidden}})
+	create(products, models.ItemStatusPublished, models.JSONMap{"title": "Rust mug", "category": mugs, "tags": []any{sale}})
+	create(products, models.ItemStatusPublished, models.JSONMap{"title": "Go poster", "category": posters})
+
+	require.NoError(t, InitializeGraphQLSchema())
+	reader := &models.UserRole{Name: "reader", Permissions: models.JSONMap{
+		"collections.products.read":   true,
+		"collections.categories.read": true,
+		"collections.tags.read":       true,
+	}}
+
+	itemQueries := 0
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:count_items", func(tx *gorm.DB) {
+		if tx.Statement.Table == "items" {
+			itemQueries++
+		}
+	}))
+	defer db.Callback().Query().Remove("test:count_items")
+
+	result := Execute(graphql.Params{
+		Schema:        GetSchema(),
+		RequestString: `{ products(orderBy: [title_ASC]) { title category { name } tags { label } } }`,
+		Context:       WithViewer(context.Background(), Viewer{Username: "reader", Role: reader}),
+	})
+	require.Empty(t, result.Errors)
+	assert.Equal(t, []any{
+		map[string]any{"title": "Go mug", "category": map[string]any{"name": "Mugs"}, "tags": []any{map[string]any{"label": "sale"}}},
+		map[string]any{"title": "Go poster", "category": map[string]any{"name": "Posters"}, "tags": nil},
+		map[string]any{"title": "Rust mug", "category": map[string]any{"name": "Mugs"}, "tags": []any{map[string]any{"label": "sale"}}},
+	}, result.Data.(map[string]any)["products"])
+	// One query for the products, then one for the categories and one for the tags.
+	assert.Equal(t, 3, itemQueries)
+}
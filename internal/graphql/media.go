@@ -0,0 +1,114 @@
+package graphql
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/graphql-go/graphql"
+)
+
+// assetVariantType describes a resized version of an image asset.
+var assetVariantType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "AssetVariant",
+	Fields: graphql.Fields{
+		"name":      &graphql.Field{Type: graphql.String},
+		"url":       &graphql.Field{Type: graphql.String},
+		"mime_type": &graphql.Field{Type: graphql.String},
+		"width":     &graphql.Field{Type: graphql.Int},
+		"height":    &graphql.Field{Type: graphql.Int},
+	},
+})
+
+// assetType describes an asset of the media library referenced by a media attribute.
+var assetType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Asset",
+	Fields: graphql.Fields{
+		"id":         &graphql.Field{Type: graphql.ID},
+		"name":       &graphql.Field{Type: graphql.String},
+		"mime_type":  &graphql.Field{Type: graphql.String},
+		"size":       &graphql.Field{Type: graphql.Int},
+		"checksum":   &graphql.Field{Type: graphql.String},
+		"width":      &graphql.Field{Type: graphql.Int},
+		"height":     &graphql.Field{Type: graphql.Int},
+		"alt_text":   &graphql.Field{Type: graphql.String},
+		"url":        &graphql.Field{Type: graphql.String},
+		"variants":   &graphql.Field{Type: graphql.NewList(assetVariantType)},
+		"created_at": &graphql.Field{Type: graphql.DateTime},
+	},
+})
+
+// mediaFieldType returns the GraphQL type of a media attribute.
+func mediaFieldType(attr models.Attribute) graphql.Output {
+	if attr.Multiple {
+		return graphql.NewList(assetType)
+	}
+	return assetType
+}
+
+// mediaInputType returns the GraphQL input type of a media attribute: asset IDs.
+func mediaInputType(attr models.Attribute) graphql.Input {
+	if attr.Multiple {
+		return graphql.NewList(graphql.ID)
+	}
+	return graphql.ID
+}
+
+// assetResult maps an asset to the fields of the Asset type.
+func assetResult(asset models.Asset) map[string]any {
+	variants := make([]map[string]any, 0, len(asset.Variants))
+	for _, name := range slices.Sorted(maps.Keys(asset.Variants)) {
+		variant := asset.Variants[name]
+		variants = append(variants, map[string]any{
+			"name":      name,
+			"url":       variant.URL,
+			"mime_type": variant.MimeType,
+			"width":     variant.Width,
+			"height":    variant.Height,
+		})
+	}
+	return map[string]any{
+		"id":         asset.ID,
+		"name":       asset.Name,
+		"mime_type":  asset.MimeType,
+		"size":       asset.Size,
+		"checksum":   asset.Checksum,
+		"width":      asset.Width,
+		"height":     asset.Height,
+		"alt_text":   asset.AltText,
+		"url":        asset.URL,
+		"variants":   variants,
+		"created_at": asset.CreatedAt,
+	}
+}
+
+// ResolveMedia loads the assets referenced by a media attribute. Deleted assets resolve to null.
+func ResolveMedia(p graphql.ResolveParams, attr models.Attribute) (any, error) {
+	sourceMap, ok := p.Source.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid source type for media '%s', expected map[string]any", attr.Name)
+	}
+	value, exists := sourceMap[attr.Name]
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	expanded, err := storage.ExpandMedia([]models.Attribute{attr}, models.JSONMap{attr.Name: value})
+	if err != nil {
+		return nil, err
+	}
+	switch assets := expanded[attr.Name].(type) {
+	case models.Asset:
+		return assetResult(assets), nil
+	case []models.Asset:
+		results := make([]map[string]any, 0, len(assets))
+		for _, asset := range assets {
+			results = append(results, assetResult(asset))
+		}
+		return results, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,192 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Errors of the persisted queries. The messages of ErrPersistedQueryNotFound and
+// ErrPersistedQueryNotSupported are those Apollo clients expect.
+var (
+	// ErrPersistedQueryNotFound is returned for a hash of no persisted query. Clients
+	// of the APQ protocol then send the query along with its hash to register it.
+	ErrPersistedQueryNotFound = errors.New("PersistedQueryNotFound")
+	// ErrPersistedQueryNotSupported is returned for a hash when no store is configured.
+	ErrPersistedQueryNotSupported = errors.New("PersistedQueryNotSupported")
+	// ErrQueryNotAllowed is returned in allow-list mode for a query of no manifest.
+	ErrQueryNotAllowed = errors.New("only the queries of a persisted query manifest are allowed")
+)
+
+// PersistedQueryExtension is the persistedQuery extension of a request, naming the
+// query to execute by its hash.
+type PersistedQueryExtension struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// PersistedQueryStore holds the persisted queries.
+type PersistedQueryStore interface {
+	// Get returns the query persisted under hash, nil when there is none.
+	Get(ctx context.Context, hash string) (*models.PersistedQuery, error)
+	// Save persists queries. Saving an automatic query does not change a manifest one.
+	Save(ctx context.Context, queries []models.PersistedQuery) error
+}
+
+// Persisted queries, set by SetPersistedQueries.
+var (
+	persistedQueries PersistedQueryStore
+	// allowListMode restricts the viewers who do not manage GraphQL to manifest queries.
+	allowListMode bool
+)
+
+// SetPersistedQueries sets the store of the persisted queries, nil to disable them. In
+// allow-list mode, only the queries uploaded in a manifest are executed, except for the
+// viewers granted 'graphql.*.manage'.
+func SetPersistedQueries(store PersistedQueryStore, allowList bool) {
+	persistedQueries = store
+	allowListMode = allowList
+}
+
+// SavePersistedQueries persists queries in the configured store.
+func SavePersistedQueries(ctx context.Context, queries []models.PersistedQuery) error {
+	if persistedQueries == nil {
+		return ErrPersistedQueryNotSupported
+	}
+	return persistedQueries.Save(ctx, queries)
+}
+
+// ResolvePersistedQuery returns the query to execute for a request made by the viewer in
+// ctx, sending query, the persisted query extension, or both to register the query.
+func ResolvePersistedQuery(ctx context.Context, query string, extension *PersistedQueryExtension) (string, error) {
+	trusted := !allowListMode || viewerCan(ctx, models.ResourceGraphQL, models.PermissionWildcard, models.ActionManage)
+	if extension == nil {
+		if trusted {
+			return query, nil
+		}
+		// Clients may also send the queries of the manifest in full.
+		extension = &PersistedQueryExtension{Version: 1, Sha256Hash: models.HashQuery(query)}
+	}
+
+	if extension.Version != 1 {
+		return "", fmt.Errorf("unsupported persisted query version %d", extension.Version)
+	}
+	if persistedQueries == nil {
+		if query != "" && trusted {
+			return query, nil
+		}
+		return "", ErrPersistedQueryNotSupported
+	}
+	hash := strings.ToLower(extension.Sha256Hash)
+	if query != "" && hash != models.HashQuery(query) {
+		return "", errors.New("the persisted query hash does not match the query")
+	}
+
+	persisted, err := persistedQueries.Get(ctx, hash)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case !trusted && (persisted == nil || persisted.Source != models.PersistedQuerySourceManifest):
+		return "", ErrQueryNotAllowed
+	case persisted != nil:
+		return persisted.Query, nil
+	case query == "":
+		return "", ErrPersistedQueryNotFound
+	}
+
+	// The query is executed even when it fails to be registered, the client sends it
+	// again next time.
+	registered := models.PersistedQuery{Hash: hash, Query: query, Source: models.PersistedQuerySourceAutomatic}
+	if err := persistedQueries.Save(ctx, []models.PersistedQuery{registered}); err != nil {
+		logger.Log.WithError(err).WithField("hash", hash).Warn("Failed to register persisted query")
+	}
+	return query, nil
+}
+
+// DatabaseQueryStore persists the queries in the database. Automatic queries expire
+// after TTL, unless it is zero, and are purged by the scheduler; manifest queries are
+// kept.
+type DatabaseQueryStore struct {
+	TTL time.Duration
+}
+
+// Get returns the query persisted under hash, nil when there is none.
+func (DatabaseQueryStore) Get(_ context.Context, hash string) (*models.PersistedQuery, error) {
+	query, err := storage.GetPersistedQuery(hash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return query, err
+}
+
+// Save persists queries.
+func (s DatabaseQueryStore) Save(_ context.Context, queries []models.PersistedQuery) error {
+	if s.TTL > 0 {
+		expiresAt := time.Now().Add(s.TTL)
+		for i := range queries {
+			if queries[i].Source != models.PersistedQuerySourceManifest {
+				queries[i].ExpiresAt = &expiresAt
+			}
+		}
+	}
+	return storage.SavePersistedQueries(queries)
+}
+
+// redisQueryKeyPrefix prefixes the keys of the queries persisted in Redis, followed by
+// their source and hash.
+const redisQueryKeyPrefix = "gohead:graphql:queries:"
+
+// RedisQueryStore persists the queries in Redis. Automatic queries expire after TTL,
+// unless it is zero; manifest queries are kept.
+type RedisQueryStore struct {
+	Client redis.UniversalClient
+	TTL    time.Duration
+}
+
+func redisQueryKey(source, hash string) string {
+	return redisQueryKeyPrefix + source + ":" + hash
+}
+
+// Get returns the query persisted under hash, nil when there is none.
+func (s RedisQueryStore) Get(ctx context.Context, hash string) (*models.PersistedQuery, error) {
+	sources := []string{models.PersistedQuerySourceManifest, models.PersistedQuerySourceAutomatic}
+	values, err := s.Client.MGet(ctx, redisQueryKey(sources[0], hash), redisQueryKey(sources[1], hash)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch persisted query '%s': %w", hash, err)
+	}
+	for i, value := range values {
+		if query, ok := value.(string); ok {
+			return &models.PersistedQuery{Hash: hash, Query: query, Source: sources[i]}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Save persists queries.
+func (s RedisQueryStore) Save(ctx context.Context, queries []models.PersistedQuery) error {
+	_, err := s.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, query := range queries {
+			if query.Source == models.PersistedQuerySourceManifest {
+				pipe.Set(ctx, redisQueryKey(query.Source, query.Hash), query.Query, 0)
+				pipe.Del(ctx, redisQueryKey(models.PersistedQuerySourceAutomatic, query.Hash))
+			} else {
+				pipe.Set(ctx, redisQueryKey(models.PersistedQuerySourceAutomatic, query.Hash), query.Query, s.TTL)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save persisted queries: %w", err)
+	}
+	return nil
+}
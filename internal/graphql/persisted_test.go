@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolvePersistedQuery resolves the queries sent by hash, registered by clients or
+// uploaded in a manifest.
+func TestResolvePersistedQuery(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.PersistedQuery{}))
+	defer SetPersistedQueries(nil, false)
+
+	const (
+		registered = "{ articles { title } }"
+		uploaded   = "{ pages { title } }"
+	)
+	reader := WithViewer(context.Background(), Viewer{Username: "reader", Role: &models.UserRole{Name: "reader", Permissions: models.JSONMap{"collections.*.read": true}}})
+	admin := WithViewer(context.Background(), Viewer{Username: "admin", Role: &models.UserRole{Name: "admin", Permissions: models.DefaultRolePermissions["admin"]}})
+	extension := func(query string) *PersistedQueryExtension {
+		return &PersistedQueryExtension{Version: 1, Sha256Hash: models.HashQuery(query)}
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		SetPersistedQueries(nil, false)
+		query, err := ResolvePersistedQuery(reader, registered, extension(registered))
+		require.NoError(t, err)
+		assert.Equal(t, registered, query)
+		_, err = ResolvePersistedQuery(reader, "", extension(registered))
+		assert.ErrorIs(t, err, ErrPersistedQueryNotSupported)
+	})
+
+	t.Run("Automatic persisted queries", func(t *testing.T) {
+		SetPersistedQueries(DatabaseQueryStore{TTL: time.Hour}, false)
+		_, err := ResolvePersistedQuery(reader, "", extension(registered))
+		assert.ErrorIs(t, err, ErrPersistedQueryNotFound)
+
+		// The client then sends the query along with its hash, which registers it.
+		query, err := ResolvePersistedQuery(reader, registered, extension(registered))
+		require.NoError(t, err)
+		assert.Equal(t, registered, query)
+		query, err = ResolvePersistedQuery(reader, "", extension(registered))
+		require.NoError(t, err)
+		assert.Equal(t, registered, query)
+		// Queries registered by clients expire.
+		persisted, err := storage.GetPersistedQuery(models.HashQuery(registered))
+		require.NoError(t, err)
+		require.NotNil(t, persisted.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *persisted.ExpiresAt, time.Minute)
+
+		_, err = ResolvePersistedQuery(reader, uploaded, extension(registered))
+		assert.ErrorContains(t, err, "does not match")
+		_, err = ResolvePersistedQuery(reader, "", &PersistedQueryExtension{Version: 2, Sha256Hash: models.HashQuery(registered)})
+		assert.ErrorContains(t, err, "unsupported persisted query version")
+
+		query, err = ResolvePersistedQuery(reader, uploaded, nil)
+		require.NoError(t, err)
+		assert.Equal(t, uploaded, query)
+	})
+
+	t.Run("Allow-list", func(t *testing.T) {
+		SetPersistedQueries(DatabaseQueryStore{}, true)
+		require.NoError(t, SavePersistedQueries(context.Background(), []models.PersistedQuery{
+			{Hash: models.HashQuery(uploaded), Query: uploaded, Source: models.PersistedQuerySourceManifest},
+		}))
+
+		query, err := ResolvePersistedQuery(reader, "", extension(uploaded))
+		require.NoError(t, err)
+		assert.Equal(t, uploaded, query)
+		query, err = ResolvePersistedQuery(reader, uploaded, nil)
+		require.NoError(t, err)
+		assert.Equal(t, uploaded, query)
+
+		// Queries registered by clients are not in the manifest.
+		for _, request := range []struct {
+			query     string
+			extension *PersistedQueryExtension
+		}{
+			{"", extension(registered)},
+			{registered, nil},
+			{"{ authors { name } }", extension("{ authors { name } }")},
+		} {
+			_, err := ResolvePersistedQuery(reader, request.query, request.extension)
+			assert.ErrorIs(t, err, ErrQueryNotAllowed)
+		}
+
+		query, err = ResolvePersistedQuery(admin, "{ authors { name } }", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "{ authors { name } }", query)
+	})
+}
@@ -0,0 +1,244 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/graphql-go/graphql"
+)
+
+// whereOperatorSuffixes maps the filter operators to the suffix of the 'where' fields
+// applying them: 'title' tests equality, 'title_contains' a substring, and so on.
+var whereOperatorSuffixes = map[string]string{
+	models.OpEq:       "",
+	models.OpNe:       "_not",
+	models.OpIn:       "_in",
+	models.OpLt:       "_lt",
+	models.OpLte:      "_lte",
+	models.OpGt:       "_gt",
+	models.OpGte:      "_gte",
+	models.OpContains: "_contains",
+	models.OpNull:     "_null",
+}
+
+var (
+	whereInputRegistry = make(map[string]*graphql.InputObject)
+	orderByRegistry    = make(map[string]*graphql.Enum)
+)
+
+// whereField is the condition a field of a 'where' input applies.
+type whereField struct {
+	attribute models.Attribute
+	operator  string
+}
+
+// orderByValue is the sort directive of a value of an 'orderBy' enum.
+type orderByValue struct {
+	field string
+	desc  bool
+}
+
+// whereFields returns the fields of the 'where' input of a collection, by name.
+func whereFields(collection models.Collection) map[string]whereField {
+	fields := make(map[string]whereField)
+	for _, attr := range collection.Attributes {
+		for _, op := range models.ItemFilterOperators(attr) {
+			fields[attr.Name+whereOperatorSuffixes[op]] = whereField{attribute: attr, operator: op}
+		}
+	}
+	return fields
+}
+
+// whereValueType returns the GraphQL type of the values an attribute is compared with.
+func whereValueType(attr models.Attribute) graphql.Input {
+	switch attr.Type {
+	case "int", "integer":
+		return graphql.Int
+	case "float", "decimal":
+		return graphql.Float
+	case "bool", "boolean":
+		return graphql.Boolean
+	case "relation":
+		return graphql.ID
+	}
+	return graphql.String
+}
+
+// whereInputType returns the input type filtering the items of a collection: a field
+// per attribute and operator, and AND and OR lists of nested conditions.
+func whereInputType(collection models.Collection) *graphql.InputObject {
+	name := collection.Name + "Where"
+	if inputType, exists := whereInputRegistry[name]; exists {
+		return inputType
+	}
+
+	var inputType *graphql.InputObject
+	inputType = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        name,
+		Description: fmt.Sprintf("Conditions on the items of '%s', which all hold.", collection.Name),
+		Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {
+			fields := graphql.InputObjectConfigFieldMap{
+				"AND": &graphql.InputObjectFieldConfig{
+					Type:        graphql.NewList(graphql.NewNonNull(inputType)),
+					Description: "Conditions which all hold.",
+				},
+				"OR": &graphql.InputObjectFieldConfig{
+					Type:        graphql.NewList(graphql.NewNonNull(inputType)),
+					Description: "Conditions of which at least one holds.",
+				},
+			}
+			for key, field := range whereFields(collection) {
+				var fieldType graphql.Input = whereValueType(field.attribute)
+				switch field.operator {
+				case models.OpIn:
+					fieldType = graphql.NewList(graphql.NewNonNull(fieldType))
+				case models.OpNull:
+					fieldType = graphql.Boolean
+				}
+				fields[key] = &graphql.InputObjectFieldConfig{Type: fieldType}
+			}
+			return fields
+		}),
+	})
+	whereInputRegistry[name] = inputType
+	return inputType
+}
+
+// orderByEnumType returns the enum of the sort directives of the items of a collection:
+// '<field>_ASC' and '<field>_DESC' for its sortable attributes and item system fields.
+func orderByEnumType(collection models.Collection) *graphql.Enum {
+	name := collection.Name + "OrderBy"
+	if enum, exists := orderByRegistry[name]; exists {
+		return enum
+	}
+
+	fields := make([]string, 0, len(models.ItemSystemFields)+len(collection.Attributes))
+	for field := range models.ItemSystemFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, attr := range collection.Attributes {
+		if models.IsSortable(attr) {
+			fields = append(fields, attr.Name)
+		}
+	}
+
+	values := graphql.EnumValueConfigMap{}
+	for _, field := range fields {
+		values[field+"_ASC"] = &graphql.EnumValueConfig{Value: orderByValue{field: field}}
+		values[field+"_DESC"] = &graphql.EnumValueConfig{Value: orderByValue{field: field, desc: true}}
+	}
+	enum := graphql.NewEnum(graphql.EnumConfig{
+		Name:   name,
+		Values: values,
+	})
+	orderByRegistry[name] = enum
+	return enum
+}
+
+// filterArgs returns the 'where' and 'orderBy' arguments of the fields reading the
+// items of a collection.
+func filterArgs(collection models.Collection) graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"where": &graphql.ArgumentConfig{
+			Type:        whereInputType(collection),
+			Description: "Conditions the items match.",
+		},
+		"orderBy": &graphql.ArgumentConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(orderByEnumType(collection))),
+			Description: "Sort order of the items, by ID when omitted.",
+		},
+	}
+}
+
+// withArgs returns the arguments of both sets.
+func withArgs(args, more graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	merged := make(graphql.FieldConfigArgument, len(args)+len(more))
+	for name, arg := range args {
+		merged[name] = arg
+	}
+	for name, arg := range more {
+		merged[name] = arg
+	}
+	return merged
+}
+
+// itemQueryArgs reads the 'where' and 'orderBy' arguments of a field into an item query.
+// Attributes the viewer may not read can be neither filtered nor sorted on.
+func itemQueryArgs(p graphql.ResolveParams, collection models.Collection, locale string) (models.ItemQuery, error) {
+	readable := collection.ReadableBy(viewerFromContext(p.Context).Role)
+
+	var query models.ItemQuery
+	if where, ok := p.Args["where"].(map[string]any); ok {
+		parsed, err := parseWhere(readable, where, locale)
+		if err != nil {
+			return query, fmt.Errorf("invalid 'where' argument: %w", err)
+		}
+		query.Where = &parsed
+	}
+	if orderBy, ok := p.Args["orderBy"].([]any); ok {
+		for _, value := range orderBy {
+			directive, _ := value.(orderByValue)
+			sortField, err := readable.NewItemSort(directive.field, directive.desc, locale)
+			if err != nil {
+				return query, fmt.Errorf("invalid 'orderBy' argument: %w", err)
+			}
+			query.Sort = append(query.Sort, sortField)
+		}
+	}
+	return query, nil
+}
+
+// parseWhere converts the value of a 'where' input into conditions on the items of a
+// collection.
+func parseWhere(collection models.Collection, where map[string]any, locale string) (models.ItemWhere, error) {
+	var parsed models.ItemWhere
+	fields := whereFields(collection)
+
+	// Sort the keys so filters are applied in a deterministic order.
+	keys := make([]string, 0, len(where))
+	for key := range where {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := where[key]
+		if value == nil {
+			continue
+		}
+		switch key {
+		case "AND", "OR":
+			list, _ := value.([]any)
+			for _, entry := range list {
+				nested, _ := entry.(map[string]any)
+				condition, err := parseWhere(collection, nested, locale)
+				if err != nil {
+					return parsed, err
+				}
+				if key == "AND" {
+					parsed.And = append(parsed.And, condition)
+				} else {
+					parsed.Or = append(parsed.Or, condition)
+				}
+			}
+			continue
+		}
+
+		field, exists := fields[key]
+		if !exists {
+			return parsed, fmt.Errorf("unknown filter field: '%s'", key)
+		}
+		values := []any{value}
+		if list, isList := value.([]any); isList {
+			values = list
+		}
+		filter, err := collection.NewItemFilter(field.attribute.Name, field.operator, values, locale)
+		if err != nil {
+			return parsed, err
+		}
+		parsed.Filters = append(parsed.Filters, filter)
+	}
+	return parsed, nil
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/graphql-go/graphql"
 )
 
-// ResolveRelation handles fetching related items in a GraphQL query.
+// ResolveRelation handles fetching related items in a GraphQL query. The related items
+// are read once the fields of the whole level of the query are resolved, together with
+// those of the other rows, see loaders.
 func ResolveRelation(p graphql.ResolveParams, collectionID uint, attr models.Attribute) (any, error) {
 	sourceMap, ok := p.Source.(map[string]any)
 	if !ok {
@@ -22,36 +24,46 @@ func ResolveRelation(p graphql.ResolveParams, collectionID uint, attr models.Att
 		return nil, nil // No relation set, return null.
 	}
 
-	targetCollection, err := storage.GetCollectionByName(attr.Target)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch target collection '%s': %w", attr.Target, err)
+	// Outside of draft preview, related drafts are hidden like any other draft.
+	preview, _ := sourceMap[previewKey].(bool)
+	locale, _ := sourceMap[localeKey].(string)
+
+	// Related items the viewer may not read are hidden.
+	if !viewerCan(p.Context, models.ResourceCollections, attr.Target, models.ActionRead) {
+		return nil, nil
 	}
 
 	// Handle Many-to-Many and One-to-Many Relations
-	if attr.Relation == "oneToMany" || attr.Relation == "manyToMany" {
-		relatedIDs, ok := relationValue.([]any)
-		if !ok {
-			return nil, fmt.Errorf("invalid relation format for '%s', expected an array", attr.Name)
+	if attr.IsToMany() {
+		ids := models.RelationIDs(relationValue)
+		if len(p.Args) > 0 {
+			// Related items are filtered for each row, only the collection is batched.
+			load := loadersFromContext(p.Context).loadRelated(attr.Target, nil)
+			return func() (any, error) {
+				targetCollection, _, err := load()
+				if err != nil {
+					return nil, fmt.Errorf("failed to fetch target collection '%s': %w", attr.Target, err)
+				}
+				return queryRelatedItems(p, *targetCollection, ids, preview, locale)
+			}, nil
 		}
 
-		var results []models.JSONMap
-		for _, rawID := range relatedIDs {
-
-			id := models.ToUint(rawID)
-			if id == 0 {
-				continue
-			}
-
-			relatedItem, err := storage.GetItemByID(targetCollection.ID, id)
+		load := loadersFromContext(p.Context).loadRelated(attr.Target, ids)
+		return func() (any, error) {
+			targetCollection, relatedItems, err := load()
 			if err != nil {
-				logger.Log.WithError(err).WithField("id", id).Warn("Failed to fetch related item, skipping.")
-				continue
+				return nil, fmt.Errorf("failed to fetch related items of '%s': %w", attr.Target, err)
 			}
-
-			relatedItem.Data["id"] = relatedItem.ID
-			results = append(results, relatedItem.Data)
-		}
-		return results, nil
+			attributes := viewerAttributes(p.Context, targetCollection.Attributes)
+			results := []map[string]any{}
+			for _, relatedItem := range relatedItems {
+				if !preview && !relatedItem.IsPublished() {
+					continue
+				}
+				results = append(results, mapItemToGraphQLResult(relatedItem, *targetCollection, attributes, preview, locale))
+			}
+			return results, nil
+		}, nil
 	}
 
 	// Handle One-to-One and Many-to-One Relations
@@ -61,14 +73,74 @@ func ResolveRelation(p graphql.ResolveParams, collectionID uint, attr models.Att
 			return nil, fmt.Errorf("invalid ID format for relation '%s'", attr.Name)
 		}
 
-		relatedItem, err := storage.GetItemByID(targetCollection.ID, id)
-		if err != nil {
-			return nil, nil // Return null if the related item isn't found
-		}
-
-		relatedItem.Data["id"] = relatedItem.ID
-		return relatedItem.Data, nil
+		load := loadersFromContext(p.Context).loadRelated(attr.Target, []uint{id})
+		return func() (any, error) {
+			targetCollection, relatedItems, err := load()
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch related item of '%s': %w", attr.Target, err)
+			}
+			if len(relatedItems) == 0 || (!preview && !relatedItems[0].IsPublished()) {
+				return nil, nil // Return null if the related item isn't found
+			}
+			attributes := viewerAttributes(p.Context, targetCollection.Attributes)
+			return mapItemToGraphQLResult(relatedItems[0], *targetCollection, attributes, preview, locale), nil
+		}, nil
 	}
 
 	return nil, fmt.Errorf("unsupported relation type '%s'", attr.Type)
 }
+
+// relationArgs returns the arguments of a to-many relation field, filtering, sorting and
+// paginating the related items of the target collection.
+func relationArgs(target models.Collection) graphql.FieldConfigArgument {
+	return withArgs(filterArgs(target), graphql.FieldConfigArgument{
+		"limit": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "The number of related items to return, all of them when omitted.",
+		},
+		"offset": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "The number of related items to skip for pagination.",
+		},
+	})
+}
+
+// queryRelatedItems reads the related items with the given IDs matching the arguments of
+// a relation field. They are sorted by 'orderBy', or by ID rather than in the order of
+// the relation.
+func queryRelatedItems(p graphql.ResolveParams, target models.Collection, ids []uint, preview bool, locale string) (any, error) {
+	query, err := itemQueryArgs(p, target, locale)
+	if err != nil {
+		return nil, err
+	}
+	query.IDs = ids
+	if !preview {
+		query.Status = models.ItemStatusPublished
+	}
+
+	limit, offset := -1, 0 // a negative limit reads every item
+	if value, ok := p.Args["limit"].(int); ok {
+		if value < 0 {
+			return nil, fmt.Errorf("invalid 'limit' argument, expected a positive number")
+		}
+		limit = value
+	}
+	if value, ok := p.Args["offset"].(int); ok {
+		if value < 0 {
+			return nil, fmt.Errorf("invalid 'offset' argument, expected a positive number")
+		}
+		offset = value
+	}
+
+	items, err := storage.QueryItemsOffset(target.ID, query, offset, limit)
+	if err != nil {
+		logger.Log.WithError(err).WithField("collection", target.Name).Warn("Failed to fetch related items")
+		return nil, fmt.Errorf("failed to fetch related items")
+	}
+	attributes := viewerAttributes(p.Context, target.Attributes)
+	results := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		results = append(results, mapItemToGraphQLResult(item, target, attributes, preview, locale))
+	}
+	return results, nil
+}
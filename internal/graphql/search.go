@@ -0,0 +1,107 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/graphql-go/graphql"
+)
+
+// searchResultType describes an item matching a full-text search.
+var searchResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SearchResult",
+	Fields: graphql.Fields{
+		"collection": &graphql.Field{Type: graphql.String},
+		"id":         &graphql.Field{Type: graphql.ID},
+		"score":      &graphql.Field{Type: graphql.Float},
+		"highlight":  &graphql.Field{Type: graphql.String, Description: "Excerpt of the matched text, matches wrapped in <mark> tags."},
+	},
+})
+
+// searchField returns the 'search' query, a full-text search over the searchable
+// attributes of the given collections.
+func searchField(collections []models.Collection) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewList(searchResultType),
+		Description: "Full-text search over the searchable attributes of items, best matches first.",
+		Args: graphql.FieldConfigArgument{
+			"q": &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "Words the results contain.",
+			},
+			"collections": &graphql.ArgumentConfig{
+				Type:        graphql.NewList(graphql.String),
+				Description: "Collections to search, every readable collection when omitted.",
+			},
+			"status": &graphql.ArgumentConfig{
+				Type:         graphql.String,
+				DefaultValue: models.ItemStatusPublished,
+				Description:  "Publication state to search: 'published' (default) or 'draft' to include drafts.",
+			},
+			"limit": &graphql.ArgumentConfig{
+				Type:         graphql.Int,
+				DefaultValue: storage.DefaultSearchLimit,
+				Description:  "The maximum number of results.",
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			status, _ := p.Args["status"].(string)
+			if status != models.ItemStatusPublished && status != models.ItemStatusDraft {
+				return nil, fmt.Errorf("invalid 'status' argument, expected 'published' or 'draft'")
+			}
+			limit, _ := p.Args["limit"].(int)
+			if limit < 1 || limit > storage.MaxSearchLimit {
+				return nil, fmt.Errorf("invalid 'limit' argument, expected 1 to %d", storage.MaxSearchLimit)
+			}
+			canSearch := func(name string) bool {
+				return viewerCan(p.Context, models.ResourceCollections, name, models.ActionRead) &&
+					(status == models.ItemStatusPublished || viewerCan(p.Context, models.ResourceCollections, name, models.ActionUpdate))
+			}
+
+			var names []string
+			if requested, ok := p.Args["collections"].([]any); ok && len(requested) > 0 {
+				for _, value := range requested {
+					name, _ := value.(string)
+					if !canSearch(name) {
+						return nil, fmt.Errorf("access denied: missing permission to search '%s'", name)
+					}
+					names = append(names, name)
+				}
+			} else {
+				for _, collection := range collections {
+					if collection.HasSearchableAttributes() && canSearch(collection.Name) {
+						names = append(names, collection.Name)
+					}
+				}
+				if len(names) == 0 {
+					return []map[string]any{}, nil
+				}
+			}
+
+			results, err := storage.SearchItems(storage.SearchQuery{
+				Text:          p.Args["q"].(string),
+				Collections:   names,
+				PublishedOnly: status == models.ItemStatusPublished,
+				Limit:         limit,
+			})
+			if err != nil {
+				logger.Log.WithError(err).Warn("Failed to search items")
+				return nil, fmt.Errorf("failed to search items")
+			}
+
+			mapped := make([]map[string]any, len(results))
+			for i, result := range results {
+				mapped[i] = map[string]any{
+					"collection": result.Collection,
+					"id":         strconv.FormatUint(uint64(result.ItemID), 10),
+					"score":      result.Score,
+					"highlight":  result.Highlight,
+				}
+			}
+			return mapped, nil
+		},
+	}
+}
@@ -0,0 +1,237 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/gohead-cms/gohead/internal/agent/events"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/graphql-go/graphql"
+)
+
+// subscriptionBufferSize is the number of events a subscription may lag behind its
+// client before the following ones are dropped.
+const subscriptionBufferSize = 32
+
+// The events notified by the subscriptions of a collection.
+var (
+	createdEvents = []events.EventType{events.EventTypeItemCreated, events.EventTypeItemRestored}
+	updatedEvents = []events.EventType{events.EventTypeItemUpdated, events.EventTypeItemPublished, events.EventTypeItemUnpublished}
+	deletedEvents = []events.EventType{events.EventTypeItemDeleted}
+)
+
+// itemEvent is an event delivered to the subscriptions listening to it.
+type itemEvent struct {
+	payload events.CollectionEventPayload
+	// item is the item as stored when the event was received, nil once deleted.
+	item *models.Item
+}
+
+// eventSubscriber is a subscription to events on the items of a collection.
+type eventSubscriber struct {
+	collection models.Collection
+	eventTypes []events.EventType
+	// accept tells whether an event is delivered to the subscription.
+	accept func(itemEvent) bool
+	events chan any
+}
+
+// eventBroker fans the events received by this server out to the subscriptions of its
+// clients.
+type eventBroker struct {
+	mu          sync.RWMutex
+	subscribers map[*eventSubscriber]struct{}
+}
+
+var broker = &eventBroker{subscribers: make(map[*eventSubscriber]struct{})}
+
+// PublishEvent delivers an event on an item to the subscriptions of this server
+// listening to it. Events reach every server through the broadcast of the dispatcher,
+// see events.ListenCollectionEvents.
+func PublishEvent(payload events.CollectionEventPayload) {
+	broker.publish(payload)
+}
+
+// subscribe registers a subscriber until ctx is done and returns the channel its events
+// are delivered on, in the form graphql.Subscribe expects.
+func (b *eventBroker) subscribe(ctx context.Context, subscriber *eventSubscriber) chan any {
+	subscriber.events = make(chan any, subscriptionBufferSize)
+
+	b.mu.Lock()
+	b.subscribers[subscriber] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subscribers, subscriber)
+		b.mu.Unlock()
+		close(subscriber.events)
+	}()
+	return subscriber.events
+}
+
+func (b *eventBroker) publish(payload events.CollectionEventPayload) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	// The item is read once for all the subscriptions notified of the event.
+	var item *models.Item
+	loaded := false
+	for subscriber := range b.subscribers {
+		if subscriber.collection.Name != payload.CollectionName || !slices.Contains(subscriber.eventTypes, payload.EventType) {
+			continue
+		}
+		event := itemEvent{payload: payload}
+		if payload.EventType != events.EventTypeItemDeleted {
+			if !loaded {
+				item, _ = storage.GetItemByID(subscriber.collection.ID, payload.ItemID)
+				loaded = true
+			}
+			if item == nil {
+				continue // deleted in the meantime
+			}
+			event.item = item
+		}
+		if !subscriber.accept(event) {
+			continue
+		}
+
+		select {
+		case subscriber.events <- event:
+		default:
+			logger.Log.
+				WithField("collection", payload.CollectionName).
+				WithField("item_id", payload.ItemID).
+				Warn("GraphQL subscription lagging behind, event dropped")
+		}
+	}
+}
+
+// generateSubscriptions creates the subscriptions of each collection, notifying of the
+// items created, updated and deleted. It returns nil when there are no collections.
+func generateSubscriptions(collections []models.Collection) (*graphql.Object, error) {
+	fields := graphql.Fields{}
+	for _, collection := range collections {
+		coll := collection
+
+		gqlType, err := ConvertCollectionToGraphQLType(coll)
+		if err != nil {
+			return nil, err
+		}
+
+		name := singularFieldName(coll.Name)
+		if _, taken := fields[name+"Created"]; taken {
+			logger.Log.WithField("collection", coll.Name).Warnf("The subscriptions of '%s' are taken by another collection and not available in GraphQL", coll.Name)
+			continue
+		}
+
+		fields[name+"Created"] = &graphql.Field{
+			Type:        gqlType,
+			Description: fmt.Sprintf("Notifies of the items created in '%s', or restored from its trash.", coll.Name),
+			Args: graphql.FieldConfigArgument{
+				"status": statusArgument,
+				"locale": readLocaleArgument,
+			},
+			Subscribe: func(p graphql.ResolveParams) (any, error) {
+				return subscribeItems(p, coll, createdEvents)
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return resolveItemEvent(p, coll)
+			},
+		}
+		fields[name+"Updated"] = &graphql.Field{
+			Type:        gqlType,
+			Description: fmt.Sprintf("Notifies of the items updated, published or unpublished in '%s'.", coll.Name),
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{
+					Type:        graphql.ID,
+					Description: "ID of the item to notify of, any item when omitted.",
+				},
+				"status": statusArgument,
+				"locale": readLocaleArgument,
+			},
+			Subscribe: func(p graphql.ResolveParams) (any, error) {
+				return subscribeItems(p, coll, updatedEvents)
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return resolveItemEvent(p, coll)
+			},
+		}
+		fields[name+"Deleted"] = &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.ID),
+			Description: fmt.Sprintf("Notifies of the IDs of the items deleted from '%s'.", coll.Name),
+			Subscribe: func(p graphql.ResolveParams) (any, error) {
+				return subscribeItems(p, coll, deletedEvents)
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				event, _ := p.Source.(itemEvent)
+				return strconv.FormatUint(uint64(event.payload.ItemID), 10), nil
+			},
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Subscription",
+		Fields: fields,
+	}), nil
+}
+
+// subscribeItems subscribes the viewer to events on the items of a collection. Like
+// queries, items are notified once published unless drafts are previewed.
+func subscribeItems(p graphql.ResolveParams, coll models.Collection, eventTypes []events.EventType) (any, error) {
+	status, err := readStatusArg(p, coll)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := localeArg(p); err != nil {
+		return nil, err
+	}
+
+	var id uint
+	if idArg, ok := p.Args["id"].(string); ok {
+		parsedID, err := strconv.ParseUint(idArg, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'id' argument")
+		}
+		id = uint(parsedID)
+	}
+
+	return broker.subscribe(p.Context, &eventSubscriber{
+		collection: coll,
+		eventTypes: eventTypes,
+		accept: func(event itemEvent) bool {
+			if id != 0 && event.payload.ItemID != id {
+				return false
+			}
+			return event.item == nil || status == models.ItemStatusDraft || event.item.IsPublished()
+		},
+	}), nil
+}
+
+// resolveItemEvent returns the item of an event delivered to a subscription, with the
+// values the viewer may read.
+func resolveItemEvent(p graphql.ResolveParams, coll models.Collection) (any, error) {
+	event, _ := p.Source.(itemEvent)
+	if event.item == nil {
+		return nil, nil
+	}
+	status, err := readStatusArg(p, coll)
+	if err != nil {
+		return nil, err
+	}
+	locale, err := localeArg(p)
+	if err != nil {
+		return nil, err
+	}
+	return mapItemToGraphQLResult(*event.item, coll, viewerAttributes(p.Context, coll.Attributes), status == models.ItemStatusDraft, locale), nil
+}
@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/agent/events"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCollectionSubscriptions subscribes to the events on the items of a collection.
+func TestCollectionSubscriptions(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Attribute{}, &models.Collection{}, &models.Item{}))
+
+	collection := models.Collection{Name: "articles", Attributes: []models.Attribute{
+		{Name: "title", Type: "text"},
+		{Name: "notes", Type: "text", ReadRoles: []string{"editor"}},
+	}}
+	require.NoError(t, db.Create(&collection).Error)
+	published := models.Item{CollectionID: collection.ID, Status: models.ItemStatusPublished, Data: models.JSONMap{"title": "Live", "notes": "internal"}}
+	draft := models.Item{CollectionID: collection.ID, Status: models.ItemStatusDraft, Data: models.JSONMap{"title": "Draft"}}
+	require.NoError(t, db.Create(&published).Error)
+	require.NoError(t, db.Create(&draft).Error)
+
+	require.NoError(t, InitializeGraphQLSchema())
+	reader := &models.UserRole{Name: "reader", Permissions: models.JSONMap{"collections.articles.read": true}}
+	subscribe := func(t *testing.T, query string) chan *graphql.Result {
+		subscribed := func(count int) func() bool {
+			return func() bool {
+				broker.mu.RLock()
+				defer broker.mu.RUnlock()
+				return len(broker.subscribers) == count
+			}
+		}
+		ctx, cancel := context.WithCancel(WithViewer(context.Background(), Viewer{Username: "reader", Role: reader}))
+		t.Cleanup(func() {
+			cancel()
+			require.Eventually(t, subscribed(0), time.Second, 5*time.Millisecond)
+		})
+		results := graphql.Subscribe(graphql.Params{Schema: GetSchema(), RequestString: query, Context: ctx})
+		// Wait for the subscription to be registered before publishing.
+		require.Eventually(t, subscribed(1), time.Second, 5*time.Millisecond)
+		return results
+	}
+	next := func(t *testing.T, results chan *graphql.Result) map[string]any {
+		select {
+		case result := <-results:
+			require.Empty(t, result.Errors)
+			return result.Data.(map[string]any)
+		case <-time.After(time.Second):
+			t.Fatal("no result received")
+			return nil
+		}
+	}
+	publish := func(eventType events.EventType, itemID uint) {
+		PublishEvent(events.CollectionEventPayload{EventType: eventType, CollectionName: "articles", ItemID: itemID})
+	}
+
+	t.Run("Updated items the viewer may read", func(t *testing.T) {
+		results := subscribe(t, `subscription { articleUpdated { id title notes } }`)
+		// Drafts are only notified when previewed.
+		publish(events.EventTypeItemUpdated, draft.ID)
+		publish(events.EventTypeItemUpdated, published.ID)
+		item := next(t, results)["articleUpdated"].(map[string]any)
+		assert.Equal(t, "Live", item["title"])
+		assert.Nil(t, item["notes"])
+	})
+
+	t.Run("Updates of a single item", func(t *testing.T) {
+		results := subscribe(t, `subscription { articleUpdated(id: "1", status: "published") { title } }`)
+		publish(events.EventTypeItemCreated, published.ID)
+		publish(events.EventTypeItemUpdated, published.ID+10)
+		publish(events.EventTypeItemPublished, published.ID)
+		assert.Equal(t, map[string]any{"title": "Live"}, next(t, results)["articleUpdated"])
+	})
+
+	t.Run("Deleted items", func(t *testing.T) {
+		results := subscribe(t, `subscription { articleDeleted }`)
+		publish(events.EventTypeItemDeleted, 42)
+		assert.Equal(t, "42", next(t, results)["articleDeleted"])
+	})
+
+	t.Run("Access denied", func(t *testing.T) {
+		results := graphql.Subscribe(graphql.Params{
+			Schema:        GetSchema(),
+			RequestString: `subscription { articleCreated(status: "draft") { title } }`,
+			Context:       WithViewer(context.Background(), Viewer{Username: "reader", Role: reader}),
+		})
+		result := <-results
+		require.NotEmpty(t, result.Errors)
+		assert.Contains(t, result.Errors[0].Message, "access denied")
+	})
+}
@@ -0,0 +1,46 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/gohead-cms/gohead/internal/models"
+)
+
+type viewerKey struct{}
+
+// Viewer describes who is executing a GraphQL operation.
+type Viewer struct {
+	Username string
+	Role     *models.UserRole
+}
+
+// WithViewer returns a copy of ctx carrying the viewer.
+func WithViewer(ctx context.Context, viewer Viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, viewer)
+}
+
+func viewerFromContext(ctx context.Context) Viewer {
+	var viewer Viewer
+	if ctx != nil {
+		viewer, _ = ctx.Value(viewerKey{}).(Viewer)
+	}
+	return viewer
+}
+
+// viewerCan reports whether the role of the viewer stored in ctx grants action on the
+// named resource. Operations executed without a viewer are not allowed anything.
+func viewerCan(ctx context.Context, resource, name, action string) bool {
+	role := viewerFromContext(ctx).Role
+	return role != nil && role.Can(models.Permission(resource, name, action))
+}
+
+// viewerActor returns the actor recorded in revisions for writes made by the viewer in ctx.
+func viewerActor(ctx context.Context) models.Actor {
+	viewer := viewerFromContext(ctx)
+	return models.UserActor(viewer.Username).WithRole(viewer.Role)
+}
+
+// viewerAttributes returns the attributes whose values the viewer in ctx may read.
+func viewerAttributes(ctx context.Context, attributes []models.Attribute) []models.Attribute {
+	return models.ReadableAttributes(attributes, viewerFromContext(ctx).Role)
+}
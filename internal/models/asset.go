@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// Asset is a file of the media library. Attributes of type "media" reference assets by ID.
+type Asset struct {
+	gorm.Model
+	ID         uint   `json:"id"`
+	Name       string `json:"name"` // original file name
+	MimeType   string `json:"mime_type" gorm:"type:varchar(255);index"`
+	Size       int64  `json:"size"`
+	Checksum   string `json:"checksum" gorm:"type:varchar(64);index"` // hex-encoded SHA-256
+	Width      *int   `json:"width,omitempty"`
+	Height     *int   `json:"height,omitempty"`
+	AltText    string `json:"alt_text"`
+	Driver     string `json:"driver" gorm:"type:varchar(20)"`
+	StorageKey string `json:"-"` // location of the file in the storage driver
+	URL        string `json:"url"`
+	// Variants holds the resized versions of an image, by preset name. They are
+	// generated in the background after the upload.
+	Variants map[string]AssetVariant `json:"variants,omitempty" gorm:"serializer:json"`
+}
+
+// AssetVariant is a resized version of an image asset.
+type AssetVariant struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// IsImage reports whether variants can be generated from the asset.
+func (a *Asset) IsImage() bool {
+	return a.Width != nil && a.Height != nil
+}
+
+// mediaIDs reads the asset IDs of a media value: a single ID, or a list of IDs for
+// attributes accepting multiple files.
+func mediaIDs(attribute Attribute, value any) ([]uint, error) {
+	if !attribute.Multiple {
+		id := ToUint(value)
+		if id == 0 {
+			return nil, fmt.Errorf("expected an asset ID")
+		}
+		return []uint{id}, nil
+	}
+
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a list of asset IDs")
+	}
+	ids := make([]uint, 0, len(list))
+	for _, element := range list {
+		id := ToUint(element)
+		if id == 0 {
+			return nil, fmt.Errorf("expected a list of asset IDs")
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// MediaIDs returns the asset IDs referenced by a stored media value, skipping invalid entries.
+// The value of a localized attribute may hold the IDs of every translation.
+func MediaIDs(attribute Attribute, value any) []uint {
+	if value == nil {
+		return nil
+	}
+	if translations, ok := value.(map[string]any); ok && attribute.Localized {
+		var ids []uint
+		for _, translation := range translations {
+			ids = append(ids, MediaIDs(attribute, translation)...)
+		}
+		return ids
+	}
+	if !attribute.Multiple {
+		if id := ToUint(value); id != 0 {
+			return []uint{id}
+		}
+		return nil
+	}
+	var ids []uint
+	switch list := value.(type) {
+	case []any:
+		for _, element := range list {
+			if id := ToUint(element); id != 0 {
+				ids = append(ids, id)
+			}
+		}
+	case []uint:
+		ids = append(ids, list...)
+	}
+	return ids
+}
+
+// validateMedia checks that a media value references existing assets and returns
+// the normalized value: an asset ID, or a list of asset IDs.
+func validateMedia(attribute Attribute, value any, tx *gorm.DB) (any, error) {
+	ids, err := mediaIDs(attribute, value)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := slices.Compact(slices.Sorted(slices.Values(ids)))
+	var count int64
+	if err := tx.Model(&Asset{}).Where("id IN ?", unique).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check assets: %w", err)
+	}
+	if int(count) != len(unique) {
+		return nil, fmt.Errorf("referenced asset does not exist")
+	}
+
+	if !attribute.Multiple {
+		return ids[0], nil
+	}
+	return ids, nil
+}
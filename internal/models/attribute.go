@@ -16,7 +16,7 @@ type BaseAttribute struct {
 	Type         string   `json:"type"`
 	Required     bool     `json:"required"`
 	Unique       bool     `json:"unique,omitempty"`
-	Options      []string `gorm:"type:json" json:"options,omitempty"`
+	Options      []string `gorm:"type:json;serializer:json" json:"options,omitempty"`
 	Min          *int     `json:"min,omitempty"`
 	Max          *int     `json:"max,omitempty"`
 	Pattern      string   `json:"pattern,omitempty"`
@@ -25,8 +25,10 @@ type BaseAttribute struct {
 	Target   string `json:"target,omitempty"`   // Target collection name for relationships
 	Relation string `json:"relation,omitempty"` // e.g., "oneToOne", "oneToMany"
 
-	// Component-specific field
-	ComponentRef string `json:"component,omitempty"` // Name of the referenced component if Type="component"
+	// Component-specific fields
+	ComponentRef string   `json:"component,omitempty"`                         // Name of the referenced component if Type="component"
+	Repeatable   bool     `json:"repeatable,omitempty"`                        // If Type="component", holds a list of instances
+	Components   []string `gorm:"serializer:json" json:"components,omitempty"` // Components allowed in a Type="dynamiczone"
 }
 
 // Attribute defines the structure for fields in a collection, single type, or component.
@@ -38,7 +40,7 @@ type Attribute struct {
 	Type         string   `json:"type"` // e.g., "text", "int", "bool", "date", "richtext", "enum", "relation", "component"
 	Required     bool     `json:"required"`
 	Unique       bool     `json:"unique,omitempty"`
-	Options      []string `gorm:"type:json" json:"options,omitempty"`
+	Options      []string `gorm:"type:json;serializer:json" json:"options,omitempty"`
 	Min          *int     `json:"min,omitempty"`
 	Max          *int     `json:"max,omitempty"`
 	Pattern      string   `json:"pattern,omitempty"`
@@ -48,6 +50,28 @@ type Attribute struct {
 	Target       string `json:"target,omitempty"`    // Target collection for relationships, or component name
 	Relation     string `json:"relation,omitempty"`  // e.g., "oneToOne", "oneToMany", "manyToMany", for relationships
 	ComponentRef string `json:"component,omitempty"` // If Type="component", which component is referenced
+	OnDelete     string `json:"on_delete,omitempty"` // For relationships, what happens to this item when the related one is deleted
+
+	// Repeatable lets a "component" attribute hold a list of component instances, and
+	// Components lists the components the entries of a "dynamiczone" attribute may use.
+	Repeatable bool     `json:"repeatable,omitempty"`
+	Components []string `gorm:"serializer:json" json:"components,omitempty"`
+
+	// Multiple lets a "media" attribute hold a list of asset IDs instead of a single one.
+	Multiple bool `json:"multiple,omitempty"`
+
+	// Localized attributes hold one value per locale of their collection.
+	Localized bool `json:"localized,omitempty"`
+
+	// Searchable attributes feed the full-text search index of their collection, and
+	// semantic ones the embeddings of its items used by semantic search.
+	Searchable bool `json:"searchable,omitempty"`
+	Semantic   bool `json:"semantic,omitempty"`
+
+	// Field-level access: names of the roles allowed to read or write the attribute.
+	// Empty lists leave access to the collection permissions.
+	ReadRoles  []string `gorm:"serializer:json" json:"read_roles,omitempty"`
+	WriteRoles []string `gorm:"serializer:json" json:"write_roles,omitempty"`
 
 	// Foreign keys (an attribute can belong to either a Collection, a SingleType, or a Component)
 	CollectionID *uint       `json:"collection_id,omitempty"`
@@ -67,14 +91,47 @@ func mapToBaseAttribute(data map[string]interface{}, attr *BaseAttribute) error
 	} else {
 		return fmt.Errorf("attribute is missing 'type'")
 	}
-	// ... map other fields like required, unique, etc.
 	if r, ok := data["required"].(bool); ok {
 		attr.Required = r
 	}
+	if u, ok := data["unique"].(bool); ok {
+		attr.Unique = u
+	}
+	if options, ok := data["options"].([]any); ok {
+		for _, option := range options {
+			if o, ok := option.(string); ok {
+				attr.Options = append(attr.Options, o)
+			}
+		}
+	}
+	if min, ok := data["min"].(float64); ok {
+		minInt := int(min)
+		attr.Min = &minInt
+	}
+	if max, ok := data["max"].(float64); ok {
+		maxInt := int(max)
+		attr.Max = &maxInt
+	}
+	if p, ok := data["pattern"].(string); ok {
+		attr.Pattern = p
+	}
+	if r, ok := data["relation"].(string); ok {
+		attr.Relation = r
+	}
+	if t, ok := data["target"].(string); ok {
+		attr.Target = t
+	}
 	if c, ok := data["component"].(string); ok {
 		attr.ComponentRef = c
 	}
-	// Add other fields as needed
+	if r, ok := data["repeatable"].(bool); ok {
+		attr.Repeatable = r
+	}
+	components, err := parseComponentList(data)
+	if err != nil {
+		return err
+	}
+	attr.Components = components
 	return nil
 }
 
@@ -105,3 +162,14 @@ func validateBaseAttributeType(attribute BaseAttribute) error {
 
 	return nil
 }
+
+// IsScalar reports whether the values of the attribute are plain text, numbers, booleans
+// or dates, as opposed to JSON, relations, media, components and dynamic zones.
+func (a Attribute) IsScalar() bool {
+	switch a.Type {
+	case "json", "relation", "media", "component", "dynamiczone":
+		return false
+	default:
+		return true
+	}
+}
@@ -23,6 +23,11 @@ type Collection struct {
 	Kind        string      `json:"kind" gorm:"type:varchar(50);not null"`
 	Description string      `json:"description"`
 	Attributes  []Attribute `json:"attributes" gorm:"constraint:OnDelete:CASCADE;"`
+
+	// Locales lists the languages the collection's localized attributes are translated
+	// into; DefaultLocale is the one read when a translation is missing.
+	Locales       []string `json:"locales,omitempty" gorm:"serializer:json"`
+	DefaultLocale string   `json:"default_locale,omitempty" gorm:"type:varchar(35)"`
 }
 
 func ParseCollectionInput(input map[string]any) (Collection, error) {
@@ -46,6 +51,25 @@ func ParseCollectionInput(input map[string]any) (Collection, error) {
 		collection.Description = description
 	}
 
+	if rawLocales, exists := input["locales"]; exists && rawLocales != nil {
+		list, ok := rawLocales.([]any)
+		if !ok {
+			return collection, fmt.Errorf("invalid field 'locales', expected a list of locales")
+		}
+		for _, value := range list {
+			locale, ok := value.(string)
+			if !ok {
+				return collection, fmt.Errorf("invalid field 'locales', expected a list of locales")
+			}
+			collection.Locales = append(collection.Locales, locale)
+		}
+	}
+	if defaultLocale, ok := input["default_locale"].(string); ok {
+		collection.DefaultLocale = defaultLocale
+	} else if len(collection.Locales) > 0 {
+		collection.DefaultLocale = collection.Locales[0]
+	}
+
 	// Extract and transform attributes
 	if rawAttributes, ok := input["attributes"].(map[string]any); ok {
 		for attrName, rawAttr := range rawAttributes {
@@ -105,6 +129,34 @@ func mapToAttribute(attrMap map[string]any, attribute *Attribute) error {
 		attribute.Pattern = pattern
 	}
 
+	if multiple, ok := attrMap["multiple"].(bool); ok {
+		attribute.Multiple = multiple
+	}
+
+	if localized, ok := attrMap["localized"].(bool); ok {
+		attribute.Localized = localized
+	}
+
+	if searchable, ok := attrMap["searchable"].(bool); ok {
+		attribute.Searchable = searchable
+	}
+
+	if semantic, ok := attrMap["semantic"].(bool); ok {
+		attribute.Semantic = semantic
+	}
+
+	readRoles, err := parseRoleList(attrMap, "read_roles")
+	if err != nil {
+		return err
+	}
+	attribute.ReadRoles = readRoles
+
+	writeRoles, err := parseRoleList(attrMap, "write_roles")
+	if err != nil {
+		return err
+	}
+	attribute.WriteRoles = writeRoles
+
 	// Handle relation-specific fields
 	if attribute.Type == "relation" {
 		if relation, ok := attrMap["relation"].(string); ok {
@@ -118,11 +170,49 @@ func mapToAttribute(attrMap map[string]any, attribute *Attribute) error {
 		} else {
 			return fmt.Errorf("missing or invalid field 'target' for type 'relation'")
 		}
+
+		if onDelete, ok := attrMap["on_delete"].(string); ok {
+			attribute.OnDelete = onDelete
+		}
+	}
+
+	// Handle component-specific fields
+	if component, ok := attrMap["component"].(string); ok {
+		attribute.ComponentRef = component
 	}
+	if repeatable, ok := attrMap["repeatable"].(bool); ok {
+		attribute.Repeatable = repeatable
+	}
+	components, err := parseComponentList(attrMap)
+	if err != nil {
+		return err
+	}
+	attribute.Components = components
 
 	return nil
 }
 
+// parseRoleList reads an optional list of role names from an attribute definition.
+func parseRoleList(attrMap map[string]any, key string) ([]string, error) {
+	raw, exists := attrMap[key]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid field '%s', expected a list of role names", key)
+	}
+	roles := make([]string, 0, len(list))
+	for _, value := range list {
+		role, ok := value.(string)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid field '%s', expected a list of role names", key)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
 // -------------------- Schema validator --------------------
 
 // ValidateCollectionSchema is the single source of truth for validating a collection's structure.
@@ -142,6 +232,10 @@ func ValidateCollectionSchema(ct Collection) error {
 		}
 		attributeNames[attribute.Name] = true
 
+		if err := validateOnDelete(attribute); err != nil {
+			return err
+		}
+
 		// --- Handle the "relation" type as a special case FIRST ---
 		if attribute.Type == "relation" {
 			if attribute.Relation == "" || attribute.Target == "" {
@@ -158,12 +252,27 @@ func ValidateCollectionSchema(ct Collection) error {
 			continue // Relation is valid, move to the next attribute.
 		}
 
+		// --- Components and dynamic zones must reference existing components ---
+		if attribute.Type == "component" || attribute.Type == "dynamiczone" {
+			if err := validateComponentRefs(attribute, database.DB); err != nil {
+				return err
+			}
+			continue
+		}
+
 		// --- For all other types, use the centralized TypeRegistry ---
 		if _, err := types.GetGraphQLType(attribute.Type); err != nil {
 			return fmt.Errorf("invalid type '%s' for attribute '%s': %w", attribute.Type, attribute.Name, err)
 		}
 	}
 
+	if err := validateLocales(ct); err != nil {
+		return err
+	}
+	if err := validateSearchable(ct); err != nil {
+		return err
+	}
+
 	logger.Log.WithField("collection", ct.Name).Info("Collection schema validated successfully")
 	return nil
 }
@@ -270,7 +379,7 @@ func validateAttributeValue(attribute Attribute, value any) error {
 			return err
 		}
 	case "media":
-		if _, err := convertToType(value, "text"); err != nil {
+		if _, err := mediaIDs(attribute, value); err != nil {
 			return err
 		}
 	case "json":
@@ -132,7 +132,7 @@ func TestValidateItemData(t *testing.T) {
 			"published_date": "2024-12-10",
 			"rating":         rating,
 		}
-		_, err := ValidateItemValues(collection, data, db)
+		_, err := ValidateItemValues(collection, data, db, WriteAccess{})
 		assert.NoError(t, err)
 	})
 
@@ -140,7 +140,7 @@ func TestValidateItemData(t *testing.T) {
 		data := map[string]interface{}{
 			"published_date": "2024-12-10",
 		}
-		_, err := ValidateItemValues(collection, data, db)
+		_, err := ValidateItemValues(collection, data, db, WriteAccess{})
 		assert.Error(t, err)
 		assert.Error(t, err)
 		assert.Equal(t, "missing required attribute: 'title'", err.Error())
@@ -151,7 +151,7 @@ func TestValidateItemData(t *testing.T) {
 			"title":          "An Article",
 			"published_date": "12-10-2024",
 		}
-		_, err := ValidateItemValues(collection, data, db)
+		_, err := ValidateItemValues(collection, data, db, WriteAccess{})
 		assert.Error(t, err)
 		assert.Error(t, err)
 		assert.Equal(t, "validation failed for attribute 'published_date': invalid date format for value: 12-10-2024", err.Error())
@@ -162,7 +162,7 @@ func TestValidateItemData(t *testing.T) {
 			"title":  "An Article",
 			"rating": 30,
 		}
-		_, err := ValidateItemValues(collection, data, db)
+		_, err := ValidateItemValues(collection, data, db, WriteAccess{})
 		assert.Error(t, err)
 		assert.Error(t, err)
 		assert.Equal(t, "validation failed for attribute 'rating': attribute 'rating' must be at most 20", err.Error())
@@ -88,6 +88,12 @@ func ValidateComponentSchema(cmp Component) error {
 			if attr.ComponentRef == "" {
 				return fmt.Errorf("attribute '%s' in component '%s' has type 'component' but is missing the 'component' reference name", attr.Name, cmp.Name)
 			}
+			if attr.ComponentRef == cmp.Name {
+				return fmt.Errorf("attribute '%s' in component '%s' cannot reference its own component", attr.Name, cmp.Name)
+			}
+		}
+		if attr.Type == "dynamiczone" && len(attr.Components) == 0 {
+			return fmt.Errorf("attribute '%s' in component '%s' has type 'dynamiczone' but lists no 'components'", attr.Name, cmp.Name)
 		}
 	}
 
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// ComponentDiscriminator is the key naming the component each entry of a dynamic zone
+// is an instance of.
+const ComponentDiscriminator = "__component"
+
+// AsAttribute returns the component attribute as an Attribute, so values of components
+// are validated and rendered like the values of collection attributes.
+func (a ComponentAttribute) AsAttribute() Attribute {
+	return Attribute{
+		Name:         a.Name,
+		Type:         a.Type,
+		Required:     a.Required,
+		Unique:       a.Unique,
+		Options:      a.Options,
+		Min:          a.Min,
+		Max:          a.Max,
+		Pattern:      a.Pattern,
+		CustomErrors: a.CustomErrors,
+		Target:       a.Target,
+		Relation:     a.Relation,
+		ComponentRef: a.ComponentRef,
+		Repeatable:   a.Repeatable,
+		Components:   a.Components,
+	}
+}
+
+// parseComponentList reads the optional list of component names allowed in a dynamic zone.
+func parseComponentList(attrMap map[string]any) ([]string, error) {
+	raw, exists := attrMap["components"]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid field 'components', expected a list of component names")
+	}
+	components := make([]string, 0, len(list))
+	for _, value := range list {
+		name, ok := value.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid field 'components', expected a list of component names")
+		}
+		components = append(components, name)
+	}
+	return components, nil
+}
+
+// validateComponentRefs checks that the components referenced by a "component" or
+// "dynamiczone" attribute exist.
+func validateComponentRefs(attribute Attribute, db *gorm.DB) error {
+	var names []string
+	switch attribute.Type {
+	case "component":
+		if attribute.ComponentRef == "" {
+			return fmt.Errorf("component attribute '%s' must define 'component'", attribute.Name)
+		}
+		names = []string{attribute.ComponentRef}
+	case "dynamiczone":
+		if len(attribute.Components) == 0 {
+			return fmt.Errorf("dynamic zone '%s' must define 'components'", attribute.Name)
+		}
+		names = attribute.Components
+	}
+
+	for _, name := range names {
+		if _, err := loadComponent(db, name); err != nil {
+			return fmt.Errorf("invalid attribute '%s': %w", attribute.Name, err)
+		}
+	}
+	return nil
+}
+
+// loadComponent fetches a component definition with its attributes.
+func loadComponent(tx *gorm.DB, name string) (Component, error) {
+	var component Component
+	if err := tx.Preload("Attributes").Where("name = ?", name).First(&component).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return component, fmt.Errorf("component '%s' does not exist", name)
+		}
+		return component, fmt.Errorf("db error loading component '%s': %w", name, err)
+	}
+	return component, nil
+}
+
+// validateComponent validates the value of a "component" attribute: one instance of the
+// component, or a list of instances when the attribute is repeatable.
+func validateComponent(attribute Attribute, value any, tx *gorm.DB, role *UserRole) (any, error) {
+	component, err := loadComponent(tx, attribute.ComponentRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if !attribute.Repeatable {
+		instance, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an object for component '%s'", component.Name)
+		}
+		return validateComponentInstance(component, instance, tx, role)
+	}
+
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array for repeatable component '%s'", component.Name)
+	}
+	instances := make([]any, 0, len(list))
+	for i, element := range list {
+		instance, ok := element.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("entry %d: expected an object for component '%s'", i, component.Name)
+		}
+		processed, err := validateComponentInstance(component, instance, tx, role)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		instances = append(instances, processed)
+	}
+	return instances, nil
+}
+
+// validateDynamicZone validates the value of a "dynamiczone" attribute: an ordered list of
+// instances of the allowed components, each naming its component under ComponentDiscriminator.
+func validateDynamicZone(attribute Attribute, value any, tx *gorm.DB, role *UserRole) (any, error) {
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array for dynamic zone '%s'", attribute.Name)
+	}
+
+	components := make(map[string]Component)
+	entries := make([]any, 0, len(list))
+	for i, element := range list {
+		entry, ok := element.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("entry %d: expected an object", i)
+		}
+		name, _ := entry[ComponentDiscriminator].(string)
+		if name == "" {
+			return nil, fmt.Errorf("entry %d: missing '%s'", i, ComponentDiscriminator)
+		}
+		if !slices.Contains(attribute.Components, name) {
+			return nil, fmt.Errorf("entry %d: component '%s' is not allowed, expected one of %v", i, name, attribute.Components)
+		}
+
+		component, loaded := components[name]
+		if !loaded {
+			var err error
+			if component, err = loadComponent(tx, name); err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i, err)
+			}
+			components[name] = component
+		}
+
+		instance := make(map[string]any, len(entry))
+		for key, val := range entry {
+			if key != ComponentDiscriminator {
+				instance[key] = val
+			}
+		}
+		processed, err := validateComponentInstance(component, instance, tx, role)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		processed[ComponentDiscriminator] = name
+		entries = append(entries, processed)
+	}
+	return entries, nil
+}
+
+// validateComponentInstance validates the values of one instance of a component against
+// its attributes, recursing into nested components and dynamic zones.
+func validateComponentInstance(component Component, instance map[string]any, tx *gorm.DB, role *UserRole) (map[string]any, error) {
+	known := make(map[string]bool, len(component.Attributes))
+	for _, attr := range component.Attributes {
+		known[attr.Name] = true
+	}
+	for key := range instance {
+		if !known[key] {
+			return nil, fmt.Errorf("unknown attribute '%s' in component '%s'", key, component.Name)
+		}
+	}
+
+	processed := make(map[string]any, len(instance))
+	for _, componentAttr := range component.Attributes {
+		attribute := componentAttr.AsAttribute()
+		value, exists := instance[attribute.Name]
+		if attribute.Required && (!exists || value == nil) {
+			return nil, fmt.Errorf("missing required attribute '%s' in component '%s'", attribute.Name, component.Name)
+		}
+		if !exists {
+			continue
+		}
+		if value == nil {
+			processed[attribute.Name] = nil
+			continue
+		}
+
+		processedValue, err := validateValue(attribute, value, tx, role)
+		if err != nil {
+			return nil, fmt.Errorf("validation failed for attribute '%s' in component '%s': %w", attribute.Name, component.Name, err)
+		}
+		processed[attribute.Name] = processedValue
+	}
+	return processed, nil
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateItemValuesComponents(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	assert.NoError(t, db.AutoMigrate(&Component{}, &ComponentAttribute{}))
+
+	components := []Component{
+		{Name: "seo", Attributes: []ComponentAttribute{
+			{BaseAttribute: BaseAttribute{Name: "title", Type: "text", Required: true}},
+			{BaseAttribute: BaseAttribute{Name: "keywords", Type: "text"}},
+		}},
+		{Name: "quote", Attributes: []ComponentAttribute{
+			{BaseAttribute: BaseAttribute{Name: "body", Type: "text", Required: true}},
+			{BaseAttribute: BaseAttribute{Name: "seo", Type: "component", ComponentRef: "seo"}},
+		}},
+		{Name: "gallery", Attributes: []ComponentAttribute{
+			{BaseAttribute: BaseAttribute{Name: "columns", Type: "int"}},
+		}},
+	}
+	for i := range components {
+		assert.NoError(t, db.Create(&components[i]).Error)
+	}
+
+	ct := Collection{
+		Name: "pages",
+		Attributes: []Attribute{
+			{Name: "seo", Type: "component", ComponentRef: "seo"},
+			{Name: "links", Type: "component", ComponentRef: "seo", Repeatable: true},
+			{Name: "blocks", Type: "dynamiczone", Components: []string{"quote", "gallery"}},
+		},
+	}
+	assert.NoError(t, ValidateCollectionSchema(ct))
+
+	t.Run("Valid values", func(t *testing.T) {
+		data, err := ValidateItemValues(ct, map[string]any{
+			"seo":   map[string]any{"title": "Home"},
+			"links": []any{map[string]any{"title": "A"}, map[string]any{"title": "B", "keywords": "b"}},
+			"blocks": []any{
+				map[string]any{"__component": "quote", "body": "Hi", "seo": map[string]any{"title": "Quote"}},
+				map[string]any{"__component": "gallery", "columns": 3},
+			},
+		}, db, WriteAccess{})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"title": "Home"}, data["seo"])
+		assert.Len(t, data["links"], 2)
+		blocks := data["blocks"].([]any)
+		assert.Equal(t, "quote", blocks[0].(map[string]any)[ComponentDiscriminator])
+		assert.Equal(t, "gallery", blocks[1].(map[string]any)[ComponentDiscriminator])
+	})
+
+	tests := []struct {
+		name string
+		data map[string]any
+		err  string
+	}{
+		{"Missing required field", map[string]any{"seo": map[string]any{"keywords": "x"}}, "missing required attribute 'title' in component 'seo'"},
+		{"Unknown field", map[string]any{"seo": map[string]any{"title": "x", "extra": 1}}, "unknown attribute 'extra'"},
+		{"Not an object", map[string]any{"seo": "x"}, "expected an object"},
+		{"Repeatable not a list", map[string]any{"links": map[string]any{"title": "x"}}, "expected an array"},
+		{"Missing discriminator", map[string]any{"blocks": []any{map[string]any{"body": "x"}}}, "missing '__component'"},
+		{"Component not allowed", map[string]any{"blocks": []any{map[string]any{"__component": "seo", "title": "x"}}}, "is not allowed"},
+		{"Nested component", map[string]any{"blocks": []any{map[string]any{"__component": "quote", "body": "x", "seo": map[string]any{}}}}, "missing required attribute 'title'"},
+		{"Nested type", map[string]any{"blocks": []any{map[string]any{"__component": "gallery", "columns": "many"}}}, "attribute 'columns' in component 'gallery'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateItemValues(ct, tt.data, db, WriteAccess{})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	t.Run("Unknown component in schema", func(t *testing.T) {
+		invalid := Collection{Name: "posts", Attributes: []Attribute{{Name: "hero", Type: "component", ComponentRef: "hero"}}}
+		assert.ErrorContains(t, ValidateCollectionSchema(invalid), "component 'hero' does not exist")
+
+		invalid.Attributes = []Attribute{{Name: "blocks", Type: "dynamiczone"}}
+		assert.ErrorContains(t, ValidateCollectionSchema(invalid), "must define 'components'")
+	})
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Vector is an embedding. It is stored in the text format of pgvector, which is also a
+// JSON array, so the same rows are read from a pgvector column or a text one.
+type Vector []float32
+
+// Value implements the driver.Valuer interface.
+func (v Vector) Value() (driver.Value, error) {
+	b, err := json.Marshal([]float32(v))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode vector: %w", err)
+	}
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (v *Vector) Scan(value any) error {
+	var b []byte
+	switch value := value.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		b = value
+	case string:
+		b = []byte(value)
+	default:
+		return fmt.Errorf("unsupported vector type %T", value)
+	}
+	return json.Unmarshal(b, (*[]float32)(v))
+}
+
+// ItemEmbedding holds the embedding of the semantic attributes of an item. ContentHash
+// identifies the text it was computed from, so unchanged items are not embedded again.
+type ItemEmbedding struct {
+	ItemID       uint      `json:"item_id" gorm:"primaryKey;autoIncrement:false"`
+	CollectionID uint      `json:"collection_id" gorm:"not null;index"`
+	Model        string    `json:"model" gorm:"type:varchar(255);not null"`
+	ContentHash  string    `json:"content_hash" gorm:"type:varchar(64);not null"`
+	Vector       Vector    `json:"vector" gorm:"type:text;not null"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// FieldAccessError is returned when a write changes an attribute the writer's role may not write.
+type FieldAccessError struct {
+	Attribute string
+	Role      string
+}
+
+func (e *FieldAccessError) Error() string {
+	if e.Role == "" {
+		return fmt.Sprintf("access denied: attribute '%s' is write-protected", e.Attribute)
+	}
+	return fmt.Sprintf("access denied: role '%s' may not write attribute '%s'", e.Role, e.Attribute)
+}
+
+// WriteAccess describes who writes an item, so attribute write rules can be enforced.
+type WriteAccess struct {
+	// Role of the writer; nil for writes made without a role, such as agent runs.
+	Role *UserRole
+	// Current is the stored data of the item being updated, nil when creating one.
+	Current JSONMap
+}
+
+// ReadableBy reports whether role may see the attribute's values.
+// Attributes without read roles are readable by anyone allowed to read the collection.
+func (a Attribute) ReadableBy(role *UserRole) bool {
+	return roleListed(a.ReadRoles, role)
+}
+
+// WritableBy reports whether role may set the attribute's values.
+// Attributes without write roles are writable by anyone allowed to write the collection.
+func (a Attribute) WritableBy(role *UserRole) bool {
+	return roleListed(a.WriteRoles, role)
+}
+
+// roleListed reports whether role is allowed by a list of role names.
+// An empty list allows every role, and roles granted "*" bypass the list.
+func roleListed(roles []string, role *UserRole) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	if role == nil {
+		return false
+	}
+	return slices.Contains(roles, role.Name) || role.Can(PermissionWildcard)
+}
+
+// ReadableAttributes returns the attributes whose values role may see.
+func ReadableAttributes(attributes []Attribute, role *UserRole) []Attribute {
+	readable := make([]Attribute, 0, len(attributes))
+	for _, attr := range attributes {
+		if attr.ReadableBy(role) {
+			readable = append(readable, attr)
+		}
+	}
+	return readable
+}
+
+// ReadableBy returns a copy of the collection limited to the attributes role may see.
+func (c Collection) ReadableBy(role *UserRole) Collection {
+	c.Attributes = ReadableAttributes(c.Attributes, role)
+	return c
+}
+
+// StripUnreadableFields returns a copy of data without the values of attributes role may not see.
+// Keys that are not attributes, such as "id", are kept.
+func StripUnreadableFields(attributes []Attribute, data map[string]any, role *UserRole) JSONMap {
+	stripped := make(JSONMap, len(data))
+	for key, value := range data {
+		stripped[key] = value
+	}
+	for _, attr := range attributes {
+		if !attr.ReadableBy(role) {
+			delete(stripped, attr.Name)
+		}
+	}
+	return stripped
+}
+
+// CheckFieldWrites rejects data setting an attribute the writer may not write.
+// On update a protected attribute may be sent back with its stored value.
+func CheckFieldWrites(attributes []Attribute, data map[string]any, access WriteAccess) error {
+	for _, attr := range attributes {
+		value, exists := data[attr.Name]
+		if !exists || attr.WritableBy(access.Role) {
+			continue
+		}
+		if current, stored := access.Current[attr.Name]; stored && sameJSONValue(current, value) {
+			continue
+		}
+		roleName := ""
+		if access.Role != nil {
+			roleName = access.Role.Name
+		}
+		return &FieldAccessError{Attribute: attr.Name, Role: roleName}
+	}
+	return nil
+}
+
+// KeepProtectedFields copies into data the stored values of the attributes the writer
+// may not write, so replacing an item's data never drops them.
+func KeepProtectedFields(attributes []Attribute, data JSONMap, access WriteAccess) {
+	for _, attr := range attributes {
+		if attr.WritableBy(access.Role) {
+			continue
+		}
+		if current, stored := access.Current[attr.Name]; stored {
+			data[attr.Name] = current
+		}
+	}
+}
+
+// sameJSONValue compares two values by their JSON encoding, so numbers decoded from a
+// request and from the database compare equal.
+func sameJSONValue(a, b any) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributeAccess(t *testing.T) {
+	admin := &UserRole{Name: "admin", Permissions: JSONMap{"*": true}}
+	editor := &UserRole{Name: "editor", Permissions: JSONMap{"collections.*.*": true}}
+	viewer := &UserRole{Name: "viewer", Permissions: JSONMap{"collections.*.read": true}}
+
+	open := Attribute{Name: "title"}
+	notes := Attribute{Name: "notes", ReadRoles: []string{"editor"}, WriteRoles: []string{"editor"}}
+	cost := Attribute{Name: "cost", ReadRoles: []string{"editor"}, WriteRoles: []string{"accounting"}}
+
+	assert.True(t, open.ReadableBy(viewer))
+	assert.True(t, open.ReadableBy(nil))
+	assert.True(t, notes.ReadableBy(editor))
+	assert.False(t, notes.ReadableBy(viewer))
+	assert.False(t, notes.ReadableBy(nil))
+	assert.True(t, notes.ReadableBy(admin), "roles granted '*' bypass attribute rules")
+	assert.True(t, cost.ReadableBy(editor))
+	assert.False(t, cost.WritableBy(editor))
+
+	data := map[string]any{"id": 1, "title": "Widget", "notes": "internal", "cost": 12.5}
+	attributes := []Attribute{open, notes, cost}
+
+	assert.Equal(t, JSONMap{"id": 1, "title": "Widget"}, StripUnreadableFields(attributes, data, viewer))
+	assert.Equal(t, JSONMap{"id": 1, "title": "Widget", "notes": "internal", "cost": 12.5}, StripUnreadableFields(attributes, data, editor))
+	assert.Len(t, data, 4, "the original data is left untouched")
+
+	collection := Collection{Name: "products", Attributes: attributes}
+	assert.Len(t, collection.ReadableBy(viewer).Attributes, 1)
+	assert.Len(t, collection.Attributes, 3)
+}
+
+func TestValidateItemValuesFieldAccess(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	editor := &UserRole{Name: "editor", Permissions: JSONMap{"collections.*.*": true}}
+	accounting := &UserRole{Name: "accounting", Permissions: JSONMap{"collections.products.*": true}}
+
+	collection := Collection{
+		Name: "products",
+		Attributes: []Attribute{
+			{Name: "name", Type: "text", Required: true},
+			{Name: "cost", Type: "int", WriteRoles: []string{"accounting"}},
+		},
+	}
+
+	t.Run("Protected attribute on create", func(t *testing.T) {
+		_, err := ValidateItemValues(collection, map[string]any{"name": "Widget", "cost": 35}, db, WriteAccess{Role: editor})
+		var accessErr *FieldAccessError
+		if assert.True(t, errors.As(err, &accessErr)) {
+			assert.Equal(t, "access denied: role 'editor' may not write attribute 'cost'", err.Error())
+		}
+
+		_, err = ValidateItemValues(collection, map[string]any{"name": "Widget", "cost": 35}, db, WriteAccess{})
+		assert.EqualError(t, err, "access denied: attribute 'cost' is write-protected")
+
+		data, err := ValidateItemValues(collection, map[string]any{"name": "Widget", "cost": 35}, db, WriteAccess{Role: accounting})
+		assert.NoError(t, err)
+		assert.Equal(t, 35, data["cost"])
+	})
+
+	t.Run("Protected attribute on update", func(t *testing.T) {
+		current := JSONMap{"name": "Widget", "cost": 35}
+
+		// Omitted protected attributes keep their stored value.
+		data, err := ValidateItemValues(collection, map[string]any{"name": "Gadget"}, db, WriteAccess{Role: editor, Current: current})
+		assert.NoError(t, err)
+		assert.Equal(t, JSONMap{"name": "Gadget", "cost": 35}, data)
+
+		// Sending back the stored value is allowed, changing it is not.
+		_, err = ValidateItemValues(collection, map[string]any{"name": "Gadget", "cost": 35}, db, WriteAccess{Role: editor, Current: current})
+		assert.NoError(t, err)
+		_, err = ValidateItemValues(collection, map[string]any{"name": "Gadget", "cost": 1}, db, WriteAccess{Role: editor, Current: current})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseAttributeRoles(t *testing.T) {
+	collection, err := ParseCollectionInput(map[string]any{
+		"name": "products",
+		"kind": "collection",
+		"attributes": map[string]any{
+			"cost": map[string]any{"type": "float", "read_roles": []any{"editor"}, "write_roles": []any{"accounting"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"editor"}, collection.Attributes[0].ReadRoles)
+	assert.Equal(t, []string{"accounting"}, collection.Attributes[0].WriteRoles)
+
+	_, err = ParseCollectionInput(map[string]any{
+		"name":       "products",
+		"kind":       "collection",
+		"attributes": map[string]any{"cost": map[string]any{"type": "float", "read_roles": "editor"}},
+	})
+	assert.EqualError(t, err, "failed to parse attribute 'cost': invalid field 'read_roles', expected a list of role names")
+}
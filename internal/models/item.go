@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"maps"
 	"strconv"
+	"time"
 
 	"github.com/gohead-cms/gohead/pkg/logger"
 	"github.com/gohead-cms/gohead/pkg/validation"
@@ -11,16 +12,34 @@ import (
 	"gorm.io/gorm"
 )
 
+// Publication states of an item. New items start as drafts and are only
+// visible to readers once published.
+const (
+	ItemStatusDraft     = "draft"
+	ItemStatusPublished = "published"
+)
+
 type Item struct {
 	gorm.Model
-	ID           uint    `json:"id"`
-	CollectionID uint    `json:"collection"`
-	Data         JSONMap `json:"data" gorm:"type:json"`
+	ID           uint       `json:"id"`
+	CollectionID uint       `json:"collection"`
+	Status       string     `json:"status" gorm:"type:varchar(20);index"`
+	PublishedAt  *time.Time `json:"published_at"`
+	PublishAt    *time.Time `json:"publish_at,omitempty" gorm:"index"`
+	UnpublishAt  *time.Time `json:"unpublish_at,omitempty" gorm:"index"`
+	Version      int        `json:"version" gorm:"not null;default:1"`
+	Data         JSONMap    `json:"data" gorm:"type:json"`
+}
+
+// IsPublished reports whether the item is visible to readers.
+func (i *Item) IsPublished() bool {
+	return i.Status == ItemStatusPublished
 }
 
 // ValidateItemValues validates item data, creates nested relations, and returns the processed data.
-// It MUST be called within a database transaction.
-func ValidateItemValues(ct Collection, itemData map[string]any, tx *gorm.DB) (JSONMap, error) {
+// Writes to attributes the writer may not write are rejected with a *FieldAccessError; on update
+// those attributes keep their stored values. It MUST be called within a database transaction.
+func ValidateItemValues(ct Collection, itemData map[string]any, tx *gorm.DB, access WriteAccess) (JSONMap, error) {
 	processedData := make(JSONMap)
 	maps.Copy(processedData, itemData) // Work on a copy
 
@@ -35,8 +54,39 @@ func ValidateItemValues(ct Collection, itemData map[string]any, tx *gorm.DB) (JS
 		}
 	}
 
+	if err := CheckFieldWrites(ct.Attributes, itemData, access); err != nil {
+		return nil, err
+	}
+
 	for _, attribute := range ct.Attributes {
 		value, exists := itemData[attribute.Name]
+		if _, stored := access.Current[attribute.Name]; !exists && stored && !attribute.WritableBy(access.Role) {
+			continue // Kept from the stored data below, without being validated again.
+		}
+
+		if attribute.Localized {
+			// Translations are validated one by one; a required attribute needs a value
+			// in the default locale, which every other locale falls back to.
+			translations := ct.translations(value)
+			if attribute.Required && translations[ct.DefaultLocale] == nil {
+				return nil, fmt.Errorf("missing required attribute: '%s' in default locale '%s'", attribute.Name, ct.DefaultLocale)
+			}
+			if !exists {
+				continue
+			}
+			for locale, translation := range translations {
+				if !ct.SupportsLocale(locale) {
+					return nil, fmt.Errorf("unsupported locale '%s' for attribute '%s'", locale, attribute.Name)
+				}
+				processedValue, err := validateValue(attribute, translation, tx, access.Role)
+				if err != nil {
+					return nil, fmt.Errorf("validation failed for attribute '%s' in locale '%s': %w", attribute.Name, locale, err)
+				}
+				translations[locale] = processedValue
+			}
+			processedData[attribute.Name] = translations
+			continue
+		}
 
 		if attribute.Required && !exists {
 			return nil, fmt.Errorf("missing required attribute: '%s'", attribute.Name)
@@ -45,35 +95,55 @@ func ValidateItemValues(ct Collection, itemData map[string]any, tx *gorm.DB) (JS
 			continue
 		}
 
-		if attribute.Unique {
-			if err := validation.CheckFieldUniqueness(ct.ID, attribute.Name, value); err != nil {
+		// An item keeping its own value does not conflict with itself.
+		if current, stored := access.Current[attribute.Name]; attribute.Unique && !(stored && fmt.Sprint(current) == fmt.Sprint(value)) {
+			if err := validation.CheckFieldUniquenessTx(tx, ct.ID, attribute.Name, value); err != nil {
 				return nil, err
 			}
 		}
 
-		if attribute.Type == "relation" {
-			// This now returns the processed value (with new IDs) and an error.
-			processedValue, err := validateRelationship(attribute, value, tx)
-			if err != nil {
+		processedValue, err := validateValue(attribute, value, tx, access.Role)
+		if err != nil {
+			if attribute.Type == "relation" {
 				return nil, fmt.Errorf("validation failed for relationship '%s': %w", attribute.Name, err)
 			}
-			// Replace the original nested object with the final ID(s).
-			processedData[attribute.Name] = processedValue
-		} else {
-			if err := validateAttributeValue(attribute, value); err != nil {
-				return nil, fmt.Errorf("validation failed for attribute '%s': %w", attribute.Name, err)
-			}
+			return nil, fmt.Errorf("validation failed for attribute '%s': %w", attribute.Name, err)
 		}
+		processedData[attribute.Name] = processedValue
 	}
 
+	KeepProtectedFields(ct.Attributes, processedData, access)
+
 	logger.Log.WithField("collection", ct.Name).Info("Item data validation passed")
 	return processedData, nil
 }
 
+// validateValue validates a single value of an attribute and returns the value to store:
+// relations and media are normalized to IDs, components are validated recursively, other
+// values are kept as is.
+func validateValue(attribute Attribute, value any, tx *gorm.DB, role *UserRole) (any, error) {
+	switch attribute.Type {
+	case "relation":
+		// Nested objects are replaced by the IDs of the related items.
+		return validateRelationship(attribute, value, tx, role)
+	case "media":
+		return validateMedia(attribute, value, tx)
+	case "component":
+		return validateComponent(attribute, value, tx, role)
+	case "dynamiczone":
+		return validateDynamicZone(attribute, value, tx, role)
+	default:
+		if err := validateAttributeValue(attribute, value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
 // validateRelationship validates and processes a relationship, creating new items if necessary.
 // It returns the final value for the relation (a single ID or an array of IDs).
 // validateRelationship validates and processes a relationship with smart lookup support.
-func validateRelationship(attribute Attribute, value any, tx *gorm.DB) (any, error) {
+func validateRelationship(attribute Attribute, value any, tx *gorm.DB, role *UserRole) (any, error) {
 	if attribute.Target == "" {
 		return nil, fmt.Errorf("missing target collection for relationship '%s'", attribute.Name)
 	}
@@ -113,7 +183,7 @@ func validateRelationship(attribute Attribute, value any, tx *gorm.DB) (any, err
 		}
 
 		// Sub-case 2b: No ID provided → Smart Lookup
-		existingID, found, err := lookup(relatedCollection, obj, tx)
+		existingID, found, err := LookupItem(relatedCollection, obj, tx)
 		if err != nil {
 			return 0, fmt.Errorf("smart lookup failed for '%s': %w", attribute.Name, err)
 		}
@@ -128,30 +198,34 @@ func validateRelationship(attribute Attribute, value any, tx *gorm.DB) (any, err
 
 		// Not found → Create new item
 		logger.Log.WithField("collection", relatedCollection.Name).Info("Creating new nested item")
-		processedNestedData, err := ValidateItemValues(relatedCollection, obj, tx)
+		processedNestedData, err := ValidateItemValues(relatedCollection, obj, tx, WriteAccess{Role: role})
 		if err != nil {
 			return 0, fmt.Errorf("invalid data for new item in '%s': %w", attribute.Target, err)
 		}
 
 		newItem := &Item{
 			CollectionID: relatedCollection.ID,
+			Status:       ItemStatusDraft,
 			Data:         processedNestedData,
 		}
 		if err := tx.Create(newItem).Error; err != nil {
 			return 0, fmt.Errorf("failed to create nested item in '%s': %w", attribute.Target, err)
 		}
+		if err := SyncItemRelations(tx, relatedCollection, newItem); err != nil {
+			return 0, err
+		}
 
 		return newItem.ID, nil
 	}
 
 	// Process single or multiple relations
-	if attribute.Relation == "manyToMany" {
+	if attribute.IsToMany() {
 		array, ok := value.([]any)
 		if !ok {
-			return nil, fmt.Errorf("expected an array for many-to-many relation '%s'", attribute.Name)
+			return nil, fmt.Errorf("expected an array for %s relation '%s'", attribute.Relation, attribute.Name)
 		}
 
-		var resolvedIDs []uint
+		resolvedIDs := make([]uint, 0, len(array))
 		for _, element := range array {
 			id, err := resolveRelationValue(element)
 			if err != nil {
@@ -213,8 +287,9 @@ func ToUint(value any) uint {
 	return 0
 }
 
-// smartLookup attempts to find an existing item based on unique/lookup fields
-func lookup(collection Collection, data map[string]any, tx *gorm.DB) (uint, bool, error) {
+// LookupItem attempts to find an existing item of a collection from the values of its
+// unique attributes in data. It reports whether exactly one item matches.
+func LookupItem(collection Collection, data map[string]any, tx *gorm.DB) (uint, bool, error) {
 	// Build a query based on unique fields and lookup keys
 	var lookupFields []string
 
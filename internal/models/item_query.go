@@ -0,0 +1,414 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value kinds used to pick the right JSON extraction and comparison in SQL.
+const (
+	QueryKindText   = "text"
+	QueryKindNumber = "number"
+	QueryKindBool   = "bool"
+)
+
+// Filter operators supported on the collection list endpoint.
+const (
+	OpEq       = "$eq"
+	OpNe       = "$ne"
+	OpIn       = "$in"
+	OpLt       = "$lt"
+	OpLte      = "$lte"
+	OpGt       = "$gt"
+	OpGte      = "$gte"
+	OpContains = "$contains"
+	OpNull     = "$null"
+)
+
+// ItemSystemFields are item columns (not keys of Item.Data) that can be used for sorting.
+var ItemSystemFields = map[string]string{
+	"id":           QueryKindNumber,
+	"created_at":   QueryKindText,
+	"updated_at":   QueryKindText,
+	"published_at": QueryKindText,
+}
+
+// ItemFilter is a single validated condition on a key of Item.Data.
+type ItemFilter struct {
+	Field    string
+	Operator string
+	Kind     string
+	Values   []any
+	Locales  []string // translations compared, in order, when the attribute is localized
+}
+
+// ItemSort is a single validated sort directive.
+type ItemSort struct {
+	Field   string
+	Kind    string
+	Desc    bool
+	System  bool     // true when Field is an item column rather than a key of Item.Data
+	Locales []string // translations sorted on, in order, when the attribute is localized
+}
+
+// ItemWhere is a tree of conditions on items: its filters and And conditions all hold,
+// and at least one of its Or conditions when it has some.
+type ItemWhere struct {
+	Filters []ItemFilter
+	And     []ItemWhere
+	Or      []ItemWhere
+}
+
+// IsEmpty reports whether the conditions hold for any item.
+func (w ItemWhere) IsEmpty() bool {
+	if len(w.Filters) > 0 {
+		return false
+	}
+	for _, and := range w.And {
+		if !and.IsEmpty() {
+			return false
+		}
+	}
+	if len(w.Or) == 0 {
+		return true
+	}
+	// The alternatives hold for any item when one of them does.
+	for _, or := range w.Or {
+		if or.IsEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+// ItemQuery holds the filters, sort order and field selection of a list request.
+type ItemQuery struct {
+	Filters []ItemFilter
+	Where   *ItemWhere // further conditions, combined with AND and OR, when set
+	Sort    []ItemSort
+	Fields  []string
+	Status  string // restricts results to a publication state when set
+	IDs     []uint // restricts results to these items when not nil
+}
+
+// QueryError is returned when a list query references an unknown field or an unsupported operator.
+type QueryError struct {
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return e.Message
+}
+
+func queryErrorf(format string, args ...any) error {
+	return &QueryError{Message: fmt.Sprintf(format, args...)}
+}
+
+var filterKeyPattern = regexp.MustCompile(`^filters\[([^\[\]]+)\]\[(\$[A-Za-z]+)\](\[\d*\])?$`)
+
+var knownOperators = map[string]bool{
+	OpEq: true, OpNe: true, OpIn: true, OpLt: true, OpLte: true, OpGt: true, OpGte: true, OpContains: true, OpNull: true,
+}
+
+// operatorsByKind lists which operators make sense for each value kind.
+var operatorsByKind = map[string]map[string]bool{
+	QueryKindText: {
+		OpEq: true, OpNe: true, OpIn: true, OpContains: true, OpNull: true,
+	},
+	QueryKindNumber: {
+		OpEq: true, OpNe: true, OpIn: true, OpLt: true, OpLte: true, OpGt: true, OpGte: true, OpNull: true,
+	},
+	QueryKindBool: {
+		OpEq: true, OpNe: true, OpNull: true,
+	},
+}
+
+// attributeQueryKind maps a CMS attribute to the kind used when filtering or sorting on it.
+// It returns false for attributes that cannot be queried.
+func attributeQueryKind(attr Attribute) (string, bool) {
+	switch attr.Type {
+	case "string", "text", "richtext", "email", "uid", "enum", "enumeration", "media":
+		return QueryKindText, true
+	case "date", "datetime", "time":
+		// ISO 8601 strings sort and compare lexicographically.
+		return QueryKindText, true
+	case "int", "integer", "float", "decimal":
+		return QueryKindNumber, true
+	case "bool", "boolean":
+		return QueryKindBool, true
+	case "relation":
+		if attr.IsToMany() {
+			return "", false
+		}
+		return QueryKindNumber, true
+	}
+	return "", false
+}
+
+// kindAllowsOperator reports whether an operator can be applied to a value kind.
+// Date-like text attributes also accept range operators.
+func kindAllowsOperator(attr Attribute, kind, op string) bool {
+	if operatorsByKind[kind][op] {
+		return true
+	}
+	switch attr.Type {
+	case "date", "datetime", "time":
+		return op == OpLt || op == OpLte || op == OpGt || op == OpGte
+	}
+	return false
+}
+
+// ParseItemQuery reads `filters[...]`, `sort` and `fields` from the query string and
+// validates them against the collection's attributes. Localized attributes are
+// compared in the `locale` parameter, following its fallback chain.
+func ParseItemQuery(ct Collection, params url.Values) (ItemQuery, error) {
+	var query ItemQuery
+	locales := ct.lookupLocales(params.Get("locale"))
+
+	attributes := attributesByName(ct.Attributes)
+
+	// Sort the keys so filters are applied in a deterministic order.
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if strings.HasPrefix(key, "filters") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// Indexed keys such as filters[tag][$in][0] and filters[tag][$in][1] are merged
+	// into a single condition.
+	type filterKey struct{ field, op string }
+	var order []filterKey
+	rawByKey := make(map[filterKey][]string)
+
+	for _, key := range keys {
+		matches := filterKeyPattern.FindStringSubmatch(key)
+		if matches == nil {
+			return query, queryErrorf("invalid filter syntax: '%s'", key)
+		}
+		fk := filterKey{field: matches[1], op: matches[2]}
+		indexed := matches[3] != ""
+
+		if _, seen := rawByKey[fk]; !seen {
+			order = append(order, fk)
+		}
+		for _, raw := range params[key] {
+			if fk.op == OpIn && !indexed {
+				rawByKey[fk] = append(rawByKey[fk], strings.Split(raw, ",")...)
+			} else {
+				rawByKey[fk] = append(rawByKey[fk], raw)
+			}
+		}
+	}
+
+	for _, fk := range order {
+		values := make([]any, len(rawByKey[fk]))
+		for i, raw := range rawByKey[fk] {
+			values[i] = raw
+		}
+		filter, err := ct.itemFilter(attributes, fk.field, fk.op, values, locales)
+		if err != nil {
+			return query, err
+		}
+		query.Filters = append(query.Filters, filter)
+	}
+
+	if sortParam := params.Get("sort"); sortParam != "" {
+		for _, part := range strings.Split(sortParam, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			field, direction, _ := strings.Cut(part, ":")
+			sortField := ItemSort{Field: field}
+
+			switch strings.ToLower(direction) {
+			case "", "asc":
+			case "desc":
+				sortField.Desc = true
+			default:
+				return query, queryErrorf("invalid sort direction '%s' for '%s'", direction, field)
+			}
+
+			if err := itemSort(attributes, &sortField, locales); err != nil {
+				return query, err
+			}
+			query.Sort = append(query.Sort, sortField)
+		}
+	}
+
+	if fieldsParam := params.Get("fields"); fieldsParam != "" {
+		for _, field := range strings.Split(fieldsParam, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if _, exists := attributes[field]; !exists {
+				return query, queryErrorf("unknown field: '%s'", field)
+			}
+			query.Fields = append(query.Fields, field)
+		}
+	}
+
+	return query, nil
+}
+
+// attributesByName indexes attributes by name.
+func attributesByName(attributes []Attribute) map[string]Attribute {
+	byName := make(map[string]Attribute, len(attributes))
+	for _, attr := range attributes {
+		byName[attr.Name] = attr
+	}
+	return byName
+}
+
+// ItemFilterOperators lists, in a stable order, the filter operators supported on an
+// attribute. It returns none for attributes that cannot be filtered.
+func ItemFilterOperators(attr Attribute) []string {
+	kind, ok := attributeQueryKind(attr)
+	if !ok {
+		return nil
+	}
+	var operators []string
+	for _, op := range []string{OpEq, OpNe, OpIn, OpLt, OpLte, OpGt, OpGte, OpContains, OpNull} {
+		if kindAllowsOperator(attr, kind, op) {
+			operators = append(operators, op)
+		}
+	}
+	return operators
+}
+
+// IsSortable reports whether items can be sorted on an attribute.
+func IsSortable(attr Attribute) bool {
+	_, ok := attributeQueryKind(attr)
+	return ok
+}
+
+// NewItemFilter validates a condition on an attribute of the collection. Values are
+// given as parsed from a query string or as typed values, and converted to the types
+// compared in SQL. Localized attributes are compared in locale, following its fallback
+// chain.
+func (ct Collection) NewItemFilter(field, op string, values []any, locale string) (ItemFilter, error) {
+	return ct.itemFilter(attributesByName(ct.Attributes), field, op, values, ct.lookupLocales(locale))
+}
+
+func (ct Collection) itemFilter(attributes map[string]Attribute, field, op string, values []any, locales []string) (ItemFilter, error) {
+	attr, exists := attributes[field]
+	if !exists {
+		return ItemFilter{}, queryErrorf("unknown filter field: '%s'", field)
+	}
+	kind, ok := attributeQueryKind(attr)
+	if !ok {
+		return ItemFilter{}, queryErrorf("attribute '%s' of type '%s' cannot be filtered", field, attr.Type)
+	}
+	if !knownOperators[op] {
+		return ItemFilter{}, queryErrorf("unknown filter operator: '%s'", op)
+	}
+	if !kindAllowsOperator(attr, kind, op) {
+		return ItemFilter{}, queryErrorf("operator '%s' is not supported for attribute '%s' of type '%s'", op, field, attr.Type)
+	}
+	if op != OpIn && len(values) != 1 {
+		return ItemFilter{}, queryErrorf("operator '%s' on '%s' expects a single value", op, field)
+	}
+
+	filter := ItemFilter{Field: field, Operator: op, Kind: kind}
+	if attr.Localized {
+		filter.Locales = locales
+	}
+	for _, raw := range values {
+		value, err := parseFilterValue(kind, op, fmt.Sprint(raw))
+		if err != nil {
+			return ItemFilter{}, queryErrorf("invalid value for filter '%s' on '%s': %v", op, field, err)
+		}
+		filter.Values = append(filter.Values, value)
+	}
+	if len(filter.Values) == 0 {
+		return ItemFilter{}, queryErrorf("operator '%s' on '%s' expects at least one value", op, field)
+	}
+	return filter, nil
+}
+
+// NewItemSort validates a sort directive on an attribute of the collection or an item
+// system field. Localized attributes are sorted on their translation in locale,
+// following its fallback chain.
+func (ct Collection) NewItemSort(field string, desc bool, locale string) (ItemSort, error) {
+	sortField := ItemSort{Field: field, Desc: desc}
+	if err := itemSort(attributesByName(ct.Attributes), &sortField, ct.lookupLocales(locale)); err != nil {
+		return ItemSort{}, err
+	}
+	return sortField, nil
+}
+
+// itemSort completes a sort directive with the kind of its field.
+func itemSort(attributes map[string]Attribute, sortField *ItemSort, locales []string) error {
+	if attr, exists := attributes[sortField.Field]; exists {
+		kind, ok := attributeQueryKind(attr)
+		if !ok {
+			return queryErrorf("attribute '%s' of type '%s' cannot be sorted", attr.Name, attr.Type)
+		}
+		sortField.Kind = kind
+		if attr.Localized {
+			sortField.Locales = locales
+		}
+		return nil
+	}
+	if kind, isSystem := ItemSystemFields[sortField.Field]; isSystem {
+		sortField.Kind = kind
+		sortField.System = true
+		return nil
+	}
+	return queryErrorf("unknown sort field: '%s'", sortField.Field)
+}
+
+// parseFilterValue converts a raw query-string value into the Go type matching the filter kind.
+func parseFilterValue(kind, op, raw string) (any, error) {
+	if op == OpNull {
+		isNull, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got '%s'", raw)
+		}
+		return isNull, nil
+	}
+
+	switch kind {
+	case QueryKindNumber:
+		number, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got '%s'", raw)
+		}
+		return number, nil
+	case QueryKindBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got '%s'", raw)
+		}
+		return b, nil
+	}
+	return raw, nil
+}
+
+// SelectFields returns a copy of data restricted to the requested fields.
+// The "id" and "published_at" keys are always kept. When no fields were requested,
+// data is returned as is.
+func (q ItemQuery) SelectFields(data JSONMap) JSONMap {
+	if len(q.Fields) == 0 {
+		return data
+	}
+	selected := make(JSONMap, len(q.Fields)+2)
+	for _, key := range []string{"id", "published_at"} {
+		if value, exists := data[key]; exists {
+			selected[key] = value
+		}
+	}
+	for _, field := range q.Fields {
+		if value, exists := data[field]; exists {
+			selected[field] = value
+		}
+	}
+	return selected
+}
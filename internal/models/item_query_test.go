@@ -0,0 +1,128 @@
+package models
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseItemQuery(t *testing.T) {
+	collection := Collection{
+		Name: "articles",
+		Attributes: []Attribute{
+			{Name: "title", Type: "text"},
+			{Name: "views", Type: "int"},
+			{Name: "featured", Type: "bool"},
+			{Name: "published_on", Type: "date"},
+			{Name: "metadata", Type: "json"},
+			{Name: "tags", Type: "relation", Relation: "manyToMany", Target: "tags"},
+		},
+	}
+
+	t.Run("Filters, sort and fields", func(t *testing.T) {
+		params, _ := url.ParseQuery("filters[title][$contains]=go&filters[views][$gt]=10&sort=published_on:desc,title&fields=title,views")
+		query, err := ParseItemQuery(collection, params)
+		assert.NoError(t, err)
+
+		assert.Len(t, query.Filters, 2)
+		assert.Equal(t, ItemFilter{Field: "title", Operator: OpContains, Kind: QueryKindText, Values: []any{"go"}}, query.Filters[0])
+		assert.Equal(t, ItemFilter{Field: "views", Operator: OpGt, Kind: QueryKindNumber, Values: []any{float64(10)}}, query.Filters[1])
+
+		assert.Equal(t, []ItemSort{
+			{Field: "published_on", Kind: QueryKindText, Desc: true},
+			{Field: "title", Kind: QueryKindText},
+		}, query.Sort)
+		assert.Equal(t, []string{"title", "views"}, query.Fields)
+	})
+
+	t.Run("In operator accepts comma-separated and indexed values", func(t *testing.T) {
+		params, _ := url.ParseQuery("filters[title][$in]=a,b")
+		query, err := ParseItemQuery(collection, params)
+		assert.NoError(t, err)
+		assert.Equal(t, []any{"a", "b"}, query.Filters[0].Values)
+
+		params, _ = url.ParseQuery("filters[views][$in][0]=1&filters[views][$in][1]=2")
+		query, err = ParseItemQuery(collection, params)
+		assert.NoError(t, err)
+		assert.Len(t, query.Filters, 1)
+		assert.Equal(t, []any{float64(1), float64(2)}, query.Filters[0].Values)
+	})
+
+	t.Run("System fields can be sorted", func(t *testing.T) {
+		params, _ := url.ParseQuery("sort=created_at:desc")
+		query, err := ParseItemQuery(collection, params)
+		assert.NoError(t, err)
+		assert.Equal(t, []ItemSort{{Field: "created_at", Kind: QueryKindText, Desc: true, System: true}}, query.Sort)
+	})
+
+	errorCases := map[string]string{
+		"filters[unknown][$eq]=x":       "unknown filter field: 'unknown'",
+		"filters[title][$foo]=x":        "unknown filter operator: '$foo'",
+		"filters[title][$gt]=x":         "operator '$gt' is not supported for attribute 'title' of type 'text'",
+		"filters[featured][$in]=true":   "operator '$in' is not supported for attribute 'featured' of type 'bool'",
+		"filters[views][$eq]=many":      "invalid value for filter '$eq' on 'views': expected a number, got 'many'",
+		"filters[metadata][$eq]=x":      "attribute 'metadata' of type 'json' cannot be filtered",
+		"filters[tags][$eq]=1":          "attribute 'tags' of type 'relation' cannot be filtered",
+		"filters[title][$null]=maybe":   "invalid value for filter '$null' on 'title': expected true or false, got 'maybe'",
+		"filters[title]=x":              "invalid filter syntax: 'filters[title]'",
+		"sort=unknown":                  "unknown sort field: 'unknown'",
+		"sort=title:sideways":           "invalid sort direction 'sideways' for 'title'",
+		"fields=title,unknown":          "unknown field: 'unknown'",
+		"filters[published_on][$gt]=20": "",
+	}
+	for rawQuery, expected := range errorCases {
+		t.Run(rawQuery, func(t *testing.T) {
+			params, _ := url.ParseQuery(rawQuery)
+			_, err := ParseItemQuery(collection, params)
+			if expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.IsType(t, &QueryError{}, err)
+			assert.Equal(t, expected, err.Error())
+		})
+	}
+}
+
+func TestItemQuerySelectFields(t *testing.T) {
+	data := JSONMap{"id": 1, "title": "Hello", "slug": "hello", "body": "..."}
+
+	assert.Equal(t, data, ItemQuery{}.SelectFields(data))
+	assert.Equal(t, JSONMap{"id": 1, "title": "Hello", "slug": "hello"}, ItemQuery{Fields: []string{"title", "slug"}}.SelectFields(data))
+}
+
+func TestNewItemFilter(t *testing.T) {
+	collection := Collection{
+		Name: "articles",
+		Attributes: []Attribute{
+			{Name: "title", Type: "text"},
+			{Name: "views", Type: "int"},
+			{Name: "metadata", Type: "json"},
+		},
+	}
+
+	filter, err := collection.NewItemFilter("views", OpIn, []any{1, 2.5}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, QueryKindNumber, filter.Kind)
+	assert.Equal(t, []any{1.0, 2.5}, filter.Values)
+
+	_, err = collection.NewItemFilter("metadata", OpEq, []any{"x"}, "")
+	assert.Error(t, err)
+	_, err = collection.NewItemFilter("title", OpGt, []any{"x"}, "")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{OpEq, OpNe, OpIn, OpContains, OpNull}, ItemFilterOperators(collection.Attributes[0]))
+	assert.Empty(t, ItemFilterOperators(collection.Attributes[2]))
+}
+
+func TestItemWhereIsEmpty(t *testing.T) {
+	filter := ItemFilter{Field: "title", Operator: OpEq, Kind: QueryKindText, Values: []any{"Go"}}
+
+	assert.True(t, ItemWhere{}.IsEmpty())
+	assert.True(t, ItemWhere{And: []ItemWhere{{}}}.IsEmpty())
+	assert.True(t, ItemWhere{Or: []ItemWhere{{Filters: []ItemFilter{filter}}, {}}}.IsEmpty())
+	assert.False(t, ItemWhere{Filters: []ItemFilter{filter}}.IsEmpty())
+	assert.False(t, ItemWhere{Or: []ItemWhere{{Filters: []ItemFilter{filter}}}}.IsEmpty())
+}
@@ -0,0 +1,251 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// LocaleAll selects every translation of localized attributes: reads return the
+// per-locale values as stored, and writes expect them in the same form.
+const LocaleAll = "all"
+
+// localePattern matches language tags such as "en", "fr-CA" or "zh-Hant".
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// localizableTypes lists the attribute types whose values can be translated.
+// Relations are shared by every locale.
+var localizableTypes = map[string]bool{
+	"string": true, "text": true, "richtext": true, "email": true, "uid": true,
+	"enum": true, "enumeration": true, "media": true,
+	"int": true, "integer": true, "float": true, "decimal": true, "bool": true, "boolean": true,
+	"date": true, "datetime": true, "time": true,
+}
+
+// IsLocalized reports whether the collection declares supported locales.
+func (c *Collection) IsLocalized() bool {
+	return len(c.Locales) > 0
+}
+
+// HasLocalizedAttributes reports whether any attribute of the collection is localized.
+func (c *Collection) HasLocalizedAttributes() bool {
+	return slices.ContainsFunc(c.Attributes, func(attr Attribute) bool { return attr.Localized })
+}
+
+// SupportsLocale reports whether locale is one of the collection's locales.
+func (c *Collection) SupportsLocale(locale string) bool {
+	return slices.Contains(c.Locales, locale)
+}
+
+// LocaleFallbacks returns the locales tried, in order, when reading a localized value in
+// locale: the locale itself, its parent tags ("fr-CA" falls back to "fr"), then the
+// default locale. An empty locale reads the default locale.
+func (c *Collection) LocaleFallbacks(locale string) []string {
+	var chain []string
+	for tag := locale; tag != ""; {
+		chain = append(chain, tag)
+		cut := strings.LastIndex(tag, "-")
+		if cut < 0 {
+			break
+		}
+		tag = tag[:cut]
+	}
+	if c.DefaultLocale != "" && !slices.Contains(chain, c.DefaultLocale) {
+		chain = append(chain, c.DefaultLocale)
+	}
+	return chain
+}
+
+// ResolveLocale returns the first supported locale of the fallback chain of locale.
+func (c *Collection) ResolveLocale(locale string) string {
+	for _, candidate := range c.LocaleFallbacks(locale) {
+		if c.SupportsLocale(candidate) {
+			return candidate
+		}
+	}
+	return c.DefaultLocale
+}
+
+// lookupLocales returns the supported locales of the fallback chain of locale, in
+// order. LocaleAll looks up the default locale.
+func (c *Collection) lookupLocales(locale string) []string {
+	if locale == LocaleAll {
+		locale = c.DefaultLocale
+	}
+	var chain []string
+	for _, candidate := range c.LocaleFallbacks(locale) {
+		if c.SupportsLocale(candidate) {
+			chain = append(chain, candidate)
+		}
+	}
+	return chain
+}
+
+// translations returns the per-locale values of a localized attribute. A plain value,
+// stored before the attribute was localized, belongs to the default locale.
+func (c *Collection) translations(value any) map[string]any {
+	switch v := value.(type) {
+	case nil:
+		return map[string]any{}
+	case map[string]any:
+		return maps.Clone(v)
+	case JSONMap:
+		return maps.Clone(map[string]any(v))
+	default:
+		return map[string]any{c.DefaultLocale: v}
+	}
+}
+
+// LocalizeInput merges the values written in locale into the stored translations of
+// localized attributes. A null value removes the translation, and attributes left out
+// of the input keep their translations. With LocaleAll, localized values are maps of
+// locales to values. Other attributes are copied as is.
+func (c *Collection) LocalizeInput(input, current map[string]any, locale string) (JSONMap, error) {
+	if locale == "" {
+		locale = c.DefaultLocale
+	}
+	if locale != LocaleAll && !c.SupportsLocale(locale) && c.HasLocalizedAttributes() {
+		return nil, fmt.Errorf("unsupported locale '%s' for collection '%s'", locale, c.Name)
+	}
+
+	result := make(JSONMap, len(input))
+	maps.Copy(result, input)
+	for _, attr := range c.Attributes {
+		if !attr.Localized {
+			continue
+		}
+		value, exists := input[attr.Name]
+		if !exists {
+			if stored, ok := current[attr.Name]; ok {
+				result[attr.Name] = stored
+			}
+			continue
+		}
+
+		translations := c.translations(current[attr.Name])
+		if locale == LocaleAll {
+			written, ok := value.(map[string]any)
+			if !ok && value != nil {
+				return nil, fmt.Errorf("attribute '%s' expects a map of locales to values", attr.Name)
+			}
+			for key, translation := range written {
+				translations[key] = translation
+			}
+		} else {
+			translations[locale] = value
+		}
+		for key, translation := range translations {
+			if translation == nil {
+				delete(translations, key)
+			}
+		}
+
+		if len(translations) == 0 {
+			delete(result, attr.Name)
+		} else {
+			result[attr.Name] = translations
+		}
+	}
+	return result, nil
+}
+
+// LocalizeData returns a copy of data where the translations of localized attributes
+// are replaced by their value in locale, following the fallback chain when the
+// translation is missing. LocaleAll returns data unchanged.
+func (c *Collection) LocalizeData(data JSONMap, locale string) JSONMap {
+	if locale == LocaleAll || !c.HasLocalizedAttributes() {
+		return data
+	}
+	chain := c.LocaleFallbacks(locale)
+
+	result := make(JSONMap, len(data))
+	maps.Copy(result, data)
+	for _, attr := range c.Attributes {
+		value, exists := data[attr.Name]
+		if !attr.Localized || !exists {
+			continue
+		}
+		translations := c.translations(value)
+		result[attr.Name] = nil
+		for _, candidate := range chain {
+			if translation, ok := translations[candidate]; ok && translation != nil {
+				result[attr.Name] = translation
+				break
+			}
+		}
+	}
+	return result
+}
+
+// MissingTranslations lists, for each supported locale, the localized attributes of
+// data that have a value in another locale but none in this one.
+func (c *Collection) MissingTranslations(data JSONMap, attributes []Attribute) map[string][]string {
+	missing := make(map[string][]string, len(c.Locales))
+	for _, locale := range c.Locales {
+		missing[locale] = []string{}
+	}
+	for _, attr := range attributes {
+		if !attr.Localized {
+			continue
+		}
+		translations := c.translations(data[attr.Name])
+		if !slices.ContainsFunc(slices.Collect(maps.Values(translations)), hasTranslation) {
+			continue // Not filled in any locale.
+		}
+		for _, locale := range c.Locales {
+			if !hasTranslation(translations[locale]) {
+				missing[locale] = append(missing[locale], attr.Name)
+			}
+		}
+	}
+	return missing
+}
+
+// hasTranslation reports whether a translation holds a value.
+func hasTranslation(value any) bool {
+	if value == nil {
+		return false
+	}
+	if s, ok := value.(string); ok {
+		return strings.TrimSpace(s) != ""
+	}
+	return true
+}
+
+// validateLocales checks the locales of a collection and of its localized attributes.
+func validateLocales(ct Collection) error {
+	seen := make(map[string]bool, len(ct.Locales))
+	for _, locale := range ct.Locales {
+		if !localePattern.MatchString(locale) || locale == LocaleAll {
+			return fmt.Errorf("invalid locale '%s'", locale)
+		}
+		if seen[locale] {
+			return fmt.Errorf("duplicate locale '%s'", locale)
+		}
+		seen[locale] = true
+	}
+	if ct.IsLocalized() && !seen[ct.DefaultLocale] {
+		return fmt.Errorf("default locale '%s' must be one of the collection's locales", ct.DefaultLocale)
+	}
+	if !ct.IsLocalized() && ct.DefaultLocale != "" {
+		return fmt.Errorf("default locale '%s' is set but the collection declares no locales", ct.DefaultLocale)
+	}
+
+	for _, attr := range ct.Attributes {
+		if !attr.Localized {
+			continue
+		}
+		if !ct.IsLocalized() {
+			return fmt.Errorf("attribute '%s' is localized but the collection declares no locales", attr.Name)
+		}
+		if !localizableTypes[attr.Type] {
+			return fmt.Errorf("attribute '%s' of type '%s' cannot be localized", attr.Name, attr.Type)
+		}
+		if attr.Unique {
+			return fmt.Errorf("attribute '%s' cannot be both localized and unique", attr.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func localizedArticles() Collection {
+	return Collection{
+		Name:          "articles",
+		Locales:       []string{"en", "fr", "fr-CA"},
+		DefaultLocale: "en",
+		Attributes: []Attribute{
+			{Name: "title", Type: "text", Required: true, Localized: true, Pattern: "^[A-Z]"},
+			{Name: "summary", Type: "text", Localized: true},
+			{Name: "views", Type: "int"},
+		},
+	}
+}
+
+func TestLocaleFallbacks(t *testing.T) {
+	ct := localizedArticles()
+
+	assert.Equal(t, []string{"fr-CA", "fr", "en"}, ct.LocaleFallbacks("fr-CA"))
+	assert.Equal(t, []string{"de-AT", "de", "en"}, ct.LocaleFallbacks("de-AT"))
+	assert.Equal(t, []string{"en"}, ct.LocaleFallbacks(""))
+	assert.Equal(t, "fr", ct.ResolveLocale("fr-BE"))
+	assert.Equal(t, "en", ct.ResolveLocale("de"))
+}
+
+func TestLocalizeInputAndData(t *testing.T) {
+	ct := localizedArticles()
+	stored := JSONMap{"title": map[string]any{"en": "Hello", "fr": "Bonjour"}, "summary": "Plain", "views": 3}
+
+	t.Run("Write a locale", func(t *testing.T) {
+		data, err := ct.LocalizeInput(map[string]any{"title": "Salut", "views": 4}, stored, "fr")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"en": "Hello", "fr": "Salut"}, data["title"])
+		assert.Equal(t, "Plain", data["summary"], "attributes left out keep their translations")
+		assert.Equal(t, 4, data["views"])
+		assert.Equal(t, "Bonjour", stored["title"].(map[string]any)["fr"], "the stored data is left untouched")
+	})
+
+	t.Run("Null removes a translation", func(t *testing.T) {
+		data, err := ct.LocalizeInput(map[string]any{"title": nil, "summary": "Résumé"}, stored, "fr")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"en": "Hello"}, data["title"])
+		assert.Equal(t, map[string]any{"en": "Plain", "fr": "Résumé"}, data["summary"])
+	})
+
+	t.Run("Write every locale", func(t *testing.T) {
+		data, err := ct.LocalizeInput(map[string]any{"title": map[string]any{"fr-CA": "Allo"}}, stored, LocaleAll)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"en": "Hello", "fr": "Bonjour", "fr-CA": "Allo"}, data["title"])
+
+		_, err = ct.LocalizeInput(map[string]any{"title": "Allo"}, stored, LocaleAll)
+		assert.ErrorContains(t, err, "expects a map of locales")
+	})
+
+	t.Run("Unsupported locale", func(t *testing.T) {
+		_, err := ct.LocalizeInput(map[string]any{"title": "Hallo"}, nil, "de")
+		assert.ErrorContains(t, err, "unsupported locale 'de'")
+	})
+
+	t.Run("Read with fallback", func(t *testing.T) {
+		data := ct.LocalizeData(stored, "fr-CA")
+		assert.Equal(t, "Bonjour", data["title"])
+		assert.Equal(t, "Plain", data["summary"], "plain values belong to the default locale")
+		assert.Equal(t, 3, data["views"])
+
+		assert.Equal(t, "Hello", ct.LocalizeData(stored, "")["title"])
+		assert.Equal(t, stored, ct.LocalizeData(stored, LocaleAll))
+	})
+}
+
+func TestMissingTranslations(t *testing.T) {
+	ct := localizedArticles()
+	data := JSONMap{"title": map[string]any{"en": "Hello", "fr": " "}, "views": 3}
+
+	missing := ct.MissingTranslations(data, ct.Attributes)
+	assert.Equal(t, map[string][]string{"en": {}, "fr": {"title"}, "fr-CA": {"title"}}, missing)
+}
+
+func TestValidateLocales(t *testing.T) {
+	valid := localizedArticles()
+	assert.NoError(t, validateLocales(valid))
+
+	tests := []struct {
+		name   string
+		change func(ct *Collection)
+		err    string
+	}{
+		{"Invalid locale", func(ct *Collection) { ct.Locales = append(ct.Locales, "French") }, "invalid locale 'French'"},
+		{"Duplicate locale", func(ct *Collection) { ct.Locales = append(ct.Locales, "fr") }, "duplicate locale 'fr'"},
+		{"Unknown default", func(ct *Collection) { ct.DefaultLocale = "de" }, "default locale 'de' must be one of"},
+		{"No locales", func(ct *Collection) { ct.Locales, ct.DefaultLocale = nil, "" }, "declares no locales"},
+		{"Relation", func(ct *Collection) {
+			ct.Attributes = append(ct.Attributes, Attribute{Name: "author", Type: "relation", Localized: true})
+		}, "cannot be localized"},
+		{"Unique", func(ct *Collection) { ct.Attributes[0].Unique = true }, "cannot be both localized and unique"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := localizedArticles()
+			tt.change(&ct)
+			assert.ErrorContains(t, validateLocales(ct), tt.err)
+		})
+	}
+}
+
+func TestValidateItemValuesLocalized(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	ct := localizedArticles()
+
+	data, err := ValidateItemValues(ct, map[string]any{"title": map[string]any{"en": "Hello", "fr": "Bonjour"}}, db, WriteAccess{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"en": "Hello", "fr": "Bonjour"}, data["title"])
+
+	data, err = ValidateItemValues(ct, map[string]any{"title": "Hello"}, db, WriteAccess{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"en": "Hello"}, data["title"], "plain values are written in the default locale")
+
+	_, err = ValidateItemValues(ct, map[string]any{"title": map[string]any{"fr": "Bonjour"}}, db, WriteAccess{})
+	assert.ErrorContains(t, err, "in default locale 'en'")
+
+	_, err = ValidateItemValues(ct, map[string]any{"title": map[string]any{"en": "Hello", "de": "Hallo"}}, db, WriteAccess{})
+	assert.ErrorContains(t, err, "unsupported locale 'de'")
+
+	_, err = ValidateItemValues(ct, map[string]any{"title": map[string]any{"en": "Hello", "fr": "bonjour"}}, db, WriteAccess{})
+	assert.ErrorContains(t, err, "in locale 'fr'")
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resources that permissions apply to.
+const (
+	ResourceCollections = "collections"
+	ResourceSingletons  = "singletons"
+	ResourceComponents  = "components"
+	ResourceAgents      = "agents"
+	ResourceRoles       = "roles"
+	ResourceMedia       = "media"
+	ResourceWebhooks    = "webhooks"
+	ResourceGraphQL     = "graphql" // persisted queries
+)
+
+// Actions that can be granted on a resource.
+const (
+	ActionCreate  = "create"
+	ActionRead    = "read"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionPublish = "publish"
+	ActionManage  = "manage" // schema and configuration changes
+)
+
+// PermissionWildcard matches any resource, name or action in a granted permission.
+const PermissionWildcard = "*"
+
+var knownResources = map[string]bool{
+	ResourceCollections: true,
+	ResourceSingletons:  true,
+	ResourceComponents:  true,
+	ResourceAgents:      true,
+	ResourceRoles:       true,
+	ResourceMedia:       true,
+	ResourceWebhooks:    true,
+	ResourceGraphQL:     true,
+}
+
+var knownActions = map[string]bool{
+	ActionCreate:  true,
+	ActionRead:    true,
+	ActionUpdate:  true,
+	ActionDelete:  true,
+	ActionPublish: true,
+	ActionManage:  true,
+}
+
+// DefaultRolePermissions are the permission sets of the built-in roles.
+var DefaultRolePermissions = map[string]JSONMap{
+	"admin": {PermissionWildcard: true},
+	"editor": {
+		"collections.*.*": true,
+		"singletons.*.*":  true,
+		"media.*.*":       true,
+	},
+	"viewer": {
+		"collections.*.read": true,
+		"singletons.*.read":  true,
+		"media.*.read":       true,
+	},
+}
+
+// Permission builds the permission string for an action on a named resource,
+// e.g. Permission(ResourceCollections, "articles", ActionUpdate) is "collections.articles.update".
+func Permission(resource, name, action string) string {
+	return resource + "." + name + "." + action
+}
+
+// Can reports whether one of the role's granted permissions matches permission.
+// A granted permission is either "*" or "<resource>.<name>.<action>" where each
+// segment may be "*". A wildcard in the checked permission only matches a wildcard grant.
+func (r *UserRole) Can(permission string) bool {
+	required := strings.Split(permission, ".")
+	for granted, value := range r.Permissions {
+		if allowed, ok := value.(bool); !ok || !allowed {
+			continue
+		}
+		if permissionMatches(granted, required) {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionMatches(granted string, required []string) bool {
+	if granted == PermissionWildcard {
+		return true
+	}
+	segments := strings.Split(granted, ".")
+	if len(segments) != len(required) {
+		return false
+	}
+	for i, segment := range segments {
+		if segment != PermissionWildcard && segment != required[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidatePermission checks that a granted permission is "*" or a well-formed
+// "<resource>.<name>.<action>" pattern.
+func ValidatePermission(permission string) error {
+	if permission == PermissionWildcard {
+		return nil
+	}
+	segments := strings.Split(permission, ".")
+	if len(segments) != 3 {
+		return fmt.Errorf("invalid permission '%s': expected '<resource>.<name>.<action>'", permission)
+	}
+	resource, name, action := segments[0], segments[1], segments[2]
+	if resource != PermissionWildcard && !knownResources[resource] {
+		return fmt.Errorf("invalid permission '%s': unknown resource '%s'", permission, resource)
+	}
+	if name == "" {
+		return fmt.Errorf("invalid permission '%s': missing name", permission)
+	}
+	if action != PermissionWildcard && !knownActions[action] {
+		return fmt.Errorf("invalid permission '%s': unknown action '%s'", permission, action)
+	}
+	return nil
+}
+
+// ValidateRolePermissions validates every permission of a role.
+// Permissions map a pattern to true (granted) or false (listed but not granted).
+func ValidateRolePermissions(permissions JSONMap) error {
+	for permission, value := range permissions {
+		if err := ValidatePermission(permission); err != nil {
+			return err
+		}
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("invalid value for permission '%s': expected true or false", permission)
+		}
+	}
+	return nil
+}
+
+// HasLegacyPermissions reports whether none of the role's permissions use the
+// "<resource>.<name>.<action>" format, as with roles seeded by older versions.
+func (r *UserRole) HasLegacyPermissions() bool {
+	for permission := range r.Permissions {
+		if ValidatePermission(permission) == nil {
+			return false
+		}
+	}
+	return true
+}
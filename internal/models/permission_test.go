@@ -0,0 +1,61 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserRoleCan(t *testing.T) {
+	role := UserRole{Permissions: JSONMap{
+		"collections.articles.update": true,
+		"collections.*.read":          true,
+		"singletons.homepage.*":       true,
+		"agents.*.manage":             true,
+		"collections.drafts.delete":   false,
+	}}
+
+	cases := map[string]bool{
+		"collections.articles.update": true,
+		"collections.articles.read":   true,
+		"collections.pages.read":      true,
+		"collections.pages.update":    false,
+		"collections.drafts.delete":   false,
+		"singletons.homepage.read":    true,
+		"singletons.homepage.publish": true,
+		"singletons.footer.read":      false,
+		"agents.summarizer.manage":    true,
+		"agents.*.manage":             true,
+		"collections.*.update":        false, // a specific grant does not cover every collection
+		"roles.*.manage":              false,
+	}
+	for permission, expected := range cases {
+		assert.Equal(t, expected, role.Can(permission), permission)
+	}
+
+	admin := UserRole{Permissions: DefaultRolePermissions["admin"]}
+	assert.True(t, admin.Can("roles.*.manage"))
+
+	assert.False(t, (&UserRole{}).Can("collections.articles.read"))
+}
+
+func TestValidateRolePermissions(t *testing.T) {
+	assert.NoError(t, ValidateRolePermissions(JSONMap{"*": true, "collections.*.read": true, "agents.bot.manage": false}))
+
+	errorCases := map[string]JSONMap{
+		"invalid permission 'read': expected '<resource>.<name>.<action>'": {"read": true},
+		"invalid permission 'posts.a.read': unknown resource 'posts'":      {"posts.a.read": true},
+		"invalid permission 'collections.a.fly': unknown action 'fly'":     {"collections.a.fly": true},
+		"invalid permission 'collections..read': missing name":             {"collections..read": true},
+		"invalid value for permission '*': expected true or false":         {"*": "yes"},
+	}
+	for expected, permissions := range errorCases {
+		err := ValidateRolePermissions(permissions)
+		if assert.Error(t, err) {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+
+	assert.True(t, (&UserRole{Permissions: JSONMap{"manage_users": true}}).HasLegacyPermissions())
+	assert.False(t, (&UserRole{Permissions: JSONMap{"manage_users": true, "*": true}}).HasLegacyPermissions())
+}
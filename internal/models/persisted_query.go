@@ -0,0 +1,100 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Sources of a persisted query.
+const (
+	// PersistedQuerySourceManifest queries are uploaded by an administrator, from a
+	// manifest produced when a frontend is built. Only they are run in allow-list mode.
+	PersistedQuerySourceManifest = "manifest"
+	// PersistedQuerySourceAutomatic queries are registered by clients sending a query
+	// along with its hash, the automatic persisted queries (APQ) protocol.
+	PersistedQuerySourceAutomatic = "automatic"
+)
+
+// PersistedQuery is a GraphQL document clients send by its SHA-256 hash.
+type PersistedQuery struct {
+	gorm.Model
+	Hash   string `json:"hash" gorm:"uniqueIndex;size:64"`
+	Query  string `json:"query" gorm:"type:text"`
+	Source string `json:"source" gorm:"size:16"`
+	// ExpiresAt is when an automatic query is forgotten, unless registered again. Manifest
+	// queries do not expire.
+	ExpiresAt *time.Time `json:"expires_at,omitempty" gorm:"index"`
+}
+
+// HashQuery returns the SHA-256 hash of a query, in hex, under which it is persisted.
+func HashQuery(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// Validate checks that the query is not empty and hashes to its hash.
+func (q PersistedQuery) Validate() error {
+	if strings.TrimSpace(q.Query) == "" {
+		return fmt.Errorf("persisted query '%s' is empty", q.Hash)
+	}
+	if !strings.EqualFold(q.Hash, HashQuery(q.Query)) {
+		return fmt.Errorf("persisted query '%s' does not match the SHA-256 hash of its query", q.Hash)
+	}
+	return nil
+}
+
+// persistedQueryManifest is the manifest of the persisted queries of an Apollo client,
+// see https://www.apollographql.com/docs/graphos/platform/security/persisted-queries.
+type persistedQueryManifest struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Type string `json:"type"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// ParsePersistedQueryManifest reads the queries of a manifest: either an Apollo
+// persisted query manifest, or an object mapping the hashes of the queries to them.
+// Every query must match its hash.
+func ParsePersistedQueryManifest(data []byte) ([]PersistedQuery, error) {
+	var queries []PersistedQuery
+	var manifest persistedQueryManifest
+	if err := json.Unmarshal(data, &manifest); err == nil && manifest.Format != "" {
+		if manifest.Format != "apollo-persisted-query-manifest" || manifest.Version != 1 {
+			return nil, fmt.Errorf("unsupported manifest format '%s', version %d", manifest.Format, manifest.Version)
+		}
+		for _, operation := range manifest.Operations {
+			queries = append(queries, PersistedQuery{Hash: operation.ID, Query: operation.Body})
+		}
+	} else {
+		var hashes map[string]string
+		if err := json.Unmarshal(data, &hashes); err != nil {
+			return nil, errors.New("invalid manifest: expected an Apollo persisted query manifest or an object of queries by hash")
+		}
+		for hash, query := range hashes {
+			queries = append(queries, PersistedQuery{Hash: hash, Query: query})
+		}
+	}
+
+	if len(queries) == 0 {
+		return nil, errors.New("the manifest holds no query")
+	}
+	for i := range queries {
+		queries[i].Hash = strings.ToLower(queries[i].Hash)
+		queries[i].Source = PersistedQuerySourceManifest
+		if err := queries[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return queries, nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const persistedQueryText = "{ articles { title } }"
+
+func TestHashQuery(t *testing.T) {
+	hash := HashQuery(persistedQueryText)
+	assert.Len(t, hash, 64)
+	assert.NoError(t, PersistedQuery{Hash: hash, Query: persistedQueryText}.Validate())
+	assert.Error(t, PersistedQuery{Hash: hash, Query: "{ pages { title } }"}.Validate())
+	assert.Error(t, PersistedQuery{Hash: HashQuery(" "), Query: " "}.Validate())
+}
+
+func TestParsePersistedQueryManifest(t *testing.T) {
+	hash := HashQuery(persistedQueryText)
+	expected := []PersistedQuery{{Hash: hash, Query: persistedQueryText, Source: PersistedQuerySourceManifest}}
+
+	queries, err := ParsePersistedQueryManifest([]byte(`{
+		"format": "apollo-persisted-query-manifest",
+		"version": 1,
+		"operations": [{"id": "` + hash + `", "name": "Articles", "type": "query", "body": "{ articles { title } }"}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, expected, queries)
+
+	queries, err = ParsePersistedQueryManifest([]byte(`{"` + hash + `": "{ articles { title } }"}`))
+	require.NoError(t, err)
+	assert.Equal(t, expected, queries)
+
+	cases := map[string]string{
+		"unknown format": `{"format": "relay", "version": 1, "operations": []}`,
+		"empty":          `{}`,
+		"wrong hash":     `{"` + HashQuery("{ pages { title } }") + `": "{ articles { title } }"}`,
+		"invalid JSON":   `[`,
+	}
+	for name, manifest := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParsePersistedQueryManifest([]byte(manifest))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// Actions applied to the items referencing an item when it is deleted, set with the
+// 'on_delete' field of a relation attribute.
+const (
+	OnDeleteSetNull  = "set_null" // drop the reference (default)
+	OnDeleteCascade  = "cascade"  // delete the referencing items too
+	OnDeleteRestrict = "restrict" // refuse to delete a referenced item
+)
+
+// ItemRelation links an item to a related item through one of its relation attributes.
+// The relation IDs kept in the item's data mirror these rows so filters and revisions
+// keep working; they are rewritten whenever the relations change.
+type ItemRelation struct {
+	ID                 uint   `json:"id" gorm:"primaryKey"`
+	SourceItemID       uint   `json:"source_item_id" gorm:"not null;index:idx_item_relations_source"`
+	SourceCollectionID uint   `json:"source_collection_id" gorm:"not null;index"`
+	Attribute          string `json:"attribute" gorm:"type:varchar(255);not null;index:idx_item_relations_source"`
+	TargetItemID       uint   `json:"target_item_id" gorm:"not null;index"`
+	Position           int    `json:"position" gorm:"not null;default:0"`
+}
+
+// IsToMany reports whether a relation attribute holds a list of related items.
+func (a Attribute) IsToMany() bool {
+	return a.Relation == "oneToMany" || a.Relation == "manyToMany"
+}
+
+// DeleteAction returns the action applied to the items referencing an item through
+// this relation attribute when it is deleted.
+func (a Attribute) DeleteAction() string {
+	if a.OnDelete == "" {
+		return OnDeleteSetNull
+	}
+	return a.OnDelete
+}
+
+// validateOnDelete checks the 'on_delete' field of an attribute.
+func validateOnDelete(attr Attribute) error {
+	if attr.OnDelete == "" {
+		return nil
+	}
+	if attr.Type != "relation" {
+		return fmt.Errorf("attribute '%s': 'on_delete' only applies to relations", attr.Name)
+	}
+	switch attr.OnDelete {
+	case OnDeleteSetNull, OnDeleteCascade, OnDeleteRestrict:
+		return nil
+	}
+	return fmt.Errorf("invalid on_delete '%s' for relationship '%s', expected 'set_null', 'cascade' or 'restrict'", attr.OnDelete, attr.Name)
+}
+
+// RelationIDs returns the IDs of the related items held by a stored relation value:
+// a single ID, or a list of IDs. Invalid entries are skipped.
+func RelationIDs(value any) []uint {
+	var ids []uint
+	switch list := value.(type) {
+	case nil:
+	case []any:
+		for _, element := range list {
+			if id := ToUint(element); id != 0 {
+				ids = append(ids, id)
+			}
+		}
+	case []uint:
+		ids = append(ids, list...)
+	case []float64:
+		for _, element := range list {
+			if element > 0 {
+				ids = append(ids, uint(element))
+			}
+		}
+	default:
+		if id := ToUint(value); id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// RelationValue returns the value stored in an item's data for the related item IDs
+// of a relation attribute: a list for to-many relations, a single ID or nil otherwise.
+func RelationValue(attr Attribute, ids []uint) any {
+	if attr.IsToMany() {
+		if ids == nil {
+			return []uint{}
+		}
+		return ids
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids[0]
+}
+
+// ItemReferencedError is returned when deleting an item that another item references
+// through a relation whose delete action is OnDeleteRestrict.
+type ItemReferencedError struct {
+	ItemID       uint
+	SourceItemID uint
+	Collection   string
+	Attribute    string
+}
+
+func (e *ItemReferencedError) Error() string {
+	return fmt.Sprintf("item %d is referenced by '%s' of item %d in '%s'", e.ItemID, e.Attribute, e.SourceItemID, e.Collection)
+}
+
+// SyncItemRelations replaces the stored relations of an item by the related item IDs
+// found in its data. IDs of items missing from the target collection are dropped, from
+// the relations and from the data. It MUST be called within a database transaction,
+// after the item is saved.
+func SyncItemRelations(tx *gorm.DB, ct Collection, item *Item) error {
+	if err := tx.Where("source_item_id = ?", item.ID).Delete(&ItemRelation{}).Error; err != nil {
+		return fmt.Errorf("failed to clear relations of item %d: %w", item.ID, err)
+	}
+
+	var rows []ItemRelation
+	changed := false
+	for _, attr := range ct.Attributes {
+		value, exists := item.Data[attr.Name]
+		if attr.Type != "relation" || !exists {
+			continue
+		}
+
+		ids := RelationIDs(value)
+		existing, err := existingItemIDs(tx, attr.Target, ids)
+		if err != nil {
+			return err
+		}
+		kept := slices.DeleteFunc(slices.Clone(ids), func(id uint) bool { return !existing[id] })
+		if !attr.IsToMany() && len(kept) > 1 {
+			kept = kept[:1]
+		}
+		if len(kept) != len(ids) {
+			item.Data[attr.Name] = RelationValue(attr, kept)
+			changed = true
+		}
+
+		for position, id := range kept {
+			rows = append(rows, ItemRelation{
+				SourceItemID:       item.ID,
+				SourceCollectionID: ct.ID,
+				Attribute:          attr.Name,
+				TargetItemID:       id,
+				Position:           position,
+			})
+		}
+	}
+
+	if len(rows) > 0 {
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to store relations of item %d: %w", item.ID, err)
+		}
+	}
+	if changed {
+		if err := tx.Model(item).UpdateColumn("data", item.Data).Error; err != nil {
+			return fmt.Errorf("failed to update relations of item %d: %w", item.ID, err)
+		}
+	}
+	return nil
+}
+
+// existingItemIDs returns which of ids belong to items of the named collection.
+func existingItemIDs(tx *gorm.DB, collectionName string, ids []uint) (map[uint]bool, error) {
+	existing := make(map[uint]bool, len(ids))
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	var found []uint
+	err := tx.Model(&Item{}).
+		Joins("JOIN collections ON collections.id = items.collection_id").
+		Where("collections.name = ? AND items.id IN ?", collectionName, ids).
+		Pluck("items.id", &found).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check related items in '%s': %w", collectionName, err)
+	}
+	for _, id := range found {
+		existing[id] = true
+	}
+	return existing, nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelationIDsAndValue(t *testing.T) {
+	assert.Nil(t, RelationIDs(nil))
+	assert.Equal(t, []uint{3}, RelationIDs(float64(3)))
+	assert.Equal(t, []uint{1, 2}, RelationIDs([]any{float64(1), "x", 2}))
+	assert.Equal(t, []uint{4, 5}, RelationIDs([]uint{4, 5}))
+
+	toMany := Attribute{Name: "tags", Type: "relation", Relation: "manyToMany"}
+	toOne := Attribute{Name: "author", Type: "relation", Relation: "manyToOne"}
+	assert.Equal(t, []uint{}, RelationValue(toMany, nil))
+	assert.Equal(t, []uint{1, 2}, RelationValue(toMany, []uint{1, 2}))
+	assert.Nil(t, RelationValue(toOne, nil))
+	assert.Equal(t, uint(1), RelationValue(toOne, []uint{1, 2}))
+}
+
+func TestValidateOnDelete(t *testing.T) {
+	assert.Equal(t, OnDeleteSetNull, Attribute{Type: "relation"}.DeleteAction())
+	assert.NoError(t, validateOnDelete(Attribute{Name: "author", Type: "relation", OnDelete: OnDeleteCascade}))
+	assert.ErrorContains(t, validateOnDelete(Attribute{Name: "author", Type: "relation", OnDelete: "drop"}), "invalid on_delete 'drop'")
+	assert.ErrorContains(t, validateOnDelete(Attribute{Name: "title", Type: "text", OnDelete: OnDeleteCascade}), "only applies to relations")
+}
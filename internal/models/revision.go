@@ -0,0 +1,133 @@
+package models
+
+import (
+	"maps"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// Revision actions recorded for every write on an item.
+const (
+	RevisionActionCreate  = "create"
+	RevisionActionUpdate  = "update"
+	RevisionActionDelete  = "delete"
+	RevisionActionRestore = "restore"
+)
+
+// Kinds of actors that can change content.
+const (
+	ActorTypeUser   = "user"
+	ActorTypeAgent  = "agent"
+	ActorTypeSystem = "system"
+)
+
+// Actor identifies who performed a write: a user (by username), an agent (by ID) or an
+// operator command (by name).
+// Role, when known, is used to enforce attribute write rules and is not recorded.
+type Actor struct {
+	Type string    `json:"type"`
+	ID   string    `json:"id"`
+	Role *UserRole `json:"-"`
+}
+
+// WithRole returns a copy of the actor acting with role.
+func (a Actor) WithRole(role *UserRole) Actor {
+	a.Role = role
+	return a
+}
+
+// UserActor returns the actor for a user authenticated by a JWT.
+func UserActor(username string) Actor {
+	return Actor{Type: ActorTypeUser, ID: username}
+}
+
+// AgentActor returns the actor for an agent run.
+func AgentActor(agentID uint) Actor {
+	return Actor{Type: ActorTypeAgent, ID: strconv.FormatUint(uint64(agentID), 10)}
+}
+
+// SystemActor returns the actor for commands run by an operator, such as content imports.
+// It acts with the permissions of the admin role.
+func SystemActor(name string) Actor {
+	return Actor{Type: ActorTypeSystem, ID: name, Role: &UserRole{Name: "admin", Permissions: DefaultRolePermissions["admin"]}}
+}
+
+// ItemRevision is a full snapshot of an item's data after a create, update or delete.
+// Versions are numbered per item, starting at 1.
+type ItemRevision struct {
+	gorm.Model
+	ItemID       uint    `json:"item_id" gorm:"index:idx_item_revision,unique"`
+	CollectionID uint    `json:"collection_id" gorm:"index"`
+	Version      int     `json:"version" gorm:"index:idx_item_revision,unique"`
+	Action       string  `json:"action" gorm:"type:varchar(20)"`
+	ActorType    string  `json:"actor_type" gorm:"type:varchar(20)"`
+	ActorID      string  `json:"actor_id"`
+	RestoredFrom *int    `json:"restored_from,omitempty"`
+	Data         JSONMap `json:"data" gorm:"type:json"`
+}
+
+// NewItemRevision builds the revision recording an action on item.
+// The item data is copied so later changes to the item do not alter the snapshot.
+func NewItemRevision(item Item, action string, actor Actor) ItemRevision {
+	data := make(JSONMap, len(item.Data))
+	maps.Copy(data, item.Data)
+	return ItemRevision{
+		ItemID:       item.ID,
+		CollectionID: item.CollectionID,
+		Action:       action,
+		ActorType:    actor.Type,
+		ActorID:      actor.ID,
+		Data:         data,
+	}
+}
+
+// Field change kinds reported by DiffRevisions.
+const (
+	FieldAdded   = "added"
+	FieldRemoved = "removed"
+	FieldChanged = "changed"
+)
+
+// FieldChange describes how a single field differs between two revisions.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Change string `json:"change"`
+	From   any    `json:"from,omitempty"`
+	To     any    `json:"to,omitempty"`
+}
+
+// DiffRevisions compares the data of two revisions field by field.
+// Changes are sorted by field name; identical fields are omitted.
+func DiffRevisions(from, to ItemRevision) []FieldChange {
+	changes := []FieldChange{}
+
+	fields := make(map[string]struct{}, len(from.Data)+len(to.Data))
+	for field := range from.Data {
+		fields[field] = struct{}{}
+	}
+	for field := range to.Data {
+		fields[field] = struct{}{}
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	for _, field := range names {
+		oldValue, inFrom := from.Data[field]
+		newValue, inTo := to.Data[field]
+		switch {
+		case !inFrom:
+			changes = append(changes, FieldChange{Field: field, Change: FieldAdded, To: newValue})
+		case !inTo:
+			changes = append(changes, FieldChange{Field: field, Change: FieldRemoved, From: oldValue})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, FieldChange{Field: field, Change: FieldChanged, From: oldValue, To: newValue})
+		}
+	}
+	return changes
+}
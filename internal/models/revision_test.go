@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffRevisions(t *testing.T) {
+	from := ItemRevision{Data: JSONMap{"title": "Old", "views": float64(1), "tags": []any{"a"}, "draft_note": "x"}}
+	to := ItemRevision{Data: JSONMap{"title": "New", "views": float64(1), "tags": []any{"a", "b"}, "subtitle": "sub"}}
+
+	assert.Equal(t, []FieldChange{
+		{Field: "draft_note", Change: FieldRemoved, From: "x"},
+		{Field: "subtitle", Change: FieldAdded, To: "sub"},
+		{Field: "tags", Change: FieldChanged, From: []any{"a"}, To: []any{"a", "b"}},
+		{Field: "title", Change: FieldChanged, From: "Old", To: "New"},
+	}, DiffRevisions(from, to))
+
+	assert.Empty(t, DiffRevisions(from, from))
+}
+
+func TestNewItemRevisionCopiesData(t *testing.T) {
+	item := Item{ID: 3, CollectionID: 2, Data: JSONMap{"title": "Hello"}}
+	revision := NewItemRevision(item, RevisionActionUpdate, AgentActor(7))
+
+	item.Data["title"] = "Changed"
+	assert.Equal(t, "Hello", revision.Data["title"])
+	assert.Equal(t, uint(3), revision.ItemID)
+	assert.Equal(t, ActorTypeAgent, revision.ActorType)
+	assert.Equal(t, "7", revision.ActorID)
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Scheduled publication state transitions.
+const (
+	TransitionPublish   = "publish"
+	TransitionUnpublish = "unpublish"
+)
+
+// ItemSchedule holds the times an item or single item is automatically published and
+// unpublished at. A nil time means no transition is scheduled.
+type ItemSchedule struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// Normalize rounds the scheduled times to the second, the precision transitions are
+// matched at.
+func (s ItemSchedule) Normalize() ItemSchedule {
+	round := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		rounded := t.UTC().Truncate(time.Second)
+		return &rounded
+	}
+	return ItemSchedule{PublishAt: round(s.PublishAt), UnpublishAt: round(s.UnpublishAt)}
+}
+
+// ValidateItemSchedule checks that the scheduled times are in the future and that an
+// item is not scheduled to be unpublished before being published.
+func ValidateItemSchedule(schedule ItemSchedule, now time.Time) error {
+	if schedule.PublishAt != nil && !schedule.PublishAt.After(now) {
+		return errors.New("publish_at must be in the future")
+	}
+	if schedule.UnpublishAt != nil && !schedule.UnpublishAt.After(now) {
+		return errors.New("unpublish_at must be in the future")
+	}
+	if schedule.PublishAt != nil && schedule.UnpublishAt != nil && !schedule.UnpublishAt.After(*schedule.PublishAt) {
+		return errors.New("unpublish_at must be after publish_at")
+	}
+	return nil
+}
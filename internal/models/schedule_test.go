@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateItemSchedule(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	evenLater := now.Add(2 * time.Hour)
+	past := now.Add(-time.Minute)
+
+	assert.NoError(t, ValidateItemSchedule(ItemSchedule{}, now))
+	assert.NoError(t, ValidateItemSchedule(ItemSchedule{PublishAt: &later, UnpublishAt: &evenLater}, now))
+	assert.NoError(t, ValidateItemSchedule(ItemSchedule{UnpublishAt: &later}, now))
+
+	assert.Error(t, ValidateItemSchedule(ItemSchedule{PublishAt: &past}, now))
+	assert.Error(t, ValidateItemSchedule(ItemSchedule{UnpublishAt: &past}, now))
+	assert.Error(t, ValidateItemSchedule(ItemSchedule{PublishAt: &evenLater, UnpublishAt: &later}, now))
+}
+
+func TestItemScheduleNormalize(t *testing.T) {
+	at := time.Date(2026, 3, 2, 9, 0, 0, 123456789, time.FixedZone("CET", 3600))
+	normalized := ItemSchedule{PublishAt: &at}.Normalize()
+	assert.Equal(t, time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC), *normalized.PublishAt)
+	assert.Nil(t, normalized.UnpublishAt)
+}
@@ -0,0 +1,90 @@
+package models
+
+// CollectionDefinition returns the definition of a collection in the input format of
+// ParseCollectionInput, the inverse of parsing it.
+func CollectionDefinition(ct Collection) map[string]any {
+	kind := ct.Kind
+	if kind == "" {
+		kind = "collection"
+	}
+	definition := map[string]any{
+		"name":       ct.Name,
+		"kind":       kind,
+		"attributes": attributeDefinitions(ct.Attributes),
+	}
+	if ct.Description != "" {
+		definition["description"] = ct.Description
+	}
+	if len(ct.Locales) > 0 {
+		definition["locales"] = ct.Locales
+		definition["default_locale"] = ct.DefaultLocale
+	}
+	return definition
+}
+
+// SingletonDefinition returns the definition of a singleton in the input format of
+// ParseSingletonInput.
+func SingletonDefinition(st Singleton) map[string]any {
+	definition := map[string]any{
+		"name":       st.Name,
+		"attributes": attributeDefinitions(st.Attributes),
+	}
+	if st.Description != "" {
+		definition["description"] = st.Description
+	}
+	return definition
+}
+
+// ComponentDefinition returns the definition of a component in the input format of
+// ParseComponentInput.
+func ComponentDefinition(cmp Component) map[string]any {
+	attributes := make([]Attribute, 0, len(cmp.Attributes))
+	for _, attr := range cmp.Attributes {
+		attributes = append(attributes, attr.AsAttribute())
+	}
+	definition := map[string]any{
+		"name":       cmp.Name,
+		"attributes": attributeDefinitions(attributes),
+	}
+	if cmp.Description != "" {
+		definition["description"] = cmp.Description
+	}
+	return definition
+}
+
+// attributeDefinitions maps attributes by name to their definition, leaving out unset fields.
+func attributeDefinitions(attributes []Attribute) map[string]any {
+	definitions := make(map[string]any, len(attributes))
+	for _, attr := range attributes {
+		definition := map[string]any{"type": attr.Type}
+		set := func(key string, value any, isSet bool) {
+			if isSet {
+				definition[key] = value
+			}
+		}
+		set("required", attr.Required, attr.Required)
+		set("unique", attr.Unique, attr.Unique)
+		set("options", attr.Options, len(attr.Options) > 0)
+		set("pattern", attr.Pattern, attr.Pattern != "")
+		set("relation", attr.Relation, attr.Relation != "")
+		set("target", attr.Target, attr.Target != "")
+		set("on_delete", attr.OnDelete, attr.OnDelete != "")
+		set("component", attr.ComponentRef, attr.ComponentRef != "")
+		set("repeatable", attr.Repeatable, attr.Repeatable)
+		set("components", attr.Components, len(attr.Components) > 0)
+		set("multiple", attr.Multiple, attr.Multiple)
+		set("localized", attr.Localized, attr.Localized)
+		set("searchable", attr.Searchable, attr.Searchable)
+		set("semantic", attr.Semantic, attr.Semantic)
+		set("read_roles", attr.ReadRoles, len(attr.ReadRoles) > 0)
+		set("write_roles", attr.WriteRoles, len(attr.WriteRoles) > 0)
+		if attr.Min != nil {
+			definition["min"] = *attr.Min
+		}
+		if attr.Max != nil {
+			definition["max"] = *attr.Max
+		}
+		definitions[attr.Name] = definition
+	}
+	return definitions
+}
@@ -0,0 +1,426 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Severities of a schema change, from the way it affects the data of existing items.
+const (
+	// SchemaChangeSafe changes leave existing items valid as they are.
+	SchemaChangeSafe = "safe"
+	// SchemaChangeConversion changes need the data of existing items to be rewritten.
+	SchemaChangeConversion = "conversion"
+	// SchemaChangeDestructive changes drop data or may leave existing items invalid.
+	SchemaChangeDestructive = "destructive"
+)
+
+// Kinds of schema changes.
+const (
+	SchemaChangeAdd        = "add"
+	SchemaChangeRemove     = "remove"
+	SchemaChangeRename     = "rename"
+	SchemaChangeRetype     = "retype"
+	SchemaChangeRequire    = "require"
+	SchemaChangeLocalize   = "localize"
+	SchemaChangeConstraint = "constraint"
+)
+
+// SchemaDirectives tell the planner how the data of existing items follows a schema change.
+type SchemaDirectives struct {
+	// Renames maps the old name of renamed attributes to their new name.
+	Renames map[string]string `json:"renames,omitempty"`
+	// Convert lists the attributes whose values are converted to a new type that may
+	// not represent every value; values that cannot be converted are dropped.
+	Convert []string `json:"convert,omitempty"`
+	// Defaults holds the value written to items missing a value for an attribute.
+	Defaults map[string]any `json:"defaults,omitempty"`
+}
+
+// ParseSchemaDirectives reads the optional directives of a collection update.
+func ParseSchemaDirectives(raw any) (SchemaDirectives, error) {
+	var directives SchemaDirectives
+	if raw == nil {
+		return directives, nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return directives, fmt.Errorf("invalid field 'directives': %v", err)
+	}
+	if err := json.Unmarshal(encoded, &directives); err != nil {
+		return directives, fmt.Errorf("invalid field 'directives': %v", err)
+	}
+	return directives, nil
+}
+
+// SchemaChange is a single change between the current and proposed schema of a collection.
+type SchemaChange struct {
+	Kind      string `json:"kind"`
+	Attribute string `json:"attribute"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+}
+
+// SchemaPlan lists the changes of a collection update and how the data of existing
+// items is migrated to the proposed schema.
+type SchemaPlan struct {
+	Collection    string           `json:"collection"`
+	Changes       []SchemaChange   `json:"changes"`
+	Directives    SchemaDirectives `json:"directives"`
+	DefaultLocale string           `json:"default_locale,omitempty"`
+
+	// Proposed holds the attributes of the proposed schema, used to check migrated data.
+	Proposed []Attribute `json:"-"`
+}
+
+// Destructive reports whether the plan drops data or may leave items invalid.
+func (p SchemaPlan) Destructive() bool {
+	return slices.ContainsFunc(p.Changes, func(c SchemaChange) bool { return c.Severity == SchemaChangeDestructive })
+}
+
+// RewritesData reports whether applying the plan rewrites the data of existing items.
+func (p SchemaPlan) RewritesData() bool {
+	return slices.ContainsFunc(p.Changes, func(c SchemaChange) bool {
+		switch c.Kind {
+		case SchemaChangeRemove, SchemaChangeRename, SchemaChangeRetype, SchemaChangeLocalize:
+			return true
+		case SchemaChangeAdd, SchemaChangeRequire:
+			return c.Severity == SchemaChangeConversion
+		}
+		return false
+	})
+}
+
+// lossless type families: values convert without loss between types of a family, and
+// from any scalar type to a text type.
+var (
+	textTypes   = []string{"string", "text", "richtext", "email", "uid", "enum", "enumeration"}
+	scalarTypes = append([]string{"int", "integer", "float", "decimal", "bool", "boolean", "date", "datetime", "time"}, textTypes...)
+	typeAliases = map[string]string{"integer": "int", "decimal": "float", "boolean": "bool", "enumeration": "enum"}
+)
+
+// canonicalType maps the aliases of a type to a single name.
+func canonicalType(t string) string {
+	if alias, ok := typeAliases[t]; ok {
+		return alias
+	}
+	return t
+}
+
+// losslessConversion reports whether every value of type from converts to type to.
+func losslessConversion(from, to string) bool {
+	from, to = canonicalType(from), canonicalType(to)
+	switch {
+	case from == to:
+		return true
+	case slices.Contains(textTypes, to) && slices.Contains(scalarTypes, from) && to != "enum":
+		return true
+	case from == "int" && to == "float":
+		return true
+	}
+	return false
+}
+
+// PlanSchemaChange computes the changes from the current to the proposed schema of a
+// collection and classifies them. Attributes missing from the proposed schema are renamed
+// when directives say so, and removed otherwise.
+func PlanSchemaChange(current, proposed Collection, directives SchemaDirectives) (SchemaPlan, error) {
+	plan := SchemaPlan{
+		Collection:    current.Name,
+		Changes:       []SchemaChange{},
+		Directives:    directives,
+		DefaultLocale: proposed.DefaultLocale,
+		Proposed:      proposed.Attributes,
+	}
+
+	currentAttrs := make(map[string]Attribute, len(current.Attributes))
+	for _, attr := range current.Attributes {
+		currentAttrs[attr.Name] = attr
+	}
+	proposedAttrs := make(map[string]Attribute, len(proposed.Attributes))
+	for _, attr := range proposed.Attributes {
+		proposedAttrs[attr.Name] = attr
+	}
+
+	// Renamed attributes are compared with the attribute they are renamed from.
+	renamedFrom := make(map[string]string, len(directives.Renames))
+	for _, oldName := range slices.Sorted(maps.Keys(directives.Renames)) {
+		newName := directives.Renames[oldName]
+		if _, exists := currentAttrs[oldName]; !exists {
+			return plan, fmt.Errorf("cannot rename unknown attribute '%s'", oldName)
+		}
+		if _, exists := proposedAttrs[newName]; !exists {
+			return plan, fmt.Errorf("cannot rename attribute '%s' to '%s': '%s' is not in the proposed schema", oldName, newName, newName)
+		}
+		if _, exists := proposedAttrs[oldName]; exists {
+			return plan, fmt.Errorf("cannot rename attribute '%s': it is still in the proposed schema", oldName)
+		}
+		if _, exists := currentAttrs[newName]; exists {
+			return plan, fmt.Errorf("cannot rename attribute '%s' to '%s': '%s' already exists", oldName, newName, newName)
+		}
+		if _, taken := renamedFrom[newName]; taken {
+			return plan, fmt.Errorf("cannot rename several attributes to '%s'", newName)
+		}
+		renamedFrom[newName] = oldName
+		plan.Changes = append(plan.Changes, SchemaChange{
+			Kind: SchemaChangeRename, Attribute: newName, From: oldName, To: newName,
+			Severity: SchemaChangeConversion,
+			Message:  fmt.Sprintf("values of '%s' are moved to '%s'", oldName, newName),
+		})
+	}
+	for _, name := range directives.Convert {
+		if _, exists := proposedAttrs[name]; !exists {
+			return plan, fmt.Errorf("cannot convert unknown attribute '%s'", name)
+		}
+	}
+	for name := range directives.Defaults {
+		if _, exists := proposedAttrs[name]; !exists {
+			return plan, fmt.Errorf("cannot set a default for unknown attribute '%s'", name)
+		}
+	}
+
+	for _, attr := range current.Attributes {
+		if _, kept := proposedAttrs[attr.Name]; kept {
+			continue
+		}
+		if _, renamed := directives.Renames[attr.Name]; renamed {
+			continue
+		}
+		plan.Changes = append(plan.Changes, SchemaChange{
+			Kind: SchemaChangeRemove, Attribute: attr.Name, From: attr.Type,
+			Severity: SchemaChangeDestructive,
+			Message:  fmt.Sprintf("values of '%s' are dropped", attr.Name),
+		})
+	}
+
+	for _, attr := range proposed.Attributes {
+		name := attr.Name
+		if oldName, renamed := renamedFrom[name]; renamed {
+			name = oldName
+		}
+		old, exists := currentAttrs[name]
+		_, hasDefault := directives.Defaults[attr.Name]
+
+		if !exists {
+			change := SchemaChange{Kind: SchemaChangeAdd, Attribute: attr.Name, To: attr.Type, Severity: SchemaChangeSafe,
+				Message: fmt.Sprintf("'%s' is added", attr.Name)}
+			switch {
+			case hasDefault:
+				change.Severity = SchemaChangeConversion
+				change.Message = fmt.Sprintf("'%s' is added and set to its default on existing items", attr.Name)
+			case attr.Required:
+				change.Severity = SchemaChangeDestructive
+				change.Message = fmt.Sprintf("'%s' is required but existing items have no value for it", attr.Name)
+			}
+			plan.Changes = append(plan.Changes, change)
+			continue
+		}
+
+		if old.Type != attr.Type {
+			change := SchemaChange{Kind: SchemaChangeRetype, Attribute: attr.Name, From: old.Type, To: attr.Type}
+			switch {
+			case losslessConversion(old.Type, attr.Type):
+				change.Severity = SchemaChangeConversion
+				change.Message = fmt.Sprintf("values of '%s' are converted from %s to %s", attr.Name, old.Type, attr.Type)
+			case slices.Contains(directives.Convert, attr.Name):
+				change.Severity = SchemaChangeDestructive
+				change.Message = fmt.Sprintf("values of '%s' are converted from %s to %s, values that cannot be converted are dropped", attr.Name, old.Type, attr.Type)
+			default:
+				change.Severity = SchemaChangeDestructive
+				change.Message = fmt.Sprintf("values of '%s' are dropped: %s does not convert to %s without a 'convert' directive", attr.Name, old.Type, attr.Type)
+			}
+			plan.Changes = append(plan.Changes, change)
+		}
+
+		if old.Localized != attr.Localized {
+			change := SchemaChange{Kind: SchemaChangeLocalize, Attribute: attr.Name, Severity: SchemaChangeConversion,
+				Message: fmt.Sprintf("values of '%s' are moved into the default locale", attr.Name)}
+			if !attr.Localized {
+				change.Severity = SchemaChangeDestructive
+				change.Message = fmt.Sprintf("values of '%s' keep their default locale, other translations are dropped", attr.Name)
+			}
+			plan.Changes = append(plan.Changes, change)
+		}
+
+		if attr.Required && !old.Required {
+			change := SchemaChange{Kind: SchemaChangeRequire, Attribute: attr.Name, Severity: SchemaChangeDestructive,
+				Message: fmt.Sprintf("'%s' becomes required, existing items without a value become invalid", attr.Name)}
+			if hasDefault {
+				change.Severity = SchemaChangeConversion
+				change.Message = fmt.Sprintf("'%s' becomes required and is set to its default on items without a value", attr.Name)
+			}
+			plan.Changes = append(plan.Changes, change)
+		}
+
+		if message := tightenedConstraint(old, attr); message != "" {
+			plan.Changes = append(plan.Changes, SchemaChange{
+				Kind: SchemaChangeConstraint, Attribute: attr.Name, Severity: SchemaChangeDestructive, Message: message,
+			})
+		}
+	}
+
+	return plan, nil
+}
+
+// tightenedConstraint describes a change of the constraints of an attribute that existing
+// values may no longer satisfy, or returns an empty string.
+func tightenedConstraint(old, updated Attribute) string {
+	switch {
+	case updated.Pattern != "" && updated.Pattern != old.Pattern:
+		return fmt.Sprintf("values of '%s' must match the new pattern", updated.Name)
+	case updated.Min != nil && (old.Min == nil || *updated.Min > *old.Min):
+		return fmt.Sprintf("values of '%s' must be at least %d", updated.Name, *updated.Min)
+	case updated.Max != nil && (old.Max == nil || *updated.Max < *old.Max):
+		return fmt.Sprintf("values of '%s' must be at most %d", updated.Name, *updated.Max)
+	case len(old.Options) > 0 && slices.ContainsFunc(old.Options, func(o string) bool { return !slices.Contains(updated.Options, o) }):
+		return fmt.Sprintf("options were removed from '%s'", updated.Name)
+	case updated.Type == "relation" && (old.Target != updated.Target || old.Relation != updated.Relation):
+		return fmt.Sprintf("relation '%s' changes its target or kind", updated.Name)
+	case updated.Type == "component" && (old.ComponentRef != updated.ComponentRef || old.Repeatable != updated.Repeatable):
+		return fmt.Sprintf("component '%s' changes its component or repeatability", updated.Name)
+	case updated.Unique && !old.Unique:
+		return fmt.Sprintf("values of '%s' must be unique", updated.Name)
+	}
+	return ""
+}
+
+// MigrateData rewrites the data of an item to the proposed schema of the plan. It returns
+// the migrated data and the names of the attributes whose values were dropped.
+func (p SchemaPlan) MigrateData(data JSONMap) (JSONMap, []string) {
+	migrated := make(JSONMap, len(data))
+	maps.Copy(migrated, data)
+	var dropped []string
+
+	for _, change := range p.Changes {
+		switch change.Kind {
+		case SchemaChangeRename:
+			if value, exists := migrated[change.From]; exists {
+				migrated[change.To] = value
+				delete(migrated, change.From)
+			}
+		case SchemaChangeRemove:
+			if _, exists := migrated[change.Attribute]; exists {
+				delete(migrated, change.Attribute)
+				dropped = append(dropped, change.Attribute)
+			}
+		}
+	}
+
+	for _, change := range p.Changes {
+		value, exists := migrated[change.Attribute]
+		switch change.Kind {
+		case SchemaChangeRetype:
+			if !exists || value == nil {
+				continue
+			}
+			converted, ok := p.convertValue(change, value)
+			if !ok {
+				delete(migrated, change.Attribute)
+				dropped = append(dropped, change.Attribute)
+				continue
+			}
+			migrated[change.Attribute] = converted
+		case SchemaChangeLocalize:
+			if !exists || value == nil {
+				continue
+			}
+			translations, isMap := value.(map[string]any)
+			if change.Severity == SchemaChangeConversion && !isMap {
+				migrated[change.Attribute] = map[string]any{p.DefaultLocale: value}
+			} else if change.Severity == SchemaChangeDestructive && isMap {
+				migrated[change.Attribute] = translations[p.DefaultLocale]
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(p.Directives.Defaults)) {
+		if value, exists := migrated[name]; !exists || value == nil {
+			migrated[name] = p.Directives.Defaults[name]
+		}
+	}
+	return migrated, dropped
+}
+
+// convertValue converts a value to the new type of a retyped attribute. Localized values
+// are converted translation by translation.
+func (p SchemaPlan) convertValue(change SchemaChange, value any) (any, bool) {
+	if change.Severity == SchemaChangeDestructive && !slices.Contains(p.Directives.Convert, change.Attribute) {
+		return nil, false
+	}
+	if translations, ok := value.(map[string]any); ok && p.localized(change.Attribute) {
+		converted := make(map[string]any, len(translations))
+		for locale, translation := range translations {
+			v, ok := convertScalar(translation, change.To)
+			if !ok {
+				return nil, false
+			}
+			converted[locale] = v
+		}
+		return converted, true
+	}
+	return convertScalar(value, change.To)
+}
+
+// localized reports whether an attribute of the proposed schema is localized.
+func (p SchemaPlan) localized(name string) bool {
+	return slices.ContainsFunc(p.Proposed, func(a Attribute) bool { return a.Name == name && a.Localized })
+}
+
+// convertScalar converts a scalar value to the given attribute type.
+func convertScalar(value any, to string) (any, bool) {
+	switch canonicalType(to) {
+	case "int":
+		v, err := convertToType(value, "int")
+		return v, err == nil
+	case "float":
+		v, err := convertToType(value, "float")
+		return v, err == nil
+	case "bool":
+		v, err := convertToType(value, "bool")
+		return v, err == nil
+	case "date", "datetime":
+		if _, err := convertToType(value, canonicalType(to)); err != nil {
+			return nil, false
+		}
+		return value, true
+	default:
+		if slices.Contains(textTypes, to) || to == "time" {
+			switch value.(type) {
+			case map[string]any, []any:
+				return nil, false
+			}
+			v, _ := convertToType(value, "text")
+			return v, true
+		}
+		return nil, false
+	}
+}
+
+// ValidateMigratedData checks the migrated data of an item against the proposed schema of
+// the plan. Only scalar attributes are checked: relations, media and components are left
+// to the next save of the item.
+func (p SchemaPlan) ValidateMigratedData(data JSONMap) error {
+	for _, attr := range p.Proposed {
+		value, exists := data[attr.Name]
+		if attr.Required && (!exists || value == nil) {
+			return fmt.Errorf("missing required attribute: '%s'", attr.Name)
+		}
+		if !exists || value == nil || !slices.Contains(scalarTypes, attr.Type) {
+			continue
+		}
+		values := []any{value}
+		if translations, ok := value.(map[string]any); ok && attr.Localized {
+			values = slices.Collect(maps.Values(translations))
+		}
+		for _, v := range values {
+			if err := validateAttributeValue(attr, v); err != nil {
+				return fmt.Errorf("invalid value for '%s': %w", attr.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func changesByKind(plan SchemaPlan) map[string]SchemaChange {
+	changes := make(map[string]SchemaChange, len(plan.Changes))
+	for _, change := range plan.Changes {
+		changes[change.Kind+":"+change.Attribute] = change
+	}
+	return changes
+}
+
+func TestPlanSchemaChange(t *testing.T) {
+	current := Collection{Name: "articles", Attributes: []Attribute{
+		{Name: "title", Type: "text", Required: true},
+		{Name: "views", Type: "int"},
+		{Name: "rating", Type: "text"},
+		{Name: "legacy", Type: "text"},
+	}}
+	proposed := Collection{Name: "articles", Attributes: []Attribute{
+		{Name: "headline", Type: "text", Required: true},
+		{Name: "views", Type: "string"},
+		{Name: "rating", Type: "int"},
+		{Name: "summary", Type: "text"},
+		{Name: "category", Type: "text", Required: true},
+	}}
+
+	t.Run("Without directives", func(t *testing.T) {
+		plan, err := PlanSchemaChange(current, proposed, SchemaDirectives{})
+		assert.NoError(t, err)
+		changes := changesByKind(plan)
+
+		assert.Equal(t, SchemaChangeDestructive, changes["remove:title"].Severity, "an attribute missing from the proposed schema is removed")
+		assert.Equal(t, SchemaChangeDestructive, changes["add:headline"].Severity, "a required attribute has no value on existing items")
+		assert.Equal(t, SchemaChangeConversion, changes["retype:views"].Severity)
+		assert.Equal(t, SchemaChangeDestructive, changes["retype:rating"].Severity)
+		assert.Equal(t, SchemaChangeSafe, changes["add:summary"].Severity)
+		assert.True(t, plan.Destructive())
+	})
+
+	t.Run("With directives", func(t *testing.T) {
+		plan, err := PlanSchemaChange(current, proposed, SchemaDirectives{
+			Renames:  map[string]string{"title": "headline"},
+			Convert:  []string{"rating"},
+			Defaults: map[string]any{"category": "news"},
+		})
+		assert.NoError(t, err)
+		changes := changesByKind(plan)
+
+		assert.Equal(t, SchemaChangeConversion, changes["rename:headline"].Severity)
+		assert.NotContains(t, changes, "remove:title")
+		assert.NotContains(t, changes, "add:headline")
+		assert.Equal(t, SchemaChangeConversion, changes["add:category"].Severity)
+		assert.Equal(t, SchemaChangeDestructive, changes["remove:legacy"].Severity)
+		assert.True(t, plan.RewritesData())
+
+		migrated, dropped := plan.MigrateData(JSONMap{"title": "Hello", "views": 12.0, "rating": "4", "legacy": "x"})
+		assert.Equal(t, JSONMap{"headline": "Hello", "views": "12", "rating": 4, "category": "news"}, migrated)
+		assert.Equal(t, []string{"legacy"}, dropped)
+		assert.NoError(t, plan.ValidateMigratedData(migrated))
+
+		migrated, dropped = plan.MigrateData(JSONMap{"title": "Hello", "rating": "great"})
+		assert.NotContains(t, migrated, "rating", "values that cannot be converted are dropped")
+		assert.Equal(t, []string{"rating"}, dropped)
+
+		again, _ := plan.MigrateData(migrated)
+		assert.Equal(t, migrated, again, "migrating data twice leaves it unchanged")
+	})
+
+	t.Run("Invalid directives", func(t *testing.T) {
+		_, err := PlanSchemaChange(current, proposed, SchemaDirectives{Renames: map[string]string{"missing": "headline"}})
+		assert.ErrorContains(t, err, "cannot rename unknown attribute 'missing'")
+
+		_, err = PlanSchemaChange(current, proposed, SchemaDirectives{Renames: map[string]string{"title": "views"}})
+		assert.ErrorContains(t, err, "already exists")
+
+		_, err = PlanSchemaChange(current, proposed, SchemaDirectives{Defaults: map[string]any{"missing": 1}})
+		assert.ErrorContains(t, err, "cannot set a default for unknown attribute 'missing'")
+	})
+
+	t.Run("Localize", func(t *testing.T) {
+		localized := Collection{Name: "articles", Locales: []string{"en", "fr"}, DefaultLocale: "en", Attributes: []Attribute{
+			{Name: "title", Type: "text", Localized: true},
+		}}
+		plain := Collection{Name: "articles", Attributes: []Attribute{{Name: "title", Type: "text"}}}
+
+		plan, err := PlanSchemaChange(plain, localized, SchemaDirectives{})
+		assert.NoError(t, err)
+		migrated, _ := plan.MigrateData(JSONMap{"title": "Hello"})
+		assert.Equal(t, map[string]any{"en": "Hello"}, migrated["title"])
+
+		plan, err = PlanSchemaChange(localized, Collection{Name: "articles", DefaultLocale: "en", Attributes: plain.Attributes}, SchemaDirectives{})
+		assert.NoError(t, err)
+		migrated, _ = plan.MigrateData(JSONMap{"title": map[string]any{"en": "Hello", "fr": "Bonjour"}})
+		assert.Equal(t, "Hello", migrated["title"])
+	})
+}
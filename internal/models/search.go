@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// searchableTypes lists the attribute types whose values are text the search index can hold.
+var searchableTypes = map[string]bool{
+	"string": true, "text": true, "richtext": true, "email": true, "uid": true,
+	"enum": true, "enumeration": true,
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// angleBrackets are removed from indexed text, so excerpts of it shown in search results
+// carry no markup.
+var angleBrackets = strings.NewReplacer("<", " ", ">", " ")
+
+// HasSearchableAttributes reports whether any attribute of the collection is searchable.
+func (c *Collection) HasSearchableAttributes() bool {
+	return slices.ContainsFunc(c.Attributes, func(attr Attribute) bool { return attr.Searchable })
+}
+
+// HasSemanticAttributes reports whether any attribute of the collection is semantic.
+func (c *Collection) HasSemanticAttributes() bool {
+	return slices.ContainsFunc(c.Attributes, func(attr Attribute) bool { return attr.Semantic })
+}
+
+// SearchText returns the text of the searchable attributes of an item, one value per
+// line. Every translation of localized attributes is included and markup is stripped.
+func (c *Collection) SearchText(data JSONMap) string {
+	return c.attributesText(data, func(attr Attribute) bool { return attr.Searchable })
+}
+
+// SemanticText returns the text of the semantic attributes of an item, in the format
+// of SearchText.
+func (c *Collection) SemanticText(data JSONMap) string {
+	return c.attributesText(data, func(attr Attribute) bool { return attr.Semantic })
+}
+
+func (c *Collection) attributesText(data JSONMap, include func(Attribute) bool) string {
+	var lines []string
+	for _, attr := range c.Attributes {
+		if !include(attr) {
+			continue
+		}
+		value, exists := data[attr.Name]
+		if !exists {
+			continue
+		}
+
+		var values []string
+		if attr.Localized {
+			translations := c.translations(value)
+			locales := make([]string, 0, len(translations))
+			for locale := range translations {
+				locales = append(locales, locale)
+			}
+			sort.Strings(locales)
+			for _, locale := range locales {
+				if s, ok := translations[locale].(string); ok {
+					values = append(values, s)
+				}
+			}
+		} else if s, ok := value.(string); ok {
+			values = append(values, s)
+		}
+
+		for _, s := range values {
+			if attr.Type == "richtext" {
+				s = html.UnescapeString(htmlTagPattern.ReplaceAllString(s, " "))
+			}
+			if s = strings.TrimSpace(angleBrackets.Replace(s)); s != "" {
+				lines = append(lines, s)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validateSearchable checks the searchable and semantic attributes of a collection.
+// Search results show excerpts of the indexed text, so attributes hidden from some roles
+// cannot be indexed.
+func validateSearchable(ct Collection) error {
+	for _, attr := range ct.Attributes {
+		for _, flag := range []struct {
+			set  bool
+			name string
+		}{{attr.Searchable, "searchable"}, {attr.Semantic, "semantic"}} {
+			if !flag.set {
+				continue
+			}
+			if !searchableTypes[attr.Type] {
+				return fmt.Errorf("attribute '%s' of type '%s' cannot be %s", attr.Name, attr.Type, flag.name)
+			}
+			if len(attr.ReadRoles) > 0 {
+				return fmt.Errorf("attribute '%s' cannot be both %s and restricted to read roles", attr.Name, flag.name)
+			}
+		}
+	}
+	return nil
+}
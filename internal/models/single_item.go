@@ -3,6 +3,7 @@ package models
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gohead-cms/gohead/pkg/database"
 	"github.com/gohead-cms/gohead/pkg/logger"
@@ -13,9 +14,19 @@ import (
 
 type SingleItem struct {
 	gorm.Model
-	SingleTypeID uint      `json:"single_type_id" gorm:"uniqueIndex"`
-	SingleType   Singleton `json:"-" gorm:"constraint:OnDelete:CASCADE;foreignKey:SingleTypeID;references:ID"`
-	Data         JSONMap   `json:"data" gorm:"type:json"`
+	SingleTypeID uint       `json:"single_type_id" gorm:"uniqueIndex"`
+	SingleType   Singleton  `json:"-" gorm:"constraint:OnDelete:CASCADE;foreignKey:SingleTypeID;references:ID"`
+	Status       string     `json:"status" gorm:"type:varchar(20);index"`
+	PublishedAt  *time.Time `json:"published_at"`
+	PublishAt    *time.Time `json:"publish_at,omitempty" gorm:"index"`
+	UnpublishAt  *time.Time `json:"unpublish_at,omitempty" gorm:"index"`
+	Version      int        `json:"version" gorm:"not null;default:1"`
+	Data         JSONMap    `json:"data" gorm:"type:json"`
+}
+
+// IsPublished reports whether the single item is visible to readers.
+func (i *SingleItem) IsPublished() bool {
+	return i.Status == ItemStatusPublished
 }
 
 // ValidateSingleItemValues validates a single item's data against the SingleType's schema (attributes).
@@ -53,6 +64,10 @@ func ValidateSingleItemValues(st Singleton, itemData map[string]any) error {
 					Warn("Validation failed for relationship")
 				return fmt.Errorf("validation failed for relationship '%s': %w", attribute.Name, err)
 			}
+		} else if attribute.Type == "media" {
+			if _, err := validateMedia(attribute, value, database.DB); err != nil {
+				return fmt.Errorf("validation failed for attribute '%s': %w", attribute.Name, err)
+			}
 		} else {
 			if err := validateAttributeValue(attribute, value); err != nil {
 				logger.Log.WithFields(logrus.Fields{
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// VersionConflictError is returned when a write expected another version of an item or
+// single item than the stored one: it was changed by someone else in the meantime.
+type VersionConflictError struct {
+	Expected int
+	Current  int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("version conflict: expected version %d, but the current version is %d", e.Expected, e.Current)
+}
+
+// CheckVersion returns a *VersionConflictError when an expected version is given and
+// differs from the current one.
+func CheckVersion(expected *int, current int) error {
+	if expected != nil && *expected != current {
+		return &VersionConflictError{Expected: *expected, Current: current}
+	}
+	return nil
+}
+
+// BeforeCreate starts the version of a new item at 1.
+func (i *Item) BeforeCreate(tx *gorm.DB) error {
+	if i.Version == 0 {
+		i.Version = 1
+	}
+	return nil
+}
+
+// BeforeUpdate bumps the version of an item on every write. Updates of many items at
+// once, without a loaded item, keep their versions.
+func (i *Item) BeforeUpdate(tx *gorm.DB) error {
+	if i.ID == 0 {
+		return nil
+	}
+	i.Version++
+	tx.Statement.SetColumn("version", i.Version)
+	return nil
+}
+
+// BeforeCreate starts the version of a new single item at 1.
+func (i *SingleItem) BeforeCreate(tx *gorm.DB) error {
+	if i.Version == 0 {
+		i.Version = 1
+	}
+	return nil
+}
+
+// BeforeUpdate bumps the version of a single item on every write.
+func (i *SingleItem) BeforeUpdate(tx *gorm.DB) error {
+	if i.ID == 0 {
+		return nil
+	}
+	i.Version++
+	tx.Statement.SetColumn("version", i.Version)
+	return nil
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckVersion(t *testing.T) {
+	assert.NoError(t, CheckVersion(nil, 3))
+
+	expected := 3
+	assert.NoError(t, CheckVersion(&expected, 3))
+
+	var conflict *VersionConflictError
+	require.ErrorAs(t, CheckVersion(&expected, 4), &conflict)
+	assert.Equal(t, 3, conflict.Expected)
+	assert.Equal(t, 4, conflict.Current)
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/agent/events"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEventWildcard subscribes a webhook to every event.
+const WebhookEventWildcard = "*"
+
+// Statuses of a webhook delivery.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// webhookEvents are the events a webhook can subscribe to.
+var webhookEvents = []events.EventType{
+	events.EventTypeCollectionCreated,
+	events.EventTypeCollectionUpdated,
+	events.EventTypeCollectionDeleted,
+	events.EventTypeItemCreated,
+	events.EventTypeItemUpdated,
+	events.EventTypeItemDeleted,
+	events.EventTypeItemRestored,
+	events.EventTypeItemPublished,
+	events.EventTypeItemUnpublished,
+}
+
+// reservedWebhookHeaders are set on every delivery and cannot be overridden.
+var reservedWebhookHeaders = []string{
+	"Content-Type",
+	"User-Agent",
+	"X-Gohead-Event",
+	"X-Gohead-Delivery",
+	"X-Gohead-Timestamp",
+	"X-Gohead-Signature",
+}
+
+// Webhook is an HTTP endpoint notified of content events. Deliveries are signed
+// with the secret, see the webhooks package.
+type Webhook struct {
+	gorm.Model
+	ID   uint   `json:"id"`
+	Name string `json:"name" gorm:"uniqueIndex;size:191"`
+	URL  string `json:"url"`
+	// Collections restricts the webhook to events of these collections. Empty matches all.
+	Collections []string          `json:"collections,omitempty" gorm:"serializer:json"`
+	Events      []string          `json:"events" gorm:"serializer:json"`
+	Secret      string            `json:"secret,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty" gorm:"serializer:json"`
+	Enabled     bool              `json:"enabled"`
+}
+
+// WebhookDelivery records the delivery of an event to a webhook: the payload sent
+// and the response to the last attempt.
+type WebhookDelivery struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	WebhookID    uint       `json:"webhook_id" gorm:"not null;index"`
+	Event        string     `json:"event" gorm:"type:varchar(50)"`
+	Payload      JSONMap    `json:"payload" gorm:"type:json"`
+	Status       string     `json:"status" gorm:"type:varchar(20);index"`
+	Attempts     int        `json:"attempts"`
+	StatusCode   int        `json:"status_code,omitempty"`
+	ResponseBody string     `json:"response_body,omitempty" gorm:"type:text"`
+	Error        string     `json:"error,omitempty" gorm:"type:text"`
+	DurationMs   int64      `json:"duration_ms,omitempty"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+	// RedeliveryOf is the delivery this one was manually created from.
+	RedeliveryOf *uint `json:"redelivery_of,omitempty"`
+}
+
+// ValidateWebhook checks the URL, events and headers of a webhook.
+func ValidateWebhook(webhook Webhook) error {
+	if strings.TrimSpace(webhook.Name) == "" {
+		return errors.New("webhook name is required")
+	}
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("invalid webhook URL '%s', expected an absolute http or https URL", webhook.URL)
+	}
+	if len(webhook.Events) == 0 {
+		return errors.New("at least one event is required for the webhook")
+	}
+	for _, event := range webhook.Events {
+		if event != WebhookEventWildcard && !slices.Contains(webhookEvents, events.EventType(event)) {
+			return fmt.Errorf("unknown webhook event '%s'", event)
+		}
+	}
+	for name := range webhook.Headers {
+		if slices.Contains(reservedWebhookHeaders, http.CanonicalHeaderKey(name)) {
+			return fmt.Errorf("header '%s' is set by the delivery and cannot be overridden", name)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the webhook is notified of an event of a collection.
+func (w *Webhook) Matches(collection, event string) bool {
+	if !w.Enabled {
+		return false
+	}
+	if len(w.Collections) > 0 && !slices.Contains(w.Collections, collection) {
+		return false
+	}
+	return slices.Contains(w.Events, WebhookEventWildcard) || slices.Contains(w.Events, event)
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWebhook(t *testing.T) {
+	valid := Webhook{Name: "site-builder", URL: "https://example.com/hooks", Events: []string{"item:published"}}
+	assert.NoError(t, ValidateWebhook(valid))
+
+	cases := map[string]func(w *Webhook){
+		"missing name":    func(w *Webhook) { w.Name = " " },
+		"relative URL":    func(w *Webhook) { w.URL = "/hooks" },
+		"unsupported URL": func(w *Webhook) { w.URL = "ftp://example.com" },
+		"no events":       func(w *Webhook) { w.Events = nil },
+		"unknown event":   func(w *Webhook) { w.Events = []string{"item:archived"} },
+		"reserved header": func(w *Webhook) { w.Headers = map[string]string{"x-gohead-signature": "forged"} },
+		"content type":    func(w *Webhook) { w.Headers = map[string]string{"content-type": "text/plain"} },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			webhook := valid
+			mutate(&webhook)
+			assert.Error(t, ValidateWebhook(webhook))
+		})
+	}
+
+	valid.Events = []string{WebhookEventWildcard}
+	valid.Headers = map[string]string{"Authorization": "Bearer token"}
+	assert.NoError(t, ValidateWebhook(valid))
+}
+
+func TestWebhookMatches(t *testing.T) {
+	webhook := Webhook{Enabled: true, Collections: []string{"articles"}, Events: []string{"item:published", "item:unpublished"}}
+	assert.True(t, webhook.Matches("articles", "item:published"))
+	assert.False(t, webhook.Matches("articles", "item:created"))
+	assert.False(t, webhook.Matches("pages", "item:published"))
+
+	webhook.Collections = nil
+	webhook.Events = []string{WebhookEventWildcard}
+	assert.True(t, webhook.Matches("pages", "collection:updated"))
+
+	webhook.Enabled = false
+	assert.False(t, webhook.Matches("pages", "collection:updated"))
+}
@@ -25,6 +25,40 @@ type LLMConfig struct {
 	APISecret string `mapstructure:"api_secret" yaml:"api_secret"`
 }
 
+// EmbeddingsConfig holds settings for the embedding model of semantic search. An empty
+// provider disables semantic search.
+type EmbeddingsConfig struct {
+	Provider  string `mapstructure:"provider" yaml:"provider"` // "openai", "ollama" or "fake" (tests only)
+	Model     string `mapstructure:"model" yaml:"model"`
+	APIKey    string `mapstructure:"api_key" yaml:"api_key"`
+	ServerURL string `mapstructure:"server_url" yaml:"server_url"` // Ollama server, the local default when empty
+}
+
+// TrashConfig holds settings for the trash of deleted items.
+type TrashConfig struct {
+	RetentionDays int `mapstructure:"retention_days" yaml:"retention_days"` // zero keeps deleted items until purged by hand
+}
+
+// WebhooksConfig holds settings for the deliveries of webhooks.
+type WebhooksConfig struct {
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks" yaml:"allow_private_networks"` // lets deliveries reach loopback, private and link-local addresses
+}
+
+// PersistedQueriesConfig holds settings for the GraphQL queries clients send by hash.
+type PersistedQueriesConfig struct {
+	Store     string `mapstructure:"store" yaml:"store"`           // "database", "redis" or "none"
+	AllowList bool   `mapstructure:"allow_list" yaml:"allow_list"` // only manifest queries, except for roles managing GraphQL
+	TTLHours  int    `mapstructure:"ttl_hours" yaml:"ttl_hours"`   // expiry of the queries registered by clients, zero keeps them
+}
+
+// GraphQLConfig holds settings for the GraphQL API: the limits of the operations executed
+// and the persisted queries.
+type GraphQLConfig struct {
+	MaxDepth         int                    `mapstructure:"max_depth" yaml:"max_depth"`           // zero disables the limit
+	MaxComplexity    int                    `mapstructure:"max_complexity" yaml:"max_complexity"` // zero disables the limit
+	PersistedQueries PersistedQueriesConfig `mapstructure:"persisted_queries" yaml:"persisted_queries"`
+}
+
 // RedisConfig holds settings for the Redis connection.
 type RedisConfig struct {
 	Address  string `mapstructure:"address" yaml:"address"`
@@ -32,6 +66,41 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db" yaml:"db"`
 }
 
+// LocalMediaConfig holds settings for storing uploads on the local filesystem.
+type LocalMediaConfig struct {
+	Directory string `mapstructure:"directory" yaml:"directory"`
+	BaseURL   string `mapstructure:"base_url" yaml:"base_url"` // URL path the directory is served under
+}
+
+// S3MediaConfig holds settings for storing uploads in an S3-compatible bucket.
+type S3MediaConfig struct {
+	Endpoint        string `mapstructure:"endpoint" yaml:"endpoint"` // e.g. https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+	Region          string `mapstructure:"region" yaml:"region"`
+	Bucket          string `mapstructure:"bucket" yaml:"bucket"`
+	AccessKeyID     string `mapstructure:"access_key_id" yaml:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key" yaml:"secret_access_key"`
+	UsePathStyle    bool   `mapstructure:"use_path_style" yaml:"use_path_style"` // required by MinIO
+	PublicURL       string `mapstructure:"public_url" yaml:"public_url"`         // base URL of public object links, e.g. a CDN
+}
+
+// ImageVariantConfig describes a variant generated for every uploaded image.
+type ImageVariantConfig struct {
+	Name   string `mapstructure:"name" yaml:"name"`
+	Width  int    `mapstructure:"width" yaml:"width"`   // zero keeps the aspect ratio from the height
+	Height int    `mapstructure:"height" yaml:"height"` // zero keeps the aspect ratio from the width
+	Fit    string `mapstructure:"fit" yaml:"fit"`       // "inside" (default), "cover" or "fill"
+	Format string `mapstructure:"format" yaml:"format"` // "webp", "jpeg" or "png"; empty keeps the original format
+}
+
+// MediaConfig holds settings for the media library.
+type MediaConfig struct {
+	Driver        string               `mapstructure:"driver" yaml:"driver"` // "local" or "s3"
+	MaxUploadSize int64                `mapstructure:"max_upload_size" yaml:"max_upload_size"`
+	Local         LocalMediaConfig     `mapstructure:"local" yaml:"local"`
+	S3            S3MediaConfig        `mapstructure:"s3" yaml:"s3"`
+	Variants      []ImageVariantConfig `mapstructure:"variants" yaml:"variants"`
+}
+
 // Config holds all application settings.
 type Config struct {
 	LogLevel          string `mapstructure:"log_level"`
@@ -50,6 +119,21 @@ type Config struct {
 
 	// LLM settings
 	LLM LLMConfig `mapstructure:"llm" yaml:"llm"`
+
+	// Embedding model settings for semantic search
+	Embeddings EmbeddingsConfig `mapstructure:"embeddings" yaml:"embeddings"`
+
+	// Media library settings
+	Media MediaConfig `mapstructure:"media" yaml:"media"`
+
+	// Trash settings
+	Trash TrashConfig `mapstructure:"trash" yaml:"trash"`
+
+	// GraphQL settings
+	GraphQL GraphQLConfig `mapstructure:"graphql" yaml:"graphql"`
+
+	// Webhook settings
+	Webhooks WebhooksConfig `mapstructure:"webhooks" yaml:"webhooks"`
 }
 
 // LoadConfig loads the configuration from file and environment variables.
@@ -66,8 +150,8 @@ func LoadConfig(configPath string) (Config, error) {
 
 	// CORS default values
 	viper.SetDefault("cors.allowed_origins", []string{"*"})
-	viper.SetDefault("cors.allowed_methods", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"})
-	viper.SetDefault("cors.allowed_headers", []string{"Access-Control-Allow-Headers", "Access-Control-Allow-Headers, Origin,Accept, X-Requested-With, Content-Type, Access-Control-Request-Method, Access-Control-Request-Headers"})
+	viper.SetDefault("cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"})
+	viper.SetDefault("cors.allowed_headers", []string{"Access-Control-Allow-Headers", "Access-Control-Allow-Headers, Origin,Accept, X-Requested-With, Content-Type, Access-Control-Request-Method, Access-Control-Request-Headers", "If-Match"})
 	viper.SetDefault("cors.allow_credentials", true)
 	viper.SetDefault("cors.max_age", 86400)
 
@@ -81,6 +165,26 @@ func LoadConfig(configPath string) (Config, error) {
 	viper.SetDefault("llm.model", "gpt-4o")
 	viper.SetDefault("llm.api_key", os.Getenv("OPENAI_API_KEY"))
 
+	// Media default values
+	viper.SetDefault("media.driver", "local")
+	viper.SetDefault("media.max_upload_size", 32<<20)
+	viper.SetDefault("media.local.directory", "uploads")
+	viper.SetDefault("media.local.base_url", "/uploads")
+	viper.SetDefault("media.s3.region", "us-east-1")
+
+	// Trash defaults
+	viper.SetDefault("trash.retention_days", 30)
+
+	// Webhooks defaults
+	viper.SetDefault("webhooks.allow_private_networks", false)
+
+	// GraphQL defaults
+	viper.SetDefault("graphql.max_depth", 10)
+	viper.SetDefault("graphql.max_complexity", 5000)
+	viper.SetDefault("graphql.persisted_queries.store", "database")
+	viper.SetDefault("graphql.persisted_queries.allow_list", false)
+	viper.SetDefault("graphql.persisted_queries.ttl_hours", 24*7)
+
 	// Set the config file path
 	viper.SetConfigFile(configPath)
 
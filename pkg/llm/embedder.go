@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	config "github.com/gohead-cms/gohead/pkg/config"
+	ollama_client "github.com/gohead-cms/gohead/pkg/llm/ollama"
+	openai_client "github.com/gohead-cms/gohead/pkg/llm/openai"
+)
+
+// Embedder is the interface that all embedding providers must implement. It turns texts
+// into vectors whose cosine similarity reflects how close the meanings of the texts are.
+type Embedder interface {
+	// Embed returns one vector per text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model identifies the vector space: vectors of different models cannot be compared.
+	Model() string
+}
+
+// EmbeddingConfig represents the configuration of the embedding provider.
+type EmbeddingConfig = config.EmbeddingsConfig
+
+// Default embedding models of the providers.
+const (
+	DefaultOpenAIEmbeddingModel = "text-embedding-3-small"
+	DefaultOllamaEmbeddingModel = "nomic-embed-text"
+)
+
+// FakeEmbeddingDimensions is the size of the vectors of the fake embedder.
+const FakeEmbeddingDimensions = 256
+
+// embeddingModel is implemented by the langchaingo clients able to compute embeddings.
+type embeddingModel interface {
+	CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// langChainEmbedder is a wrapper around a langchaingo client computing embeddings.
+type langChainEmbedder struct {
+	client embeddingModel
+	model  string
+}
+
+// Embed implements the Embedder interface using the langchaingo library.
+func (e *langChainEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := e.client.CreateEmbedding(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("langchaingo embedding failed: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vectors))
+	}
+	return vectors, nil
+}
+
+func (e *langChainEmbedder) Model() string {
+	return e.model
+}
+
+// NewEmbedder creates the `Embedder` of the configured provider.
+func NewEmbedder(cfg EmbeddingConfig) (Embedder, error) {
+	switch cfg.Provider {
+	case "openai":
+		model := cfg.Model
+		if model == "" {
+			model = DefaultOpenAIEmbeddingModel
+		}
+		client, err := openai_client.NewEmbedder(model, cfg.APIKey)
+		if err != nil {
+			return nil, err
+		}
+		return &langChainEmbedder{client: client, model: "openai/" + model}, nil
+	case "ollama":
+		model := cfg.Model
+		if model == "" {
+			model = DefaultOllamaEmbeddingModel
+		}
+		client, err := ollama_client.NewEmbedder(model, cfg.ServerURL)
+		if err != nil {
+			return nil, err
+		}
+		return &langChainEmbedder{client: client, model: "ollama/" + model}, nil
+	case "fake":
+		return NewFakeEmbedder(FakeEmbeddingDimensions), nil
+	default:
+		return nil, errors.New("unsupported embedding provider")
+	}
+}
+
+// fakeEmbedder hashes the words of a text into a vector, so texts sharing words are
+// similar. It is deterministic and needs no model, for tests.
+type fakeEmbedder struct {
+	dimensions int
+}
+
+// NewFakeEmbedder returns an Embedder computing vectors of the given size from the
+// words of the texts, without any model.
+func NewFakeEmbedder(dimensions int) Embedder {
+	return &fakeEmbedder{dimensions: dimensions}
+}
+
+func (e *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, e.dimensions)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, word := range words {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(word))
+			vector[h.Sum32()%uint32(e.dimensions)]++
+		}
+
+		var norm float64
+		for _, v := range vector {
+			norm += float64(v) * float64(v)
+		}
+		if norm > 0 {
+			for j := range vector {
+				vector[j] = float32(float64(vector[j]) / math.Sqrt(norm))
+			}
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func (e *fakeEmbedder) Model() string {
+	return fmt.Sprintf("fake/%d", e.dimensions)
+}
@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewEmbedder validates the NewEmbedder factory function for all providers.
+func TestNewEmbedder(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           EmbeddingConfig
+		expectedModel string
+		expectedErr   bool
+	}{
+		{
+			name:          "openai with default model",
+			cfg:           EmbeddingConfig{Provider: "openai", APIKey: "fake-key"},
+			expectedModel: "openai/" + DefaultOpenAIEmbeddingModel,
+		},
+		{
+			name:          "ollama with model",
+			cfg:           EmbeddingConfig{Provider: "ollama", Model: "mxbai-embed-large"},
+			expectedModel: "ollama/mxbai-embed-large",
+		},
+		{
+			name:          "fake",
+			cfg:           EmbeddingConfig{Provider: "fake"},
+			expectedModel: "fake/256",
+		},
+		{
+			name:        "unsupported provider",
+			cfg:         EmbeddingConfig{Provider: "unknown"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder, err := NewEmbedder(tt.cfg)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedModel, embedder.Model())
+		})
+	}
+}
+
+// TestFakeEmbedder checks that the fake embedder is deterministic and that texts
+// sharing words are more similar than unrelated ones.
+func TestFakeEmbedder(t *testing.T) {
+	embedder := NewFakeEmbedder(64)
+	vectors, err := embedder.Embed(context.Background(), []string{"red apple", "Red apple!", "green apple", "blue car"})
+	require.NoError(t, err)
+	require.Len(t, vectors, 4)
+	assert.Len(t, vectors[0], 64)
+	assert.Equal(t, vectors[0], vectors[1])
+
+	dot := func(a, b []float32) (sum float32) {
+		for i := range a {
+			sum += a[i] * b[i]
+		}
+		return sum
+	}
+	assert.InDelta(t, 1, dot(vectors[0], vectors[0]), 1e-5)
+	assert.Greater(t, dot(vectors[0], vectors[2]), dot(vectors[0], vectors[3]))
+}
@@ -21,3 +21,22 @@ func New(model string) (*ollama.LLM, error) {
 
 	return llm, nil
 }
+
+// NewEmbedder creates an Ollama client computing embeddings with the specified model,
+// served at serverURL or at the local default when it is empty.
+func NewEmbedder(model, serverURL string) (*ollama.LLM, error) {
+	if model == "" {
+		return nil, fmt.Errorf("Ollama embedding model name cannot be empty")
+	}
+
+	opts := []ollama.Option{ollama.WithModel(model)}
+	if serverURL != "" {
+		opts = append(opts, ollama.WithServerURL(serverURL))
+	}
+	llm, err := ollama.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Ollama embedding client for model %s: %w", model, err)
+	}
+
+	return llm, nil
+}
@@ -22,3 +22,22 @@ func New() (*openai.LLM, error) {
 
 	return llm, nil
 }
+
+// NewEmbedder creates an OpenAI client computing embeddings with the given model.
+// It relies on the OPENAI_API_KEY environment variable unless apiKey is set.
+func NewEmbedder(model, apiKey string) (*openai.LLM, error) {
+	if apiKey == "" && os.Getenv("OPENAI_API_KEY") == "" {
+		return nil, fmt.Errorf("OPENAI_API_KEY environment variable not set")
+	}
+
+	opts := []openai.Option{openai.WithEmbeddingModel(model)}
+	if apiKey != "" {
+		opts = append(opts, openai.WithToken(apiKey))
+	}
+	llm, err := openai.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenAI embedding client: %w", err)
+	}
+
+	return llm, nil
+}
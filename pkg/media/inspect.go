@@ -0,0 +1,76 @@
+package media
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for image dimensions
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FileInfo describes the content of an uploaded file.
+type FileInfo struct {
+	MimeType string
+	Size     int64
+	Checksum string // hex-encoded SHA-256 of the content
+	Width    *int   // set for images in a supported format
+	Height   *int
+}
+
+// Inspect reads a file to compute its size, checksum, mime type and, for images,
+// its dimensions. The file is rewound before Inspect returns.
+func Inspect(file io.ReadSeeker) (FileInfo, error) {
+	var info FileInfo
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return info, fmt.Errorf("failed to read upload: %w", err)
+	}
+	info.MimeType = http.DetectContentType(sniff[:n])
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return info, fmt.Errorf("failed to rewind upload: %w", err)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return info, fmt.Errorf("failed to read upload: %w", err)
+	}
+	info.Size = size
+	info.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	if strings.HasPrefix(info.MimeType, "image/") {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return info, fmt.Errorf("failed to rewind upload: %w", err)
+		}
+		if cfg, _, err := image.DecodeConfig(file); err == nil {
+			info.Width, info.Height = &cfg.Width, &cfg.Height
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return info, fmt.Errorf("failed to rewind upload: %w", err)
+	}
+	return info, nil
+}
+
+// ObjectKey returns the storage key of a file from its checksum, so identical uploads
+// share a single stored object. The extension is taken from the file name, or from
+// the mime type when the name has none.
+func ObjectKey(info FileInfo, filename string) string {
+	ext := strings.ToLower(path.Ext(filename))
+	if ext == "" {
+		if exts, err := mime.ExtensionsByType(info.MimeType); err == nil && len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return info.Checksum[:2] + "/" + info.Checksum + ext
+}
@@ -0,0 +1,110 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalDriver stores files in a directory of the local filesystem.
+type LocalDriver struct {
+	Directory string
+	BaseURL   string
+}
+
+// NewLocalDriver creates a driver storing files under directory, served under baseURL.
+func NewLocalDriver(directory, baseURL string) (*LocalDriver, error) {
+	if directory == "" {
+		return nil, fmt.Errorf("local media driver requires a directory")
+	}
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create media directory: %w", err)
+	}
+	return &LocalDriver{Directory: directory, BaseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+func (d *LocalDriver) Name() string {
+	return "local"
+}
+
+// path returns the filesystem path of key, refusing keys escaping the directory.
+func (d *LocalDriver) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" {
+		return "", fmt.Errorf("invalid media key '%s'", key)
+	}
+	return filepath.Join(d.Directory, filepath.FromSlash(cleaned)), nil
+}
+
+func (d *LocalDriver) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	target, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create media directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial upload.
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create media file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write media file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write media file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to store media file: %w", err)
+	}
+	return nil
+}
+
+func (d *LocalDriver) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return file, err
+}
+
+func (d *LocalDriver) Delete(ctx context.Context, key string) error {
+	target, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete media file: %w", err)
+	}
+	return nil
+}
+
+func (d *LocalDriver) DeletePrefix(ctx context.Context, prefix string) error {
+	target, err := d.path(prefix)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("failed to delete media files: %w", err)
+	}
+	return nil
+}
+
+func (d *LocalDriver) URL(key string) string {
+	return d.BaseURL + "/" + strings.TrimPrefix(key, "/")
+}
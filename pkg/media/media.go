@@ -0,0 +1,97 @@
+// Package media stores uploaded files through pluggable storage drivers.
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gohead-cms/gohead/pkg/config"
+)
+
+// ErrObjectNotFound is returned by drivers when an object does not exist.
+var ErrObjectNotFound = errors.New("media object not found")
+
+// Driver stores and serves the files of the media library.
+type Driver interface {
+	// Name identifies the driver, e.g. "local" or "s3".
+	Name() string
+	// Put stores size bytes read from r under key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the content stored under key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes every object stored under a folder-like prefix such as "variants/ab/".
+	DeletePrefix(ctx context.Context, prefix string) error
+	// URL returns the public URL of the object stored under key.
+	URL(key string) string
+}
+
+// NewDriver creates the driver selected by the media configuration.
+func NewDriver(cfg config.MediaConfig) (Driver, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalDriver(cfg.Local.Directory, cfg.Local.BaseURL)
+	case "s3":
+		return NewS3Driver(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown media driver '%s'", cfg.Driver)
+	}
+}
+
+// Preset is a named variant generated for every uploaded image.
+type Preset struct {
+	Name string
+	Transform
+}
+
+var (
+	driver        Driver
+	maxUploadSize int64
+	presets       []Preset
+)
+
+// Init sets the driver used by the media library, along with the maximum upload size
+// and the variant presets read from the media configuration.
+func Init(d Driver, cfg config.MediaConfig) error {
+	parsed := make([]Preset, 0, len(cfg.Variants))
+	seen := map[string]bool{}
+	for _, variant := range cfg.Variants {
+		if variant.Name == "" || seen[variant.Name] {
+			return fmt.Errorf("media variants must have a unique name")
+		}
+		seen[variant.Name] = true
+		preset := Preset{Name: variant.Name, Transform: Transform{
+			Width:  variant.Width,
+			Height: variant.Height,
+			Fit:    variant.Fit,
+			Format: variant.Format,
+		}}
+		if err := preset.Validate(); err != nil {
+			return fmt.Errorf("invalid media variant '%s': %w", variant.Name, err)
+		}
+		parsed = append(parsed, preset)
+	}
+
+	driver = d
+	maxUploadSize = cfg.MaxUploadSize
+	presets = parsed
+	return nil
+}
+
+// CurrentDriver returns the driver used by the media library, or nil if none is configured.
+func CurrentDriver() Driver {
+	return driver
+}
+
+// MaxUploadSize returns the maximum size of an upload in bytes; zero means no limit.
+func MaxUploadSize() int64 {
+	return maxUploadSize
+}
+
+// Presets returns the variants generated for every uploaded image.
+func Presets() []Preset {
+	return presets
+}
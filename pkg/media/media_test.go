@@ -0,0 +1,68 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalDriver(t *testing.T) {
+	driver, err := NewLocalDriver(t.TempDir(), "/uploads/")
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, driver.Put(ctx, "ab/file.txt", strings.NewReader("hello"), 5, "text/plain"))
+
+	file, err := driver.Open(ctx, "ab/file.txt")
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, "hello", string(content))
+	assert.Equal(t, "/uploads/ab/file.txt", driver.URL("ab/file.txt"))
+
+	t.Run("Keys cannot escape the directory", func(t *testing.T) {
+		target, err := driver.path("../../etc/passwd")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(target, driver.Directory))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.NoError(t, driver.Delete(ctx, "ab/file.txt"))
+		_, err := driver.Open(ctx, "ab/file.txt")
+		assert.ErrorIs(t, err, ErrObjectNotFound)
+		assert.NoError(t, driver.Delete(ctx, "ab/file.txt"), "deleting a missing file is not an error")
+	})
+}
+
+func TestInspect(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))))
+	file := bytes.NewReader(buf.Bytes())
+
+	info, err := Inspect(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", info.MimeType)
+	assert.Equal(t, int64(buf.Len()), info.Size)
+	assert.Len(t, info.Checksum, 64)
+	if assert.NotNil(t, info.Width) && assert.NotNil(t, info.Height) {
+		assert.Equal(t, 3, *info.Width)
+		assert.Equal(t, 2, *info.Height)
+	}
+
+	offset, _ := file.Seek(0, io.SeekCurrent)
+	assert.Zero(t, offset, "the file is rewound")
+
+	key := ObjectKey(info, "Logo.PNG")
+	assert.Equal(t, info.Checksum[:2]+"/"+info.Checksum+".png", key)
+
+	text, err := Inspect(strings.NewReader("plain text"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", text.MimeType)
+	assert.Nil(t, text.Width)
+}
@@ -0,0 +1,98 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const (
+	// TaskTypeGenerateVariants is the task rendering the preset variants of an image.
+	TaskTypeGenerateVariants = "media:variants"
+	// QueueName is the Asynq queue of media processing tasks.
+	QueueName = "media"
+)
+
+// VariantsPayload is the payload of a TaskTypeGenerateVariants task.
+type VariantsPayload struct {
+	AssetID uint `json:"asset_id"`
+}
+
+var asynqClient *asynq.Client
+
+// InitAsynqClient sets the client used to enqueue media processing tasks.
+func InitAsynqClient(client *asynq.Client) {
+	asynqClient = client
+}
+
+// EnqueueVariants schedules the generation of the preset variants of an image asset.
+// It does nothing for other assets, or when no preset is configured.
+func EnqueueVariants(ctx context.Context, asset *models.Asset) error {
+	if asynqClient == nil || len(presets) == 0 || !asset.IsImage() {
+		return nil
+	}
+
+	payloadBytes, err := json.Marshal(VariantsPayload{AssetID: asset.ID})
+	if err != nil {
+		return fmt.Errorf("could not marshal payload: %w", err)
+	}
+	task := asynq.NewTask(TaskTypeGenerateVariants, payloadBytes, asynq.Queue(QueueName), asynq.MaxRetry(5))
+
+	info, err := asynqClient.EnqueueContext(ctx, task)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to enqueue media variants task")
+		return fmt.Errorf("could not enqueue task: %w", err)
+	}
+	logger.Log.
+		WithField("job_id", info.ID).
+		WithField("asset_id", asset.ID).
+		Info("Successfully enqueued media variants task")
+	return nil
+}
+
+// HandleVariantsTask renders the preset variants of an asset and records them on the asset.
+func HandleVariantsTask(ctx context.Context, task *asynq.Task) error {
+	var payload VariantsPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid media variants payload: %v: %w", err, asynq.SkipRetry)
+	}
+	if driver == nil {
+		return fmt.Errorf("media storage is not configured")
+	}
+
+	asset, err := storage.GetAssetByID(payload.AssetID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Log.WithField("asset_id", payload.AssetID).Info("Asset was deleted, skipping its variants")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !asset.IsImage() {
+		return nil
+	}
+
+	variants, err := GenerateVariants(ctx, driver, asset, presets)
+	if err != nil {
+		return err
+	}
+	err = storage.UpdateAssetVariants(asset.ID, variants)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Log.WithField("asset_id", asset.ID).Info("Asset was deleted while generating its variants")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Log.WithField("asset_id", asset.ID).WithField("variants", len(variants)).Info("Media variants generated")
+	return nil
+}
@@ -0,0 +1,225 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+// Resize modes of a transform.
+const (
+	FitInside = "inside" // scale down to fit within the box, keeping the aspect ratio
+	FitCover  = "cover"  // scale and crop to fill the box exactly
+	FitFill   = "fill"   // stretch to the box
+)
+
+// Output formats of a transform.
+const (
+	FormatWebP = "webp"
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+const (
+	// MaxTransformDimension bounds the width and height of a transformed image.
+	MaxTransformDimension = 4096
+	// maxSourcePixels bounds the size of the images we agree to decode.
+	maxSourcePixels = 50_000_000
+	jpegQuality     = 85
+)
+
+// Transform describes how to derive a variant from an image.
+type Transform struct {
+	Width  int    // zero keeps the aspect ratio from the height
+	Height int    // zero keeps the aspect ratio from the width
+	Fit    string // FitInside when empty
+	Format string // the format of the source image when empty
+}
+
+// Validate checks the dimensions, fit and format of the transform.
+func (t Transform) Validate() error {
+	if t.Width < 0 || t.Height < 0 || t.Width > MaxTransformDimension || t.Height > MaxTransformDimension {
+		return fmt.Errorf("width and height must be between 1 and %d", MaxTransformDimension)
+	}
+	if t.Width == 0 && t.Height == 0 {
+		return fmt.Errorf("a width or a height is required")
+	}
+	switch t.Fit {
+	case "", FitInside, FitCover, FitFill:
+	default:
+		return fmt.Errorf("unsupported fit '%s', expected 'inside', 'cover' or 'fill'", t.Fit)
+	}
+	switch t.Format {
+	case "", FormatWebP, FormatJPEG, "jpg", FormatPNG:
+	default:
+		return fmt.Errorf("unsupported format '%s', expected 'webp', 'jpeg' or 'png'", t.Format)
+	}
+	return nil
+}
+
+// formatMimeTypes maps output formats to their mime type.
+var formatMimeTypes = map[string]string{
+	FormatWebP: "image/webp",
+	FormatJPEG: "image/jpeg",
+	FormatPNG:  "image/png",
+}
+
+// WithDefaults returns the transform with its defaults applied for a source image of the
+// given mime type: JPEG and WebP images keep their format, other images become PNG.
+func (t Transform) WithDefaults(sourceMimeType string) Transform {
+	if t.Fit == "" {
+		t.Fit = FitInside
+	}
+	switch {
+	case t.Format == "jpg":
+		t.Format = FormatJPEG
+	case t.Format != "":
+	case sourceMimeType == "image/jpeg":
+		t.Format = FormatJPEG
+	case sourceMimeType == "image/webp":
+		t.Format = FormatWebP
+	default:
+		t.Format = FormatPNG
+	}
+	return t
+}
+
+// VariantKey returns the storage key of a transform of the file stored under key.
+// Identical transforms of a file share a key, so results can be cached. The transform
+// must have its defaults applied.
+func VariantKey(key string, t Transform) string {
+	return VariantPrefix(key) + strconv.Itoa(t.Width) + "x" + strconv.Itoa(t.Height) + "-" + t.Fit + "." + t.Format
+}
+
+// VariantPrefix returns the folder holding every variant of the file stored under key.
+func VariantPrefix(key string) string {
+	return "variants/" + strings.TrimPrefix(key, "/") + "/"
+}
+
+// Variant is an encoded image produced by TransformImage.
+type Variant struct {
+	Data     []byte
+	MimeType string
+	Width    int
+	Height   int
+}
+
+// DecodeImage decodes an image, refusing images too large to be processed in memory.
+func DecodeImage(r io.Reader) (image.Image, error) {
+	var buf bytes.Buffer
+	cfg, _, err := image.DecodeConfig(io.TeeReader(r, &buf))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported image: %w", err)
+	}
+	if cfg.Width*cfg.Height > maxSourcePixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large to be transformed", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(io.MultiReader(&buf, r))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// TransformImage resizes a decoded image and encodes it. The transform must have its
+// defaults applied.
+func TransformImage(src image.Image, t Transform) (*Variant, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	img := resize(src, t)
+	var buf bytes.Buffer
+	switch t.Format {
+	case FormatWebP:
+		if err := nativewebp.Encode(&buf, img, nil); err != nil {
+			return nil, fmt.Errorf("failed to encode webp: %w", err)
+		}
+	case FormatJPEG:
+		if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+	case FormatPNG:
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode png: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("no output format set for the transform")
+	}
+
+	bounds := img.Bounds()
+	return &Variant{Data: buf.Bytes(), MimeType: formatMimeTypes[t.Format], Width: bounds.Dx(), Height: bounds.Dy()}, nil
+}
+
+// resize scales src according to the transform. Images are never enlarged beyond their
+// original size, except by the "fill" fit.
+func resize(src image.Image, t Transform) image.Image {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW == 0 || srcH == 0 {
+		return src
+	}
+
+	width, height := t.Width, t.Height
+	switch {
+	case width == 0:
+		width = max(1, srcW*height/srcH)
+	case height == 0:
+		height = max(1, srcH*width/srcW)
+	}
+
+	srcRect := bounds
+	switch t.Fit {
+	case FitCover:
+		// Crop the source to the aspect ratio of the box, centered.
+		if srcW*height > srcH*width {
+			cropW := srcH * width / height
+			srcRect.Min.X += (srcW - cropW) / 2
+			srcRect.Max.X = srcRect.Min.X + cropW
+		} else {
+			cropH := srcW * height / width
+			srcRect.Min.Y += (srcH - cropH) / 2
+			srcRect.Max.Y = srcRect.Min.Y + cropH
+		}
+		if width > srcRect.Dx() || height > srcRect.Dy() {
+			width, height = srcRect.Dx(), srcRect.Dy()
+		}
+	case FitInside:
+		if srcW*height > srcH*width {
+			height = max(1, srcH*width/srcW)
+		} else {
+			width = max(1, srcW*height/srcH)
+		}
+		if width > srcW || height > srcH {
+			width, height = srcW, srcH
+		}
+	}
+
+	if width == srcRect.Dx() && height == srcRect.Dy() && srcRect == bounds {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Src, nil)
+	return dst
+}
+
+// flatten draws an image over a white background, as JPEG has no transparency.
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,126 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+func TestTransformImage(t *testing.T) {
+	src := testImage(400, 200)
+
+	tests := []struct {
+		name          string
+		transform     Transform
+		width, height int
+	}{
+		{"Inside keeps the aspect ratio", Transform{Width: 100, Height: 100, Fit: FitInside}, 100, 50},
+		{"Width only", Transform{Width: 200}, 200, 100},
+		{"Height only", Transform{Height: 50}, 100, 50},
+		{"Cover crops to the box", Transform{Width: 100, Height: 100, Fit: FitCover}, 100, 100},
+		{"Fill stretches", Transform{Width: 50, Height: 80, Fit: FitFill}, 50, 80},
+		{"Images are not enlarged", Transform{Width: 1000}, 400, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, err := TransformImage(src, tt.transform.WithDefaults("image/png"))
+			assert.NoError(t, err)
+			assert.Equal(t, "image/png", variant.MimeType)
+			assert.Equal(t, tt.width, variant.Width)
+			assert.Equal(t, tt.height, variant.Height)
+
+			decoded, err := DecodeImage(bytes.NewReader(variant.Data))
+			assert.NoError(t, err)
+			assert.Equal(t, image.Rect(0, 0, tt.width, tt.height), decoded.Bounds())
+		})
+	}
+
+	t.Run("WebP output", func(t *testing.T) {
+		variant, err := TransformImage(src, Transform{Width: 64, Format: "webp"}.WithDefaults("image/png"))
+		assert.NoError(t, err)
+		assert.Equal(t, "image/webp", variant.MimeType)
+		decoded, err := DecodeImage(bytes.NewReader(variant.Data))
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 64, 32), decoded.Bounds())
+	})
+
+	t.Run("Defaults follow the source format", func(t *testing.T) {
+		assert.Equal(t, FormatJPEG, Transform{Width: 10}.WithDefaults("image/jpeg").Format)
+		assert.Equal(t, FormatPNG, Transform{Width: 10}.WithDefaults("image/gif").Format)
+		assert.Equal(t, FormatJPEG, Transform{Width: 10, Format: "jpg"}.WithDefaults("image/png").Format)
+		assert.Equal(t, FitInside, Transform{Width: 10}.WithDefaults("image/png").Fit)
+	})
+
+	t.Run("Invalid transforms", func(t *testing.T) {
+		assert.Error(t, Transform{}.Validate())
+		assert.Error(t, Transform{Width: MaxTransformDimension + 1}.Validate())
+		assert.Error(t, Transform{Width: 10, Fit: "zoom"}.Validate())
+		assert.Error(t, Transform{Width: 10, Format: "bmp"}.Validate())
+	})
+}
+
+func TestVariants(t *testing.T) {
+	driver, err := NewLocalDriver(t.TempDir(), "/uploads")
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, testImage(300, 300)))
+	assert.NoError(t, driver.Put(ctx, "ab/abc.png", bytes.NewReader(buf.Bytes()), int64(buf.Len()), "image/png"))
+	width, height := 300, 300
+	asset := &models.Asset{MimeType: "image/png", StorageKey: "ab/abc.png", Width: &width, Height: &height}
+
+	variants, err := GenerateVariants(ctx, driver, asset, []Preset{
+		{Name: "thumbnail", Transform: Transform{Width: 50, Height: 50, Fit: FitCover, Format: FormatWebP}},
+		{Name: "small", Transform: Transform{Width: 120}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.AssetVariant{
+		URL:      "/uploads/variants/ab/abc.png/50x50-cover.webp",
+		MimeType: "image/webp",
+		Width:    50,
+		Height:   50,
+	}, variants["thumbnail"])
+	assert.Equal(t, "/uploads/variants/ab/abc.png/120x0-inside.png", variants["small"].URL)
+	assert.Equal(t, 120, variants["small"].Height)
+
+	t.Run("On-demand variants are cached", func(t *testing.T) {
+		file, mimeType, err := OpenVariant(ctx, driver, asset, Transform{Width: 30, Format: "jpg"})
+		assert.NoError(t, err)
+		first, _ := io.ReadAll(file)
+		file.Close()
+		assert.Equal(t, "image/jpeg", mimeType)
+
+		cached, err := driver.Open(ctx, "variants/ab/abc.png/30x0-inside.jpeg")
+		assert.NoError(t, err)
+		stored, _ := io.ReadAll(cached)
+		cached.Close()
+		assert.Equal(t, first, stored)
+	})
+
+	t.Run("Delete every variant", func(t *testing.T) {
+		assert.NoError(t, driver.DeletePrefix(ctx, VariantPrefix(asset.StorageKey)))
+		_, err := driver.Open(ctx, "variants/ab/abc.png/50x50-cover.webp")
+		assert.ErrorIs(t, err, ErrObjectNotFound)
+		_, err = driver.Open(ctx, asset.StorageKey)
+		assert.NoError(t, err, "the original file is kept")
+	})
+}
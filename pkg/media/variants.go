@@ -0,0 +1,84 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+)
+
+// openImage decodes the original file of an image asset.
+func openImage(ctx context.Context, d Driver, asset *models.Asset) (image.Image, error) {
+	file, err := d.Open(ctx, asset.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open asset file: %w", err)
+	}
+	defer file.Close()
+	return DecodeImage(file)
+}
+
+// GenerateVariants renders the presets of an image asset and stores them in the driver.
+// It returns the stored variants by preset name.
+func GenerateVariants(ctx context.Context, d Driver, asset *models.Asset, presets []Preset) (map[string]models.AssetVariant, error) {
+	img, err := openImage(ctx, d, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make(map[string]models.AssetVariant, len(presets))
+	for _, preset := range presets {
+		t := preset.WithDefaults(asset.MimeType)
+		variant, err := TransformImage(img, t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render variant '%s': %w", preset.Name, err)
+		}
+		key := VariantKey(asset.StorageKey, t)
+		if err := d.Put(ctx, key, bytes.NewReader(variant.Data), int64(len(variant.Data)), variant.MimeType); err != nil {
+			return nil, fmt.Errorf("failed to store variant '%s': %w", preset.Name, err)
+		}
+		variants[preset.Name] = models.AssetVariant{
+			URL:      d.URL(key),
+			MimeType: variant.MimeType,
+			Width:    variant.Width,
+			Height:   variant.Height,
+		}
+	}
+	return variants, nil
+}
+
+// OpenVariant returns a transform of an image asset and its mime type. The result is
+// rendered on first use and cached in the driver.
+func OpenVariant(ctx context.Context, d Driver, asset *models.Asset, t Transform) (io.ReadCloser, string, error) {
+	t = t.WithDefaults(asset.MimeType)
+	if err := t.Validate(); err != nil {
+		return nil, "", err
+	}
+	key := VariantKey(asset.StorageKey, t)
+
+	cached, err := d.Open(ctx, key)
+	if err == nil {
+		return cached, formatMimeTypes[t.Format], nil
+	}
+	if !errors.Is(err, ErrObjectNotFound) {
+		return nil, "", fmt.Errorf("failed to open cached variant: %w", err)
+	}
+
+	img, err := openImage(ctx, d, asset)
+	if err != nil {
+		return nil, "", err
+	}
+	variant, err := TransformImage(img, t)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := d.Put(ctx, key, bytes.NewReader(variant.Data), int64(len(variant.Data)), variant.MimeType); err != nil {
+		// Serve the result anyway; it is rendered again on the next request.
+		logger.Log.WithField("key", key).WithError(err).Warn("Failed to cache image variant")
+	}
+	return io.NopCloser(bytes.NewReader(variant.Data)), variant.MimeType, nil
+}
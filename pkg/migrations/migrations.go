@@ -2,20 +2,108 @@
 package migrations
 
 import (
+	"fmt"
+
 	"github.com/gohead-cms/gohead/internal/models"
 	agents "github.com/gohead-cms/gohead/internal/models/agents"
 	"gorm.io/gorm"
 )
 
 func MigrateDatabase(db *gorm.DB) error {
-	return db.AutoMigrate(
+	backfillRelations := !db.Migrator().HasTable(&models.ItemRelation{})
+	// Content is only backfilled when the status column is added, so that drafts saved
+	// since are never published by a restart.
+	var backfillStatus []any
+	for _, model := range []any{&models.Item{}, &models.SingleItem{}} {
+		if db.Migrator().HasTable(model) && !db.Migrator().HasColumn(model, "status") {
+			backfillStatus = append(backfillStatus, model)
+		}
+	}
+	if err := db.AutoMigrate(
 		&models.Collection{},
 		&models.Attribute{},
+		&models.Component{},
+		&models.ComponentAttribute{},
 		&models.Singleton{},
 		&models.SingleItem{},
 		&models.Item{},
+		&models.ItemRevision{},
+		&models.ItemRelation{},
+		&models.Asset{},
 		&models.User{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.PersistedQuery{},
 		&agents.Agent{},
 		&agents.AgentMessage{},
-	)
+	); err != nil {
+		return err
+	}
+	if err := backfillPublicationStatus(db, backfillStatus); err != nil {
+		return err
+	}
+	if backfillRelations {
+		return backfillItemRelations(db)
+	}
+	return nil
+}
+
+// backfillPublicationStatus marks content created before the draft/publish workflow
+// existed as published, so it stays visible to readers.
+func backfillPublicationStatus(db *gorm.DB, tables []any) error {
+	for _, model := range tables {
+		err := db.Model(model).
+			Where("status IS NULL OR status = ''").
+			Updates(map[string]any{"status": models.ItemStatusPublished, "published_at": gorm.Expr("updated_at")}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillItemRelations fills the item_relations table, when it is created, from the
+// related item IDs stored in the data of items. IDs of items that no longer exist are
+// dropped, and the IDs of oneToMany relations, once stored as a single ID, become lists.
+func backfillItemRelations(db *gorm.DB) error {
+	var collections []models.Collection
+	if err := db.Preload("Attributes").Find(&collections).Error; err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		var relations []models.Attribute
+		for _, attr := range collection.Attributes {
+			if attr.Type == "relation" {
+				relations = append(relations, attr)
+			}
+		}
+		if len(relations) == 0 {
+			continue
+		}
+
+		var items []models.Item
+		err := db.Where("collection_id = ?", collection.ID).FindInBatches(&items, 500, func(*gorm.DB, int) error {
+			return db.Transaction(func(tx *gorm.DB) error {
+				for i := range items {
+					item := &items[i]
+					for _, attr := range relations {
+						if value, exists := item.Data[attr.Name]; exists {
+							item.Data[attr.Name] = models.RelationValue(attr, models.RelationIDs(value))
+						}
+					}
+					if err := tx.Model(item).UpdateColumn("data", item.Data).Error; err != nil {
+						return err
+					}
+					if err := models.SyncItemRelations(tx, collection, item); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to backfill relations of collection '%s': %w", collection.Name, err)
+		}
+	}
+	return nil
 }
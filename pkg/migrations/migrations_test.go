@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillPublicationStatus(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, MigrateDatabase(db))
+
+	// An item stored before the draft/publish workflow is published on upgrade.
+	require.NoError(t, db.Migrator().DropColumn(&models.Item{}, "status"))
+	require.NoError(t, db.Exec("INSERT INTO items (collection_id, data, created_at, updated_at) VALUES (1, '{}', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)").Error)
+	require.NoError(t, MigrateDatabase(db))
+	status := func() string {
+		var status string
+		require.NoError(t, db.Model(&models.Item{}).Select("status").Scan(&status).Error)
+		return status
+	}
+	assert.Equal(t, models.ItemStatusPublished, status())
+
+	// Later migrations leave items without a status alone.
+	require.NoError(t, db.Model(&models.Item{}).Where("1 = 1").UpdateColumn("status", "").Error)
+	require.NoError(t, MigrateDatabase(db))
+	assert.Empty(t, status())
+}
@@ -0,0 +1,483 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	agents "github.com/gohead-cms/gohead/internal/models/agents"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+)
+
+// Operations of a plan action.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Options tune how the database is reconciled against the definitions.
+type Options struct {
+	// Prune deletes the definitions stored in the database but missing from the files.
+	Prune bool
+	// Force applies collection updates even when they break existing items.
+	Force bool
+}
+
+// Action is one change of a plan.
+type Action struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	Op   string `json:"op"`
+
+	// Changes and Report describe how a collection update affects the existing items.
+	Changes []models.SchemaChange       `json:"changes,omitempty"`
+	Report  *storage.SchemaDryRunReport `json:"report,omitempty"`
+
+	id         uint
+	component  models.Component
+	collection CollectionDefinition
+	plan       *models.SchemaPlan
+	singleton  models.Singleton
+	agent      agents.Agent
+}
+
+// String describes the action on one line, e.g. "~ collection articles (2 changes)".
+func (a Action) String() string {
+	symbol := map[string]string{OpCreate: "+", OpUpdate: "~", OpDelete: "-"}[a.Op]
+	line := fmt.Sprintf("%s %s %s", symbol, a.Kind, a.Name)
+	if a.Op != OpUpdate || a.Kind != KindCollection {
+		return line
+	}
+	var details []string
+	if len(a.Changes) > 0 {
+		details = append(details, fmt.Sprintf("%d change(s)", len(a.Changes)))
+	}
+	if a.Report != nil && a.Report.BrokenItems > 0 {
+		details = append(details, fmt.Sprintf("breaks %d item(s)", a.Report.BrokenItems))
+	}
+	if len(details) > 0 {
+		line += " (" + strings.Join(details, ", ") + ")"
+	}
+	return line
+}
+
+// Plan lists the actions reconciling the database with the definitions, in the order
+// they are applied.
+type Plan struct {
+	Actions   []Action `json:"actions"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// Empty reports whether the database already matches the definitions.
+func (p *Plan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+// BrokenItems counts the existing items the collection updates of the plan would break.
+func (p *Plan) BrokenItems() int {
+	broken := 0
+	for _, action := range p.Actions {
+		if action.Report != nil {
+			broken += action.Report.BrokenItems
+		}
+	}
+	return broken
+}
+
+// Diff compares the definitions with the database and plans the actions reconciling them.
+// Definitions whose normalized form matches the database are left out of the plan, so
+// diffing a directory just applied yields an empty plan.
+func Diff(defs *Definitions, opts Options) (*Plan, error) {
+	plan := &Plan{}
+	var deletions []Action
+
+	components, err := storage.GetAllComponents()
+	if err != nil {
+		return nil, err
+	}
+	storedComponents := make(map[string]models.Component, len(components))
+	for _, component := range components {
+		storedComponents[component.Name] = component
+	}
+	for _, component := range defs.Components {
+		action := Action{Kind: KindComponent, Name: component.Name, component: component}
+		stored, exists := storedComponents[component.Name]
+		delete(storedComponents, component.Name)
+		if !exists {
+			action.Op = OpCreate
+		} else if !sameDefinition(models.ComponentDefinition(stored), models.ComponentDefinition(component)) {
+			action.Op = OpUpdate
+		}
+		plan.add(action)
+	}
+	for name := range storedComponents {
+		deletions = append(deletions, Action{Kind: KindComponent, Name: name, Op: OpDelete})
+	}
+
+	collections, _, err := storage.GetAllCollections(nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	storedCollections := make(map[string]models.Collection, len(collections))
+	for _, collection := range collections {
+		storedCollections[collection.Name] = collection
+	}
+	for _, definition := range defs.Collections {
+		name := definition.Collection.Name
+		action := Action{Kind: KindCollection, Name: name, collection: definition}
+		stored, exists := storedCollections[name]
+		delete(storedCollections, name)
+		if !exists {
+			action.Op = OpCreate
+		} else if !sameDefinition(models.CollectionDefinition(stored), models.CollectionDefinition(definition.Collection)) {
+			action.Op = OpUpdate
+			action.id = stored.ID
+			if action.plan, err = storage.PlanCollectionUpdate(stored.ID, definition.Collection, definition.Directives); err != nil {
+				return nil, fmt.Errorf("collection '%s': %w", name, err)
+			}
+			if action.Report, err = storage.DryRunSchemaPlan(stored.ID, *action.plan); err != nil {
+				return nil, err
+			}
+			action.Changes = action.plan.Changes
+		}
+		plan.add(action)
+	}
+	for name, collection := range storedCollections {
+		deletions = append(deletions, Action{Kind: KindCollection, Name: name, Op: OpDelete, id: collection.ID})
+	}
+
+	singletons, err := storage.GetAllSingletons()
+	if err != nil {
+		return nil, err
+	}
+	storedSingletons := make(map[string]models.Singleton, len(singletons))
+	for _, singleton := range singletons {
+		storedSingletons[singleton.Name] = singleton
+	}
+	for _, singleton := range defs.Singletons {
+		action := Action{Kind: KindSingleton, Name: singleton.Name, singleton: singleton}
+		stored, exists := storedSingletons[singleton.Name]
+		delete(storedSingletons, singleton.Name)
+		if !exists {
+			action.Op = OpCreate
+		} else if !sameDefinition(models.SingletonDefinition(stored), models.SingletonDefinition(singleton)) {
+			action.Op = OpUpdate
+		}
+		plan.add(action)
+	}
+	for name, singleton := range storedSingletons {
+		deletions = append(deletions, Action{Kind: KindSingleton, Name: name, Op: OpDelete, id: singleton.ID})
+	}
+
+	storedAgentList, _, err := storage.GetAllAgents(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	storedAgents := make(map[string]agents.Agent, len(storedAgentList))
+	for _, agent := range storedAgentList {
+		storedAgents[agent.Name] = agent
+	}
+	for _, agent := range defs.Agents {
+		action := Action{Kind: KindAgent, Name: agent.Name}
+		stored, exists := storedAgents[agent.Name]
+		delete(storedAgents, agent.Name)
+		if !exists {
+			action.Op = OpCreate
+		} else {
+			// Credentials left out of the file keep their stored value.
+			if agent.LLMConfig.APIKey == "" {
+				agent.LLMConfig.APIKey = stored.LLMConfig.APIKey
+			}
+			if agent.LLMConfig.APISecret == "" {
+				agent.LLMConfig.APISecret = stored.LLMConfig.APISecret
+			}
+			if agent.Trigger.Type == "webhook" && agent.Trigger.WebhookToken == "" {
+				agent.Trigger.WebhookToken = stored.Trigger.WebhookToken
+			}
+			action.id = stored.ID
+			same, err := sameAgent(stored, agent)
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				action.Op = OpUpdate
+			}
+		}
+		if err := agents.ValidateAgentSchema(agent); err != nil {
+			return nil, fmt.Errorf("agent '%s': %w", agent.Name, err)
+		}
+		action.agent = agent
+		plan.add(action)
+	}
+	for name, agent := range storedAgents {
+		deletions = append(deletions, Action{Kind: KindAgent, Name: name, Op: OpDelete, id: agent.ID})
+	}
+
+	if opts.Prune {
+		// Deletions run in the reverse order of creations, so agents and singletons go
+		// before the collections and components they may reference.
+		order := map[string]int{KindAgent: 0, KindSingleton: 1, KindCollection: 2, KindComponent: 3}
+		sort.Slice(deletions, func(i, j int) bool {
+			if deletions[i].Kind != deletions[j].Kind {
+				return order[deletions[i].Kind] < order[deletions[j].Kind]
+			}
+			return deletions[i].Name < deletions[j].Name
+		})
+		plan.Actions = append(plan.Actions, deletions...)
+	}
+	return plan, nil
+}
+
+// add appends an action to the plan, or counts it as unchanged when it has no operation.
+func (p *Plan) add(action Action) {
+	if action.Op == "" {
+		p.Unchanged++
+		return
+	}
+	p.Actions = append(p.Actions, action)
+}
+
+// sameDefinition reports whether two definitions serialize to the same JSON.
+func sameDefinition(a, b map[string]any) bool {
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(left) == string(right)
+}
+
+func sameAgent(a, b agents.Agent) (bool, error) {
+	left, err := agentDefinition(a, true)
+	if err != nil {
+		return false, err
+	}
+	right, err := agentDefinition(b, true)
+	if err != nil {
+		return false, err
+	}
+	return sameDefinition(left, right), nil
+}
+
+// Apply runs the actions of a plan: components first, then collections, singletons and
+// agents, and finally the deletions. Collection updates breaking existing items are
+// refused unless opts.Force is set, before anything is written.
+func Apply(ctx context.Context, plan *Plan, opts Options) error {
+	if broken := plan.BrokenItems(); broken > 0 && !opts.Force {
+		return fmt.Errorf("the plan would break %d existing item(s); review it and apply it with force", broken)
+	}
+
+	var creations []Action
+	for _, action := range plan.Actions {
+		if action.Kind == KindCollection && action.Op == OpCreate {
+			creations = append(creations, action)
+		}
+	}
+	createdCollections := false
+
+	for _, action := range plan.Actions {
+		var err error
+		switch {
+		case action.Kind == KindCollection && action.Op == OpCreate:
+			if !createdCollections {
+				err = createCollections(ctx, creations)
+				createdCollections = true
+			}
+		case action.Op == OpDelete:
+			continue
+		default:
+			err = applyAction(ctx, action)
+		}
+		if err != nil {
+			return err
+		}
+		if action.Kind != KindCollection || action.Op != OpCreate {
+			logger.Log.WithField("kind", action.Kind).WithField("name", action.Name).Infof("Schema %s applied", action.Op)
+		}
+	}
+
+	return prune(plan.Actions)
+}
+
+// applyAction creates or updates one definition.
+func applyAction(ctx context.Context, action Action) error {
+	switch action.Kind {
+	case KindComponent:
+		if action.Op == OpCreate {
+			return storage.CreateComponent(&action.component)
+		}
+		return storage.UpdateComponent(action.Name, &action.component)
+
+	case KindCollection:
+		collection := action.collection.Collection
+		if err := models.ValidateCollectionSchema(collection); err != nil {
+			return fmt.Errorf("collection '%s': %w", action.Name, err)
+		}
+		if err := storage.UpdateCollection(action.id, &collection); err != nil {
+			return err
+		}
+		if action.plan.RewritesData() {
+			if _, err := storage.EnqueueSchemaMigration(ctx, action.id, *action.plan); err != nil {
+				return fmt.Errorf("collection '%s' updated but its items could not be migrated: %w", action.Name, err)
+			}
+		}
+		return nil
+
+	case KindSingleton:
+		singleton := action.singleton
+		if err := models.ValidateSingletonSchema(singleton); err != nil {
+			return fmt.Errorf("singleton '%s': %w", action.Name, err)
+		}
+		return storage.SaveOrUpdateSingleton(&singleton)
+
+	default:
+		agent := action.agent
+		if err := agents.ValidateAgentSchema(agent); err != nil {
+			return fmt.Errorf("agent '%s': %w", action.Name, err)
+		}
+		if action.Op == OpCreate {
+			return storage.SaveAgent(&agent)
+		}
+		return storage.UpdateAgent(action.id, &agent)
+	}
+}
+
+// createCollections creates new collections once the targets of their relations exist.
+// When the remaining collections reference each other, one of them is created without the
+// relations to missing collections, which are added back once every collection exists.
+func createCollections(ctx context.Context, creations []Action) error {
+	pending := creations
+	var deferred []Action
+
+	for len(pending) > 0 {
+		var waiting []Action
+		for _, action := range pending {
+			if len(missingTargets(action.collection.Collection)) > 0 {
+				waiting = append(waiting, action)
+				continue
+			}
+			if err := createCollection(action.collection.Collection); err != nil {
+				return err
+			}
+		}
+		if len(waiting) == len(pending) {
+			action := waiting[0]
+			collection := action.collection.Collection
+			missing := missingTargets(collection)
+			var attributes []models.Attribute
+			for _, attr := range collection.Attributes {
+				if attr.Type != "relation" || !missing[attr.Target] {
+					attributes = append(attributes, attr)
+				}
+			}
+			collection.Attributes = attributes
+			if err := createCollection(collection); err != nil {
+				return err
+			}
+			deferred = append(deferred, action)
+			waiting = waiting[1:]
+		}
+		pending = waiting
+	}
+
+	for _, action := range deferred {
+		stored, err := storage.GetCollectionByName(action.Name)
+		if err != nil {
+			return err
+		}
+		action.id = stored.ID
+		if action.plan, err = storage.PlanCollectionUpdate(stored.ID, action.collection.Collection, models.SchemaDirectives{}); err != nil {
+			return fmt.Errorf("collection '%s': %w", action.Name, err)
+		}
+		if err := applyAction(ctx, action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createCollection(collection models.Collection) error {
+	if err := models.ValidateCollectionSchema(collection); err != nil {
+		return fmt.Errorf("collection '%s': %w", collection.Name, err)
+	}
+	if err := storage.SaveCollection(&collection); err != nil {
+		return err
+	}
+	logger.Log.WithField("kind", KindCollection).WithField("name", collection.Name).Infof("Schema %s applied", OpCreate)
+	return nil
+}
+
+// missingTargets returns the targets of the relations of a collection that do not exist yet.
+func missingTargets(collection models.Collection) map[string]bool {
+	missing := make(map[string]bool)
+	for _, attr := range collection.Attributes {
+		if attr.Type != "relation" || attr.Target == "" {
+			continue
+		}
+		if _, err := storage.GetCollectionByName(attr.Target); err != nil {
+			missing[attr.Target] = true
+		}
+	}
+	return missing
+}
+
+// prune runs the deletions of a plan. Collections referenced by other collections being
+// deleted are retried once those are gone.
+func prune(actions []Action) error {
+	var collections []Action
+	for _, action := range actions {
+		if action.Op != OpDelete {
+			continue
+		}
+		var err error
+		switch action.Kind {
+		case KindAgent:
+			err = storage.DeleteAgent(action.id)
+		case KindSingleton:
+			err = storage.DeleteSingleton(action.id)
+		case KindCollection:
+			collections = append(collections, action)
+			continue
+		case KindComponent:
+			if err = deleteCollections(collections); err == nil {
+				collections = nil
+				err = storage.DeleteComponent(action.Name)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		logger.Log.WithField("kind", action.Kind).WithField("name", action.Name).Infof("Schema %s applied", OpDelete)
+	}
+	return deleteCollections(collections)
+}
+
+// deleteCollections deletes collections, retrying those still referenced until no more
+// can be deleted.
+func deleteCollections(collections []Action) error {
+	for len(collections) > 0 {
+		var remaining []Action
+		var lastErr error
+		for _, action := range collections {
+			if err := storage.DeleteCollection(action.id); err != nil {
+				remaining = append(remaining, action)
+				lastErr = err
+				continue
+			}
+			logger.Log.WithField("kind", KindCollection).WithField("name", action.Name).Infof("Schema %s applied", OpDelete)
+		}
+		if len(remaining) == len(collections) {
+			return lastErr
+		}
+		collections = remaining
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// Package schema exports the content model (collections, singletons, components and
+// agents) to a directory of YAML files and reconciles the database against such a directory.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	agents "github.com/gohead-cms/gohead/internal/models/agents"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of definitions, each stored in its own subdirectory.
+const (
+	KindComponent  = "component"
+	KindCollection = "collection"
+	KindSingleton  = "singleton"
+	KindAgent      = "agent"
+)
+
+// kindDirs maps each kind of definition to the subdirectory of its files.
+var kindDirs = map[string]string{
+	KindComponent:  "components",
+	KindCollection: "collections",
+	KindSingleton:  "singletons",
+	KindAgent:      "agents",
+}
+
+// directivesKey is the optional key of a collection file holding the directives used to
+// migrate existing items when the collection changes.
+const directivesKey = "directives"
+
+// CollectionDefinition is a collection read from a file, with its migration directives.
+type CollectionDefinition struct {
+	Collection models.Collection
+	Directives models.SchemaDirectives
+}
+
+// Definitions is the content model read from a schema directory.
+type Definitions struct {
+	Components  []models.Component
+	Collections []CollectionDefinition
+	Singletons  []models.Singleton
+	Agents      []agents.Agent
+}
+
+// Load reads the definitions of a schema directory. Missing subdirectories hold no definitions.
+func Load(dir string) (*Definitions, error) {
+	defs := &Definitions{}
+	seen := make(map[string]map[string]string)
+
+	for _, kind := range []string{KindComponent, KindCollection, KindSingleton, KindAgent} {
+		seen[kind] = make(map[string]string)
+		inputs, err := readDefinitionFiles(filepath.Join(dir, kindDirs[kind]))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range inputs {
+			name, err := parseDefinition(defs, kind, file.input)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file.path, err)
+			}
+			if previous, exists := seen[kind][name]; exists {
+				return nil, fmt.Errorf("%s: %s '%s' is already defined in %s", file.path, kind, name, previous)
+			}
+			seen[kind][name] = file.path
+		}
+	}
+	return defs, nil
+}
+
+// parseDefinition parses the input of one file into defs and returns the name it defines.
+func parseDefinition(defs *Definitions, kind string, input map[string]any) (string, error) {
+	switch kind {
+	case KindComponent:
+		component, err := models.ParseComponentInput(input)
+		if err != nil {
+			return "", err
+		}
+		defs.Components = append(defs.Components, component)
+		return component.Name, nil
+	case KindCollection:
+		collection, err := models.ParseCollectionInput(input)
+		if err != nil {
+			return "", err
+		}
+		directives, err := models.ParseSchemaDirectives(input[directivesKey])
+		if err != nil {
+			return "", err
+		}
+		defs.Collections = append(defs.Collections, CollectionDefinition{Collection: collection, Directives: directives})
+		return collection.Name, nil
+	case KindSingleton:
+		singleton, err := models.ParseSingletonInput(input)
+		if err != nil {
+			return "", err
+		}
+		if singleton.Name == "" {
+			return "", fmt.Errorf("missing or invalid field 'name'")
+		}
+		defs.Singletons = append(defs.Singletons, singleton)
+		return singleton.Name, nil
+	default:
+		agent, err := parseAgentFile(input)
+		if err != nil {
+			return "", err
+		}
+		defs.Agents = append(defs.Agents, agent)
+		return agent.Name, nil
+	}
+}
+
+// parseAgentFile parses and validates an agent definition. Its LLM credentials and the
+// token of its webhook trigger are usually left out of the file, in which case the stored
+// ones are kept.
+func parseAgentFile(input map[string]any) (agents.Agent, error) {
+	var agent agents.Agent
+	b, err := json.Marshal(input)
+	if err != nil {
+		return agent, err
+	}
+	if err := json.Unmarshal(b, &agent); err != nil {
+		return agent, fmt.Errorf("invalid agent input format: %w", err)
+	}
+	// Stored agents without functions read back an empty list.
+	if agent.Functions == nil {
+		agent.Functions = agents.FunctionSpecs{}
+	}
+	// A missing webhook token is checked once the stored one is known, when planning.
+	validated := agent
+	if validated.Trigger.Type == "webhook" && validated.Trigger.WebhookToken == "" {
+		validated.Trigger.WebhookToken = "stored"
+	}
+	if err := agents.ValidateAgentSchema(validated); err != nil {
+		return agent, err
+	}
+	return agent, nil
+}
+
+type definitionFile struct {
+	path  string
+	input map[string]any
+}
+
+// readDefinitionFiles decodes the YAML files of a directory, sorted by name. Values go through
+// JSON so they have the types of a decoded request body (float64 numbers, []any lists).
+func readDefinitionFiles(dir string) ([]definitionFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read schema directory '%s': %w", dir, err)
+	}
+
+	var files []definitionFile
+	for _, entry := range entries {
+		if entry.IsDir() || !isYAMLFile(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read '%s': %w", path, err)
+		}
+
+		var raw map[string]any
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, fmt.Errorf("%s: invalid YAML: %w", path, err)
+		}
+		normalized, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		var input map[string]any
+		if err := json.Unmarshal(normalized, &input); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if input == nil {
+			return nil, fmt.Errorf("%s: empty definition", path)
+		}
+		files = append(files, definitionFile{path: path, input: input})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files, nil
+}
+
+func isYAMLFile(name string) bool {
+	return strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")
+}
+
+// Export writes every definition stored in the database to dir, one YAML file per
+// definition. YAML files left in the subdirectories by definitions that no longer exist
+// are removed, so the directory mirrors the database. It returns the number of files written.
+func Export(dir string) (int, error) {
+	files := make(map[string]map[string]map[string]any)
+	for kind := range kindDirs {
+		files[kind] = make(map[string]map[string]any)
+	}
+
+	components, err := storage.GetAllComponents()
+	if err != nil {
+		return 0, err
+	}
+	for _, component := range components {
+		files[KindComponent][component.Name] = models.ComponentDefinition(component)
+	}
+
+	collections, _, err := storage.GetAllCollections(nil, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, collection := range collections {
+		files[KindCollection][collection.Name] = models.CollectionDefinition(collection)
+	}
+
+	singletons, err := storage.GetAllSingletons()
+	if err != nil {
+		return 0, err
+	}
+	for _, singleton := range singletons {
+		files[KindSingleton][singleton.Name] = models.SingletonDefinition(singleton)
+	}
+
+	storedAgents, _, err := storage.GetAllAgents(nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, agent := range storedAgents {
+		definition, err := agentDefinition(agent, false)
+		if err != nil {
+			return 0, err
+		}
+		files[KindAgent][agent.Name] = definition
+	}
+
+	written := 0
+	for kind, definitions := range files {
+		kindDir := filepath.Join(dir, kindDirs[kind])
+		if err := os.MkdirAll(kindDir, 0o755); err != nil {
+			return written, fmt.Errorf("failed to create '%s': %w", kindDir, err)
+		}
+		if err := removeStaleFiles(kindDir, definitions); err != nil {
+			return written, err
+		}
+		for name, definition := range definitions {
+			content, err := yaml.Marshal(definition)
+			if err != nil {
+				return written, fmt.Errorf("failed to encode %s '%s': %w", kind, name, err)
+			}
+			path := filepath.Join(kindDir, name+".yaml")
+			if err := os.WriteFile(path, content, 0o644); err != nil {
+				return written, fmt.Errorf("failed to write '%s': %w", path, err)
+			}
+			written++
+		}
+	}
+	return written, nil
+}
+
+// removeStaleFiles deletes the YAML files of a directory not named after a definition.
+func removeStaleFiles(dir string, definitions map[string]map[string]any) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", dir, err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isYAMLFile(name) {
+			continue
+		}
+		if _, exists := definitions[strings.TrimSuffix(name, filepath.Ext(name))]; exists && filepath.Ext(name) == ".yaml" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("failed to remove stale file '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// agentDefinition returns the definition of an agent without its database bookkeeping.
+// Credentials of its LLM and the token of its webhook trigger are left out unless
+// withSecrets is set.
+func agentDefinition(agent agents.Agent, withSecrets bool) (map[string]any, error) {
+	b, err := json.Marshal(agent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode agent '%s': %w", agent.Name, err)
+	}
+	var definition map[string]any
+	if err := json.Unmarshal(b, &definition); err != nil {
+		return nil, fmt.Errorf("failed to encode agent '%s': %w", agent.Name, err)
+	}
+	for _, key := range []string{"ID", "CreatedAt", "UpdatedAt", "DeletedAt"} {
+		delete(definition, key)
+	}
+	if llmConfig, ok := definition["llm_config"].(map[string]any); ok && !withSecrets {
+		delete(llmConfig, "api_key")
+		delete(llmConfig, "api_secret")
+	}
+	if trigger, ok := definition["trigger"].(map[string]any); ok && !withSecrets {
+		delete(trigger, "webhook_token")
+	}
+	return definition, nil
+}
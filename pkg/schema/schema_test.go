@@ -0,0 +1,159 @@
+package schema
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	agents "github.com/gohead-cms/gohead/internal/models/agents"
+	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, path, content string) {
+	t.Helper()
+	full := filepath.Join(dir, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+	require.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+}
+
+func applyDir(t *testing.T, dir string, opts Options) *Plan {
+	t.Helper()
+	defs, err := Load(dir)
+	require.NoError(t, err)
+	plan, err := Diff(defs, opts)
+	require.NoError(t, err)
+	require.NoError(t, Apply(context.Background(), plan, opts))
+	return plan
+}
+
+func TestApplyAndExport(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(
+		&models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRelation{},
+		&models.Singleton{}, &models.Component{}, &models.ComponentAttribute{}, &agents.Agent{},
+	))
+
+	dir := t.TempDir()
+	writeFile(t, dir, "components/seo.yaml", `
+name: seo
+attributes:
+  title: {type: text, required: true}
+`)
+	// Authors and articles reference each other, so one is created before its relation.
+	writeFile(t, dir, "collections/articles.yaml", `
+name: articles
+kind: collection
+description: Blog posts
+attributes:
+  title: {type: text, required: true, max: 120}
+  author: {type: relation, relation: oneToOne, target: authors}
+  seo: {type: component, component: seo}
+`)
+	writeFile(t, dir, "collections/authors.yaml", `
+name: authors
+kind: collection
+attributes:
+  name: {type: text}
+  status: {type: enum, options: [active, retired]}
+  featured: {type: relation, relation: oneToOne, target: articles}
+`)
+	writeFile(t, dir, "singletons/homepage.yaml", `
+name: homepage
+attributes:
+  headline: {type: text}
+`)
+	writeFile(t, dir, "agents/writer.yaml", `
+name: writer
+system_prompt: Write articles.
+max_turns: 3
+llm_config: {provider: openai, model: gpt-4o, api_key: secret}
+memory: {type: in-memory, session_scope: global}
+trigger: {type: webhook, webhook_token: hook-token}
+`)
+
+	plan := applyDir(t, dir, Options{})
+	assert.Len(t, plan.Actions, 5)
+
+	articles, err := storage.GetCollectionByName("articles")
+	require.NoError(t, err)
+	assert.Equal(t, "Blog posts", articles.Description)
+	assert.Len(t, articles.Attributes, 3)
+
+	// Applying the same files again changes nothing.
+	plan = applyDir(t, dir, Options{})
+	assert.True(t, plan.Empty())
+	assert.Equal(t, 5, plan.Unchanged)
+
+	// Exported files apply as unchanged, and leave the agent credentials out.
+	exported := t.TempDir()
+	written, err := Export(exported)
+	require.NoError(t, err)
+	assert.Equal(t, 5, written)
+	agentFile, err := os.ReadFile(filepath.Join(exported, "agents", "writer.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(agentFile), "secret")
+	assert.NotContains(t, string(agentFile), "hook-token")
+
+	plan = applyDir(t, exported, Options{})
+	assert.True(t, plan.Empty())
+	writer, err := storage.GetAgentByName("writer")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", writer.LLMConfig.APIKey)
+	assert.Equal(t, "hook-token", writer.Trigger.WebhookToken)
+
+	// Updates are planned like API updates, and pruning deletes what the files dropped.
+	require.NoError(t, db.Create(&models.Item{CollectionID: articles.ID, Data: models.JSONMap{"title": "Hello"}}).Error)
+	require.NoError(t, os.Remove(filepath.Join(exported, "singletons", "homepage.yaml")))
+	writeFile(t, exported, "collections/articles.yaml", `
+name: articles
+kind: collection
+description: Blog posts
+attributes:
+  headline: {type: text, required: true, max: 120}
+  author: {type: relation, relation: oneToOne, target: authors}
+  seo: {type: component, component: seo}
+directives:
+  renames: {title: headline}
+`)
+	plan = applyDir(t, exported, Options{Prune: true})
+	require.Len(t, plan.Actions, 2)
+	assert.Equal(t, OpUpdate, plan.Actions[0].Op)
+	assert.Equal(t, OpDelete, plan.Actions[1].Op)
+
+	var item models.Item
+	require.NoError(t, db.Where("collection_id = ?", articles.ID).First(&item).Error)
+	assert.Equal(t, "Hello", item.Data["headline"])
+	_, err = storage.GetSingletonByName("homepage")
+	assert.Error(t, err)
+
+	// Updates breaking existing items need force.
+	writeFile(t, exported, "collections/articles.yaml", `
+name: articles
+kind: collection
+attributes:
+  headline: {type: text, required: true}
+  body: {type: text, required: true}
+`)
+	defs, err := Load(exported)
+	require.NoError(t, err)
+	plan, err = Diff(defs, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, plan.BrokenItems())
+	assert.Error(t, Apply(context.Background(), plan, Options{}))
+}
+
+func TestLoadRejectsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "singletons/a.yaml", "name: footer\nattributes:\n  text: {type: text}\n")
+	writeFile(t, dir, "singletons/b.yaml", "name: footer\nattributes:\n  text: {type: text}\n")
+
+	_, err := Load(dir)
+	assert.ErrorContains(t, err, "already defined")
+}
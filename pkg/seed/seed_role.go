@@ -10,16 +10,29 @@ import (
 
 func SeedRoles() {
 	roles := []models.UserRole{
-		{Name: "admin", Description: "Administrator with full access", Permissions: models.JSONMap{"manage_users": true, "manage_content": true}},
-		{Name: "editor", Description: "Editor with content management access", Permissions: models.JSONMap{"manage_content": true}},
-		{Name: "viewer", Description: "Viewer with read-only access", Permissions: models.JSONMap{"read_content": true}},
+		{Name: "admin", Description: "Administrator with full access", Permissions: models.DefaultRolePermissions["admin"]},
+		{Name: "editor", Description: "Editor with content management access", Permissions: models.DefaultRolePermissions["editor"]},
+		{Name: "viewer", Description: "Viewer with read-only access", Permissions: models.DefaultRolePermissions["viewer"]},
 	}
 
 	for _, role := range roles {
-		if err := database.DB.FirstOrCreate(&models.UserRole{}, role).Error; err != nil {
+		var existing models.UserRole
+		if err := database.DB.Where(models.UserRole{Name: role.Name}).Attrs(role).FirstOrCreate(&existing).Error; err != nil {
 			logger.Log.WithFields(logrus.Fields{
 				"role": role.Name,
 			}).Warn("Failed to seed role : ", err)
+			continue
+		}
+
+		// Roles seeded by older versions only hold free-form flags that grant nothing.
+		if existing.HasLegacyPermissions() {
+			if err := database.DB.Model(&existing).Update("permissions", role.Permissions).Error; err != nil {
+				logger.Log.WithFields(logrus.Fields{
+					"role": role.Name,
+				}).Warn("Failed to upgrade role permissions : ", err)
+				continue
+			}
+			logger.Log.WithField("role", role.Name).Info("Upgraded legacy role permissions")
 		}
 	}
 	logger.Log.Info("Default roles seeded successfully.")
@@ -50,3 +50,25 @@ func TestSeedRoles(t *testing.T) {
 		logger.Log.Infof("Verified role '%s' with description '%s'", role.Name, role.Description)
 	}
 }
+
+func TestSeedRolesUpgradesLegacyPermissions(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	assert.NoError(t, db.AutoMigrate(&models.UserRole{}))
+
+	legacy := models.UserRole{Name: "editor", Description: "Editor", Permissions: models.JSONMap{"manage_content": true}}
+	custom := models.UserRole{Name: "viewer", Description: "Viewer", Permissions: models.JSONMap{"collections.articles.read": true}}
+	assert.NoError(t, db.Create(&legacy).Error)
+	assert.NoError(t, db.Create(&custom).Error)
+
+	SeedRoles()
+
+	var editor, viewer models.UserRole
+	assert.NoError(t, db.Where("name = ?", "editor").First(&editor).Error)
+	assert.Equal(t, models.DefaultRolePermissions["editor"], editor.Permissions)
+
+	// Roles already using the permission format are left untouched.
+	assert.NoError(t, db.Where("name = ?", "viewer").First(&viewer).Error)
+	assert.Equal(t, models.JSONMap{"collections.articles.read": true}, viewer.Permissions)
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// CreateAsset saves the record of an uploaded file.
+func CreateAsset(asset *models.Asset) error {
+	if err := database.DB.Create(asset).Error; err != nil {
+		logger.Log.WithField("name", asset.Name).WithError(err).Error("Failed to save asset")
+		return fmt.Errorf("failed to save asset: %w", err)
+	}
+	logger.Log.WithField("asset_id", asset.ID).Info("Asset saved successfully")
+	return nil
+}
+
+// GetAssetByID fetches an asset by its ID.
+func GetAssetByID(id uint) (*models.Asset, error) {
+	var asset models.Asset
+	if err := database.DB.First(&asset, id).Error; err != nil {
+		return nil, fmt.Errorf("asset with ID %d not found: %w", id, err)
+	}
+	return &asset, nil
+}
+
+// GetAssets fetches a page of assets, newest first. When mimePrefix is set only assets
+// whose mime type starts with it are returned, e.g. "image/".
+func GetAssets(mimePrefix string, page, pageSize int) ([]models.Asset, int, error) {
+	var assets []models.Asset
+	var total int64
+
+	query := database.DB.Model(&models.Asset{})
+	if mimePrefix != "" {
+		query = query.Where("mime_type LIKE ? ESCAPE '!'", escapeLike(mimePrefix)+"%")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count assets: %w", err)
+	}
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&assets).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch assets: %w", err)
+	}
+	return assets, int(total), nil
+}
+
+// UpdateAssetAltText changes the alternative text of an asset.
+func UpdateAssetAltText(id uint, altText string) (*models.Asset, error) {
+	asset, err := GetAssetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	asset.AltText = altText
+	if err := database.DB.Save(asset).Error; err != nil {
+		return nil, fmt.Errorf("failed to update asset: %w", err)
+	}
+	return asset, nil
+}
+
+// UpdateAssetVariants replaces the variants of an asset.
+func UpdateAssetVariants(id uint, variants map[string]models.AssetVariant) error {
+	result := database.DB.Model(&models.Asset{ID: id}).Select("Variants").Updates(&models.Asset{Variants: variants})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update asset variants: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("asset with ID %d not found: %w", id, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// DeleteAsset deletes the record of an asset. It reports whether the stored object is
+// no longer referenced by any other asset and can be removed from the storage driver.
+func DeleteAsset(asset *models.Asset) (bool, error) {
+	if err := database.DB.Delete(asset).Error; err != nil {
+		return false, fmt.Errorf("failed to delete asset: %w", err)
+	}
+	var remaining int64
+	if err := database.DB.Model(&models.Asset{}).
+		Where("driver = ? AND storage_key = ?", asset.Driver, asset.StorageKey).
+		Count(&remaining).Error; err != nil {
+		return false, fmt.Errorf("failed to check asset references: %w", err)
+	}
+	return remaining == 0, nil
+}
+
+// getAssetsByIDs fetches assets by ID, indexed by ID.
+func getAssetsByIDs(ids []uint) (map[uint]models.Asset, error) {
+	assets := make(map[uint]models.Asset, len(ids))
+	if len(ids) == 0 {
+		return assets, nil
+	}
+	var found []models.Asset
+	if err := database.DB.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch assets: %w", err)
+	}
+	for _, asset := range found {
+		assets[asset.ID] = asset
+	}
+	return assets, nil
+}
+
+// ExpandMedia returns a copy of data where the asset IDs of media attributes are replaced
+// by the assets. Deleted assets are left out.
+func ExpandMedia(attributes []models.Attribute, data models.JSONMap) (models.JSONMap, error) {
+	var ids []uint
+	for _, attr := range attributes {
+		if attr.Type == "media" {
+			ids = append(ids, models.MediaIDs(attr, data[attr.Name])...)
+		}
+	}
+	if len(ids) == 0 {
+		return data, nil
+	}
+
+	assets, err := getAssetsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	expanded := make(models.JSONMap, len(data))
+	for key, value := range data {
+		expanded[key] = value
+	}
+	for _, attr := range attributes {
+		value, exists := data[attr.Name]
+		if attr.Type != "media" || !exists {
+			continue
+		}
+		if translations, ok := value.(map[string]any); ok && attr.Localized {
+			localized := make(map[string]any, len(translations))
+			for locale, translation := range translations {
+				localized[locale] = expandMediaValue(attr, translation, assets)
+			}
+			expanded[attr.Name] = localized
+			continue
+		}
+		expanded[attr.Name] = expandMediaValue(attr, value, assets)
+	}
+	return expanded, nil
+}
+
+// expandMediaValue returns the assets referenced by a media value: an asset, or a list
+// of assets for attributes accepting multiple files.
+func expandMediaValue(attr models.Attribute, value any, assets map[uint]models.Asset) any {
+	if !attr.Multiple {
+		if asset, ok := assets[models.ToUint(value)]; ok {
+			return asset
+		}
+		return nil
+	}
+	list := []models.Asset{}
+	for _, id := range models.MediaIDs(attr, value) {
+		if asset, ok := assets[id]; ok {
+			list = append(list, asset)
+		}
+	}
+	return list
+}
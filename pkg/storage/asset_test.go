@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaAttributes(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.Attribute{}, &models.ItemRevision{}, &models.ItemRelation{}, &models.Asset{}))
+
+	cover := models.Asset{Name: "cover.png", MimeType: "image/png", Driver: "local", StorageKey: "ab/abc.png", URL: "/uploads/ab/abc.png"}
+	photo := models.Asset{Name: "photo.jpg", MimeType: "image/jpeg", Driver: "local", StorageKey: "cd/cde.jpg", URL: "/uploads/cd/cde.jpg"}
+	assert.NoError(t, CreateAsset(&cover))
+	assert.NoError(t, CreateAsset(&photo))
+
+	collection := models.Collection{
+		Name: "posts",
+		Attributes: []models.Attribute{
+			{Name: "title", Type: "text", Required: true},
+			{Name: "cover", Type: "media"},
+			{Name: "gallery", Type: "media", Multiple: true},
+		},
+	}
+	assert.NoError(t, db.Create(&collection).Error)
+
+	actor := models.UserActor("editor1")
+	item, err := SaveItem(collection, models.JSONMap{
+		"title":   "Hello",
+		"cover":   cover.ID,
+		"gallery": []any{float64(photo.ID), float64(cover.ID)},
+	}, actor)
+	assert.NoError(t, err)
+
+	t.Run("Unknown assets are rejected", func(t *testing.T) {
+		_, err := SaveItem(collection, models.JSONMap{"title": "Broken", "cover": 9999}, actor)
+		assert.ErrorContains(t, err, "referenced asset does not exist")
+		_, err = SaveItem(collection, models.JSONMap{"title": "Broken", "gallery": float64(cover.ID)}, actor)
+		assert.Error(t, err)
+	})
+
+	t.Run("Expand media", func(t *testing.T) {
+		stored, err := GetItemByID(collection.ID, item.ID)
+		assert.NoError(t, err)
+
+		expanded, err := ExpandMedia(collection.Attributes, stored.Data)
+		assert.NoError(t, err)
+		assert.Equal(t, "cover.png", expanded["cover"].(models.Asset).Name)
+		gallery := expanded["gallery"].([]models.Asset)
+		if assert.Len(t, gallery, 2) {
+			assert.Equal(t, photo.ID, gallery[0].ID)
+			assert.Equal(t, cover.ID, gallery[1].ID)
+		}
+		assert.Equal(t, cover.ID, models.ToUint(stored.Data["cover"]), "stored data is not modified")
+	})
+
+	t.Run("Deleted assets are left out", func(t *testing.T) {
+		copyOfPhoto := models.Asset{Name: "again.jpg", Driver: "local", StorageKey: photo.StorageKey}
+		assert.NoError(t, CreateAsset(&copyOfPhoto))
+
+		unused, err := DeleteAsset(&photo)
+		assert.NoError(t, err)
+		assert.False(t, unused, "the file is still used by another asset")
+
+		stored, err := GetItemByID(collection.ID, item.ID)
+		assert.NoError(t, err)
+		expanded, err := ExpandMedia(collection.Attributes, stored.Data)
+		assert.NoError(t, err)
+		assert.Len(t, expanded["gallery"], 1)
+
+		unused, err = DeleteAsset(&copyOfPhoto)
+		assert.NoError(t, err)
+		assert.True(t, unused)
+	})
+
+	t.Run("Update variants", func(t *testing.T) {
+		variants := map[string]models.AssetVariant{
+			"thumbnail": {URL: "/uploads/variants/ab/abc.png/50x50-cover.webp", MimeType: "image/webp", Width: 50, Height: 50},
+		}
+		assert.NoError(t, UpdateAssetVariants(cover.ID, variants))
+		stored, err := GetAssetByID(cover.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, variants, stored.Variants)
+		assert.Equal(t, "cover.png", stored.Name)
+
+		assert.Error(t, UpdateAssetVariants(9999, variants))
+	})
+
+	t.Run("Filter assets by mime type", func(t *testing.T) {
+		assets, total, err := GetAssets("image/", 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, cover.ID, assets[0].ID)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/gohead-cms/gohead/internal/agent/events"
@@ -65,13 +66,15 @@ func SaveCollection(ct *models.Collection) error {
 		return fmt.Errorf("failed to save collection: %w", err)
 	}
 
-	payload := events.CollectionEventPayload{
-		EventType:      events.EventTypeCollectionCreated,
-		CollectionName: ct.Name,
-	}
-	if err := events.EnqueueCollectionEvent(context.Background(), asynqClient, payload); err != nil {
-		// Log the enqueuing error but don't fail the overall SaveItem operation.
-		logger.Log.WithError(err).Error("Failed to enqueue collection:created event")
+	if asynqClient != nil {
+		payload := events.CollectionEventPayload{
+			EventType:      events.EventTypeCollectionCreated,
+			CollectionName: ct.Name,
+		}
+		if err := events.EnqueueCollectionEvent(context.Background(), asynqClient, payload); err != nil {
+			// Log the enqueuing error but don't fail the overall SaveItem operation.
+			logger.Log.WithError(err).Error("Failed to enqueue collection:created event")
+		}
 	}
 
 	logger.Log.WithField("collection", ct.Name).Info("Collection created successfully")
@@ -150,6 +153,16 @@ func GetCollectionByName(name string) (*models.Collection, error) {
 	return &ct, nil
 }
 
+// GetCollectionsByNames retrieves the collections with the given names. Names of no
+// collection are skipped.
+func GetCollectionsByNames(names []string) ([]models.Collection, error) {
+	var collections []models.Collection
+	if err := database.DB.Preload("Attributes").Where("name IN ?", names).Find(&collections).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch collections: %w", err)
+	}
+	return collections, nil
+}
+
 // GetAllCollectionsWithFilters retrieves collections with optional filtering, sorting, and pagination.
 func GetAllCollections(filters map[string]any, sortValues []string, rangeValues []int) ([]models.Collection, int, error) {
 	var collections []models.Collection
@@ -220,8 +233,17 @@ func UpdateCollection(id uint, updated *models.Collection) error {
 		return fmt.Errorf("failed to retrieve collection: %w", err)
 	}
 
+	// Items are reindexed when the text their search entries hold changes
+	reindex := searchFieldsChanged(existing.Attributes, updated.Attributes)
+
 	// Update the basic attributes of the collection
 	existing.Name = updated.Name
+	existing.Description = updated.Description
+	if updated.Kind != "" {
+		existing.Kind = updated.Kind
+	}
+	existing.Locales = updated.Locales
+	existing.DefaultLocale = updated.DefaultLocale
 
 	// Start a transaction for updating associated attributes and relationships
 	tx := database.DB.Begin()
@@ -253,19 +275,43 @@ func UpdateCollection(id uint, updated *models.Collection) error {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	payload := events.CollectionEventPayload{
-		EventType:      events.EventTypeCollectionUpdated,
-		CollectionName: existing.Name,
+	if reindex {
+		if err := ReindexCollection(database.DB, existing.ID); err != nil {
+			logger.Log.WithError(err).WithField("collection_id", id).Error("Failed to reindex collection items")
+			return fmt.Errorf("failed to reindex collection items: %w", err)
+		}
 	}
-	if err := events.EnqueueCollectionEvent(context.Background(), asynqClient, payload); err != nil {
-		// Log the enqueuing error but don't fail the overall SaveItem operation.
-		logger.Log.WithError(err).Error("Failed to enqueue collection:updated event")
+
+	if asynqClient != nil {
+		payload := events.CollectionEventPayload{
+			EventType:      events.EventTypeCollectionUpdated,
+			CollectionName: existing.Name,
+		}
+		if err := events.EnqueueCollectionEvent(context.Background(), asynqClient, payload); err != nil {
+			// Log the enqueuing error but don't fail the overall SaveItem operation.
+			logger.Log.WithError(err).Error("Failed to enqueue collection:updated event")
+		}
 	}
 
 	logger.Log.WithField("collection_id", id).Info("Collection updated successfully")
 	return nil
 }
 
+// searchFieldsChanged reports whether an update changes which attributes are searchable,
+// or how their values are read.
+func searchFieldsChanged(existing, updated []models.Attribute) bool {
+	searchFields := func(attributes []models.Attribute) map[string]string {
+		fields := make(map[string]string)
+		for _, attr := range attributes {
+			if attr.Searchable {
+				fields[attr.Name] = fmt.Sprintf("%s/%t", attr.Type, attr.Localized)
+			}
+		}
+		return fields
+	}
+	return !maps.Equal(searchFields(existing), searchFields(updated))
+}
+
 func updateAssociatedFields(tx *gorm.DB, collectionID *uint, updatedAttributes []models.Attribute) error {
 	// Fetch existing attributes
 	var existingAttributes []models.Attribute
@@ -356,11 +402,28 @@ func DeleteCollection(collectionID uint) error {
 		return fmt.Errorf("Cannot delete collection '%s': it is referenced by fields: %s", Collection.Name, strings.Join(refs, ", "))
 	}
 
-	// Delete associated content items
+	// Delete associated content items and their relations. The collection is not
+	// referenced by others, so the relations all start from its items.
+	if err := tx.Where("source_collection_id = ?", Collection.ID).Delete(&models.ItemRelation{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete relations for collection '%s': %w", Collection.Name, err)
+	}
 	if err := tx.Where("collection_id = ?", Collection.ID).Delete(&models.Item{}).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to delete content items for collection '%s': %w", Collection.Name, err)
 	}
+	if searchIndex != nil {
+		if err := searchIndex.RemoveCollection(tx, Collection.ID); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to remove search entries for collection '%s': %w", Collection.Name, err)
+		}
+	}
+	if vectorStore != nil {
+		if err := tx.Where("collection_id = ?", Collection.ID).Delete(&models.ItemEmbedding{}).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to remove item embeddings for collection '%s': %w", Collection.Name, err)
+		}
+	}
 	logger.Log.WithField("collection_id", collectionID).Info("DeleteCollection: delete successfully associated items")
 
 	// Delete the collection itself
@@ -369,13 +432,15 @@ func DeleteCollection(collectionID uint) error {
 		return fmt.Errorf("failed to delete collection with ID '%d': %w", collectionID, err)
 	}
 
-	payload := events.CollectionEventPayload{
-		EventType:      events.EventTypeCollectionDeleted,
-		CollectionName: Collection.Name,
-	}
-	if err := events.EnqueueCollectionEvent(context.Background(), asynqClient, payload); err != nil {
-		// Log the enqueuing error but don't fail the overall SaveItem operation.
-		logger.Log.WithError(err).Error("Failed to enqueue collection:deleted event")
+	if asynqClient != nil {
+		payload := events.CollectionEventPayload{
+			EventType:      events.EventTypeCollectionDeleted,
+			CollectionName: Collection.Name,
+		}
+		if err := events.EnqueueCollectionEvent(context.Background(), asynqClient, payload); err != nil {
+			// Log the enqueuing error but don't fail the overall SaveItem operation.
+			logger.Log.WithError(err).Error("Failed to enqueue collection:deleted event")
+		}
 	}
 
 	// Commit the transaction
@@ -62,6 +62,15 @@ func GetComponentByName(name string) (*models.Component, error) {
 	return &cmp, nil
 }
 
+// GetAllComponents retrieves every component definition with its attributes, ordered by name.
+func GetAllComponents() ([]models.Component, error) {
+	var components []models.Component
+	if err := database.DB.Preload("Attributes").Order("name ASC").Find(&components).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch components: %w", err)
+	}
+	return components, nil
+}
+
 // UpdateComponent updates an existing component definition by name.
 func UpdateComponent(name string, updated *models.Component) error {
 	// Validate schema of the incoming data
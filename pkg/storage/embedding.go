@@ -0,0 +1,377 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/llm"
+	"github.com/gohead-cms/gohead/pkg/logger"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// TaskTypeEmbeddings is the task bringing the embeddings of items up to date.
+	TaskTypeEmbeddings = "embeddings:update"
+	// EmbeddingsQueueName is the Asynq queue of embedding tasks.
+	EmbeddingsQueueName = "embeddings"
+
+	// embeddingBatchSize is the number of texts sent to the embedding provider at once.
+	embeddingBatchSize = 64
+)
+
+// ErrSemanticSearchDisabled is returned when no embedding provider is configured.
+var ErrSemanticSearchDisabled = errors.New("semantic search is not enabled")
+
+// SemanticQuery describes a semantic search.
+type SemanticQuery struct {
+	// Text is compared by meaning with the semantic attributes of items.
+	Text string
+	// Collections restricts the search to the named collections. Empty searches them all.
+	Collections []string
+	// PublishedOnly hides draft items.
+	PublishedOnly bool
+	// Limit is the maximum number of results, DefaultSearchLimit when zero.
+	Limit int
+}
+
+// SemanticResult is an item close in meaning to a semantic search. Score is the cosine
+// similarity of their embeddings, 1 for identical meanings.
+type SemanticResult struct {
+	Collection string  `json:"collection"`
+	ItemID     uint    `json:"id"`
+	Score      float64 `json:"score"`
+}
+
+// VectorStore finds the item embeddings nearest to a vector, implemented with pgvector
+// on Postgres and by brute force on other databases.
+type VectorStore interface {
+	// Migrate creates the table of item embeddings if needed.
+	Migrate(db *gorm.DB) error
+	// Nearest returns the items whose embedding computed by model is the most similar
+	// to vector, most similar first.
+	Nearest(db *gorm.DB, vector []float32, model string, query SemanticQuery) ([]SemanticResult, error)
+}
+
+// EmbeddingsPayload is the payload of a TaskTypeEmbeddings task: the items, or every
+// item of the collection, whose embeddings are brought up to date.
+type EmbeddingsPayload struct {
+	CollectionID uint   `json:"collection_id,omitempty"`
+	ItemIDs      []uint `json:"item_ids,omitempty"`
+}
+
+var (
+	embedder    llm.Embedder
+	vectorStore VectorStore
+)
+
+// NewVectorStore returns the vector store for the dialect of db: pgvector on Postgres
+// when the extension can be enabled, brute force otherwise.
+func NewVectorStore(db *gorm.DB) VectorStore {
+	if db.Dialector.Name() == "postgres" {
+		if err := db.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error; err == nil {
+			return &pgVectorStore{}
+		} else {
+			logger.Log.WithError(err).Warn("pgvector is not available, semantic search falls back to brute force")
+		}
+	}
+	return &bruteForceVectorStore{}
+}
+
+// InitSemanticSearch enables semantic search with the embeddings computed by e and
+// creates the table of item embeddings.
+func InitSemanticSearch(db *gorm.DB, e llm.Embedder) error {
+	store := NewVectorStore(db)
+	if err := store.Migrate(db); err != nil {
+		return fmt.Errorf("failed to create the item embeddings table: %w", err)
+	}
+	embedder = e
+	vectorStore = store
+	return nil
+}
+
+// SemanticSearchEnabled reports whether an embedding provider is configured.
+func SemanticSearchEnabled() bool {
+	return embedder != nil
+}
+
+// SemanticSearch returns the items whose semantic attributes are the closest in
+// meaning to the query. Items not embedded yet are not found.
+func SemanticSearch(ctx context.Context, query SemanticQuery) ([]SemanticResult, error) {
+	if embedder == nil {
+		return nil, ErrSemanticSearchDisabled
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultSearchLimit
+	}
+	query.Limit = min(query.Limit, MaxSearchLimit)
+
+	vectors, err := embedder.Embed(ctx, []string{query.Text})
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to embed search query")
+		return nil, fmt.Errorf("failed to embed search query: %w", err)
+	}
+	results, err := vectorStore.Nearest(database.DB, vectors[0], embedder.Model(), query)
+	if err != nil {
+		logger.Log.WithError(err).WithField("query", query.Text).Error("Failed to search item embeddings")
+		return nil, fmt.Errorf("failed to search item embeddings: %w", err)
+	}
+	return results, nil
+}
+
+// EnqueueEmbeddings enqueues a task bringing the embeddings of items up to date.
+func EnqueueEmbeddings(ctx context.Context, client *asynq.Client, payload EmbeddingsPayload) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal payload: %w", err)
+	}
+	task := asynq.NewTask(TaskTypeEmbeddings, payloadBytes, asynq.Queue(EmbeddingsQueueName), asynq.MaxRetry(5))
+
+	info, err := client.EnqueueContext(ctx, task)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to enqueue embeddings task")
+		return fmt.Errorf("could not enqueue task: %w", err)
+	}
+	logger.Log.
+		WithField("job_id", info.ID).
+		WithField("collection_id", payload.CollectionID).
+		WithField("item_ids", payload.ItemIDs).
+		Info("Successfully enqueued embeddings task")
+	return nil
+}
+
+// HandleEmbeddingsTask processes a TaskTypeEmbeddings task.
+func HandleEmbeddingsTask(ctx context.Context, task *asynq.Task) error {
+	var payload EmbeddingsPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid embeddings payload: %v: %w", err, asynq.SkipRetry)
+	}
+	if embedder == nil {
+		return fmt.Errorf("%w: %w", ErrSemanticSearchDisabled, asynq.SkipRetry)
+	}
+	if payload.CollectionID != 0 {
+		return EmbedCollection(ctx, payload.CollectionID)
+	}
+	return EmbedItems(ctx, payload.ItemIDs)
+}
+
+// EmbedCollection brings the embeddings of every item of a collection up to date.
+func EmbedCollection(ctx context.Context, collectionID uint) error {
+	var ids []uint
+	if err := database.DB.Model(&models.Item{}).Where("collection_id = ?", collectionID).Order("id").Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to list items of collection %d: %w", collectionID, err)
+	}
+	for start := 0; start < len(ids); start += schemaMigrationBatchSize {
+		if err := EmbedItems(ctx, ids[start:min(start+schemaMigrationBatchSize, len(ids))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EmbedItems brings the embeddings of items up to date with the text of their semantic
+// attributes. Items whose text did not change since their embedding was computed are
+// skipped, and the embeddings of deleted items and of items without text are removed.
+func EmbedItems(ctx context.Context, ids []uint) error {
+	if embedder == nil {
+		return ErrSemanticSearchDisabled
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var items []models.Item
+	if err := database.DB.Unscoped().Where("id IN ?", ids).Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to load items to embed: %w", err)
+	}
+	var stored []models.ItemEmbedding
+	if err := database.DB.Where("item_id IN ?", ids).Find(&stored).Error; err != nil {
+		return fmt.Errorf("failed to load item embeddings: %w", err)
+	}
+	storedByItem := make(map[uint]models.ItemEmbedding, len(stored))
+	for _, embedding := range stored {
+		storedByItem[embedding.ItemID] = embedding
+	}
+
+	model := embedder.Model()
+	collections := make(map[uint]*models.Collection)
+	found := make(map[uint]bool, len(items))
+	var removed []uint
+	var pending []models.ItemEmbedding
+	var texts []string
+	for _, item := range items {
+		found[item.ID] = true
+		collection, loaded := collections[item.CollectionID]
+		if !loaded {
+			collection = &models.Collection{}
+			if err := database.DB.Preload("Attributes").First(collection, item.CollectionID).Error; err != nil {
+				return fmt.Errorf("failed to load collection of item %d: %w", item.ID, err)
+			}
+			collections[item.CollectionID] = collection
+		}
+
+		text := ""
+		if !item.DeletedAt.Valid {
+			text = collection.SemanticText(item.Data)
+		}
+		if text == "" {
+			removed = append(removed, item.ID)
+			continue
+		}
+		hash := sha256.Sum256([]byte(text))
+		contentHash := hex.EncodeToString(hash[:])
+		if current, exists := storedByItem[item.ID]; exists && current.Model == model && current.ContentHash == contentHash {
+			continue
+		}
+		pending = append(pending, models.ItemEmbedding{ItemID: item.ID, CollectionID: item.CollectionID, Model: model, ContentHash: contentHash})
+		texts = append(texts, text)
+	}
+	for _, id := range ids {
+		if !found[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	for start := 0; start < len(pending); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(pending))
+		vectors, err := embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to embed items: %w", err)
+		}
+		batch := pending[start:end]
+		for i := range batch {
+			batch[i].Vector = vectors[i]
+		}
+		if err := database.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&batch).Error; err != nil {
+			return fmt.Errorf("failed to store item embeddings: %w", err)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := database.DB.Where("item_id IN ?", removed).Delete(&models.ItemEmbedding{}).Error; err != nil {
+			return fmt.Errorf("failed to remove item embeddings: %w", err)
+		}
+	}
+	logger.Log.WithField("embedded", len(pending)).WithField("removed", len(removed)).Info("Item embeddings updated")
+	return nil
+}
+
+// embeddingMatches returns the query joining the embeddings computed by model to their
+// live items and collections, restricted to the collections and publication state of
+// the search.
+func embeddingMatches(db *gorm.DB, model string, query SemanticQuery) *gorm.DB {
+	tx := db.Table("item_embeddings").
+		Joins("JOIN items ON items.id = item_embeddings.item_id AND items.deleted_at IS NULL").
+		Joins("JOIN collections ON collections.id = items.collection_id AND collections.deleted_at IS NULL").
+		Where("item_embeddings.model = ?", model)
+	if len(query.Collections) > 0 {
+		tx = tx.Where("collections.name IN ?", query.Collections)
+	}
+	if query.PublishedOnly {
+		tx = tx.Where("items.status = ?", models.ItemStatusPublished)
+	}
+	return tx
+}
+
+// bruteForceVectorStore compares the vector with every candidate embedding in Go.
+type bruteForceVectorStore struct{}
+
+func (s *bruteForceVectorStore) Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&models.ItemEmbedding{})
+}
+
+func (s *bruteForceVectorStore) Nearest(db *gorm.DB, vector []float32, model string, query SemanticQuery) ([]SemanticResult, error) {
+	rows, err := embeddingMatches(db, model, query).
+		Select("collections.name, item_embeddings.item_id, item_embeddings.vector").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SemanticResult{}
+	for rows.Next() {
+		var result SemanticResult
+		var candidate models.Vector
+		if err := rows.Scan(&result.Collection, &result.ItemID, &candidate); err != nil {
+			return nil, err
+		}
+		result.Score = cosineSimilarity(vector, candidate)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(a, b int) bool { return results[a].Score > results[b].Score })
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+// pgVectorStore keeps embeddings in a pgvector column and lets Postgres rank them by
+// cosine distance.
+type pgVectorStore struct{}
+
+func (s *pgVectorStore) Migrate(db *gorm.DB) error {
+	if db.Migrator().HasTable(&models.ItemEmbedding{}) {
+		return nil
+	}
+	// The vector column has no fixed size, so embeddings of any model can be stored.
+	err := db.Exec(`CREATE TABLE item_embeddings (
+		item_id BIGINT PRIMARY KEY,
+		collection_id BIGINT NOT NULL,
+		model VARCHAR(255) NOT NULL,
+		content_hash VARCHAR(64) NOT NULL,
+		vector VECTOR NOT NULL,
+		updated_at TIMESTAMPTZ
+	)`).Error
+	if err == nil {
+		err = db.Exec("CREATE INDEX idx_item_embeddings_collection_id ON item_embeddings (collection_id)").Error
+	}
+	return err
+}
+
+func (s *pgVectorStore) Nearest(db *gorm.DB, vector []float32, model string, query SemanticQuery) ([]SemanticResult, error) {
+	literal, err := models.Vector(vector).Value()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []SemanticResult{}
+	err = embeddingMatches(db, model, query).
+		Select("collections.name AS collection, items.id AS item_id, 1 - (item_embeddings.vector <=> ?::vector) AS score", literal).
+		Order(clause.Expr{SQL: "item_embeddings.vector <=> ?::vector", Vars: []any{literal}}).
+		Limit(query.Limit).
+		Scan(&results).Error
+	return results, err
+}
+
+// cosineSimilarity returns the cosine of the angle between two vectors, 0 when their
+// sizes differ or one of them is null.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
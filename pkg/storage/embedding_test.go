@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/llm"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder counts the texts embedded by the wrapped embedder.
+type countingEmbedder struct {
+	llm.Embedder
+	texts int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.texts += len(texts)
+	return e.Embedder.Embed(ctx, texts)
+}
+
+func TestSemanticSearch(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRelation{}, &models.ItemRevision{}))
+
+	ctx := context.Background()
+	_, err := SemanticSearch(ctx, SemanticQuery{Text: "anything"})
+	assert.ErrorIs(t, err, ErrSemanticSearchDisabled)
+
+	counter := &countingEmbedder{Embedder: llm.NewFakeEmbedder(64)}
+	require.NoError(t, InitSemanticSearch(db, counter))
+	defer func() { embedder, vectorStore = nil, nil }()
+
+	actor := models.SystemActor("test")
+	recipes := models.Collection{Name: "recipes", Attributes: []models.Attribute{
+		{Name: "title", Type: "text", Semantic: true},
+		{Name: "notes", Type: "text"},
+	}}
+	require.NoError(t, db.Create(&recipes).Error)
+
+	soup, err := SaveItem(recipes, models.JSONMap{"title": "Tomato soup with basil", "notes": "bicycle"}, actor)
+	require.NoError(t, err)
+	_, err = PublishItem(recipes, soup.ID)
+	require.NoError(t, err)
+	bread, err := SaveItem(recipes, models.JSONMap{"title": "Sourdough bread"}, actor)
+	require.NoError(t, err)
+	empty, err := SaveItem(recipes, models.JSONMap{"notes": "no title"}, actor)
+	require.NoError(t, err)
+
+	require.NoError(t, EmbedCollection(ctx, recipes.ID))
+	assert.Equal(t, 2, counter.texts)
+
+	results, err := SemanticSearch(ctx, SemanticQuery{Text: "basil soup"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, soup.ID, results[0].ItemID)
+	assert.Equal(t, "recipes", results[0].Collection)
+	assert.Greater(t, results[0].Score, results[1].Score)
+
+	results, err = SemanticSearch(ctx, SemanticQuery{Text: "bread", PublishedOnly: true})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, soup.ID, results[0].ItemID)
+
+	// Items whose semantic text did not change are not embedded again.
+	counter.texts = 0
+	require.NoError(t, UpdateItem(soup.ID, models.JSONMap{"title": "Tomato soup with basil", "notes": "car"}, actor))
+	require.NoError(t, EmbedItems(ctx, []uint{soup.ID, bread.ID, empty.ID}))
+	assert.Zero(t, counter.texts)
+
+	require.NoError(t, UpdateItem(bread.ID, models.JSONMap{"title": "Basil bread"}, actor))
+	require.NoError(t, EmbedItems(ctx, []uint{bread.ID}))
+	assert.Equal(t, 1, counter.texts)
+
+	// The embeddings of deleted items are removed.
+	require.NoError(t, DeleteItem(soup.ID, actor))
+	require.NoError(t, EmbedItems(ctx, []uint{soup.ID}))
+	var embeddings int64
+	require.NoError(t, db.Model(&models.ItemEmbedding{}).Count(&embeddings).Error)
+	assert.Equal(t, int64(1), embeddings)
+
+	require.NoError(t, DeleteCollection(recipes.ID))
+	require.NoError(t, db.Model(&models.ItemEmbedding{}).Count(&embeddings).Error)
+	assert.Equal(t, int64(0), embeddings)
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	assert.InDelta(t, 1, cosineSimilarity([]float32{1, 2}, []float32{2, 4}), 1e-9)
+	assert.InDelta(t, 0, cosineSimilarity([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	assert.Zero(t, cosineSimilarity([]float32{1}, []float32{1, 0}))
+	assert.Zero(t, cosineSimilarity([]float32{0, 0}, []float32{1, 0}))
+}
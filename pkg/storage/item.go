@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"time"
 
 	"github.com/gohead-cms/gohead/internal/agent/events"
 	"github.com/gohead-cms/gohead/internal/models"
@@ -12,14 +13,14 @@ import (
 	"gorm.io/gorm"
 )
 
-// SaveItem creates a new item in the database and then publishes a generic
-// 'item:created' event to the dispatcher queue.
-func SaveItem(collection models.Collection, itemData models.JSONMap) (*models.Item, error) {
+// SaveItem creates a new item in the database, records its first revision and then
+// publishes a generic 'item:created' event to the dispatcher queue.
+func SaveItem(collection models.Collection, itemData models.JSONMap, actor models.Actor) (*models.Item, error) {
 	var finalItem models.Item
 
 	txErr := database.DB.Transaction(func(tx *gorm.DB) error {
 		// 1. Validate and process the data, which creates nested items.
-		processedData, err := models.ValidateItemValues(collection, itemData, tx)
+		processedData, err := models.ValidateItemValues(collection, itemData, tx, models.WriteAccess{Role: actor.Role})
 		if err != nil {
 			return err // Rolls back transaction.
 		}
@@ -27,11 +28,18 @@ func SaveItem(collection models.Collection, itemData models.JSONMap) (*models.It
 		// 2. Create the main item with the processed data.
 		item := models.Item{
 			CollectionID: collection.ID,
+			Status:       models.ItemStatusDraft,
 			Data:         processedData,
 		}
 		if err := tx.Create(&item).Error; err != nil {
 			return err
 		}
+		if err := models.SyncItemRelations(tx, collection, &item); err != nil {
+			return err
+		}
+		if err := RecordItemRevision(tx, &item, models.RevisionActionCreate, actor); err != nil {
+			return err
+		}
 
 		finalItem = item
 		return nil // Commits transaction.
@@ -78,41 +86,14 @@ func publishEvent(db *gorm.DB, eventType events.EventType, item *models.Item) {
 	}
 }
 
-func SaveIteem(collection models.Collection, itemData models.JSONMap) (*models.Item, error) {
-	var finalItem models.Item
-
-	txErr := database.DB.Transaction(func(tx *gorm.DB) error {
-		// 1. Validate and process the data, which creates nested items.
-		processedData, err := models.ValidateItemValues(collection, itemData, tx)
-		if err != nil {
-			return err // Rolls back transaction.
-		}
-
-		// 2. Create the main item with the processed data.
-		item := models.Item{
-			CollectionID: collection.ID,
-			Data:         processedData,
-		}
-		if err := tx.Create(&item).Error; err != nil {
-			return err
-		}
-
-		finalItem = item
-		return nil // Commits transaction.
-	})
-
-	if txErr != nil {
-		return nil, txErr
+// SaveItemWithTransaction creates a new item within tx, records its first revision and
+// then publishes a generic 'item:created' event to the dispatcher queue.
+func SaveItemWithTransaction(tx *gorm.DB, item *models.Item, actor models.Actor) error {
+	// New items start as drafts unless the caller chose otherwise.
+	if item.Status == "" {
+		item.Status = models.ItemStatusDraft
 	}
 
-	// 3. Publish event after transaction is successfully committed.
-	publishEvent(database.DB, events.EventTypeItemCreated, &finalItem)
-	return &finalItem, nil
-}
-
-// SaveItem creates a new item in the database and then publishes a generic
-// 'item:created' event to the dispatcher queue.
-func SaveItemWithTransaction(tx *gorm.DB, item *models.Item) error {
 	// Step 1: Create the item in the database.
 	// After this call succeeds, GORM will automatically populate the 'ID'
 	// field on the 'item' struct that was passed in.
@@ -120,22 +101,20 @@ func SaveItemWithTransaction(tx *gorm.DB, item *models.Item) error {
 		logger.Log.WithError(err).Error("Failed to save new item")
 		return err
 	}
+	var collection models.Collection
+	if err := tx.Preload("Attributes").First(&collection, item.CollectionID).Error; err != nil {
+		return fmt.Errorf("failed to load collection of item %d: %w", item.ID, err)
+	}
+	if err := models.SyncItemRelations(tx, collection, item); err != nil {
+		return err
+	}
+	if err := RecordItemRevision(tx, item, models.RevisionActionCreate, actor); err != nil {
+		return err
+	}
 
 	// Step 2: Publish the creation event.
 	// We check if the asynqClient has been initialized.
 	if asynqClient != nil {
-		// We need the collection name. We can get it efficiently from the item's CollectionID.
-		var collection models.Collection
-		if err := tx.First(&collection, item.CollectionID).Error; err != nil {
-			// The item was saved, but we couldn't find its collection to publish the event.
-			// This should be logged, but we don't return an error because the main
-			// operation (saving the item) was successful.
-			logger.Log.WithError(err).
-				WithField("collection_id", item.CollectionID).
-				Error("Failed to find collection for event publishing after item creation")
-			return nil
-		}
-
 		// Now, item.ID has the correct, non-zero ID from the database.
 		payload := events.CollectionEventPayload{
 			EventType:      events.EventTypeItemCreated,
@@ -161,53 +140,53 @@ func GetItemByID(collectionID uint, itemID uint) (*models.Item, error) {
 	return &item, nil
 }
 
+// GetItems fetches a page of items of a collection, ordered by ID.
 func GetItems(collectionID uint, page, pageSize int) ([]models.Item, int, error) {
-	var items []models.Item
-	var totalItems int64
-
-	// Calculate offset
-	offset := (page - 1) * pageSize
-
-	// Fetch total count
-	err := database.DB.Model(&models.Item{}).
-		Where("collection_id = ?", collectionID).
-		Count(&totalItems).Error
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count items: %w", err)
-	}
-
-	// Fetch paginated data
-	err = database.DB.Where("collection_id = ?", collectionID).
-		Offset(offset).Limit(pageSize).
-		Find(&items).Error
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch items: %w", err)
-	}
-
-	return items, int(totalItems), nil
+	return QueryItems(collectionID, models.ItemQuery{}, page, pageSize)
 }
 
-func UpdateItem(itemID uint, data models.JSONMap) error {
+// UpdateItem replaces the data of an item and records the change as a new revision.
+// Attributes the actor's role may not write keep their stored values.
+func UpdateItem(itemID uint, data models.JSONMap, actor models.Actor) error {
 	var item models.Item
 	if err := database.DB.Where("id = ?", itemID).First(&item).Error; err != nil {
 		logger.Log.WithField("item_id", itemID).WithError(err).Error("Failed to find item")
 		return fmt.Errorf("collection item not found")
 	}
 
-	item.Data = data
-	if err := database.DB.Save(&item).Error; err != nil {
-		logger.Log.WithField("item_id", itemID).WithError(err).Error("Failed to update item data")
-		return fmt.Errorf("failed to update item data: %w", err)
+	var collection models.Collection
+	if err := database.DB.Preload("Attributes").First(&collection, item.CollectionID).Error; err != nil {
+		return fmt.Errorf("failed to load collection attributes: %w", err)
+	}
+	attributes := collection.Attributes
+	// Plain values of localized attributes are written in the default locale.
+	newData, err := collection.LocalizeInput(data, item.Data, collection.DefaultLocale)
+	if err != nil {
+		return err
 	}
+	access := models.WriteAccess{Role: actor.Role, Current: item.Data}
+	if err := models.CheckFieldWrites(attributes, newData, access); err != nil {
+		return err
+	}
+	models.KeepProtectedFields(attributes, newData, access)
 
-	// Publish the 'updated' event.
-	if asynqClient != nil {
-		var collection models.Collection
-		if err := database.DB.First(&collection, item.CollectionID).Error; err != nil {
-			logger.Log.WithError(err).WithField("collection_id", item.CollectionID).Error("Failed to find collection for event publishing after item update")
-			return nil
+	item.Data = newData
+	txErr := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&item).Error; err != nil {
+			return err
 		}
+		if err := models.SyncItemRelations(tx, collection, &item); err != nil {
+			return err
+		}
+		return RecordItemRevision(tx, &item, models.RevisionActionUpdate, actor)
+	})
+	if txErr != nil {
+		logger.Log.WithField("item_id", itemID).WithError(txErr).Error("Failed to update item data")
+		return fmt.Errorf("failed to update item data: %w", txErr)
+	}
 
+	// Publish the 'updated' event.
+	if asynqClient != nil {
 		payload := events.CollectionEventPayload{
 			EventType:      events.EventTypeItemUpdated,
 			CollectionName: collection.Name,
@@ -223,122 +202,173 @@ func UpdateItem(itemID uint, data models.JSONMap) error {
 	return nil
 }
 
-func DeleteItem(id uint) error {
-	// --- NEW: Publish Event ---
-	// Before deleting, we must fetch the item to get its data for the event payload.
-	var item models.Item
-	// Fetch the item before deleting it so we can include its data in the event.
-	err := database.DB.First(&item, id).Error
-	if err == nil && asynqClient != nil {
-		// If the item was found, try to find its collection to get the name.
-		var collection models.Collection
-		if err := database.DB.First(&collection, item.CollectionID).Error; err == nil {
-			// If both are found, publish the event.
-			payload := events.CollectionEventPayload{
-				EventType:      events.EventTypeItemDeleted,
-				CollectionName: collection.Name,
-				ItemID:         item.ID,
-				ItemData:       item.Data,
-			}
-			if err := events.EnqueueCollectionEvent(context.Background(), asynqClient, payload); err != nil {
-				logger.Log.WithError(err).Error("Failed to enqueue item:deleted event")
-			}
-		}
-	}
-	// --- END ---
-
-	if err := database.DB.Where("id = ?", id).Delete(&models.Item{}).Error; err != nil {
+// DeleteItem deletes an item, keeping a final revision with its last data. Items
+// referencing it are handled according to the delete action of their relation: the
+// reference is dropped, they are deleted as well, or the deletion is refused with a
+// *models.ItemReferencedError.
+func DeleteItem(id uint, actor models.Actor) error {
+	var deleted, updated []models.Item
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		deleted, updated, err = deleteItems(tx, id, actor)
+		return err
+	})
+	if err != nil {
 		return err
 	}
 
+	for i := range deleted {
+		publishEvent(database.DB, events.EventTypeItemDeleted, &deleted[i])
+	}
+	for i := range updated {
+		publishEvent(database.DB, events.EventTypeItemUpdated, &updated[i])
+	}
 	return nil
 }
 
-func FetchNestedRelations(collection models.Collection, data models.JSONMap, level uint) (models.JSONMap, error) {
+// ReadOptions controls which related items are visible when hydrating relations.
+type ReadOptions struct {
+	// PublishedOnly hides draft items behind relations.
+	PublishedOnly bool
+	// CanRead, when set, hides relations to collections it returns false for.
+	CanRead func(collectionName string) bool
+	// CanReadField, when set, removes the values of attributes it returns false for,
+	// on the item and on related items.
+	CanReadField func(attribute models.Attribute) bool
+	// Locale selects the translation of localized attributes, on the item and on related
+	// items. Empty reads the default locale and models.LocaleAll keeps every translation.
+	Locale string
+}
+
+// FetchNestedRelations replaces relation IDs in data by the related items, up to 'level' deep,
+// and the asset IDs of media attributes by the assets. The related items are those stored
+// for the item whose ID is data["id"], or the IDs held by data when it has none.
+// Hidden attributes are removed and localized attributes translated at every level,
+// including the last one.
+func FetchNestedRelations(collection models.Collection, data models.JSONMap, level uint, opts ReadOptions) (models.JSONMap, error) {
+	results, err := FetchItemsRelations(collection, []models.JSONMap{data}, level, opts)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// FetchItemsRelations is FetchNestedRelations for a list of items of a collection. Related
+// items are loaded in batches: the number of queries depends on the depth and on the
+// relation attributes, not on the number of items.
+func FetchItemsRelations(collection models.Collection, items []models.JSONMap, level uint, opts ReadOptions) ([]models.JSONMap, error) {
+	localized := opts.Locale != models.LocaleAll && collection.HasLocalizedAttributes()
+	if level == 0 && opts.CanReadField == nil && !localized {
+		return items, nil
+	}
+
+	results := make([]models.JSONMap, len(items))
+	for i, data := range items {
+		// We'll mutate a copy
+		result := make(models.JSONMap, len(data))
+		maps.Copy(result, data)
+		if localized {
+			result = collection.LocalizeData(result, opts.Locale)
+		}
+		for _, attr := range collection.Attributes {
+			if opts.CanReadField != nil && !opts.CanReadField(attr) {
+				delete(result, attr.Name)
+			}
+		}
+		results[i] = result
+	}
 	if level == 0 {
-		return data, nil
+		return results, nil
 	}
-	// We'll mutate a copy
-	result := make(models.JSONMap, len(data))
-	maps.Copy(result, data)
 
+	stored, err := loadItemRelations(items)
+	if err != nil {
+		return nil, err
+	}
 	for _, attr := range collection.Attributes {
-		if attr.Type != "relation" {
+		if attr.Type != "relation" || (opts.CanReadField != nil && !opts.CanReadField(attr)) {
 			continue
 		}
 
-		raw, exists := data[attr.Name]
-		if !exists || raw == nil {
-			// Always output as empty array/object if missing
-			switch attr.Relation {
-			case "manyToMany":
-				result[attr.Name] = []any{}
-			case "oneToOne", "oneToMany":
-				result[attr.Name] = nil
+		idsByItem := make([][]uint, len(items))
+		var targetIDs []uint
+		for i, data := range items {
+			if id := models.ToUint(data["id"]); id != 0 {
+				idsByItem[i] = stored[id][attr.Name]
+			} else {
+				idsByItem[i] = models.RelationIDs(data[attr.Name])
 			}
-			continue
-		}
-
-		// Fetch target collection
-		var targetCollection models.Collection
-		err := database.DB.Where("name = ?", attr.Target).First(&targetCollection).Error
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch target collection '%s': %w", attr.Target, err)
+			targetIDs = append(targetIDs, idsByItem[i]...)
 		}
 
-		switch attr.Relation {
-		case "oneToOne", "oneToMany":
-			id := models.ToUint(raw)
-			if id == 0 {
-				result[attr.Name] = nil
-				break
-			}
-			nested, err := fetchItemWithRelations(targetCollection, id, level-1)
+		// Related items of collections the reader may not read are hidden.
+		var related map[uint]models.JSONMap
+		if len(targetIDs) > 0 && (opts.CanRead == nil || opts.CanRead(attr.Target)) {
+			related, err = fetchRelatedItems(attr.Target, targetIDs, level-1, opts)
 			if err != nil {
-				return nil, fmt.Errorf("failed to fetch nested item for '%s': %w", attr.Name, err)
+				return nil, fmt.Errorf("failed to fetch nested items for '%s': %w", attr.Name, err)
 			}
-			result[attr.Name] = nested
-		case "manyToMany":
-			var nestedItems []models.JSONMap
-			logger.Log.WithField("netste", nestedItems)
-			switch ids := raw.(type) {
-			case []any:
-				for _, elem := range ids {
-					id := models.ToUint(elem)
-					if id == 0 {
-						continue
-					}
-					nested, err := fetchItemWithRelations(targetCollection, id, level-1)
-					if err != nil {
-						return nil, fmt.Errorf("failed to fetch nested item for '%s': %w", attr.Name, err)
-					}
-					nestedItems = append(nestedItems, nested)
-				}
-			case []float64:
-				for _, elem := range ids {
-					id := uint(elem)
-					nested, err := fetchItemWithRelations(targetCollection, id, level-1)
-					if err != nil {
-						return nil, fmt.Errorf("failed to fetch nested item for '%s': %w", attr.Name, err)
-					}
-					nestedItems = append(nestedItems, nested)
+		}
+
+		for i, ids := range idsByItem {
+			nested := []models.JSONMap{}
+			for _, id := range ids {
+				if item, ok := related[id]; ok {
+					nested = append(nested, item)
 				}
+			}
+			switch {
+			case attr.IsToMany():
+				results[i][attr.Name] = nested
+			case len(nested) > 0:
+				results[i][attr.Name] = nested[0]
 			default:
-				// If not an array, just output empty array
+				results[i][attr.Name] = nil
 			}
-			result[attr.Name] = nestedItems
 		}
 	}
-	return result, nil
+
+	for i, result := range results {
+		expanded, err := ExpandMedia(collection.Attributes, result)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = expanded
+	}
+	return results, nil
+}
+
+// PublishItem marks an item as published and publishes an 'item:published' event.
+func PublishItem(collection models.Collection, itemID uint) (*models.Item, error) {
+	return setItemStatus(collection, itemID, models.ItemStatusPublished, events.EventTypeItemPublished)
 }
 
-func fetchItemWithRelations(collection models.Collection, itemID uint, level uint) (models.JSONMap, error) {
+// UnpublishItem moves an item back to draft and publishes an 'item:unpublished' event.
+func UnpublishItem(collection models.Collection, itemID uint) (*models.Item, error) {
+	return setItemStatus(collection, itemID, models.ItemStatusDraft, events.EventTypeItemUnpublished)
+}
+
+func setItemStatus(collection models.Collection, itemID uint, status string, eventType events.EventType) (*models.Item, error) {
 	var item models.Item
+	if err := database.DB.Where("id = ? AND collection_id = ?", itemID, collection.ID).First(&item).Error; err != nil {
+		return nil, fmt.Errorf("item with ID %d not found: %w", itemID, err)
+	}
 
-	err := database.DB.Where("id = ? AND collection_id = ?", itemID, collection.ID).First(&item).Error
-	if err != nil {
-		return nil, err
+	// A manual transition replaces the scheduled one.
+	updates := map[string]any{"status": status, "published_at": nil, "unpublish_at": nil}
+	if status == models.ItemStatusPublished {
+		now := time.Now()
+		updates = map[string]any{"status": status, "published_at": &now, "publish_at": nil}
+	}
+	if err := database.DB.Model(&item).Updates(updates).Error; err != nil {
+		logger.Log.WithField("item_id", itemID).WithError(err).Error("Failed to change item status")
+		return nil, fmt.Errorf("failed to change item status: %w", err)
 	}
-	item.Data["id"] = item.ID
-	return FetchNestedRelations(collection, item.Data, level)
+	if err := database.DB.First(&item, item.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to reload item: %w", err)
+	}
+
+	publishEvent(database.DB, eventType, &item)
+	logger.Log.WithField("item_id", itemID).WithField("status", status).Info("Item status changed successfully")
+	return &item, nil
 }
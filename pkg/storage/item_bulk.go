@@ -0,0 +1,300 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gohead-cms/gohead/internal/agent/events"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// Operations of a bulk write.
+const (
+	BulkOpCreate = "create"
+	BulkOpUpdate = "update"
+	BulkOpUpsert = "upsert"
+	BulkOpDelete = "delete"
+)
+
+// Outcomes of the operations of a bulk write.
+const (
+	BulkStatusSucceeded = "succeeded"
+	BulkStatusFailed    = "failed"
+	// BulkStatusRolledBack marks the operations undone because a later one failed in an
+	// atomic bulk write.
+	BulkStatusRolledBack = "rolled_back"
+	// BulkStatusSkipped marks the operations not run because an earlier one failed in an
+	// atomic bulk write.
+	BulkStatusSkipped = "skipped"
+)
+
+// MaxBulkOperations caps the number of operations of a bulk write.
+const MaxBulkOperations = 1000
+
+// BulkOperation is one write of a bulk request. Update and delete target the item with
+// ID. Upsert updates the item whose UpsertOn attribute has the value given in Data, or
+// creates one when there is none.
+type BulkOperation struct {
+	Op       string         `json:"op"`
+	ID       uint           `json:"id,omitempty"`
+	UpsertOn string         `json:"upsert_on,omitempty"`
+	Data     models.JSONMap `json:"data,omitempty"`
+}
+
+// BulkResult is the outcome of an operation of a bulk write. Item is the item written,
+// nil for deletions and operations that did not succeed; Err is the error of a failed
+// operation.
+type BulkResult struct {
+	Index  int          `json:"index"`
+	Op     string       `json:"op"`
+	Status string       `json:"status"`
+	ID     uint         `json:"id,omitempty"`
+	Error  string       `json:"error,omitempty"`
+	Item   *models.Item `json:"-"`
+	Err    error        `json:"-"`
+}
+
+// BulkOptions tune how a bulk write runs.
+type BulkOptions struct {
+	// Atomic rolls every operation back when one fails. Otherwise a failing operation is
+	// rolled back alone and the others are committed.
+	Atomic bool
+	// Locale is the locale plain values of localized attributes are written in.
+	Locale string
+	Actor  models.Actor
+}
+
+// BulkError is returned when an operation of an atomic bulk write failed.
+type BulkError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("operation %d failed: %v", e.Index, e.Err)
+}
+
+func (e *BulkError) Unwrap() error {
+	return e.Err
+}
+
+// bulkWrite is an item written by a bulk operation, with the event to publish for it.
+type bulkWrite struct {
+	item  models.Item
+	event events.EventType
+}
+
+// ValidateBulkOperations checks the operations of a bulk write are well-formed, before
+// any of them runs.
+func ValidateBulkOperations(collection models.Collection, ops []BulkOperation) error {
+	if len(ops) == 0 {
+		return fmt.Errorf("no operations given")
+	}
+	if len(ops) > MaxBulkOperations {
+		return fmt.Errorf("too many operations: %d, the maximum is %d", len(ops), MaxBulkOperations)
+	}
+	for i, op := range ops {
+		switch op.Op {
+		case BulkOpCreate:
+		case BulkOpUpdate, BulkOpDelete:
+			if op.ID == 0 {
+				return fmt.Errorf("operation %d: '%s' requires an item 'id'", i, op.Op)
+			}
+		case BulkOpUpsert:
+			if op.UpsertOn == "" {
+				return fmt.Errorf("operation %d: 'upsert' requires an 'upsert_on' attribute", i)
+			}
+			if err := checkUpsertAttribute(collection, op.UpsertOn); err != nil {
+				return fmt.Errorf("operation %d: %w", i, err)
+			}
+		default:
+			return fmt.Errorf("operation %d: unknown operation '%s'", i, op.Op)
+		}
+		if op.Op != BulkOpDelete && op.Data == nil {
+			return fmt.Errorf("operation %d: '%s' requires item 'data'", i, op.Op)
+		}
+	}
+	return nil
+}
+
+// BulkWriteItems runs operations on the items of a collection in one transaction, each
+// in a savepoint, and returns the outcome of every operation. When an operation of an
+// atomic bulk write fails, nothing is written and a *BulkError is returned along with
+// the results. Events are published once the transaction is committed.
+func BulkWriteItems(collection models.Collection, ops []BulkOperation, opts BulkOptions) ([]BulkResult, error) {
+	if err := ValidateBulkOperations(collection, ops); err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkResult, len(ops))
+	for i, op := range ops {
+		results[i] = BulkResult{Index: i, Op: op.Op, Status: BulkStatusSkipped, ID: op.ID}
+	}
+	var writes []bulkWrite
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for i, op := range ops {
+			var opWrites []bulkWrite
+			err := tx.Transaction(func(opTx *gorm.DB) error {
+				var err error
+				opWrites, err = runBulkOperation(opTx, collection, op, opts)
+				return err
+			})
+			if err != nil {
+				results[i].Status = BulkStatusFailed
+				results[i].Error = err.Error()
+				results[i].Err = err
+				if !opts.Atomic {
+					continue
+				}
+				for j := range i {
+					if results[j].Status == BulkStatusSucceeded {
+						results[j].Status = BulkStatusRolledBack
+						results[j].ID = ops[j].ID
+						results[j].Item = nil
+					}
+				}
+				return &BulkError{Index: i, Err: err}
+			}
+
+			results[i].Status = BulkStatusSucceeded
+			if op.Op != BulkOpDelete {
+				results[i].ID = opWrites[0].item.ID
+				results[i].Item = &opWrites[0].item
+			}
+			writes = append(writes, opWrites...)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Log.WithField("collection", collection.Name).WithError(err).Warn("Bulk write rolled back")
+		return results, err
+	}
+
+	for i := range writes {
+		publishEvent(database.DB, writes[i].event, &writes[i].item)
+	}
+	logger.Log.WithField("collection", collection.Name).
+		WithField("operations", len(ops)).
+		WithField("atomic", opts.Atomic).
+		Info("Bulk write completed")
+	return results, nil
+}
+
+// runBulkOperation runs one operation within tx and returns the items it wrote.
+func runBulkOperation(tx *gorm.DB, collection models.Collection, op BulkOperation, opts BulkOptions) ([]bulkWrite, error) {
+	var item models.Item
+	switch op.Op {
+	case BulkOpDelete:
+		if err := tx.Where("id = ? AND collection_id = ?", op.ID, collection.ID).First(&item).Error; err != nil {
+			return nil, fmt.Errorf("item with ID %d not found: %w", op.ID, err)
+		}
+		deleted, updated, err := deleteItems(tx, op.ID, opts.Actor)
+		if err != nil {
+			return nil, err
+		}
+		writes := make([]bulkWrite, 0, len(deleted)+len(updated))
+		for i := range deleted {
+			writes = append(writes, bulkWrite{item: deleted[i], event: events.EventTypeItemDeleted})
+		}
+		for i := range updated {
+			writes = append(writes, bulkWrite{item: updated[i], event: events.EventTypeItemUpdated})
+		}
+		return writes, nil
+
+	case BulkOpUpdate:
+		if err := tx.Where("id = ? AND collection_id = ?", op.ID, collection.ID).First(&item).Error; err != nil {
+			return nil, fmt.Errorf("item with ID %d not found: %w", op.ID, err)
+		}
+
+	case BulkOpUpsert:
+		if key := op.Data[op.UpsertOn]; key != nil {
+			match, err := findUpsertTarget(tx, collection, op.UpsertOn, key)
+			if err != nil {
+				return nil, err
+			}
+			if match != nil {
+				item = *match
+			}
+		}
+	}
+	return writeBulkItem(tx, collection, &item, op.Data, opts)
+}
+
+// writeBulkItem validates data and writes it as the data of item, which is created
+// when it has no ID yet.
+func writeBulkItem(tx *gorm.DB, collection models.Collection, item *models.Item, data models.JSONMap, opts BulkOptions) ([]bulkWrite, error) {
+	created := item.ID == 0
+	access := models.WriteAccess{Role: opts.Actor.Role}
+	if !created {
+		access.Current = item.Data
+	}
+	data, err := collection.LocalizeInput(data, access.Current, opts.Locale)
+	if err != nil {
+		return nil, err
+	}
+	processed, err := models.ValidateItemValues(collection, data, tx, access)
+	if err != nil {
+		return nil, err
+	}
+	item.Data = processed
+
+	event, action := events.EventTypeItemUpdated, models.RevisionActionUpdate
+	if created {
+		item.CollectionID = collection.ID
+		item.Status = models.ItemStatusDraft
+		event, action = events.EventTypeItemCreated, models.RevisionActionCreate
+		err = tx.Create(item).Error
+	} else {
+		err = tx.Save(item).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := models.SyncItemRelations(tx, collection, item); err != nil {
+		return nil, err
+	}
+	if err := RecordItemRevision(tx, item, action, opts.Actor); err != nil {
+		return nil, err
+	}
+	return []bulkWrite{{item: *item, event: event}}, nil
+}
+
+// checkUpsertAttribute checks items can be matched on an attribute of a collection:
+// only plain values which can be filtered on with 'eq' can identify an item.
+func checkUpsertAttribute(collection models.Collection, name string) error {
+	attr := findAttribute(collection, name)
+	if attr == nil {
+		return fmt.Errorf("unknown upsert attribute '%s'", name)
+	}
+	if attr.Localized || !attr.IsScalar() || !slices.Contains(models.ItemFilterOperators(*attr), models.OpEq) {
+		return fmt.Errorf("cannot upsert on attribute '%s' of type '%s'", attr.Name, attr.Type)
+	}
+	return nil
+}
+
+// findUpsertTarget returns the item of a collection whose attribute has the value key,
+// or nil when there is none. Several matching items are an error. The value is compared
+// as the type of the attribute, like an 'eq' filter.
+func findUpsertTarget(tx *gorm.DB, collection models.Collection, attribute string, key any) (*models.Item, error) {
+	filter, err := collection.NewItemFilter(attribute, models.OpEq, []any{key}, "")
+	if err != nil {
+		return nil, err
+	}
+	var matches []models.Item
+	query := applyItemFilters(tx.Where("collection_id = ?", collection.ID), []models.ItemFilter{filter})
+	if err := query.Limit(2).Find(&matches).Error; err != nil {
+		return nil, err
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("several items have '%s' = %v", attribute, key)
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	return &matches[0], nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestBulkWriteItems(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRelation{}, &models.ItemRevision{}))
+
+	actor := models.SystemActor("test")
+	articles := models.Collection{Name: "articles", Attributes: []models.Attribute{
+		{Name: "title", Type: "text", Required: true},
+		{Name: "slug", Type: "text", Unique: true},
+		{Name: "rank", Type: "int"},
+		{Name: "featured", Type: "bool"},
+		{Name: "metadata", Type: "json"},
+	}}
+	require.NoError(t, db.Create(&articles).Error)
+	existing, err := SaveItem(articles, models.JSONMap{"title": "Existing", "slug": "existing", "rank": 1, "featured": true}, actor)
+	require.NoError(t, err)
+	obsolete, err := SaveItem(articles, models.JSONMap{"title": "Obsolete", "slug": "obsolete"}, actor)
+	require.NoError(t, err)
+
+	countItems := func() int64 {
+		var count int64
+		require.NoError(t, db.Model(&models.Item{}).Where("collection_id = ?", articles.ID).Count(&count).Error)
+		return count
+	}
+
+	t.Run("Invalid operations", func(t *testing.T) {
+		assert.Error(t, ValidateBulkOperations(articles, nil))
+		assert.Error(t, ValidateBulkOperations(articles, []BulkOperation{{Op: "rename"}}))
+		assert.Error(t, ValidateBulkOperations(articles, []BulkOperation{{Op: BulkOpUpdate, Data: models.JSONMap{}}}))
+		assert.Error(t, ValidateBulkOperations(articles, []BulkOperation{{Op: BulkOpUpsert, UpsertOn: "missing", Data: models.JSONMap{}}}))
+		assert.Error(t, ValidateBulkOperations(articles, []BulkOperation{{Op: BulkOpUpsert, UpsertOn: "metadata", Data: models.JSONMap{}}}))
+	})
+
+	t.Run("Upsert on numbers and booleans", func(t *testing.T) {
+		results, err := BulkWriteItems(articles, []BulkOperation{
+			{Op: BulkOpUpsert, UpsertOn: "rank", Data: models.JSONMap{"title": "First", "slug": "existing", "rank": float64(1), "featured": true}},
+			{Op: BulkOpUpsert, UpsertOn: "featured", Data: models.JSONMap{"title": "Featured", "slug": "existing", "featured": true}},
+		}, BulkOptions{Atomic: true, Actor: actor})
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, existing.ID, results[0].ID)
+		assert.Equal(t, existing.ID, results[1].ID)
+		updated, err := GetItemByID(articles.ID, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Featured", updated.Data["title"])
+	})
+
+	t.Run("Atomic failure writes nothing", func(t *testing.T) {
+		results, err := BulkWriteItems(articles, []BulkOperation{
+			{Op: BulkOpCreate, Data: models.JSONMap{"title": "New", "slug": "new"}},
+			{Op: BulkOpDelete, ID: obsolete.ID},
+			{Op: BulkOpCreate, Data: models.JSONMap{"slug": "untitled"}},
+			{Op: BulkOpCreate, Data: models.JSONMap{"title": "Never", "slug": "never"}},
+		}, BulkOptions{Atomic: true, Actor: actor})
+
+		var bulkErr *BulkError
+		require.ErrorAs(t, err, &bulkErr)
+		assert.Equal(t, 2, bulkErr.Index)
+		require.Len(t, results, 4)
+		assert.Equal(t, BulkStatusRolledBack, results[0].Status)
+		assert.Zero(t, results[0].ID)
+		assert.Equal(t, BulkStatusRolledBack, results[1].Status)
+		assert.Equal(t, BulkStatusFailed, results[2].Status)
+		assert.Contains(t, results[2].Error, "missing required attribute")
+		assert.Equal(t, BulkStatusSkipped, results[3].Status)
+
+		assert.Equal(t, int64(2), countItems())
+		_, err = GetItemByID(articles.ID, obsolete.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Mixed operations", func(t *testing.T) {
+		results, err := BulkWriteItems(articles, []BulkOperation{
+			{Op: BulkOpCreate, Data: models.JSONMap{"title": "Created", "slug": "created"}},
+			{Op: BulkOpUpdate, ID: existing.ID, Data: models.JSONMap{"title": "Updated", "slug": "existing"}},
+			{Op: BulkOpUpsert, UpsertOn: "slug", Data: models.JSONMap{"title": "Upserted", "slug": "created"}},
+			{Op: BulkOpDelete, ID: obsolete.ID},
+		}, BulkOptions{Atomic: true, Actor: actor})
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+		for _, result := range results {
+			assert.Equal(t, BulkStatusSucceeded, result.Status)
+		}
+
+		// The upsert sees the item created earlier in the same transaction.
+		assert.Equal(t, results[0].ID, results[2].ID)
+		created, err := GetItemByID(articles.ID, results[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Upserted", created.Data["title"])
+		updated, err := GetItemByID(articles.ID, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Updated", updated.Data["title"])
+		_, err = GetItemByID(articles.ID, obsolete.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("Non-atomic failures are skipped", func(t *testing.T) {
+		results, err := BulkWriteItems(articles, []BulkOperation{
+			{Op: BulkOpCreate, Data: models.JSONMap{"title": "Duplicate", "slug": "existing"}},
+			{Op: BulkOpUpdate, ID: 9999, Data: models.JSONMap{"title": "Missing"}},
+			{Op: BulkOpCreate, Data: models.JSONMap{"title": "Kept", "slug": "kept"}},
+		}, BulkOptions{Actor: actor})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, BulkStatusFailed, results[0].Status)
+		assert.Equal(t, BulkStatusFailed, results[1].Status)
+		assert.ErrorIs(t, results[1].Err, gorm.ErrRecordNotFound)
+		assert.Equal(t, BulkStatusSucceeded, results[2].Status)
+		require.NotNil(t, results[2].Item)
+		assert.Equal(t, "Kept", results[2].Item.Data["title"])
+	})
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ItemPage is a page of items read after a cursor.
+type ItemPage struct {
+	Items []models.Item
+	// Cursors holds the cursor of every item of the page, to resume reading after it.
+	Cursors     []string
+	HasNextPage bool
+}
+
+// EndCursor returns the cursor of the last item of the page, empty when it has none.
+func (p *ItemPage) EndCursor() string {
+	if len(p.Cursors) == 0 {
+		return ""
+	}
+	return p.Cursors[len(p.Cursors)-1]
+}
+
+// itemCursor is the position of an item in a sort order: the values of its sort fields
+// and its ID, which breaks ties. Sort identifies the order the cursor was read in.
+type itemCursor struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v,omitempty"`
+	ID     uint   `json:"id"`
+}
+
+// QueryItemsAfter fetches up to limit items matching the given filters and sort order,
+// following the item the cursor after points to; an empty cursor starts at the first
+// item. Unlike pages read with an offset, the next page does not shift when items are
+// created or deleted in between, and no items are counted. An invalid cursor is a
+// *models.QueryError.
+func QueryItemsAfter(collectionID uint, query models.ItemQuery, after string, limit int) (*ItemPage, error) {
+	base := itemQueryScope(collectionID, query)
+	if after != "" {
+		cursor, err := decodeItemCursor(after, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		expr, vars := itemsAfterExpr(base, query.Sort, cursor)
+		base = base.Where(expr, vars...)
+	}
+
+	var items []models.Item
+	// One more item than asked for tells whether there is a next page.
+	if err := applyItemSort(base, query.Sort).Limit(limit + 1).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch items: %w", err)
+	}
+
+	page := &ItemPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.HasNextPage = true
+	}
+	page.Cursors = make([]string, len(page.Items))
+	for i := range page.Items {
+		page.Cursors[i] = ItemCursor(query.Sort, &page.Items[i])
+	}
+	return page, nil
+}
+
+// ItemCursor returns the opaque cursor pointing to an item in a sort order.
+func ItemCursor(sorts []models.ItemSort, item *models.Item) string {
+	cursor := itemCursor{Sort: sortSignature(sorts), ID: item.ID}
+	for _, s := range sorts {
+		cursor.Values = append(cursor.Values, itemSortValue(s, item))
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// sortSignature identifies a sort order, so cursors are not used with another one.
+func sortSignature(sorts []models.ItemSort) string {
+	parts := make([]string, 0, len(sorts))
+	for _, s := range sorts {
+		part := s.Field
+		if s.Desc {
+			part += ":desc"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
+
+// itemSortValue returns the value an item is sorted on for a sort directive, as stored
+// in a cursor. A localized attribute is sorted on its first translation among the sort
+// locales, like jsonFieldExpr extracts it.
+func itemSortValue(s models.ItemSort, item *models.Item) any {
+	if s.System {
+		switch s.Field {
+		case "id":
+			return item.ID
+		case "created_at":
+			return item.CreatedAt
+		case "updated_at":
+			return item.UpdatedAt
+		case "published_at":
+			if item.PublishedAt == nil {
+				return nil
+			}
+			return *item.PublishedAt
+		}
+		return nil
+	}
+	value := item.Data[s.Field]
+	if len(s.Locales) == 0 {
+		return value
+	}
+	translations, _ := value.(map[string]any)
+	for _, locale := range s.Locales {
+		if translation, ok := translations[locale]; ok && translation != nil {
+			return translation
+		}
+	}
+	return nil
+}
+
+// decodeItemCursor reads a cursor and checks it was created in the sort order given.
+func decodeItemCursor(raw string, sorts []models.ItemSort) (*itemCursor, error) {
+	invalid := &models.QueryError{Message: "invalid cursor"}
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+	var cursor itemCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil || len(cursor.Values) != len(sorts) {
+		return nil, invalid
+	}
+	if cursor.Sort != sortSignature(sorts) {
+		return nil, &models.QueryError{Message: "the cursor was created with another sort order"}
+	}
+	for i, s := range sorts {
+		// Dates are compared as the time values they were stored from.
+		if s.System && s.Field != "id" && cursor.Values[i] != nil {
+			text, _ := cursor.Values[i].(string)
+			t, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return nil, invalid
+			}
+			cursor.Values[i] = t
+		}
+	}
+	return &cursor, nil
+}
+
+// itemsAfterExpr returns the condition selecting the items following a cursor in a sort
+// order, along with its bind variables: the items with a following value for a sort
+// field and the same values for the fields before it, then the items with the same
+// values and a greater ID, on which applyItemSort ends.
+func itemsAfterExpr(db *gorm.DB, sorts []models.ItemSort, cursor *itemCursor) (string, []any) {
+	var alternatives []string
+	var vars []any
+	var equal []string
+	var equalVars []any
+
+	for i, s := range sorts {
+		expr, exprVars := sortFieldExpr(db, s)
+		value := cursor.Values[i]
+		if s.Kind == models.QueryKindBool && value != nil {
+			if b, ok := value.(bool); ok {
+				value = boolFilterValue(db, b)
+			}
+		}
+
+		if after, afterVars := sortedAfterExpr(db, s, expr, exprVars, value); after != "" {
+			alternatives = append(alternatives, strings.Join(append(append([]string{}, equal...), after), " AND "))
+			vars = append(append(vars, equalVars...), afterVars...)
+		}
+
+		if value == nil {
+			equal = append(equal, expr+" IS NULL")
+			equalVars = append(equalVars, exprVars...)
+		} else {
+			equal = append(equal, expr+" = ?")
+			equalVars = append(append(equalVars, exprVars...), value)
+		}
+	}
+	alternatives = append(alternatives, strings.Join(append(equal, "id > ?"), " AND "))
+	vars = append(append(vars, equalVars...), cursor.ID)
+
+	return "((" + strings.Join(alternatives, ") OR (") + "))", vars
+}
+
+// sortedAfterExpr returns the condition on a sort field selecting the values sorted
+// after value, empty when no value is. PostgreSQL sorts NULL after any value, SQLite
+// and MySQL before.
+func sortedAfterExpr(db *gorm.DB, s models.ItemSort, expr string, exprVars []any, value any) (string, []any) {
+	nullsLast := (db.Dialector.Name() == "postgres") != s.Desc
+	if value == nil {
+		if nullsLast {
+			return "", nil
+		}
+		return expr + " IS NOT NULL", exprVars
+	}
+
+	operator := " > ?"
+	if s.Desc {
+		operator = " < ?"
+	}
+	vars := append(append([]any{}, exprVars...), value)
+	if nullsLast {
+		return "(" + expr + operator + " OR " + expr + " IS NULL)", append(vars, exprVars...)
+	}
+	return expr + operator, vars
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryItemsAfter(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.Attribute{}))
+
+	collection := models.Collection{
+		Name: "articles",
+		Attributes: []models.Attribute{
+			{Name: "title", Type: "text"},
+			{Name: "views", Type: "int"},
+			{Name: "subtitle", Type: "text"},
+		},
+	}
+	require.NoError(t, db.Create(&collection).Error)
+
+	items := []models.Item{
+		{CollectionID: collection.ID, Data: models.JSONMap{"title": "A", "views": 10, "subtitle": "x"}},
+		{CollectionID: collection.ID, Data: models.JSONMap{"title": "B", "views": 30}},
+		{CollectionID: collection.ID, Data: models.JSONMap{"title": "C", "views": 10, "subtitle": "y"}},
+		{CollectionID: collection.ID, Data: models.JSONMap{"title": "D", "views": 20}},
+		{CollectionID: collection.ID, Data: models.JSONMap{"title": "E", "views": 10, "subtitle": "x"}},
+		{CollectionID: collection.ID + 1, Data: models.JSONMap{"title": "Elsewhere", "views": 5}},
+	}
+	require.NoError(t, db.Create(&items).Error)
+
+	parse := func(raw string) models.ItemQuery {
+		params, err := url.ParseQuery(raw)
+		require.NoError(t, err)
+		q, err := models.ParseItemQuery(collection, params)
+		require.NoError(t, err)
+		return q
+	}
+	titles := func(items []models.Item) []string {
+		result := make([]string, 0, len(items))
+		for _, item := range items {
+			result = append(result, item.Data["title"].(string))
+		}
+		return result
+	}
+	// readAll reads every page of a query and checks the pages follow the offset order.
+	readAll := func(q models.ItemQuery, pageSize int) []string {
+		expected, err := QueryItemsOffset(collection.ID, q, 0, 100)
+		require.NoError(t, err)
+
+		var read []string
+		after := ""
+		for {
+			page, err := QueryItemsAfter(collection.ID, q, after, pageSize)
+			require.NoError(t, err)
+			read = append(read, titles(page.Items)...)
+			if !page.HasNextPage {
+				break
+			}
+			after = page.EndCursor()
+		}
+		assert.Equal(t, titles(expected), read)
+		return read
+	}
+
+	t.Run("Default order", func(t *testing.T) {
+		assert.Equal(t, []string{"A", "B", "C", "D", "E"}, readAll(models.ItemQuery{}, 2))
+	})
+
+	t.Run("Sorted with ties", func(t *testing.T) {
+		assert.Equal(t, []string{"B", "D", "A", "C", "E"}, readAll(parse("sort=views:desc"), 2))
+	})
+
+	t.Run("Sorted on several fields with missing values", func(t *testing.T) {
+		readAll(parse("sort=subtitle,views"), 2)
+		readAll(parse("sort=subtitle:desc,title:desc"), 1)
+		readAll(parse("sort=created_at:desc"), 2)
+	})
+
+	t.Run("Items created in between are not read twice", func(t *testing.T) {
+		q := parse("sort=title")
+		first, err := QueryItemsAfter(collection.ID, q, "", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A", "B"}, titles(first.Items))
+
+		require.NoError(t, db.Create(&models.Item{CollectionID: collection.ID, Data: models.JSONMap{"title": "0"}}).Error)
+		next, err := QueryItemsAfter(collection.ID, q, first.EndCursor(), 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"C", "D"}, titles(next.Items))
+		assert.True(t, next.HasNextPage)
+
+		total, err := CountItems(collection.ID, q)
+		require.NoError(t, err)
+		assert.Equal(t, 6, total)
+	})
+
+	t.Run("Invalid cursors", func(t *testing.T) {
+		var queryErr *models.QueryError
+		_, err := QueryItemsAfter(collection.ID, models.ItemQuery{}, "not-a-cursor", 2)
+		assert.ErrorAs(t, err, &queryErr)
+
+		page, err := QueryItemsAfter(collection.ID, parse("sort=title"), "", 1)
+		require.NoError(t, err)
+		_, err = QueryItemsAfter(collection.ID, parse("sort=views"), page.EndCursor(), 1)
+		assert.ErrorAs(t, err, &queryErr)
+	})
+}
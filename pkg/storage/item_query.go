@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QueryItems fetches a page of items matching the given filters and sort order.
+// It returns the items and the total number of matching items.
+func QueryItems(collectionID uint, query models.ItemQuery, page, pageSize int) ([]models.Item, int, error) {
+	total, err := CountItems(collectionID, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	items, err := QueryItemsOffset(collectionID, query, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// QueryItemsOffset fetches up to limit items matching the given filters and sort order,
+// skipping the first offset ones.
+func QueryItemsOffset(collectionID uint, query models.ItemQuery, offset, limit int) ([]models.Item, error) {
+	var items []models.Item
+	err := applyItemSort(itemQueryScope(collectionID, query), query.Sort).
+		Offset(offset).Limit(limit).
+		Find(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch items: %w", err)
+	}
+	return items, nil
+}
+
+// CountItems returns the number of items matching the given filters.
+func CountItems(collectionID uint, query models.ItemQuery) (int, error) {
+	var total int64
+	if err := itemQueryScope(collectionID, query).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count items: %w", err)
+	}
+	return int(total), nil
+}
+
+// itemQueryScope selects the items of a collection matching the filters and status of
+// a query.
+func itemQueryScope(collectionID uint, query models.ItemQuery) *gorm.DB {
+	base := database.DB.Model(&models.Item{}).Where("collection_id = ?", collectionID)
+	if query.Status != "" {
+		base = base.Where("status = ?", query.Status)
+	}
+	if query.IDs != nil {
+		base = base.Where("id IN ?", query.IDs)
+	}
+	base = applyItemFilters(base, query.Filters)
+	if query.Where != nil {
+		base = applyItemWhere(base, *query.Where)
+	}
+	return base
+}
+
+// jsonFieldExpr returns the SQL expression extracting a key of the `data` column for the
+// current dialect, along with its bind variables. Numbers and booleans are extracted as
+// native values where the dialect allows it so comparisons and ordering are not lexical.
+// For a localized attribute, the first translation found among locales is extracted.
+func jsonFieldExpr(db *gorm.DB, field string, locales []string, kind string) (string, []any) {
+	if len(locales) == 0 {
+		return jsonPathExpr(db, []string{field}, kind)
+	}
+	exprs := make([]string, 0, len(locales))
+	var vars []any
+	for _, locale := range locales {
+		expr, exprVars := jsonPathExpr(db, []string{field, locale}, kind)
+		exprs = append(exprs, expr)
+		vars = append(vars, exprVars...)
+	}
+	if len(exprs) == 1 {
+		return exprs[0], vars
+	}
+	return "COALESCE(" + strings.Join(exprs, ", ") + ")", vars
+}
+
+// jsonPathExpr returns the SQL expression extracting the value at the nested keys of
+// the `data` column.
+func jsonPathExpr(db *gorm.DB, keys []string, kind string) (string, []any) {
+	switch db.Dialector.Name() {
+	case "postgres":
+		expr := "data" + strings.Repeat(" -> ?", len(keys)-1) + " ->> ?"
+		vars := make([]any, len(keys))
+		for i, key := range keys {
+			vars[i] = key
+		}
+		if kind == models.QueryKindNumber {
+			return "CAST(" + expr + " AS NUMERIC)", vars
+		}
+		return "(" + expr + ")", vars
+	case "mysql":
+		path := jsonPath(keys)
+		if kind == models.QueryKindNumber {
+			return "JSON_EXTRACT(data, ?)", []any{path}
+		}
+		return "JSON_UNQUOTE(JSON_EXTRACT(data, ?))", []any{path}
+	default:
+		// SQLite's json_extract already returns integers, reals and text natively.
+		return "json_extract(data, ?)", []any{jsonPath(keys)}
+	}
+}
+
+// jsonPath returns the SQLite or MySQL JSON path of nested keys.
+func jsonPath(keys []string) string {
+	path := "$"
+	for _, key := range keys {
+		path += "." + quoteJSONPathKey(key)
+	}
+	return path
+}
+
+// quoteJSONPathKey quotes a key so it can be used in a SQLite or MySQL JSON path.
+func quoteJSONPathKey(field string) string {
+	return `"` + strings.ReplaceAll(field, `"`, `\"`) + `"`
+}
+
+// boolFilterValue returns the value a JSON boolean is compared against for the dialect.
+func boolFilterValue(db *gorm.DB, value bool) any {
+	if db.Dialector.Name() == "sqlite" {
+		if value {
+			return 1
+		}
+		return 0
+	}
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+// escapeLike escapes LIKE wildcards using '!' as the escape character, which needs
+// no special quoting on any of the supported dialects.
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return replacer.Replace(value)
+}
+
+func applyItemFilters(db *gorm.DB, filters []models.ItemFilter) *gorm.DB {
+	for _, filter := range filters {
+		expr, vars := jsonFieldExpr(db, filter.Field, filter.Locales, filter.Kind)
+
+		values := filter.Values
+		if filter.Kind == models.QueryKindBool && filter.Operator != models.OpNull {
+			values = make([]any, len(filter.Values))
+			for i, v := range filter.Values {
+				values[i] = boolFilterValue(db, v.(bool))
+			}
+		}
+
+		switch filter.Operator {
+		case models.OpEq:
+			db = db.Where(expr+" = ?", append(vars, values[0])...)
+		case models.OpNe:
+			// A missing key is considered different from any value.
+			db = db.Where("("+expr+" <> ? OR "+expr+" IS NULL)", append(append(vars, values[0]), vars...)...)
+		case models.OpIn:
+			db = db.Where(expr+" IN ?", append(vars, values)...)
+		case models.OpLt:
+			db = db.Where(expr+" < ?", append(vars, values[0])...)
+		case models.OpLte:
+			db = db.Where(expr+" <= ?", append(vars, values[0])...)
+		case models.OpGt:
+			db = db.Where(expr+" > ?", append(vars, values[0])...)
+		case models.OpGte:
+			db = db.Where(expr+" >= ?", append(vars, values[0])...)
+		case models.OpContains:
+			pattern := "%" + escapeLike(strings.ToLower(fmt.Sprintf("%v", values[0]))) + "%"
+			db = db.Where("LOWER("+expr+") LIKE ? ESCAPE '!'", append(vars, pattern)...)
+		case models.OpNull:
+			nullExpr := expr + " IS NULL"
+			nullVars := vars
+			if db.Dialector.Name() == "mysql" {
+				// MySQL keeps JSON null as a JSON value instead of SQL NULL.
+				raw, rawVars := jsonFieldExpr(db, filter.Field, filter.Locales, models.QueryKindNumber)
+				nullExpr = "(" + raw + " IS NULL OR JSON_TYPE(" + raw + ") = 'NULL')"
+				nullVars = append(append([]any{}, rawVars...), rawVars...)
+			}
+			if values[0].(bool) {
+				db = db.Where(nullExpr, nullVars...)
+			} else {
+				db = db.Not(nullExpr, nullVars...)
+			}
+		}
+	}
+	return db
+}
+
+// applyItemWhere adds a tree of conditions to db, its alternatives grouped in parentheses.
+func applyItemWhere(db *gorm.DB, where models.ItemWhere) *gorm.DB {
+	db = applyItemFilters(db, where.Filters)
+	for _, and := range where.And {
+		db = applyItemWhere(db, and)
+	}
+	// Alternatives among which one holds for any item add no condition.
+	if len(where.Or) == 0 || slices.ContainsFunc(where.Or, models.ItemWhere.IsEmpty) {
+		return db
+	}
+	alternatives := db.Session(&gorm.Session{NewDB: true})
+	for _, or := range where.Or {
+		alternatives = alternatives.Or(applyItemWhere(db.Session(&gorm.Session{NewDB: true}), or))
+	}
+	return db.Where(alternatives)
+}
+
+func applyItemSort(db *gorm.DB, sorts []models.ItemSort) *gorm.DB {
+	var parts []string
+	var vars []any
+	for _, s := range sorts {
+		expr, exprVars := sortFieldExpr(db, s)
+		vars = append(vars, exprVars...)
+		if s.Desc {
+			expr += " DESC"
+		}
+		parts = append(parts, expr)
+	}
+	// Always finish on the primary key so pagination is stable.
+	parts = append(parts, "id ASC")
+
+	return db.Order(clause.OrderBy{
+		Expression: clause.Expr{SQL: strings.Join(parts, ", "), Vars: vars, WithoutParentheses: true},
+	})
+}
+
+// sortFieldExpr returns the SQL expression a sort directive orders on.
+func sortFieldExpr(db *gorm.DB, s models.ItemSort) (string, []any) {
+	if s.System {
+		return s.Field, nil // system fields come from a fixed allow-list
+	}
+	return jsonFieldExpr(db, s.Field, s.Locales, s.Kind)
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryItems(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.Attribute{}))
+
+	collection := models.Collection{
+		Name: "articles",
+		Attributes: []models.Attribute{
+			{Name: "title", Type: "text"},
+			{Name: "views", Type: "int"},
+			{Name: "featured", Type: "bool"},
+			{Name: "subtitle", Type: "text"},
+		},
+	}
+	assert.NoError(t, db.Create(&collection).Error)
+
+	items := []models.Item{
+		{CollectionID: collection.ID, Data: models.JSONMap{"title": "Learning Go", "views": 120, "featured": true}},
+		{CollectionID: collection.ID, Data: models.JSONMap{"title": "Rust 101", "views": 15, "featured": false, "subtitle": "basics"}},
+		{CollectionID: collection.ID, Data: models.JSONMap{"title": "Go_Concurrency", "views": 9, "featured": true}},
+		{CollectionID: collection.ID + 1, Data: models.JSONMap{"title": "Go elsewhere", "views": 1000}},
+	}
+	assert.NoError(t, db.Create(&items).Error)
+
+	query := func(raw string) ([]string, int) {
+		params, err := url.ParseQuery(raw)
+		assert.NoError(t, err)
+		q, err := models.ParseItemQuery(collection, params)
+		assert.NoError(t, err)
+
+		results, total, err := QueryItems(collection.ID, q, 1, 10)
+		assert.NoError(t, err)
+		titles := make([]string, 0, len(results))
+		for _, item := range results {
+			titles = append(titles, item.Data["title"].(string))
+		}
+		return titles, total
+	}
+
+	t.Run("Contains is case-insensitive", func(t *testing.T) {
+		titles, total := query("filters[title][$contains]=GO")
+		assert.Equal(t, []string{"Learning Go", "Go_Concurrency"}, titles)
+		assert.Equal(t, 2, total)
+	})
+
+	t.Run("Contains escapes wildcards", func(t *testing.T) {
+		titles, _ := query("filters[title][$contains]=o_c")
+		assert.Equal(t, []string{"Go_Concurrency"}, titles)
+	})
+
+	t.Run("Numeric comparison is not lexical", func(t *testing.T) {
+		titles, _ := query("filters[views][$gt]=10")
+		assert.Equal(t, []string{"Learning Go", "Rust 101"}, titles)
+	})
+
+	t.Run("Equality, inequality and in", func(t *testing.T) {
+		titles, _ := query("filters[title][$eq]=Rust 101")
+		assert.Equal(t, []string{"Rust 101"}, titles)
+
+		titles, _ = query("filters[title][$ne]=Rust 101")
+		assert.Equal(t, []string{"Learning Go", "Go_Concurrency"}, titles)
+
+		titles, _ = query("filters[views][$in]=9,15")
+		assert.Equal(t, []string{"Rust 101", "Go_Concurrency"}, titles)
+	})
+
+	t.Run("Boolean filters", func(t *testing.T) {
+		titles, _ := query("filters[featured][$eq]=false")
+		assert.Equal(t, []string{"Rust 101"}, titles)
+	})
+
+	t.Run("Null filters", func(t *testing.T) {
+		titles, _ := query("filters[subtitle][$null]=true")
+		assert.Equal(t, []string{"Learning Go", "Go_Concurrency"}, titles)
+
+		titles, _ = query("filters[subtitle][$null]=false")
+		assert.Equal(t, []string{"Rust 101"}, titles)
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		assert.NoError(t, db.Model(&models.Item{}).Where("id = ?", items[1].ID).Update("status", models.ItemStatusPublished).Error)
+
+		results, total, err := QueryItems(collection.ID, models.ItemQuery{Status: models.ItemStatusPublished}, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "Rust 101", results[0].Data["title"])
+
+		assert.NoError(t, db.Model(&models.Item{}).Where("id = ?", items[1].ID).Update("status", "").Error)
+	})
+
+	t.Run("Sorting", func(t *testing.T) {
+		titles, _ := query("sort=views:desc")
+		assert.Equal(t, []string{"Learning Go", "Rust 101", "Go_Concurrency"}, titles)
+
+		titles, _ = query("sort=featured,title:desc")
+		assert.Equal(t, []string{"Rust 101", "Learning Go", "Go_Concurrency"}, titles)
+
+		titles, _ = query("sort=id:desc")
+		assert.Equal(t, []string{"Go_Concurrency", "Rust 101", "Learning Go"}, titles)
+	})
+}
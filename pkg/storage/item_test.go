@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"errors"
+	"net/url"
 	"testing"
 
 	"github.com/gohead-cms/gohead/internal/models"
@@ -16,7 +18,7 @@ func TestSaveItem(t *testing.T) {
 	defer testutils.CleanupTestDB()
 
 	// Apply migrations
-	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}))
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.ItemRevision{}, &models.ItemRelation{}))
 
 	// Create a sample collection
 	collection := &models.Collection{Name: "articles"}
@@ -29,7 +31,7 @@ func TestSaveItem(t *testing.T) {
 	}
 
 	// Save the content item
-	final, err := SaveItem(*collection, itemData)
+	final, err := SaveItem(*collection, itemData, models.UserActor("tester"))
 	assert.NoError(t, err)
 	assert.NotZero(t, final.ID)
 	// Verify the content item exists in the database
@@ -67,7 +69,7 @@ func TestGetItems(t *testing.T) {
 	defer testutils.CleanupTestDB()
 
 	// Apply migrations
-	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}))
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.ItemRevision{}, &models.ItemRelation{}))
 
 	// Create a collection and multiple items
 	collection := &models.Collection{Name: "articles"}
@@ -94,7 +96,7 @@ func TestUpdateItem(t *testing.T) {
 	defer testutils.CleanupTestDB()
 
 	// Apply migrations
-	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}))
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.ItemRevision{}, &models.ItemRelation{}))
 
 	// Create a collection
 	collection := &models.Collection{Name: "articles"}
@@ -113,7 +115,7 @@ func TestUpdateItem(t *testing.T) {
 		"content":           "New Content",
 		"related_attribute": map[string]interface{}{"title": "Nested Title"},
 	}
-	err := UpdateItem(item.ID, updatedData)
+	err := UpdateItem(item.ID, updatedData, models.UserActor("tester"))
 	assert.NoError(t, err)
 
 	// Verify the content item is updated
@@ -134,7 +136,7 @@ func TestDeleteItem(t *testing.T) {
 	defer testutils.CleanupTestDB()
 
 	// Apply migrations
-	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}))
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.ItemRevision{}, &models.ItemRelation{}))
 
 	// Create and save a collection
 	collection := &models.Collection{Name: "articles"}
@@ -148,7 +150,7 @@ func TestDeleteItem(t *testing.T) {
 	assert.NoError(t, db.Create(item).Error)
 
 	// Delete the content item
-	err := DeleteItem(item.ID)
+	err := DeleteItem(item.ID, models.UserActor("tester"))
 	assert.NoError(t, err)
 
 	// Verify the content item is deleted
@@ -199,3 +201,137 @@ func TestCheckFieldUniqueness(t *testing.T) {
 		assert.NoError(t, err, "Expected no error for non-existent field")
 	})
 }
+
+func TestPublishItem(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.Attribute{}, &models.ItemRevision{}, &models.ItemRelation{}))
+
+	collection := models.Collection{Name: "articles", Attributes: []models.Attribute{{Name: "title", Type: "text"}}}
+	assert.NoError(t, db.Create(&collection).Error)
+
+	item, err := SaveItem(collection, models.JSONMap{"title": "Draft"}, models.UserActor("tester"))
+	assert.NoError(t, err)
+	assert.Equal(t, models.ItemStatusDraft, item.Status)
+	assert.Nil(t, item.PublishedAt)
+
+	published, err := PublishItem(collection, item.ID)
+	assert.NoError(t, err)
+	assert.True(t, published.IsPublished())
+	assert.NotNil(t, published.PublishedAt)
+
+	unpublished, err := UnpublishItem(collection, item.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ItemStatusDraft, unpublished.Status)
+	assert.Nil(t, unpublished.PublishedAt)
+
+	// Items of another collection cannot be published through this one
+	_, err = PublishItem(models.Collection{ID: collection.ID + 1}, item.ID)
+	assert.Error(t, err)
+}
+
+func TestItemFieldAccess(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.Attribute{}, &models.ItemRevision{}, &models.ItemRelation{}))
+
+	collection := models.Collection{Name: "products", Attributes: []models.Attribute{
+		{Name: "name", Type: "text"},
+		{Name: "notes", Type: "text", ReadRoles: []string{"editor"}, WriteRoles: []string{"editor"}},
+	}}
+	assert.NoError(t, db.Create(&collection).Error)
+
+	editor := &models.UserRole{Name: "editor", Permissions: models.JSONMap{"collections.*.*": true}}
+	viewer := &models.UserRole{Name: "viewer", Permissions: models.JSONMap{"collections.*.read": true}}
+
+	item, err := SaveItem(collection, models.JSONMap{"name": "Widget", "notes": "internal"}, models.UserActor("ed").WithRole(editor))
+	assert.NoError(t, err)
+
+	t.Run("Hidden attributes are removed on read", func(t *testing.T) {
+		opts := ReadOptions{CanReadField: func(attr models.Attribute) bool { return attr.ReadableBy(viewer) }}
+		data, err := FetchNestedRelations(collection, item.Data, 0, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, models.JSONMap{"name": "Widget"}, data)
+	})
+
+	t.Run("Protected attributes are kept on update", func(t *testing.T) {
+		assert.NoError(t, UpdateItem(item.ID, models.JSONMap{"name": "Gadget"}, models.AgentActor(1)))
+		updated, err := GetItemByID(collection.ID, item.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Gadget", updated.Data["name"])
+		assert.Equal(t, "internal", updated.Data["notes"])
+
+		err = UpdateItem(item.ID, models.JSONMap{"name": "Gadget", "notes": "changed"}, models.AgentActor(1))
+		var accessErr *models.FieldAccessError
+		assert.True(t, errors.As(err, &accessErr))
+	})
+}
+
+func TestLocalizedItems(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.Attribute{}, &models.ItemRevision{}, &models.ItemRelation{}))
+
+	collection := models.Collection{
+		Name:          "articles",
+		Locales:       []string{"en", "fr", "fr-CA"},
+		DefaultLocale: "en",
+		Attributes: []models.Attribute{
+			{Name: "title", Type: "text", Localized: true},
+			{Name: "views", Type: "int"},
+		},
+	}
+	assert.NoError(t, db.Create(&collection).Error)
+
+	first, err := SaveItem(collection, models.JSONMap{"title": map[string]any{"en": "Apple", "fr": "Pomme"}, "views": 1}, models.UserActor("tester"))
+	assert.NoError(t, err)
+	second, err := SaveItem(collection, models.JSONMap{"title": "Zucchini", "views": 2}, models.UserActor("tester"))
+	assert.NoError(t, err)
+
+	t.Run("Plain values update the default locale", func(t *testing.T) {
+		assert.NoError(t, UpdateItem(first.ID, models.JSONMap{"title": "Green apple", "views": 3}, models.AgentActor(1)))
+		updated, err := GetItemByID(collection.ID, first.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"en": "Green apple", "fr": "Pomme"}, updated.Data["title"])
+		first = updated
+	})
+
+	t.Run("Read in a locale", func(t *testing.T) {
+		data, err := FetchNestedRelations(collection, first.Data, 0, ReadOptions{Locale: "fr-CA"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Pomme", data["title"])
+
+		data, err = FetchNestedRelations(collection, second.Data, 1, ReadOptions{Locale: "fr"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Zucchini", data["title"], "missing translations fall back to the default locale")
+
+		data, err = FetchNestedRelations(collection, first.Data, 1, ReadOptions{Locale: models.LocaleAll})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"en": "Green apple", "fr": "Pomme"}, data["title"])
+	})
+
+	t.Run("Filter and sort in a locale", func(t *testing.T) {
+		query := func(raw string) []uint {
+			params, err := url.ParseQuery(raw)
+			assert.NoError(t, err)
+			q, err := models.ParseItemQuery(collection, params)
+			assert.NoError(t, err)
+			items, _, err := QueryItems(collection.ID, q, 1, 10)
+			assert.NoError(t, err)
+			ids := make([]uint, 0, len(items))
+			for _, item := range items {
+				ids = append(ids, item.ID)
+			}
+			return ids
+		}
+
+		assert.Equal(t, []uint{first.ID}, query("locale=fr&filters[title][$eq]=Pomme"))
+		assert.Equal(t, []uint{second.ID}, query("locale=fr-CA&filters[title][$contains]=zucc"))
+		assert.Empty(t, query("filters[title][$eq]=Pomme"))
+		assert.Equal(t, []uint{first.ID, second.ID}, query("locale=fr&sort=title:asc"))
+		assert.Equal(t, []uint{second.ID, first.ID}, query("locale=fr&sort=title:desc"))
+	})
+}
@@ -0,0 +1,596 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/agent/events"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// Formats of exported and imported items.
+const (
+	TransferFormatJSONL = "jsonl"
+	TransferFormatCSV   = "csv"
+)
+
+const (
+	// transferBatchSize is the number of items read or written per query or transaction.
+	transferBatchSize = 500
+	// maxReportedRowErrors caps the row errors listed in an import report.
+	maxReportedRowErrors = 100
+)
+
+// Columns of a CSV export written before the attributes of the collection.
+var transferMetaColumns = []string{"id", "status", "published_at"}
+
+// TransferRecord is an item as exported, one per JSON line or CSV row. Relations hold the
+// values of the unique attributes of the related items, so they resolve in another database.
+type TransferRecord struct {
+	ID          uint           `json:"id,omitempty"`
+	Status      string         `json:"status,omitempty"`
+	PublishedAt *time.Time     `json:"published_at,omitempty"`
+	Data        models.JSONMap `json:"data"`
+}
+
+// ImportOptions tune how items are imported.
+type ImportOptions struct {
+	Format string
+	// UpsertOn names the attribute identifying the item a record updates. Records matching
+	// no item, and every record when it is empty, create new items.
+	UpsertOn string
+	Actor    models.Actor
+}
+
+// ImportRowError is the error of one record, identified by its line in the input.
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportReport counts the imported records. Failed records are skipped without stopping
+// the import.
+type ImportReport struct {
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors,omitempty"`
+}
+
+func checkTransferFormat(format string) error {
+	if format != TransferFormatJSONL && format != TransferFormatCSV {
+		return fmt.Errorf("unsupported format '%s', expected '%s' or '%s'", format, TransferFormatJSONL, TransferFormatCSV)
+	}
+	return nil
+}
+
+// ExportItems writes every item of a collection to w, ordered by ID, and returns the
+// number of items written.
+func ExportItems(w io.Writer, collection models.Collection, format string) (int, error) {
+	if err := checkTransferFormat(format); err != nil {
+		return 0, err
+	}
+
+	var csvWriter *csv.Writer
+	if format == TransferFormatCSV {
+		csvWriter = csv.NewWriter(w)
+		header := slices.Clone(transferMetaColumns)
+		for _, attr := range collection.Attributes {
+			header = append(header, attr.Name)
+		}
+		if err := csvWriter.Write(header); err != nil {
+			return 0, err
+		}
+	}
+
+	refs := newRelationRefs()
+	written := 0
+	var items []models.Item
+	err := database.DB.Where("collection_id = ?", collection.ID).Order("id ASC").FindInBatches(&items, transferBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, item := range items {
+			record := TransferRecord{ID: item.ID, Status: item.Status, PublishedAt: item.PublishedAt, Data: item.Data}
+			if err := refs.export(tx, collection, record.Data); err != nil {
+				return err
+			}
+			if csvWriter != nil {
+				if err := csvWriter.Write(csvRow(collection, record)); err != nil {
+					return err
+				}
+			} else {
+				line, err := json.Marshal(record)
+				if err != nil {
+					return fmt.Errorf("failed to encode item %d: %w", item.ID, err)
+				}
+				if _, err := w.Write(append(line, '\n')); err != nil {
+					return err
+				}
+			}
+			written++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return written, fmt.Errorf("failed to export items of collection '%s': %w", collection.Name, err)
+	}
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// relationRefs replaces the IDs of related items by references to their unique attributes,
+// caching the collections and references already built.
+type relationRefs struct {
+	collections map[string]*models.Collection
+	refs        map[string]map[uint]any
+}
+
+func newRelationRefs() *relationRefs {
+	return &relationRefs{collections: make(map[string]*models.Collection), refs: make(map[string]map[uint]any)}
+}
+
+// export rewrites the relation values of data in place.
+func (r *relationRefs) export(tx *gorm.DB, collection models.Collection, data models.JSONMap) error {
+	for _, attr := range collection.Attributes {
+		value, exists := data[attr.Name]
+		if attr.Type != "relation" || !exists || value == nil {
+			continue
+		}
+		var refs []any
+		for _, id := range models.RelationIDs(value) {
+			ref, err := r.ref(tx, attr.Target, id)
+			if err != nil {
+				return err
+			}
+			refs = append(refs, ref)
+		}
+		if attr.IsToMany() {
+			data[attr.Name] = refs
+		} else if len(refs) > 0 {
+			data[attr.Name] = refs[0]
+		}
+	}
+	return nil
+}
+
+// ref returns the reference of a related item: the values of the unique attributes of its
+// collection, or its ID when the collection has none.
+func (r *relationRefs) ref(tx *gorm.DB, target string, id uint) (any, error) {
+	if ref, cached := r.refs[target][id]; cached {
+		return ref, nil
+	}
+	collection, cached := r.collections[target]
+	if !cached {
+		var loaded models.Collection
+		if err := tx.Session(&gorm.Session{NewDB: true}).Preload("Attributes").Where("name = ?", target).First(&loaded).Error; err != nil {
+			return nil, fmt.Errorf("failed to load related collection '%s': %w", target, err)
+		}
+		collection = &loaded
+		r.collections[target] = collection
+		r.refs[target] = make(map[uint]any)
+	}
+
+	ref := map[string]any{"id": id}
+	var item models.Item
+	err := tx.Session(&gorm.Session{NewDB: true}).Where("id = ? AND collection_id = ?", id, collection.ID).First(&item).Error
+	if err == nil {
+		unique := make(map[string]any)
+		for _, attr := range collection.Attributes {
+			if attr.Unique && item.Data[attr.Name] != nil {
+				unique[attr.Name] = item.Data[attr.Name]
+			}
+		}
+		if len(unique) > 0 {
+			ref = unique
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load related item %d: %w", id, err)
+	}
+	r.refs[target][id] = ref
+	return ref, nil
+}
+
+// csvRow encodes a record as a CSV row. Text values of scalar attributes are written as is
+// and other values as JSON.
+func csvRow(collection models.Collection, record TransferRecord) []string {
+	row := []string{strconv.FormatUint(uint64(record.ID), 10), record.Status, ""}
+	if record.PublishedAt != nil {
+		row[2] = record.PublishedAt.Format(time.RFC3339)
+	}
+	for _, attr := range collection.Attributes {
+		row = append(row, csvCell(attr, record.Data[attr.Name]))
+	}
+	return row
+}
+
+func csvCell(attr models.Attribute, value any) string {
+	if value == nil {
+		return ""
+	}
+	if text, ok := value.(string); ok && attr.IsScalar() && !attr.Localized {
+		return text
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// importRecord is a record read from the input, or the error reading it.
+type importRecord struct {
+	line   int
+	record TransferRecord
+	err    error
+}
+
+// ImportItems reads items from r and creates or updates them in a collection, in batches
+// of one transaction each. Every record goes through the same validation as API writes,
+// and relations given as unique attribute values are resolved to the matching items.
+// Records failing are reported and skipped; the error returned is for input that cannot
+// be read at all.
+func ImportItems(r io.Reader, collection models.Collection, opts ImportOptions) (*ImportReport, error) {
+	if err := checkTransferFormat(opts.Format); err != nil {
+		return nil, err
+	}
+	if opts.UpsertOn != "" {
+		if err := checkUpsertAttribute(collection, opts.UpsertOn); err != nil {
+			return nil, err
+		}
+	}
+
+	next, err := recordReader(r, collection, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{}
+	batch := make([]importRecord, 0, transferBatchSize)
+	for {
+		record, done := next()
+		if !done {
+			batch = append(batch, record)
+		}
+		if len(batch) == transferBatchSize || (done && len(batch) > 0) {
+			if err := importBatch(collection, batch, opts, report); err != nil {
+				return report, err
+			}
+			batch = batch[:0]
+		}
+		if done {
+			break
+		}
+	}
+
+	logger.Log.WithField("collection", collection.Name).
+		WithField("created", report.Created).
+		WithField("updated", report.Updated).
+		WithField("failed", report.Failed).
+		Info("Items imported")
+	return report, nil
+}
+
+// importBatch imports a batch of records in one transaction, each record in a savepoint
+// so a failing record is rolled back alone.
+func importBatch(collection models.Collection, batch []importRecord, opts ImportOptions, report *ImportReport) error {
+	type written struct {
+		item  models.Item
+		event events.EventType
+	}
+	var writes []written
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, record := range batch {
+			report.Total++
+			err := record.err
+			if err == nil {
+				err = tx.Transaction(func(rowTx *gorm.DB) error {
+					item, created, err := importItem(rowTx, collection, record.record, opts)
+					if err != nil {
+						return err
+					}
+					event := events.EventTypeItemUpdated
+					if created {
+						event = events.EventTypeItemCreated
+					}
+					writes = append(writes, written{item: *item, event: event})
+					return nil
+				})
+			}
+			if err != nil {
+				report.Failed++
+				if len(report.Errors) < maxReportedRowErrors {
+					report.Errors = append(report.Errors, ImportRowError{Line: record.line, Error: err.Error()})
+				}
+				continue
+			}
+			if writes[len(writes)-1].event == events.EventTypeItemCreated {
+				report.Created++
+			} else {
+				report.Updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import items of collection '%s': %w", collection.Name, err)
+	}
+
+	for i := range writes {
+		publishEvent(database.DB, writes[i].event, &writes[i].item)
+	}
+	return nil
+}
+
+// importItem writes one record within tx and reports whether the item was created.
+func importItem(tx *gorm.DB, collection models.Collection, record TransferRecord, opts ImportOptions) (*models.Item, bool, error) {
+	if record.Data == nil {
+		return nil, false, errors.New("missing item data")
+	}
+	data, err := resolveRelationRefs(tx, collection, record.Data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var item models.Item
+	created := true
+	if key := data[opts.UpsertOn]; opts.UpsertOn != "" && key != nil {
+		match, err := findUpsertTarget(tx, collection, opts.UpsertOn, key)
+		if err != nil {
+			return nil, false, err
+		}
+		if match != nil {
+			item = *match
+			created = false
+		}
+	}
+
+	access := models.WriteAccess{Role: opts.Actor.Role}
+	if !created {
+		access.Current = item.Data
+	}
+	processed, err := models.ValidateItemValues(collection, data, tx, access)
+	if err != nil {
+		return nil, false, err
+	}
+
+	item.CollectionID = collection.ID
+	item.Data = processed
+	switch record.Status {
+	case models.ItemStatusPublished:
+		item.Status = models.ItemStatusPublished
+		item.PublishedAt = record.PublishedAt
+		if item.PublishedAt == nil {
+			now := time.Now()
+			item.PublishedAt = &now
+		}
+	case models.ItemStatusDraft:
+		item.Status = models.ItemStatusDraft
+		item.PublishedAt = nil
+	case "":
+		if created {
+			item.Status = models.ItemStatusDraft
+		}
+	default:
+		return nil, false, fmt.Errorf("invalid status '%s'", record.Status)
+	}
+
+	action := models.RevisionActionUpdate
+	if created {
+		action = models.RevisionActionCreate
+		err = tx.Create(&item).Error
+	} else {
+		err = tx.Save(&item).Error
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if err := models.SyncItemRelations(tx, collection, &item); err != nil {
+		return nil, false, err
+	}
+	if err := RecordItemRevision(tx, &item, action, opts.Actor); err != nil {
+		return nil, false, err
+	}
+	return &item, created, nil
+}
+
+// resolveRelationRefs returns a copy of data where the relations given as unique attribute
+// values are replaced by the IDs of the matching items. Unlike nested writes through the
+// API, references matching no item are errors instead of creating the related items.
+func resolveRelationRefs(tx *gorm.DB, collection models.Collection, data models.JSONMap) (models.JSONMap, error) {
+	resolved := make(models.JSONMap, len(data))
+	for key, value := range data {
+		resolved[key] = value
+	}
+
+	for _, attr := range collection.Attributes {
+		value, exists := data[attr.Name]
+		if attr.Type != "relation" || !exists || value == nil {
+			continue
+		}
+
+		var target *models.Collection
+		resolve := func(element any) (any, error) {
+			ref, isRef := element.(map[string]any)
+			if !isRef || ref["id"] != nil {
+				return element, nil
+			}
+			if target == nil {
+				var loaded models.Collection
+				if err := tx.Preload("Attributes").Where("name = ?", attr.Target).First(&loaded).Error; err != nil {
+					return nil, fmt.Errorf("target collection '%s' does not exist", attr.Target)
+				}
+				target = &loaded
+			}
+			id, found, err := models.LookupItem(*target, ref, tx)
+			if err != nil {
+				return nil, fmt.Errorf("relation '%s': %w", attr.Name, err)
+			}
+			if !found {
+				encoded, _ := json.Marshal(ref)
+				return nil, fmt.Errorf("relation '%s': no item of '%s' matches %s", attr.Name, attr.Target, encoded)
+			}
+			return id, nil
+		}
+
+		if list, ok := value.([]any); ok {
+			ids := make([]any, 0, len(list))
+			for _, element := range list {
+				id, err := resolve(element)
+				if err != nil {
+					return nil, err
+				}
+				ids = append(ids, id)
+			}
+			resolved[attr.Name] = ids
+			continue
+		}
+		id, err := resolve(value)
+		if err != nil {
+			return nil, err
+		}
+		resolved[attr.Name] = id
+	}
+	return resolved, nil
+}
+
+// recordReader returns a function reading the next record of the input, which reports
+// true once the input is exhausted.
+func recordReader(r io.Reader, collection models.Collection, format string) (func() (importRecord, bool), error) {
+	if format == TransferFormatJSONL {
+		reader := bufio.NewReader(r)
+		line := 0
+		return func() (importRecord, bool) {
+			for {
+				content, err := reader.ReadBytes('\n')
+				if len(content) == 0 && err != nil {
+					if err != io.EOF {
+						line++
+						return importRecord{line: line, err: err}, false
+					}
+					return importRecord{}, true
+				}
+				line++
+				content = bytes.TrimSpace(content)
+				if len(content) == 0 {
+					continue
+				}
+				record := importRecord{line: line}
+				decoder := json.NewDecoder(bytes.NewReader(content))
+				if err := decoder.Decode(&record.record); err != nil {
+					record.err = fmt.Errorf("invalid JSON: %w", err)
+				}
+				return record, false
+			}
+		}, nil
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CSV header: %w", err)
+	}
+	columns := make([]*models.Attribute, len(header))
+	for i, name := range header {
+		if slices.Contains(transferMetaColumns, name) {
+			continue
+		}
+		if columns[i] = findAttribute(collection, name); columns[i] == nil {
+			return nil, fmt.Errorf("unknown attribute '%s' in the CSV header", name)
+		}
+	}
+
+	line := 1
+	return func() (importRecord, bool) {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return importRecord{}, true
+		}
+		line++
+		record := importRecord{line: line}
+		if err != nil {
+			record.err = err
+			return record, false
+		}
+		record.record, record.err = parseCSVRow(header, columns, row)
+		return record, false
+	}, nil
+}
+
+// parseCSVRow decodes a CSV row into a record. Empty cells leave the attribute unset.
+func parseCSVRow(header []string, columns []*models.Attribute, row []string) (TransferRecord, error) {
+	record := TransferRecord{Data: models.JSONMap{}}
+	if len(row) != len(header) {
+		return record, fmt.Errorf("expected %d columns, got %d", len(header), len(row))
+	}
+	for i, cell := range row {
+		if cell == "" {
+			continue
+		}
+		switch header[i] {
+		case "id":
+			continue // IDs of the source database are not kept.
+		case "status":
+			record.Status = cell
+			continue
+		case "published_at":
+			publishedAt, err := time.Parse(time.RFC3339, cell)
+			if err != nil {
+				return record, fmt.Errorf("invalid published_at '%s': %w", cell, err)
+			}
+			record.PublishedAt = &publishedAt
+			continue
+		}
+		value, err := parseCSVCell(*columns[i], cell)
+		if err != nil {
+			return record, fmt.Errorf("invalid value for attribute '%s': %w", header[i], err)
+		}
+		record.Data[header[i]] = value
+	}
+	return record, nil
+}
+
+// parseCSVCell decodes a cell to the value of an attribute as a JSON body would hold it.
+func parseCSVCell(attr models.Attribute, cell string) (any, error) {
+	if attr.Localized || !attr.IsScalar() {
+		var value any
+		if err := json.Unmarshal([]byte(cell), &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	switch attr.Type {
+	case "int", "integer", "float", "decimal":
+		return strconv.ParseFloat(cell, 64)
+	case "bool", "boolean":
+		return strconv.ParseBool(cell)
+	default:
+		return cell, nil
+	}
+}
+
+func findAttribute(collection models.Collection, name string) *models.Attribute {
+	for i := range collection.Attributes {
+		if collection.Attributes[i].Name == name {
+			return &collection.Attributes[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemTransfer(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRelation{}, &models.ItemRevision{}))
+
+	authors := models.Collection{Name: "authors", Attributes: []models.Attribute{
+		{Name: "name", Type: "text", Unique: true},
+	}}
+	require.NoError(t, db.Create(&authors).Error)
+	articles := models.Collection{Name: "articles", Attributes: []models.Attribute{
+		{Name: "title", Type: "text", Required: true},
+		{Name: "slug", Type: "text", Unique: true},
+		{Name: "views", Type: "int"},
+		{Name: "author", Type: "relation", Relation: "oneToOne", Target: "authors"},
+	}}
+	require.NoError(t, db.Create(&articles).Error)
+
+	actor := models.SystemActor("test")
+	ada, err := SaveItem(authors, models.JSONMap{"name": "Ada"}, actor)
+	require.NoError(t, err)
+	_, err = SaveItem(articles, models.JSONMap{"title": "Hello", "slug": "hello", "views": float64(3), "author": float64(ada.ID)}, actor)
+	require.NoError(t, err)
+
+	// Relations are exported as the unique values of the related item.
+	var out bytes.Buffer
+	written, err := ExportItems(&out, articles, TransferFormatJSONL)
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+	assert.Contains(t, out.String(), `"author":{"name":"Ada"}`)
+
+	input := strings.Join([]string{
+		`{"data":{"title":"Hello again","slug":"hello","views":4,"author":{"name":"Ada"}}}`,
+		`{"status":"published","data":{"title":"Second","slug":"second"}}`,
+		``,
+		`{"data":{"slug":"untitled"}}`,
+		`{"data":{"title":"Orphan","slug":"orphan","author":{"name":"Grace"}}}`,
+		`not json`,
+	}, "\n")
+	report, err := ImportItems(strings.NewReader(input), articles, ImportOptions{Format: TransferFormatJSONL, UpsertOn: "slug", Actor: actor})
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 3, report.Failed)
+	require.Len(t, report.Errors, 3)
+	assert.Equal(t, 4, report.Errors[0].Line)
+	assert.Contains(t, report.Errors[1].Error, "no item of 'authors' matches")
+	assert.Equal(t, 6, report.Errors[2].Line)
+
+	var items []models.Item
+	require.NoError(t, db.Where("collection_id = ?", articles.ID).Order("id").Find(&items).Error)
+	require.Len(t, items, 2)
+	assert.Equal(t, "Hello again", items[0].Data["title"])
+	assert.Equal(t, ada.ID, models.ToUint(items[0].Data["author"]))
+	assert.Equal(t, models.ItemStatusPublished, items[1].Status)
+	assert.NotNil(t, items[1].PublishedAt)
+
+	// A CSV export imports back onto the same items.
+	out.Reset()
+	_, err = ExportItems(&out, articles, TransferFormatCSV)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out.String(), "id,status,published_at,title,slug,views,author\n"))
+
+	report, err = ImportItems(&out, articles, ImportOptions{Format: TransferFormatCSV, UpsertOn: "slug", Actor: actor})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Updated)
+	assert.Empty(t, report.Errors)
+
+	var first models.Item
+	require.NoError(t, db.First(&first, items[0].ID).Error)
+	assert.Equal(t, 4, first.Data["views"])
+	assert.Equal(t, ada.ID, models.ToUint(first.Data["author"]))
+
+	// Numbers are matched as numbers.
+	report, err = ImportItems(strings.NewReader(`{"data":{"title":"Most viewed","views":4}}`), articles,
+		ImportOptions{Format: TransferFormatJSONL, UpsertOn: "views", Actor: actor})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Updated)
+	assert.Zero(t, report.Created)
+	require.NoError(t, db.First(&first, items[0].ID).Error)
+	assert.Equal(t, "Most viewed", first.Data["title"])
+
+	_, err = ImportItems(strings.NewReader(""), articles, ImportOptions{Format: "xml"})
+	assert.Error(t, err)
+	_, err = ImportItems(strings.NewReader(""), articles, ImportOptions{Format: TransferFormatJSONL, UpsertOn: "author"})
+	assert.Error(t, err)
+}
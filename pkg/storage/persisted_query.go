@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TaskTypePurgePersistedQueries is the periodic task deleting the automatic persisted
+// queries past their expiry. It runs in the ScheduleQueueName queue.
+const TaskTypePurgePersistedQueries = "graphql:purge_queries"
+
+// NewPurgePersistedQueriesTask returns the task deleting the expired persisted queries.
+func NewPurgePersistedQueriesTask() *asynq.Task {
+	return asynq.NewTask(TaskTypePurgePersistedQueries, nil, asynq.Queue(ScheduleQueueName), asynq.MaxRetry(3))
+}
+
+// HandlePurgePersistedQueriesTask deletes the persisted queries past their expiry.
+func HandlePurgePersistedQueriesTask(ctx context.Context, task *asynq.Task) error {
+	purged, err := PurgeExpiredPersistedQueries(time.Now())
+	if err != nil {
+		return err
+	}
+	logger.Log.WithField("purged_queries", purged).Info("Expired persisted queries purged")
+	return nil
+}
+
+// GetPersistedQuery retrieves the persisted query with the given hash, unless expired.
+func GetPersistedQuery(hash string) (*models.PersistedQuery, error) {
+	var query models.PersistedQuery
+	err := database.DB.Where("hash = ? AND (expires_at IS NULL OR expires_at > ?)", hash, time.Now()).First(&query).Error
+	if err != nil {
+		return nil, fmt.Errorf("persisted query '%s' not found: %w", hash, err)
+	}
+	return &query, nil
+}
+
+// SavePersistedQueries persists queries. Queries of a manifest become manifest queries
+// which do not expire; automatic queries already persisted only have their expiry
+// pushed back.
+func SavePersistedQueries(queries []models.PersistedQuery) error {
+	var manifest, automatic []models.PersistedQuery
+	for _, query := range queries {
+		if query.Source == models.PersistedQuerySourceManifest {
+			query.ExpiresAt = nil
+			manifest = append(manifest, query)
+		} else {
+			automatic = append(automatic, query)
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(manifest) > 0 {
+			onConflict := clause.OnConflict{Columns: []clause.Column{{Name: "hash"}}, DoUpdates: clause.AssignmentColumns([]string{"source", "expires_at", "updated_at"})}
+			if err := tx.Clauses(onConflict).Create(&manifest).Error; err != nil {
+				return err
+			}
+		}
+		for _, query := range automatic {
+			err := tx.Model(&models.PersistedQuery{}).
+				Where("hash = ? AND source = ?", query.Hash, models.PersistedQuerySourceAutomatic).
+				Updates(map[string]any{"expires_at": query.ExpiresAt, "updated_at": time.Now()}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(automatic) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&automatic).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to save persisted queries")
+		return fmt.Errorf("failed to save persisted queries: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpiredPersistedQueries permanently deletes the persisted queries expired before
+// now, so that their hashes can be registered again. It returns the number purged.
+func PurgeExpiredPersistedQueries(now time.Time) (int64, error) {
+	result := database.DB.Unscoped().Where("expires_at <= ?", now).Delete(&models.PersistedQuery{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge persisted queries: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPersistedQueries(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.PersistedQuery{}))
+
+	articles := models.PersistedQuery{Hash: models.HashQuery("{ articles { title } }"), Query: "{ articles { title } }", Source: models.PersistedQuerySourceAutomatic}
+	pages := models.PersistedQuery{Hash: models.HashQuery("{ pages { title } }"), Query: "{ pages { title } }", Source: models.PersistedQuerySourceManifest}
+	require.NoError(t, SavePersistedQueries([]models.PersistedQuery{articles, pages}))
+
+	query, err := GetPersistedQuery(articles.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, articles.Query, query.Query)
+	assert.Equal(t, models.PersistedQuerySourceAutomatic, query.Source)
+
+	// Registering a manifest query again does not make it automatic, uploading an
+	// automatic one in a manifest makes it a manifest query.
+	pages.Source = models.PersistedQuerySourceAutomatic
+	articles.Source = models.PersistedQuerySourceManifest
+	require.NoError(t, SavePersistedQueries([]models.PersistedQuery{articles, pages}))
+	for _, hash := range []string{articles.Hash, pages.Hash} {
+		query, err := GetPersistedQuery(hash)
+		require.NoError(t, err)
+		assert.Equal(t, models.PersistedQuerySourceManifest, query.Source)
+	}
+
+	_, err = GetPersistedQuery(models.HashQuery("{ authors { name } }"))
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	// Automatic queries are forgotten once expired, until registered again, and purged.
+	expired := time.Now().Add(-time.Minute)
+	authors := models.PersistedQuery{Hash: models.HashQuery("{ authors { name } }"), Query: "{ authors { name } }", Source: models.PersistedQuerySourceAutomatic, ExpiresAt: &expired}
+	require.NoError(t, SavePersistedQueries([]models.PersistedQuery{authors}))
+	_, err = GetPersistedQuery(authors.Hash)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	later := time.Now().Add(time.Hour)
+	authors.ExpiresAt = &later
+	require.NoError(t, SavePersistedQueries([]models.PersistedQuery{authors}))
+	_, err = GetPersistedQuery(authors.Hash)
+	require.NoError(t, err)
+
+	purged, err := PurgeExpiredPersistedQueries(time.Now().Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	_, err = GetPersistedQuery(authors.Hash)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	_, err = GetPersistedQuery(pages.Hash)
+	assert.NoError(t, err)
+}
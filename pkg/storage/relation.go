@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"gorm.io/gorm"
+)
+
+// loadItemRelations returns the related item IDs stored for the items with an "id",
+// by item ID and relation attribute, in order.
+func loadItemRelations(items []models.JSONMap) (map[uint]map[string][]uint, error) {
+	var sourceIDs []uint
+	for _, data := range items {
+		if id := models.ToUint(data["id"]); id != 0 {
+			sourceIDs = append(sourceIDs, id)
+		}
+	}
+	stored := make(map[uint]map[string][]uint, len(sourceIDs))
+	if len(sourceIDs) == 0 {
+		return stored, nil
+	}
+
+	var rows []models.ItemRelation
+	err := database.DB.Where("source_item_id IN ?", sourceIDs).
+		Order("source_item_id, attribute, position").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load item relations: %w", err)
+	}
+	for _, row := range rows {
+		if stored[row.SourceItemID] == nil {
+			stored[row.SourceItemID] = make(map[string][]uint)
+		}
+		stored[row.SourceItemID][row.Attribute] = append(stored[row.SourceItemID][row.Attribute], row.TargetItemID)
+	}
+	return stored, nil
+}
+
+// fetchRelatedItems loads the items of the named collection with the given IDs and their
+// own relations, by ID. Items hidden by opts are left out.
+func fetchRelatedItems(collectionName string, ids []uint, level uint, opts ReadOptions) (map[uint]models.JSONMap, error) {
+	var collection models.Collection
+	err := database.DB.Where("name = ?", collectionName).Preload("Attributes").First(&collection).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch target collection '%s': %w", collectionName, err)
+	}
+
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	query := database.DB.Where("collection_id = ? AND id IN ?", collection.ID, ids)
+	if opts.PublishedOnly {
+		query = query.Where("status = ?", models.ItemStatusPublished)
+	}
+	var items []models.Item
+	if err := query.Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch items of '%s': %w", collectionName, err)
+	}
+
+	data := make([]models.JSONMap, len(items))
+	for i, item := range items {
+		item.Data["id"] = item.ID
+		data[i] = item.Data
+	}
+	hydrated, err := FetchItemsRelations(collection, data, level, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	related := make(map[uint]models.JSONMap, len(items))
+	for i, item := range items {
+		related[item.ID] = hydrated[i]
+	}
+	return related, nil
+}
+
+// ItemReference is an item referencing another item through a relation attribute.
+type ItemReference struct {
+	Collection string `json:"collection"`
+	Attribute  string `json:"attribute"`
+	ItemID     uint   `json:"id"`
+	Status     string `json:"status"`
+}
+
+// GetItemReferences lists the items referencing an item, e.g. the articles whose author
+// is a given author. When set, collectionName and attribute restrict the references to
+// those made by that collection and relation attribute.
+func GetItemReferences(itemID uint, collectionName, attribute string) ([]ItemReference, error) {
+	query := database.DB.Table("item_relations").
+		Select("DISTINCT collections.name AS collection, item_relations.attribute, items.id AS item_id, items.status").
+		Joins("JOIN items ON items.id = item_relations.source_item_id AND items.deleted_at IS NULL").
+		Joins("JOIN collections ON collections.id = item_relations.source_collection_id").
+		Where("item_relations.target_item_id = ?", itemID)
+	if collectionName != "" {
+		query = query.Where("collections.name = ?", collectionName)
+	}
+	if attribute != "" {
+		query = query.Where("item_relations.attribute = ?", attribute)
+	}
+
+	references := []ItemReference{}
+	err := query.Order("collections.name, item_relations.attribute, items.id").Scan(&references).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch references to item %d: %w", itemID, err)
+	}
+	return references, nil
+}
+
+// deleteItems deletes an item and applies the delete actions of the relations referencing
+// it. It returns the deleted items, including those deleted by cascade, and the items
+// whose references were dropped.
+func deleteItems(tx *gorm.DB, id uint, actor models.Actor) ([]models.Item, []models.Item, error) {
+	var root models.Item
+	if err := tx.First(&root, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil // Nothing to delete.
+		}
+		return nil, nil, err
+	}
+
+	// Collect the items deleted by cascade, then check that no remaining item forbids it.
+	collections := make(map[uint]*models.Collection)
+	deleting := map[uint]bool{root.ID: true}
+	deleted := []models.Item{root}
+	var restricted []models.ItemRelation
+	dropped := make(map[uint]bool)
+	for i := 0; i < len(deleted); i++ {
+		var incoming []models.ItemRelation
+		if err := tx.Where("target_item_id = ?", deleted[i].ID).Find(&incoming).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to load references to item %d: %w", deleted[i].ID, err)
+		}
+		for _, rel := range incoming {
+			if deleting[rel.SourceItemID] {
+				continue
+			}
+			attr, err := relationAttribute(tx, collections, rel)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch attr.DeleteAction() {
+			case models.OnDeleteCascade:
+				var source models.Item
+				if err := tx.First(&source, rel.SourceItemID).Error; err != nil {
+					return nil, nil, fmt.Errorf("failed to load referencing item %d: %w", rel.SourceItemID, err)
+				}
+				deleting[source.ID] = true
+				deleted = append(deleted, source)
+			case models.OnDeleteRestrict:
+				restricted = append(restricted, rel)
+			default:
+				dropped[rel.SourceItemID] = true
+			}
+		}
+	}
+	for _, rel := range restricted {
+		if !deleting[rel.SourceItemID] {
+			return nil, nil, &models.ItemReferencedError{
+				ItemID:       rel.TargetItemID,
+				SourceItemID: rel.SourceItemID,
+				Collection:   collections[rel.SourceCollectionID].Name,
+				Attribute:    rel.Attribute,
+			}
+		}
+	}
+
+	// Drop the references held by the items that remain.
+	var updated []models.Item
+	for _, sourceID := range slices.Sorted(maps.Keys(dropped)) {
+		if deleting[sourceID] {
+			continue
+		}
+		var source models.Item
+		if err := tx.First(&source, sourceID).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to load referencing item %d: %w", sourceID, err)
+		}
+		for _, attr := range collections[source.CollectionID].Attributes {
+			value, exists := source.Data[attr.Name]
+			if attr.Type != "relation" || !exists {
+				continue
+			}
+			ids := slices.DeleteFunc(models.RelationIDs(value), func(id uint) bool { return deleting[id] })
+			source.Data[attr.Name] = models.RelationValue(attr, ids)
+		}
+		if err := tx.Save(&source).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to drop references of item %d: %w", sourceID, err)
+		}
+		if err := tx.Where("source_item_id = ? AND target_item_id IN ?", sourceID, slices.Collect(maps.Keys(deleting))).
+			Delete(&models.ItemRelation{}).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to drop references of item %d: %w", sourceID, err)
+		}
+		if err := RecordItemRevision(tx, &source, models.RevisionActionUpdate, actor); err != nil {
+			return nil, nil, err
+		}
+		updated = append(updated, source)
+	}
+
+	for i := range deleted {
+		item := &deleted[i]
+		if err := tx.Delete(item).Error; err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Where("source_item_id = ? OR target_item_id = ?", item.ID, item.ID).Delete(&models.ItemRelation{}).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to delete relations of item %d: %w", item.ID, err)
+		}
+		if err := RecordItemRevision(tx, item, models.RevisionActionDelete, actor); err != nil {
+			return nil, nil, err
+		}
+	}
+	return deleted, updated, nil
+}
+
+// relationAttribute returns the attribute a stored relation was made through, loading the
+// source collection into collections when needed. Relations of attributes removed from
+// the schema since are dropped like the default delete action.
+func relationAttribute(tx *gorm.DB, collections map[uint]*models.Collection, rel models.ItemRelation) (models.Attribute, error) {
+	collection, loaded := collections[rel.SourceCollectionID]
+	if !loaded {
+		collection = &models.Collection{}
+		if err := tx.Preload("Attributes").First(collection, rel.SourceCollectionID).Error; err != nil {
+			return models.Attribute{}, fmt.Errorf("failed to load collection %d: %w", rel.SourceCollectionID, err)
+		}
+		collections[rel.SourceCollectionID] = collection
+	}
+	for _, attr := range collection.Attributes {
+		if attr.Name == rel.Attribute {
+			return attr, nil
+		}
+	}
+	return models.Attribute{Name: rel.Attribute, Type: "relation"}, nil
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupRelations creates an 'authors' collection and an 'articles' collection relating to
+// it, with the given delete action on the 'author' relation.
+func setupRelations(t *testing.T, db *gorm.DB, onDelete string) (models.Collection, models.Collection) {
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.Attribute{}, &models.ItemRevision{}, &models.ItemRelation{}))
+
+	authors := models.Collection{Name: "authors", Attributes: []models.Attribute{{Name: "name", Type: "text"}}}
+	assert.NoError(t, db.Create(&authors).Error)
+	articles := models.Collection{Name: "articles", Attributes: []models.Attribute{
+		{Name: "title", Type: "text"},
+		{Name: "author", Type: "relation", Target: "authors", Relation: "manyToOne", OnDelete: onDelete},
+		{Name: "reviewers", Type: "relation", Target: "authors", Relation: "oneToMany"},
+	}}
+	assert.NoError(t, db.Create(&articles).Error)
+	return authors, articles
+}
+
+func saveAuthor(t *testing.T, authors models.Collection, name string) uint {
+	item, err := SaveItem(authors, models.JSONMap{"name": name}, models.UserActor("tester"))
+	assert.NoError(t, err)
+	return item.ID
+}
+
+func TestSyncItemRelations(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	authors, articles := setupRelations(t, db, "")
+
+	alice := saveAuthor(t, authors, "Alice")
+	bob := saveAuthor(t, authors, "Bob")
+
+	article, err := SaveItem(articles, models.JSONMap{
+		"title":     "Relations",
+		"author":    alice,
+		"reviewers": []any{bob, alice},
+	}, models.UserActor("tester"))
+	assert.NoError(t, err)
+
+	var rows []models.ItemRelation
+	assert.NoError(t, db.Where("source_item_id = ?", article.ID).Order("attribute, position").Find(&rows).Error)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "author", rows[0].Attribute)
+	assert.Equal(t, alice, rows[0].TargetItemID)
+	assert.Equal(t, []uint{bob, alice}, []uint{rows[1].TargetItemID, rows[2].TargetItemID})
+
+	t.Run("Dangling IDs are dropped", func(t *testing.T) {
+		item := models.Item{CollectionID: articles.ID, Data: models.JSONMap{"reviewers": []any{bob, 9999}}}
+		assert.NoError(t, db.Create(&item).Error)
+		assert.NoError(t, models.SyncItemRelations(db, articles, &item))
+
+		var stored models.Item
+		assert.NoError(t, db.First(&stored, item.ID).Error)
+		assert.Equal(t, []uint{bob}, models.RelationIDs(stored.Data["reviewers"]))
+	})
+}
+
+func TestFetchItemsRelations(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	authors, articles := setupRelations(t, db, "")
+
+	alice := saveAuthor(t, authors, "Alice")
+	bob := saveAuthor(t, authors, "Bob")
+	first, err := SaveItem(articles, models.JSONMap{"title": "First", "author": alice, "reviewers": []any{bob}}, models.UserActor("tester"))
+	assert.NoError(t, err)
+	second, err := SaveItem(articles, models.JSONMap{"title": "Second", "author": bob}, models.UserActor("tester"))
+	assert.NoError(t, err)
+
+	items := []models.JSONMap{
+		{"id": first.ID, "title": "First"},
+		{"id": second.ID, "title": "Second"},
+	}
+	results, err := FetchItemsRelations(articles, items, 1, ReadOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "Alice", results[0]["author"].(models.JSONMap)["name"], "relations are read from the stored relations")
+	assert.Equal(t, "Bob", results[0]["reviewers"].([]models.JSONMap)[0]["name"])
+	assert.Equal(t, "Bob", results[1]["author"].(models.JSONMap)["name"])
+	assert.Empty(t, results[1]["reviewers"], "to-many relations are lists, even when empty")
+
+	t.Run("Drafts are hidden", func(t *testing.T) {
+		results, err := FetchItemsRelations(articles, items, 1, ReadOptions{PublishedOnly: true})
+		assert.NoError(t, err)
+		assert.Nil(t, results[0]["author"])
+	})
+}
+
+func TestDeleteItemRelations(t *testing.T) {
+	t.Run("Set null", func(t *testing.T) {
+		_, db := testutils.SetupTestServer()
+		defer testutils.CleanupTestDB()
+		authors, articles := setupRelations(t, db, models.OnDeleteSetNull)
+
+		alice := saveAuthor(t, authors, "Alice")
+		bob := saveAuthor(t, authors, "Bob")
+		article, err := SaveItem(articles, models.JSONMap{"author": alice, "reviewers": []any{alice, bob}}, models.UserActor("tester"))
+		assert.NoError(t, err)
+
+		assert.NoError(t, DeleteItem(alice, models.UserActor("tester")))
+
+		var stored models.Item
+		assert.NoError(t, db.First(&stored, article.ID).Error)
+		assert.Nil(t, stored.Data["author"])
+		assert.Equal(t, []uint{bob}, models.RelationIDs(stored.Data["reviewers"]))
+
+		var count int64
+		db.Model(&models.ItemRelation{}).Where("target_item_id = ?", alice).Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Cascade", func(t *testing.T) {
+		_, db := testutils.SetupTestServer()
+		defer testutils.CleanupTestDB()
+		authors, articles := setupRelations(t, db, models.OnDeleteCascade)
+
+		alice := saveAuthor(t, authors, "Alice")
+		article, err := SaveItem(articles, models.JSONMap{"author": alice}, models.UserActor("tester"))
+		assert.NoError(t, err)
+
+		assert.NoError(t, DeleteItem(alice, models.UserActor("tester")))
+		assert.ErrorIs(t, db.First(&models.Item{}, article.ID).Error, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("Restrict", func(t *testing.T) {
+		_, db := testutils.SetupTestServer()
+		defer testutils.CleanupTestDB()
+		authors, articles := setupRelations(t, db, models.OnDeleteRestrict)
+
+		alice := saveAuthor(t, authors, "Alice")
+		article, err := SaveItem(articles, models.JSONMap{"author": alice}, models.UserActor("tester"))
+		assert.NoError(t, err)
+
+		err = DeleteItem(alice, models.UserActor("tester"))
+		var referenced *models.ItemReferencedError
+		assert.True(t, errors.As(err, &referenced))
+		assert.Equal(t, article.ID, referenced.SourceItemID)
+		assert.NoError(t, db.First(&models.Item{}, alice).Error, "the referenced item is kept")
+
+		// Once the reference is gone, the item can be deleted.
+		assert.NoError(t, DeleteItem(article.ID, models.UserActor("tester")))
+		assert.NoError(t, DeleteItem(alice, models.UserActor("tester")))
+	})
+}
+
+func TestGetItemReferences(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	authors, articles := setupRelations(t, db, "")
+
+	alice := saveAuthor(t, authors, "Alice")
+	first, err := SaveItem(articles, models.JSONMap{"author": alice, "reviewers": []any{alice}}, models.UserActor("tester"))
+	assert.NoError(t, err)
+	second, err := SaveItem(articles, models.JSONMap{"reviewers": []any{alice}}, models.UserActor("tester"))
+	assert.NoError(t, err)
+
+	references, err := GetItemReferences(alice, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []ItemReference{
+		{Collection: "articles", Attribute: "author", ItemID: first.ID, Status: models.ItemStatusDraft},
+		{Collection: "articles", Attribute: "reviewers", ItemID: first.ID, Status: models.ItemStatusDraft},
+		{Collection: "articles", Attribute: "reviewers", ItemID: second.ID, Status: models.ItemStatusDraft},
+	}, references)
+
+	references, err = GetItemReferences(alice, "articles", "author")
+	assert.NoError(t, err)
+	assert.Len(t, references, 1)
+
+	assert.NoError(t, DeleteItem(second.ID, models.UserActor("tester")))
+	references, err = GetItemReferences(alice, "", "reviewers")
+	assert.NoError(t, err)
+	assert.Len(t, references, 1, "deleted items no longer reference")
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/gohead-cms/gohead/internal/agent/events"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// RecordItemRevision stores a snapshot of item as its next revision.
+// It must be called within the transaction performing the write.
+func RecordItemRevision(tx *gorm.DB, item *models.Item, action string, actor models.Actor) error {
+	return recordItemRevision(tx, item, action, actor, nil)
+}
+
+func recordItemRevision(tx *gorm.DB, item *models.Item, action string, actor models.Actor, restoredFrom *int) error {
+	var latest int
+	if err := tx.Model(&models.ItemRevision{}).
+		Where("item_id = ?", item.ID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return fmt.Errorf("failed to read latest revision: %w", err)
+	}
+
+	revision := models.NewItemRevision(*item, action, actor)
+	revision.Version = latest + 1
+	revision.RestoredFrom = restoredFrom
+	if err := tx.Create(&revision).Error; err != nil {
+		logger.Log.WithField("item_id", item.ID).WithError(err).Error("Failed to record item revision")
+		return fmt.Errorf("failed to record item revision: %w", err)
+	}
+	return nil
+}
+
+// GetItemRevisions lists the revisions of an item, newest first.
+func GetItemRevisions(collectionID, itemID uint) ([]models.ItemRevision, error) {
+	var revisions []models.ItemRevision
+	err := database.DB.
+		Where("collection_id = ? AND item_id = ?", collectionID, itemID).
+		Order("version DESC").
+		Find(&revisions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// GetItemRevision fetches a single revision of an item by version number.
+func GetItemRevision(collectionID, itemID uint, version int) (*models.ItemRevision, error) {
+	var revision models.ItemRevision
+	err := database.DB.
+		Where("collection_id = ? AND item_id = ? AND version = ?", collectionID, itemID, version).
+		First(&revision).Error
+	if err != nil {
+		return nil, fmt.Errorf("revision %d of item %d not found: %w", version, itemID, err)
+	}
+	return &revision, nil
+}
+
+// RestoreItemRevision writes the data of an old revision back to the item.
+// The data is validated again and the restore is recorded as a new 'update' revision.
+func RestoreItemRevision(collection models.Collection, itemID uint, version int, actor models.Actor) (*models.Item, error) {
+	revision, err := GetItemRevision(collection.ID, itemID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var item models.Item
+	txErr := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND collection_id = ?", itemID, collection.ID).First(&item).Error; err != nil {
+			return fmt.Errorf("item with ID %d not found: %w", itemID, err)
+		}
+
+		processedData, err := models.ValidateItemValues(collection, revision.Data, tx, models.WriteAccess{Role: actor.Role, Current: item.Data})
+		if err != nil {
+			return err
+		}
+
+		item.Data = processedData
+		if err := tx.Save(&item).Error; err != nil {
+			return fmt.Errorf("failed to restore item data: %w", err)
+		}
+		if err := models.SyncItemRelations(tx, collection, &item); err != nil {
+			return err
+		}
+		return recordItemRevision(tx, &item, models.RevisionActionUpdate, actor, &version)
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	publishEvent(database.DB, events.EventTypeItemUpdated, &item)
+	logger.Log.WithField("item_id", itemID).WithField("version", version).Info("Item revision restored successfully")
+	return &item, nil
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemRevisions(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+
+	assert.NoError(t, db.AutoMigrate(&models.Item{}, &models.Collection{}, &models.Attribute{}, &models.ItemRevision{}, &models.ItemRelation{}))
+
+	collection := models.Collection{
+		Name: "articles",
+		Attributes: []models.Attribute{
+			{Name: "title", Type: "text", Required: true},
+			{Name: "body", Type: "text"},
+		},
+	}
+	assert.NoError(t, db.Create(&collection).Error)
+
+	editor := models.UserActor("editor1")
+	item, err := SaveItem(collection, models.JSONMap{"title": "First", "body": "v1"}, editor)
+	assert.NoError(t, err)
+	assert.NoError(t, UpdateItem(item.ID, models.JSONMap{"title": "Second"}, models.AgentActor(4)))
+
+	revisions, err := GetItemRevisions(collection.ID, item.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[0].Version)
+	assert.Equal(t, models.RevisionActionUpdate, revisions[0].Action)
+	assert.Equal(t, models.ActorTypeAgent, revisions[0].ActorType)
+	assert.Equal(t, "4", revisions[0].ActorID)
+	assert.Equal(t, 1, revisions[1].Version)
+	assert.Equal(t, models.RevisionActionCreate, revisions[1].Action)
+	assert.Equal(t, "editor1", revisions[1].ActorID)
+	assert.Equal(t, "v1", revisions[1].Data["body"])
+
+	t.Run("Restore an old revision", func(t *testing.T) {
+		restored, err := RestoreItemRevision(collection, item.ID, 1, editor)
+		assert.NoError(t, err)
+		assert.Equal(t, "First", restored.Data["title"])
+		assert.Equal(t, "v1", restored.Data["body"])
+
+		latest, err := GetItemRevision(collection.ID, item.ID, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, models.RevisionActionUpdate, latest.Action)
+		assert.Equal(t, 1, *latest.RestoredFrom)
+	})
+
+	t.Run("Restored data is validated again", func(t *testing.T) {
+		assert.NoError(t, UpdateItem(item.ID, models.JSONMap{"body": "no title"}, editor))
+		invalid, err := GetItemRevisions(collection.ID, item.ID)
+		assert.NoError(t, err)
+
+		_, err = RestoreItemRevision(collection, item.ID, invalid[0].Version, editor)
+		assert.Error(t, err)
+	})
+
+	t.Run("Delete keeps a final revision", func(t *testing.T) {
+		assert.NoError(t, DeleteItem(item.ID, editor))
+
+		revisions, err := GetItemRevisions(collection.ID, item.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.RevisionActionDelete, revisions[0].Action)
+		assert.Equal(t, "no title", revisions[0].Data["body"])
+	})
+
+	t.Run("Unknown revision", func(t *testing.T) {
+		_, err := GetItemRevision(collection.ID, item.ID, 99)
+		assert.Error(t, err)
+	})
+}
@@ -73,3 +73,13 @@ func DeleteRole(id uint) error {
 	logger.Log.WithField("id", id).Info("Role deleted successfully")
 	return nil
 }
+
+// CountUsersWithRole returns how many users are assigned the role with the given ID.
+func CountUsersWithRole(roleID uint) (int64, error) {
+	var count int64
+	if err := database.DB.Model(&models.User{}).Where("user_role_id = ?", roleID).Count(&count).Error; err != nil {
+		logger.Log.WithError(err).WithField("id", roleID).Error("Failed to count users with role")
+		return 0, fmt.Errorf("failed to count users with role: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,308 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const (
+	// TaskTypeScheduledTransition is the task publishing or unpublishing an item at its scheduled time.
+	TaskTypeScheduledTransition = "items:scheduled_transition"
+	// ScheduleQueueName is the Asynq queue of scheduled transitions.
+	ScheduleQueueName = "scheduling"
+)
+
+// Kinds of content a scheduled transition applies to.
+const (
+	TransitionTargetItem       = "item"
+	TransitionTargetSingleItem = "single_item"
+)
+
+// TransitionPayload is the payload of a TaskTypeScheduledTransition task. At is the
+// time the transition was scheduled at: the task is stale, and does nothing, when the
+// schedule of the item changed since.
+type TransitionPayload struct {
+	Target     string    `json:"target"`
+	ID         uint      `json:"id"`
+	Transition string    `json:"transition"`
+	At         time.Time `json:"at"`
+}
+
+// ScheduledTransition is an upcoming publication or unpublication. Collection is the
+// name of the collection of an item, or of the singleton of a single item.
+type ScheduledTransition struct {
+	Target     string    `json:"target"`
+	Collection string    `json:"collection"`
+	ItemID     uint      `json:"item_id"`
+	Transition string    `json:"transition"`
+	At         time.Time `json:"at"`
+}
+
+// ScheduleItem replaces the publication schedule of an item and enqueues its transitions.
+func ScheduleItem(collection models.Collection, itemID uint, schedule models.ItemSchedule) (*models.Item, error) {
+	var item models.Item
+	if err := database.DB.Where("id = ? AND collection_id = ?", itemID, collection.ID).First(&item).Error; err != nil {
+		return nil, fmt.Errorf("item with ID %d not found: %w", itemID, err)
+	}
+
+	schedule = schedule.Normalize()
+	// Transitions are enqueued first: if the schedule cannot be saved, they are stale.
+	if err := enqueueTransitions(TransitionTargetItem, item.ID, schedule); err != nil {
+		return nil, err
+	}
+	updates := map[string]any{"publish_at": schedule.PublishAt, "unpublish_at": schedule.UnpublishAt}
+	if err := database.DB.Model(&item).Updates(updates).Error; err != nil {
+		logger.Log.WithField("item_id", itemID).WithError(err).Error("Failed to schedule item")
+		return nil, fmt.Errorf("failed to schedule item: %w", err)
+	}
+	if err := database.DB.First(&item, item.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to reload item: %w", err)
+	}
+
+	logger.Log.WithField("item_id", itemID).
+		WithField("publish_at", schedule.PublishAt).
+		WithField("unpublish_at", schedule.UnpublishAt).
+		Info("Item scheduled successfully")
+	return &item, nil
+}
+
+// ScheduleSingleItem replaces the publication schedule of the single item of a
+// singleton and enqueues its transitions.
+func ScheduleSingleItem(singletonName string, schedule models.ItemSchedule) (*models.SingleItem, error) {
+	item, err := GetSingleItemByType(singletonName)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule = schedule.Normalize()
+	if err := enqueueTransitions(TransitionTargetSingleItem, item.ID, schedule); err != nil {
+		return nil, err
+	}
+	updates := map[string]any{"publish_at": schedule.PublishAt, "unpublish_at": schedule.UnpublishAt}
+	if err := database.DB.Model(item).Updates(updates).Error; err != nil {
+		logger.Log.WithField("Singleton", singletonName).WithError(err).Error("Failed to schedule single item")
+		return nil, fmt.Errorf("failed to schedule single item: %w", err)
+	}
+	if err := database.DB.First(item, item.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to reload single item: %w", err)
+	}
+
+	logger.Log.WithField("Singleton", singletonName).
+		WithField("publish_at", schedule.PublishAt).
+		WithField("unpublish_at", schedule.UnpublishAt).
+		Info("Single item scheduled successfully")
+	return item, nil
+}
+
+// enqueueTransitions enqueues the transitions of a schedule, processed at their time.
+// Without a task queue the schedule is recorded but never applied.
+func enqueueTransitions(target string, id uint, schedule models.ItemSchedule) error {
+	for transition, at := range map[string]*time.Time{
+		models.TransitionPublish:   schedule.PublishAt,
+		models.TransitionUnpublish: schedule.UnpublishAt,
+	} {
+		if at == nil {
+			continue
+		}
+		if asynqClient == nil {
+			logger.Log.WithField("target", target).WithField("id", id).
+				Warn("No task queue is configured, the scheduled transition will not be applied")
+			return nil
+		}
+
+		payload := TransitionPayload{Target: target, ID: id, Transition: transition, At: *at}
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("could not marshal payload: %w", err)
+		}
+		// The task ID makes scheduling the same transition twice a no-op.
+		task := asynq.NewTask(TaskTypeScheduledTransition, payloadBytes,
+			asynq.Queue(ScheduleQueueName),
+			asynq.ProcessAt(*at),
+			asynq.TaskID(fmt.Sprintf("%s:%d:%s:%d", target, id, transition, at.Unix())),
+			asynq.MaxRetry(5),
+		)
+		info, err := asynqClient.EnqueueContext(context.Background(), task)
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			continue
+		}
+		if err != nil {
+			logger.Log.WithError(err).Error("Failed to enqueue scheduled transition")
+			return fmt.Errorf("could not enqueue task: %w", err)
+		}
+		logger.Log.
+			WithField("job_id", info.ID).
+			WithField("target", target).
+			WithField("id", id).
+			WithField("transition", transition).
+			WithField("at", at).
+			Info("Successfully enqueued scheduled transition")
+	}
+	return nil
+}
+
+// HandleScheduledTransitionTask publishes or unpublishes an item or single item at its
+// scheduled time, emitting the usual events. Transitions of deleted items and
+// transitions whose schedule changed since they were enqueued are skipped, and items
+// already in the scheduled state only have their schedule cleared.
+func HandleScheduledTransitionTask(ctx context.Context, task *asynq.Task) error {
+	var payload TransitionPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid scheduled transition payload: %v: %w", err, asynq.SkipRetry)
+	}
+	if payload.Transition != models.TransitionPublish && payload.Transition != models.TransitionUnpublish {
+		return fmt.Errorf("unknown transition '%s': %w", payload.Transition, asynq.SkipRetry)
+	}
+
+	var err error
+	switch payload.Target {
+	case TransitionTargetItem:
+		err = applyItemTransition(payload)
+	case TransitionTargetSingleItem:
+		err = applySingleItemTransition(payload)
+	default:
+		return fmt.Errorf("unknown transition target '%s': %w", payload.Target, asynq.SkipRetry)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Log.WithField("target", payload.Target).WithField("id", payload.ID).
+			Info("Scheduled item was deleted, skipping its transition")
+		return nil
+	}
+	return err
+}
+
+// scheduledAt returns the time of a transition in a schedule.
+func scheduledAt(publishAt, unpublishAt *time.Time, transition string) *time.Time {
+	if transition == models.TransitionPublish {
+		return publishAt
+	}
+	return unpublishAt
+}
+
+// isScheduled reports whether a transition is still scheduled at the time of the payload.
+func isScheduled(payload TransitionPayload, publishAt, unpublishAt *time.Time) bool {
+	at := scheduledAt(publishAt, unpublishAt, payload.Transition)
+	if at == nil || at.Unix() != payload.At.Unix() {
+		logger.Log.WithField("target", payload.Target).WithField("id", payload.ID).
+			WithField("transition", payload.Transition).
+			Info("Schedule changed, skipping stale transition")
+		return false
+	}
+	return true
+}
+
+func applyItemTransition(payload TransitionPayload) error {
+	var item models.Item
+	if err := database.DB.First(&item, payload.ID).Error; err != nil {
+		return err
+	}
+	if !isScheduled(payload, item.PublishAt, item.UnpublishAt) {
+		return nil
+	}
+	if item.IsPublished() == (payload.Transition == models.TransitionPublish) {
+		column := payload.Transition + "_at"
+		return database.DB.Model(&item).Update(column, nil).Error
+	}
+	var collection models.Collection
+	if err := database.DB.First(&collection, item.CollectionID).Error; err != nil {
+		return err
+	}
+
+	if payload.Transition == models.TransitionPublish {
+		_, err := PublishItem(collection, item.ID)
+		return err
+	}
+	_, err := UnpublishItem(collection, item.ID)
+	return err
+}
+
+func applySingleItemTransition(payload TransitionPayload) error {
+	var item models.SingleItem
+	if err := database.DB.First(&item, payload.ID).Error; err != nil {
+		return err
+	}
+	if !isScheduled(payload, item.PublishAt, item.UnpublishAt) {
+		return nil
+	}
+	if item.IsPublished() == (payload.Transition == models.TransitionPublish) {
+		column := payload.Transition + "_at"
+		return database.DB.Model(&item).Update(column, nil).Error
+	}
+	var singleton models.Singleton
+	if err := database.DB.First(&singleton, item.SingleTypeID).Error; err != nil {
+		return err
+	}
+
+	if payload.Transition == models.TransitionPublish {
+		_, err := PublishSingleItem(singleton.Name)
+		return err
+	}
+	_, err := UnpublishSingleItem(singleton.Name)
+	return err
+}
+
+// GetScheduledTransitions lists the upcoming transitions of items and single items,
+// soonest first. A zero until lists them all.
+func GetScheduledTransitions(until time.Time) ([]ScheduledTransition, error) {
+	transitions := []ScheduledTransition{}
+	collect := func(target, name string, id uint, publishAt, unpublishAt *time.Time) {
+		for _, transition := range []string{models.TransitionPublish, models.TransitionUnpublish} {
+			at := scheduledAt(publishAt, unpublishAt, transition)
+			if at == nil || (!until.IsZero() && at.After(until)) {
+				continue
+			}
+			transitions = append(transitions, ScheduledTransition{
+				Target:     target,
+				Collection: name,
+				ItemID:     id,
+				Transition: transition,
+				At:         *at,
+			})
+		}
+	}
+
+	var items []struct {
+		ID          uint
+		Name        string
+		PublishAt   *time.Time
+		UnpublishAt *time.Time
+	}
+	err := database.DB.Model(&models.Item{}).
+		Select("items.id, collections.name, items.publish_at, items.unpublish_at").
+		Joins("JOIN collections ON collections.id = items.collection_id AND collections.deleted_at IS NULL").
+		Where("items.publish_at IS NOT NULL OR items.unpublish_at IS NOT NULL").
+		Scan(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled items: %w", err)
+	}
+	for _, item := range items {
+		collect(TransitionTargetItem, item.Name, item.ID, item.PublishAt, item.UnpublishAt)
+	}
+
+	items = nil
+	err = database.DB.Model(&models.SingleItem{}).
+		Select("single_items.id, singletons.name, single_items.publish_at, single_items.unpublish_at").
+		Joins("JOIN singletons ON singletons.id = single_items.single_type_id AND singletons.deleted_at IS NULL").
+		Where("single_items.publish_at IS NOT NULL OR single_items.unpublish_at IS NOT NULL").
+		Scan(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled single items: %w", err)
+	}
+	for _, item := range items {
+		collect(TransitionTargetSingleItem, item.Name, item.ID, item.PublishAt, item.UnpublishAt)
+	}
+
+	sort.SliceStable(transitions, func(a, b int) bool { return transitions[a].At.Before(transitions[b].At) })
+	return transitions, nil
+}
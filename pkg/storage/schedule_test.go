@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// transitionTask returns the task applying a transition scheduled at the given time.
+func transitionTask(t *testing.T, target string, id uint, transition string, at time.Time) *asynq.Task {
+	payload, err := json.Marshal(TransitionPayload{Target: target, ID: id, Transition: transition, At: at})
+	require.NoError(t, err)
+	return asynq.NewTask(TaskTypeScheduledTransition, payload)
+}
+
+func TestScheduledTransitions(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRelation{}, &models.ItemRevision{}, &models.Singleton{}, &models.SingleItem{}))
+
+	actor := models.SystemActor("test")
+	articles := models.Collection{Name: "articles", Attributes: []models.Attribute{{Name: "title", Type: "text"}}}
+	require.NoError(t, db.Create(&articles).Error)
+	campaign, err := SaveItem(articles, models.JSONMap{"title": "Campaign"}, actor)
+	require.NoError(t, err)
+
+	publishAt := time.Now().Add(time.Hour).Round(0)
+	unpublishAt := publishAt.Add(24 * time.Hour)
+	scheduled, err := ScheduleItem(articles, campaign.ID, models.ItemSchedule{PublishAt: &publishAt, UnpublishAt: &unpublishAt})
+	require.NoError(t, err)
+	require.NotNil(t, scheduled.PublishAt)
+	assert.Equal(t, publishAt.Unix(), scheduled.PublishAt.Unix())
+
+	homepage := models.Singleton{Name: "homepage"}
+	require.NoError(t, db.Create(&homepage).Error)
+	single := models.SingleItem{SingleTypeID: homepage.ID, Status: models.ItemStatusPublished, Data: models.JSONMap{}}
+	require.NoError(t, db.Create(&single).Error)
+	homepageUnpublishAt := publishAt.Add(time.Hour)
+	_, err = ScheduleSingleItem("homepage", models.ItemSchedule{UnpublishAt: &homepageUnpublishAt})
+	require.NoError(t, err)
+
+	transitions, err := GetScheduledTransitions(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, transitions, 3)
+	assert.Equal(t, models.TransitionPublish, transitions[0].Transition)
+	assert.Equal(t, "articles", transitions[0].Collection)
+	assert.Equal(t, TransitionTargetSingleItem, transitions[1].Target)
+	assert.Equal(t, models.TransitionUnpublish, transitions[2].Transition)
+	transitions, err = GetScheduledTransitions(publishAt.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, transitions, 1)
+
+	// A transition whose schedule changed since it was enqueued does nothing.
+	ctx := context.Background()
+	require.NoError(t, HandleScheduledTransitionTask(ctx, transitionTask(t, TransitionTargetItem, campaign.ID, models.TransitionPublish, publishAt.Add(-time.Hour))))
+	item, err := GetItemByID(articles.ID, campaign.ID)
+	require.NoError(t, err)
+	assert.False(t, item.IsPublished())
+
+	require.NoError(t, HandleScheduledTransitionTask(ctx, transitionTask(t, TransitionTargetItem, campaign.ID, models.TransitionPublish, publishAt)))
+	item, err = GetItemByID(articles.ID, campaign.ID)
+	require.NoError(t, err)
+	assert.True(t, item.IsPublished())
+	assert.Nil(t, item.PublishAt)
+	require.NotNil(t, item.UnpublishAt)
+
+	require.NoError(t, HandleScheduledTransitionTask(ctx, transitionTask(t, TransitionTargetItem, campaign.ID, models.TransitionUnpublish, unpublishAt)))
+	item, err = GetItemByID(articles.ID, campaign.ID)
+	require.NoError(t, err)
+	assert.False(t, item.IsPublished())
+	assert.Nil(t, item.UnpublishAt)
+
+	require.NoError(t, HandleScheduledTransitionTask(ctx, transitionTask(t, TransitionTargetSingleItem, single.ID, models.TransitionUnpublish, homepageUnpublishAt)))
+	homepageItem, err := GetSingleItemByType("homepage")
+	require.NoError(t, err)
+	assert.False(t, homepageItem.IsPublished())
+
+	// Transitions of deleted items are skipped.
+	require.NoError(t, DeleteItem(campaign.ID, actor))
+	assert.NoError(t, HandleScheduledTransitionTask(ctx, transitionTask(t, TransitionTargetItem, campaign.ID, models.TransitionPublish, publishAt)))
+
+	transitions, err = GetScheduledTransitions(time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, transitions)
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const (
+	// TaskTypeSchemaMigration is the task rewriting the items of a collection after a schema change.
+	TaskTypeSchemaMigration = "schema:migrate"
+	// SchemaQueueName is the Asynq queue of schema migration tasks.
+	SchemaQueueName = "schema"
+
+	// schemaMigrationBatchSize is the number of items rewritten per transaction.
+	schemaMigrationBatchSize = 500
+	// maxReportedItems caps the IDs of broken items listed in a dry run report.
+	maxReportedItems = 20
+)
+
+// SchemaMigrationPayload is the payload of a TaskTypeSchemaMigration task.
+type SchemaMigrationPayload struct {
+	CollectionID uint              `json:"collection_id"`
+	Plan         models.SchemaPlan `json:"plan"`
+}
+
+// SchemaDryRunReport tells how the items of a collection are affected by a schema plan.
+type SchemaDryRunReport struct {
+	TotalItems    int    `json:"total_items"`
+	ChangedItems  int    `json:"changed_items"`
+	BrokenItems   int    `json:"broken_items"`
+	BrokenItemIDs []uint `json:"broken_item_ids,omitempty"`
+}
+
+// SchemaMigrationResult counts the items rewritten by a schema migration.
+type SchemaMigrationResult struct {
+	MigratedItems int `json:"migrated_items"`
+	DroppedValues int `json:"dropped_values"`
+}
+
+// PlanCollectionUpdate computes the plan of updating a collection to the proposed schema.
+func PlanCollectionUpdate(id uint, proposed models.Collection, directives models.SchemaDirectives) (*models.SchemaPlan, error) {
+	current, err := GetCollectionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := models.PlanSchemaChange(*current, proposed, directives)
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// DryRunSchemaPlan migrates the data of every item of a collection in memory, those in
+// the trash included, and counts the items whose values would be dropped or would no
+// longer validate.
+func DryRunSchemaPlan(collectionID uint, plan models.SchemaPlan) (*SchemaDryRunReport, error) {
+	report := &SchemaDryRunReport{}
+	var items []models.Item
+	err := database.DB.Unscoped().Where("collection_id = ?", collectionID).FindInBatches(&items, schemaMigrationBatchSize, func(*gorm.DB, int) error {
+		for _, item := range items {
+			report.TotalItems++
+			migrated, dropped := plan.MigrateData(item.Data)
+			if !jsonEqual(item.Data, migrated) {
+				report.ChangedItems++
+			}
+			if len(dropped) > 0 || plan.ValidateMigratedData(migrated) != nil {
+				report.BrokenItems++
+				if len(report.BrokenItemIDs) < maxReportedItems {
+					report.BrokenItemIDs = append(report.BrokenItemIDs, item.ID)
+				}
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read items of collection '%s': %w", plan.Collection, err)
+	}
+	return report, nil
+}
+
+// jsonEqual reports whether two item data maps serialize to the same JSON.
+func jsonEqual(a, b models.JSONMap) bool {
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(left) == string(right)
+}
+
+// MigrateCollectionItems rewrites the data of every item of a collection to the proposed
+// schema of a plan, in batches of one transaction each. The collection must already have
+// its new schema, which the relations of the rewritten items are synced with. Items in the
+// trash are rewritten too, so they can be restored; their relations are synced when they
+// are.
+func MigrateCollectionItems(collectionID uint, plan models.SchemaPlan) (*SchemaMigrationResult, error) {
+	collection, err := GetCollectionByID(collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.Proposed = collection.Attributes
+
+	result := &SchemaMigrationResult{}
+	var items []models.Item
+	err = database.DB.Unscoped().Where("collection_id = ?", collectionID).FindInBatches(&items, schemaMigrationBatchSize, func(*gorm.DB, int) error {
+		return database.DB.Transaction(func(tx *gorm.DB) error {
+			for i := range items {
+				item := &items[i]
+				migrated, dropped := plan.MigrateData(item.Data)
+				result.DroppedValues += len(dropped)
+				if jsonEqual(item.Data, migrated) {
+					continue
+				}
+				item.Data = migrated
+				if err := tx.Unscoped().Model(item).UpdateColumn("data", item.Data).Error; err != nil {
+					return fmt.Errorf("failed to rewrite item %d: %w", item.ID, err)
+				}
+				if !item.DeletedAt.Valid {
+					if err := models.SyncItemRelations(tx, *collection, item); err != nil {
+						return err
+					}
+				}
+				result.MigratedItems++
+			}
+			return nil
+		})
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate items of collection '%s': %w", collection.Name, err)
+	}
+
+	logger.Log.WithField("collection", collection.Name).
+		WithField("migrated_items", result.MigratedItems).
+		WithField("dropped_values", result.DroppedValues).
+		Info("Collection items migrated to the new schema")
+	return result, nil
+}
+
+// EnqueueSchemaMigration schedules the rewrite of the items of a collection after a schema
+// change. Without a task queue the items are rewritten right away. It returns the ID of
+// the enqueued task, or an empty string when the items were rewritten in place.
+func EnqueueSchemaMigration(ctx context.Context, collectionID uint, plan models.SchemaPlan) (string, error) {
+	if asynqClient == nil {
+		_, err := MigrateCollectionItems(collectionID, plan)
+		return "", err
+	}
+
+	payloadBytes, err := json.Marshal(SchemaMigrationPayload{CollectionID: collectionID, Plan: plan})
+	if err != nil {
+		return "", fmt.Errorf("could not marshal payload: %w", err)
+	}
+	task := asynq.NewTask(TaskTypeSchemaMigration, payloadBytes, asynq.Queue(SchemaQueueName), asynq.MaxRetry(3))
+
+	info, err := asynqClient.EnqueueContext(ctx, task)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to enqueue schema migration task")
+		return "", fmt.Errorf("could not enqueue task: %w", err)
+	}
+	logger.Log.
+		WithField("job_id", info.ID).
+		WithField("collection", plan.Collection).
+		Info("Successfully enqueued schema migration task")
+	return info.ID, nil
+}
+
+// HandleSchemaMigrationTask rewrites the items of a collection to its new schema.
+// Rewriting is idempotent, so a failed batch is retried by running the task again.
+func HandleSchemaMigrationTask(ctx context.Context, task *asynq.Task) error {
+	var payload SchemaMigrationPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid schema migration payload: %v: %w", err, asynq.SkipRetry)
+	}
+	_, err := MigrateCollectionItems(payload.CollectionID, payload.Plan)
+	return err
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaMigration(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	assert.NoError(t, db.AutoMigrate(&models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRelation{}))
+
+	collection := models.Collection{Name: "articles", Attributes: []models.Attribute{
+		{Name: "title", Type: "text", Required: true},
+		{Name: "rating", Type: "text"},
+	}}
+	assert.NoError(t, db.Create(&collection).Error)
+	for _, data := range []models.JSONMap{
+		{"title": "First", "rating": "4"},
+		{"title": "Second", "rating": "great"},
+		{"title": "Third"},
+	} {
+		assert.NoError(t, db.Create(&models.Item{CollectionID: collection.ID, Data: data}).Error)
+	}
+	// Items in the trash are migrated too, so they can be restored.
+	trashed := models.Item{CollectionID: collection.ID, Data: models.JSONMap{"title": "Trashed", "rating": "5"}}
+	assert.NoError(t, db.Create(&trashed).Error)
+	assert.NoError(t, db.Delete(&trashed).Error)
+
+	proposed := models.Collection{Name: "articles", Attributes: []models.Attribute{
+		{Name: "headline", Type: "text", Required: true},
+		{Name: "rating", Type: "int"},
+	}}
+	plan, err := PlanCollectionUpdate(collection.ID, proposed, models.SchemaDirectives{
+		Renames: map[string]string{"title": "headline"},
+		Convert: []string{"rating"},
+	})
+	assert.NoError(t, err)
+
+	report, err := DryRunSchemaPlan(collection.ID, *plan)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.TotalItems)
+	assert.Equal(t, 4, report.ChangedItems)
+	assert.Equal(t, 1, report.BrokenItems, "the rating that cannot be converted is dropped")
+
+	var unchanged models.Item
+	assert.NoError(t, db.Where("collection_id = ?", collection.ID).Order("id").First(&unchanged).Error)
+	assert.Equal(t, "First", unchanged.Data["title"], "a dry run does not write items")
+
+	assert.NoError(t, UpdateCollection(collection.ID, &proposed))
+	jobID, err := EnqueueSchemaMigration(t.Context(), collection.ID, *plan)
+	assert.NoError(t, err)
+	assert.Empty(t, jobID, "without a task queue items are migrated right away")
+
+	var items []models.Item
+	assert.NoError(t, db.Where("collection_id = ?", collection.ID).Order("id").Find(&items).Error)
+	assert.Equal(t, models.JSONMap{"headline": "First", "rating": 4}, items[0].Data)
+	assert.Equal(t, models.JSONMap{"headline": "Second"}, items[1].Data)
+	assert.Equal(t, models.JSONMap{"headline": "Third"}, items[2].Data)
+	assert.Len(t, items, 3)
+	assert.NoError(t, db.Unscoped().First(&trashed, trashed.ID).Error)
+	assert.Equal(t, models.JSONMap{"headline": "Trashed", "rating": 5}, trashed.Data)
+
+	result, err := MigrateCollectionItems(collection.ID, *plan)
+	assert.NoError(t, err)
+	assert.Zero(t, result.MigratedItems, "migrated items are left unchanged by a second run")
+}
@@ -0,0 +1,545 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// searchTableName is the table holding the searchable text of items, one row per item.
+const searchTableName = "search_documents"
+
+// Bounds of the number of results a search returns.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// maxSearchTerms bounds the number of words of a search taken into account.
+const maxSearchTerms = 16
+
+// ErrSearchDisabled is returned by SearchItems when no search index was initialized.
+var ErrSearchDisabled = errors.New("search is not enabled")
+
+// SearchDocument is the searchable text of an item.
+type SearchDocument struct {
+	ItemID       uint
+	CollectionID uint
+	Content      string
+}
+
+// SearchQuery describes a full-text search.
+type SearchQuery struct {
+	// Text is the user's search, a list of words all matched results contain. The last
+	// word also matches the words it is a prefix of.
+	Text string
+	// Collections restricts the search to the named collections. Empty searches them all.
+	Collections []string
+	// PublishedOnly hides draft items.
+	PublishedOnly bool
+	// Limit is the maximum number of results, DefaultSearchLimit when zero.
+	Limit int
+}
+
+// SearchResult is an item matching a search. Highlight is an excerpt of its searchable
+// text with the matched words wrapped in <mark> tags, the only markup it holds.
+type SearchResult struct {
+	Collection string  `json:"collection"`
+	ItemID     uint    `json:"id"`
+	Score      float64 `json:"score"`
+	Highlight  string  `json:"highlight"`
+}
+
+// SearchIndex is a full-text index of the searchable attributes of items, implemented
+// with the search features of each database.
+type SearchIndex interface {
+	// Migrate creates the index storage if needed and reports whether it was created.
+	Migrate(db *gorm.DB) (bool, error)
+	// Index stores the text of an item, replacing the text stored before.
+	Index(tx *gorm.DB, doc SearchDocument) error
+	// Remove drops the text of items.
+	Remove(tx *gorm.DB, itemIDs []uint) error
+	// RemoveCollection drops the text of every item of a collection.
+	RemoveCollection(tx *gorm.DB, collectionID uint) error
+	// Search returns the items matching every term, best matches first.
+	Search(db *gorm.DB, query SearchQuery, terms []string) ([]SearchResult, error)
+}
+
+var searchIndex SearchIndex
+
+// NewSearchIndex returns the search index for the dialect of db: FTS5 on SQLite,
+// tsvector on Postgres and FULLTEXT on MySQL. SQLite builds without FTS5 (the
+// sqlite_fts5 build tag of go-sqlite3) fall back to a plain table matched with LIKE.
+func NewSearchIndex(db *gorm.DB) (SearchIndex, error) {
+	switch db.Dialector.Name() {
+	case "sqlite":
+		if err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS temp.fts5_probe USING fts5(content)").Error; err != nil {
+			logger.Log.WithError(err).Warn("SQLite is built without FTS5, search falls back to LIKE matching")
+			return &likeSearchIndex{searchTable: searchTable{key: "item_id"}}, nil
+		}
+		if err := db.Exec("DROP TABLE temp.fts5_probe").Error; err != nil {
+			return nil, err
+		}
+		return &fts5SearchIndex{searchTable: searchTable{key: "rowid"}}, nil
+	case "postgres":
+		return &postgresSearchIndex{searchTable: searchTable{key: "item_id"}}, nil
+	case "mysql":
+		return &mysqlSearchIndex{searchTable: searchTable{key: "item_id"}}, nil
+	default:
+		return nil, fmt.Errorf("search is not supported on %s", db.Dialector.Name())
+	}
+}
+
+// InitSearchIndex creates the search index of db and keeps it up to date: callbacks
+// reindex the items written through db, including the items created through relations,
+// so no write path can forget to. Items stored before the index existed are indexed.
+func InitSearchIndex(db *gorm.DB) error {
+	index, err := NewSearchIndex(db)
+	if err != nil {
+		return err
+	}
+	created, err := index.Migrate(db)
+	if err != nil {
+		return fmt.Errorf("failed to create the search index: %w", err)
+	}
+	searchIndex = index
+
+	callbacks := db.Callback()
+	if callbacks.Create().Get("search:index") == nil {
+		if err := callbacks.Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+			Register("search:index", reindexCallback); err != nil {
+			return err
+		}
+		if err := callbacks.Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+			Register("search:index", reindexCallback); err != nil {
+			return err
+		}
+		if err := callbacks.Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").
+			Register("search:remove", removeCallback); err != nil {
+			return err
+		}
+	}
+
+	if created {
+		var collections []models.Collection
+		if err := db.Find(&collections).Error; err != nil {
+			return err
+		}
+		for _, collection := range collections {
+			if err := ReindexCollection(db, collection.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SearchItems returns the items whose searchable attributes match the query.
+func SearchItems(query SearchQuery) ([]SearchResult, error) {
+	if searchIndex == nil {
+		return nil, ErrSearchDisabled
+	}
+	terms := searchTerms(query.Text)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultSearchLimit
+	}
+	query.Limit = min(query.Limit, MaxSearchLimit)
+
+	results, err := searchIndex.Search(database.DB, query, terms)
+	if err != nil {
+		logger.Log.WithError(err).WithField("query", query.Text).Error("Failed to search items")
+		return nil, fmt.Errorf("failed to search items: %w", err)
+	}
+	return results, nil
+}
+
+// ReindexCollection rebuilds the index entries of the items of a collection, after its
+// searchable attributes changed.
+func ReindexCollection(db *gorm.DB, collectionID uint) error {
+	if searchIndex == nil {
+		return nil
+	}
+	var items []models.Item
+	return db.Select("id").Where("collection_id = ?", collectionID).FindInBatches(&items, 500, func(tx *gorm.DB, _ int) error {
+		ids := make([]uint, len(items))
+		for i, item := range items {
+			ids[i] = item.ID
+		}
+		return reindexItems(tx, ids)
+	}).Error
+}
+
+// reindexItems stores the current searchable text of items. Deleted items and items
+// without searchable text are removed from the index.
+func reindexItems(tx *gorm.DB, ids []uint) error {
+	var items []models.Item
+	if err := tx.Unscoped().Where("id IN ?", ids).Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to load items to index: %w", err)
+	}
+
+	collections := make(map[uint]*models.Collection)
+	var removed []uint
+	for _, item := range items {
+		collection, loaded := collections[item.CollectionID]
+		if !loaded {
+			collection = &models.Collection{}
+			if err := tx.Preload("Attributes").First(collection, item.CollectionID).Error; err != nil {
+				return fmt.Errorf("failed to load collection of item %d: %w", item.ID, err)
+			}
+			collections[item.CollectionID] = collection
+		}
+
+		content := ""
+		if !item.DeletedAt.Valid {
+			content = collection.SearchText(item.Data)
+		}
+		if content == "" {
+			removed = append(removed, item.ID)
+			continue
+		}
+		if err := searchIndex.Index(tx, SearchDocument{ItemID: item.ID, CollectionID: item.CollectionID, Content: content}); err != nil {
+			return fmt.Errorf("failed to index item %d: %w", item.ID, err)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	return searchIndex.Remove(tx, removed)
+}
+
+// reindexCallback reindexes the items created or updated by a statement.
+func reindexCallback(db *gorm.DB) {
+	if ids := statementItemIDs(db); len(ids) > 0 {
+		if err := reindexItems(db.Session(&gorm.Session{NewDB: true}), ids); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+}
+
+// removeCallback drops the index entries of the items deleted by a statement. Items
+// deleted by conditions rather than by value are removed by their caller.
+func removeCallback(db *gorm.DB) {
+	if ids := statementItemIDs(db); len(ids) > 0 {
+		if err := searchIndex.Remove(db.Session(&gorm.Session{NewDB: true}), ids); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+}
+
+// statementItemIDs returns the IDs of the items a successful statement was given by
+// value, none for other models.
+func statementItemIDs(db *gorm.DB) []uint {
+	stmt := db.Statement
+	if db.Error != nil || searchIndex == nil || stmt.Schema == nil || stmt.Schema.Table != "items" || stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil
+	}
+
+	var ids []uint
+	collect := func(value reflect.Value) {
+		value = reflect.Indirect(value)
+		if value.Kind() != reflect.Struct {
+			return
+		}
+		if id, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, value); !zero {
+			if id, ok := id.(uint); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			collect(stmt.ReflectValue.Index(i))
+		}
+	default:
+		collect(stmt.ReflectValue)
+	}
+	return ids
+}
+
+// searchTerms splits the text of a search into lowercase words, dropping punctuation so
+// the words are safe to use in the query syntax of every database.
+func searchTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+	return words
+}
+
+// searchTable implements the writes of the indexes storing one row per item in
+// searchTableName, identified by the key column.
+type searchTable struct {
+	key string
+}
+
+func (t searchTable) Index(tx *gorm.DB, doc SearchDocument) error {
+	if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", searchTableName, t.key), doc.ItemID).Error; err != nil {
+		return err
+	}
+	return tx.Exec(fmt.Sprintf("INSERT INTO %s (%s, collection_id, content) VALUES (?, ?, ?)", searchTableName, t.key),
+		doc.ItemID, doc.CollectionID, doc.Content).Error
+}
+
+func (t searchTable) Remove(tx *gorm.DB, itemIDs []uint) error {
+	return tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s IN ?", searchTableName, t.key), itemIDs).Error
+}
+
+func (t searchTable) RemoveCollection(tx *gorm.DB, collectionID uint) error {
+	return tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE collection_id = ?", searchTableName), collectionID).Error
+}
+
+// matches returns the query joining the index rows to their live items and collections,
+// restricted to the collections and publication state of the search.
+func (t searchTable) matches(db *gorm.DB, query SearchQuery) *gorm.DB {
+	tx := db.Table(searchTableName).
+		Joins(fmt.Sprintf("JOIN items ON items.id = %s.%s AND items.deleted_at IS NULL", searchTableName, t.key)).
+		Joins("JOIN collections ON collections.id = items.collection_id AND collections.deleted_at IS NULL")
+	if len(query.Collections) > 0 {
+		tx = tx.Where("collections.name IN ?", query.Collections)
+	}
+	if query.PublishedOnly {
+		tx = tx.Where("items.status = ?", models.ItemStatusPublished)
+	}
+	return tx.Limit(query.Limit)
+}
+
+// searchRow is a match whose highlight is computed from its content.
+type searchRow struct {
+	SearchResult
+	Content string
+}
+
+func highlightRows(rows []searchRow, terms []string) []SearchResult {
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = row.SearchResult
+		results[i].Highlight = highlightTerms(row.Content, terms)
+	}
+	return results
+}
+
+// fts5SearchIndex is the SQLite index: an FTS5 table whose rowid is the item ID, ranked
+// with bm25.
+type fts5SearchIndex struct {
+	searchTable
+}
+
+func (i *fts5SearchIndex) Migrate(db *gorm.DB) (bool, error) {
+	if db.Migrator().HasTable(searchTableName) {
+		return false, nil
+	}
+	err := db.Exec(fmt.Sprintf("CREATE VIRTUAL TABLE %s USING fts5(content, collection_id UNINDEXED, tokenize = 'unicode61 remove_diacritics 2')", searchTableName)).Error
+	return err == nil, err
+}
+
+func (i *fts5SearchIndex) Search(db *gorm.DB, query SearchQuery, terms []string) ([]SearchResult, error) {
+	quoted := make([]string, len(terms))
+	for n, term := range terms {
+		quoted[n] = `"` + term + `"`
+	}
+	quoted[len(quoted)-1] += "*"
+
+	results := []SearchResult{}
+	err := i.matches(db, query).
+		Select(fmt.Sprintf("collections.name AS collection, items.id AS item_id, -bm25(%[1]s) AS score, "+
+			"snippet(%[1]s, 0, '<mark>', '</mark>', '…', 24) AS highlight", searchTableName)).
+		Where(searchTableName+" MATCH ?", strings.Join(quoted, " ")).
+		Order("score DESC").
+		Scan(&results).Error
+	return results, err
+}
+
+// likeSearchIndex is the SQLite index without FTS5: a plain table matched with LIKE and
+// ranked by the number of occurrences of the terms.
+type likeSearchIndex struct {
+	searchTable
+}
+
+func (i *likeSearchIndex) Migrate(db *gorm.DB) (bool, error) {
+	if db.Migrator().HasTable(searchTableName) {
+		return false, nil
+	}
+	err := db.Exec(fmt.Sprintf("CREATE TABLE %s (item_id INTEGER PRIMARY KEY, collection_id INTEGER NOT NULL, content TEXT NOT NULL)", searchTableName)).Error
+	if err == nil {
+		err = db.Exec(fmt.Sprintf("CREATE INDEX idx_%[1]s_collection_id ON %[1]s (collection_id)", searchTableName)).Error
+	}
+	return err == nil, err
+}
+
+func (i *likeSearchIndex) Search(db *gorm.DB, query SearchQuery, terms []string) ([]SearchResult, error) {
+	tx := i.matches(db, query).Limit(-1).
+		Select(fmt.Sprintf("collections.name AS collection, items.id AS item_id, %s.content AS content", searchTableName))
+	for _, term := range terms {
+		tx = tx.Where(searchTableName+".content LIKE ?", "%"+term+"%")
+	}
+	var rows []searchRow
+	if err := tx.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for n := range rows {
+		content := strings.ToLower(rows[n].Content)
+		for _, term := range terms {
+			rows[n].Score += float64(strings.Count(content, term))
+		}
+	}
+	sortSearchRows(rows)
+	if len(rows) > query.Limit {
+		rows = rows[:query.Limit]
+	}
+	return highlightRows(rows, terms), nil
+}
+
+// postgresSearchIndex is the Postgres index: a generated tsvector column with a GIN
+// index, ranked with ts_rank and highlighted with ts_headline.
+type postgresSearchIndex struct {
+	searchTable
+}
+
+func (i *postgresSearchIndex) Migrate(db *gorm.DB) (bool, error) {
+	if db.Migrator().HasTable(searchTableName) {
+		return false, nil
+	}
+	err := db.Exec(fmt.Sprintf(`CREATE TABLE %[1]s (
+		item_id BIGINT PRIMARY KEY,
+		collection_id BIGINT NOT NULL,
+		content TEXT NOT NULL,
+		document TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED
+	)`, searchTableName)).Error
+	if err == nil {
+		err = db.Exec(fmt.Sprintf("CREATE INDEX idx_%[1]s_document ON %[1]s USING GIN (document)", searchTableName)).Error
+	}
+	if err == nil {
+		err = db.Exec(fmt.Sprintf("CREATE INDEX idx_%[1]s_collection_id ON %[1]s (collection_id)", searchTableName)).Error
+	}
+	return err == nil, err
+}
+
+func (i *postgresSearchIndex) Search(db *gorm.DB, query SearchQuery, terms []string) ([]SearchResult, error) {
+	tsQuery := strings.Join(terms, " & ") + ":*"
+
+	results := []SearchResult{}
+	err := i.matches(db, query).
+		Select(fmt.Sprintf("collections.name AS collection, items.id AS item_id, "+
+			"ts_rank(%[1]s.document, to_tsquery('simple', ?)) AS score, "+
+			"ts_headline('simple', %[1]s.content, to_tsquery('simple', ?), 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15') AS highlight",
+			searchTableName), tsQuery, tsQuery).
+		Where(fmt.Sprintf("%s.document @@ to_tsquery('simple', ?)", searchTableName), tsQuery).
+		Order("score DESC").
+		Scan(&results).Error
+	return results, err
+}
+
+// mysqlSearchIndex is the MySQL index: a FULLTEXT index queried in boolean mode and
+// ranked by its relevance.
+type mysqlSearchIndex struct {
+	searchTable
+}
+
+func (i *mysqlSearchIndex) Migrate(db *gorm.DB) (bool, error) {
+	if db.Migrator().HasTable(searchTableName) {
+		return false, nil
+	}
+	err := db.Exec(fmt.Sprintf(`CREATE TABLE %s (
+		item_id BIGINT UNSIGNED PRIMARY KEY,
+		collection_id BIGINT UNSIGNED NOT NULL,
+		content LONGTEXT NOT NULL,
+		INDEX idx_search_collection_id (collection_id),
+		FULLTEXT INDEX idx_search_content (content)
+	) ENGINE=InnoDB`, searchTableName)).Error
+	return err == nil, err
+}
+
+func (i *mysqlSearchIndex) Search(db *gorm.DB, query SearchQuery, terms []string) ([]SearchResult, error) {
+	required := make([]string, len(terms))
+	for n, term := range terms {
+		required[n] = "+" + term
+	}
+	required[len(required)-1] += "*"
+	match := fmt.Sprintf("MATCH(%s.content) AGAINST (? IN BOOLEAN MODE)", searchTableName)
+	against := strings.Join(required, " ")
+
+	var rows []searchRow
+	err := i.matches(db, query).
+		Select(fmt.Sprintf("collections.name AS collection, items.id AS item_id, %s AS score, %s.content AS content", match, searchTableName), against).
+		Where(match, against).
+		Order("score DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return highlightRows(rows, terms), nil
+}
+
+func sortSearchRows(rows []searchRow) {
+	sort.SliceStable(rows, func(a, b int) bool { return rows[a].Score > rows[b].Score })
+}
+
+// highlightRadius is the number of characters kept around the first match by highlightTerms.
+const highlightRadius = 80
+
+// highlightTerms returns the excerpt of content around the first occurrence of a term,
+// with every occurrence of the terms wrapped in <mark> tags. Terms are lowercase.
+func highlightTerms(content string, terms []string) string {
+	text := []rune(content)
+	lower := []rune(strings.ToLower(content))
+	if len(lower) != len(text) {
+		// Lowercasing changed the length of the text, positions cannot be shared.
+		lower = text
+	}
+	matchAt := func(pos int) int {
+		for _, term := range terms {
+			t := []rune(term)
+			if pos+len(t) <= len(lower) && string(lower[pos:pos+len(t)]) == term {
+				return len(t)
+			}
+		}
+		return 0
+	}
+
+	first := -1
+	for pos := range lower {
+		if matchAt(pos) > 0 {
+			first = pos
+			break
+		}
+	}
+	start, end := 0, len(text)
+	if first >= 0 {
+		start = max(first-highlightRadius, 0)
+	}
+	end = min(start+2*highlightRadius, end)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for pos := start; pos < end; {
+		if n := matchAt(pos); n > 0 {
+			b.WriteString("<mark>" + string(text[pos:pos+n]) + "</mark>")
+			pos += n
+			continue
+		}
+		b.WriteRune(text[pos])
+		pos++
+	}
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
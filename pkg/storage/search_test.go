@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchItems(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRelation{}, &models.ItemRevision{}))
+
+	actor := models.SystemActor("test")
+	articles := models.Collection{Name: "articles", Locales: []string{"en", "fr"}, DefaultLocale: "en", Attributes: []models.Attribute{
+		{Name: "title", Type: "text", Searchable: true, Localized: true},
+		{Name: "body", Type: "richtext", Searchable: true},
+		{Name: "code", Type: "text"},
+	}}
+	require.NoError(t, db.Create(&articles).Error)
+	pages := models.Collection{Name: "pages", Attributes: []models.Attribute{
+		{Name: "title", Type: "text", Searchable: true},
+	}}
+	require.NoError(t, db.Create(&pages).Error)
+
+	// Items stored before the index exists are indexed when it is created.
+	existing, err := SaveItem(pages, models.JSONMap{"title": "Gardening basics"}, actor)
+	require.NoError(t, err)
+	_, err = PublishItem(pages, existing.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, InitSearchIndex(db))
+	defer func() { searchIndex = nil }()
+
+	first, err := SaveItem(articles, models.JSONMap{
+		"title": map[string]any{"en": "Growing tomatoes", "fr": "Cultiver des tomates"},
+		"body":  "<p>Tomatoes need <b>sun</b> and water.</p>",
+		"code":  "gardening",
+	}, actor)
+	require.NoError(t, err)
+	_, err = PublishItem(articles, first.ID)
+	require.NoError(t, err)
+	draft, err := SaveItem(articles, models.JSONMap{"title": "Tomato sauce", "body": "Cook the tomatoes slowly."}, actor)
+	require.NoError(t, err)
+
+	results, err := SearchItems(SearchQuery{Text: "tomatoes", PublishedOnly: true})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "articles", results[0].Collection)
+	assert.Equal(t, first.ID, results[0].ItemID)
+	assert.Contains(t, results[0].Highlight, "<mark>")
+	assert.NotContains(t, results[0].Highlight, "<p>")
+
+	// Drafts are found unless hidden, translations are searched and the last word is a prefix.
+	results, err = SearchItems(SearchQuery{Text: "tomat"})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	results, err = SearchItems(SearchQuery{Text: "cultiver"})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// Attributes that are not searchable are not indexed, and collections can be restricted.
+	results, err = SearchItems(SearchQuery{Text: "gardening"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "pages", results[0].Collection)
+	results, err = SearchItems(SearchQuery{Text: "gardening", Collections: []string{"articles"}})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	// Updates and deletions are reflected in the index.
+	require.NoError(t, UpdateItem(draft.ID, models.JSONMap{"title": "Pepper sauce", "body": "Cook the peppers slowly."}, actor))
+	results, err = SearchItems(SearchQuery{Text: "tomato"})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+	results, err = SearchItems(SearchQuery{Text: "pepper sauce"})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	require.NoError(t, DeleteItem(draft.ID, actor))
+	results, err = SearchItems(SearchQuery{Text: "pepper"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	var entries int64
+	require.NoError(t, db.Table(searchTableName).Count(&entries).Error)
+	assert.Equal(t, int64(2), entries)
+
+	// Making an attribute searchable reindexes the items of its collection.
+	updated := articles
+	updated.Attributes = []models.Attribute{
+		{Name: "title", Type: "text", Searchable: true, Localized: true},
+		{Name: "body", Type: "richtext", Searchable: true},
+		{Name: "code", Type: "text", Searchable: true},
+	}
+	require.NoError(t, UpdateCollection(articles.ID, &updated))
+	results, err = SearchItems(SearchQuery{Text: "gardening"})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	require.NoError(t, DeleteCollection(pages.ID))
+	require.NoError(t, db.Table(searchTableName).Count(&entries).Error)
+	assert.Equal(t, int64(1), entries)
+
+	results, err = SearchItems(SearchQuery{Text: " ?! "})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestHighlightTerms(t *testing.T) {
+	assert.Equal(t, "Growing <mark>Tomato</mark>es", highlightTerms("Growing Tomatoes", []string{"tomato"}))
+	assert.Equal(t, "no match", highlightTerms("no match", []string{"other"}))
+
+	long := strings.Repeat("filler ", 40) + "needle " + strings.Repeat("filler ", 40)
+	highlighted := highlightTerms(long, []string{"needle"})
+	assert.Contains(t, highlighted, "<mark>needle</mark>")
+	assert.True(t, len([]rune(highlighted)) < len([]rune(long)))
+}
@@ -1,14 +1,19 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"time"
 
+	"github.com/gohead-cms/gohead/internal/agent/events"
 	"github.com/gohead-cms/gohead/internal/models"
 	"github.com/gohead-cms/gohead/pkg/database"
 	"github.com/gohead-cms/gohead/pkg/logger"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateSingleItem creates a new SingleItem for a given Singleton.
@@ -40,6 +45,7 @@ func CreateSingleItem(st *models.Singleton, itemData map[string]any) (*models.Si
 	// If validation passes, create the item
 	item := &models.SingleItem{
 		SingleTypeID: st.ID,
+		Status:       models.ItemStatusDraft,
 		Data:         itemData,
 	}
 	if createErr := database.DB.Create(item).Error; createErr != nil {
@@ -76,40 +82,68 @@ func GetSingleItemByType(SingletonName string) (*models.SingleItem, error) {
 	return &item, nil
 }
 
+// UpdateOptions tune the update of a single item.
+type UpdateOptions struct {
+	// ExpectedVersion, when set, makes the update fail with a *models.VersionConflictError
+	// unless the single item is still at this version.
+	ExpectedVersion *int
+	// Partial keeps the stored values of the attributes left out of the new data.
+	Partial bool
+}
+
 // UpdateSingleItem updates the SingleItem for a given single type with new data.
 // Returns the updated SingleItem or an error if not found or validation fails.
-func UpdateSingleItem(SingletonName string, newData map[string]any) (*models.SingleItem, error) {
-	// Retrieve the single type
+func UpdateSingleItem(SingletonName string, newData map[string]any, opts UpdateOptions) (*models.SingleItem, error) {
+	// Retrieve the single type with the attributes the new data is validated against
 	var st models.Singleton
-	if err := database.DB.Where("name = ?", SingletonName).First(&st).Error; err != nil {
+	if err := database.DB.Preload("Attributes").Where("name = ?", SingletonName).First(&st).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("single type '%s' not found", SingletonName)
 		}
 		return nil, fmt.Errorf("failed to retrieve single type: %w", err)
 	}
 
-	// Retrieve the existing single item
 	var item models.SingleItem
-	if err := database.DB.Where("single_type_id = ?", st.ID).First(&item).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("no existing single item for single type '%s'", SingletonName)
+	txErr := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Retrieve the existing single item, locked until the update is saved
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("single_type_id = ?", st.ID).First(&item).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("no existing single item for single type '%s'", SingletonName)
+			}
+			return fmt.Errorf("failed to retrieve single item: %w", err)
+		}
+		if err := models.CheckVersion(opts.ExpectedVersion, item.Version); err != nil {
+			return err
 		}
-		return nil, fmt.Errorf("failed to retrieve single item: %w", err)
-	}
 
-	// Validate new data
-	if valErr := models.ValidateSingleItemValues(st, newData); valErr != nil {
-		logger.Log.WithError(valErr).WithField("Singleton", SingletonName).
-			Warn("Validation failed for single item update")
-		return nil, valErr
-	}
+		data := newData
+		if opts.Partial {
+			data = maps.Clone(item.Data)
+			if data == nil {
+				data = map[string]any{}
+			}
+			maps.Copy(data, newData)
+		}
+
+		// Validate new data
+		if valErr := models.ValidateSingleItemValues(st, data); valErr != nil {
+			logger.Log.WithError(valErr).WithField("Singleton", SingletonName).
+				Warn("Validation failed for single item update")
+			return valErr
+		}
 
-	// Update
-	item.Data = newData
-	if saveErr := database.DB.Save(&item).Error; saveErr != nil {
-		logger.Log.WithError(saveErr).WithField("Singleton", SingletonName).
-			Error("Failed to save updated single item in DB")
-		return nil, fmt.Errorf("failed to update single item: %w", saveErr)
+		// Update
+		item.Data = data
+		if saveErr := tx.Save(&item).Error; saveErr != nil {
+			logger.Log.WithError(saveErr).WithField("Singleton", SingletonName).
+				Error("Failed to save updated single item in DB")
+			return fmt.Errorf("failed to update single item: %w", saveErr)
+		}
+		return nil
+	})
+	if txErr != nil {
+		return nil, txErr
 	}
 
 	logger.Log.WithField("Singleton", SingletonName).Info("Single item updated successfully")
@@ -146,3 +180,52 @@ func DeleteSingleItem(SingletonName string) error {
 	logger.Log.WithField("Singleton", SingletonName).Info("Single item deleted successfully")
 	return nil
 }
+
+// PublishSingleItem marks the single item of a single type as published and publishes an 'item:published' event.
+func PublishSingleItem(SingletonName string) (*models.SingleItem, error) {
+	return setSingleItemStatus(SingletonName, models.ItemStatusPublished, events.EventTypeItemPublished)
+}
+
+// UnpublishSingleItem moves the single item of a single type back to draft and publishes an 'item:unpublished' event.
+func UnpublishSingleItem(SingletonName string) (*models.SingleItem, error) {
+	return setSingleItemStatus(SingletonName, models.ItemStatusDraft, events.EventTypeItemUnpublished)
+}
+
+func setSingleItemStatus(SingletonName, status string, eventType events.EventType) (*models.SingleItem, error) {
+	item, err := GetSingleItemByType(SingletonName)
+	if err != nil {
+		return nil, err
+	}
+
+	// A manual transition replaces the scheduled one.
+	updates := map[string]any{"status": status, "published_at": nil, "unpublish_at": nil}
+	if status == models.ItemStatusPublished {
+		now := time.Now()
+		updates = map[string]any{"status": status, "published_at": &now, "publish_at": nil}
+	}
+	if err := database.DB.Model(item).Updates(updates).Error; err != nil {
+		logger.Log.WithError(err).WithField("Singleton", SingletonName).
+			Error("Failed to change single item status")
+		return nil, fmt.Errorf("failed to change single item status: %w", err)
+	}
+	if err := database.DB.First(item, item.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to reload single item: %w", err)
+	}
+
+	if asynqClient != nil {
+		payload := events.CollectionEventPayload{
+			EventType:      eventType,
+			CollectionName: SingletonName,
+			ItemID:         item.ID,
+			ItemData:       item.Data,
+		}
+		if err := events.EnqueueCollectionEvent(context.Background(), asynqClient, payload); err != nil {
+			logger.Log.WithError(err).WithField("Singleton", SingletonName).
+				Error("Failed to enqueue single item event")
+		}
+	}
+
+	logger.Log.WithField("Singleton", SingletonName).WithField("status", status).
+		Info("Single item status changed successfully")
+	return item, nil
+}
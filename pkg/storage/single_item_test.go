@@ -76,7 +76,7 @@ func TestSingleItemStorage(t *testing.T) {
 			"title": "Updated Homepage Title",
 		}
 
-		updatedItem, err := storage.UpdateSingleItem("homepage", newData)
+		updatedItem, err := storage.UpdateSingleItem("homepage", newData, storage.UpdateOptions{})
 		assert.NoError(t, err)
 		assert.NotNil(t, updatedItem)
 		assert.Equal(t, "Updated Homepage Title", updatedItem.Data["title"])
@@ -91,7 +91,7 @@ func TestSingleItemStorage(t *testing.T) {
 		newData := map[string]interface{}{
 			"title": "Won't matter",
 		}
-		updatedItem, err := storage.UpdateSingleItem("unknown", newData)
+		updatedItem, err := storage.UpdateSingleItem("unknown", newData, storage.UpdateOptions{})
 		assert.Nil(t, updatedItem)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "single type 'unknown' not found")
@@ -111,7 +111,7 @@ func TestSingleItemStorage(t *testing.T) {
 		newData := map[string]interface{}{
 			"title": "About Us Page",
 		}
-		updatedItem, err := storage.UpdateSingleItem("about-us", newData)
+		updatedItem, err := storage.UpdateSingleItem("about-us", newData, storage.UpdateOptions{})
 		assert.Nil(t, updatedItem)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "no existing single item for single type 'about-us'")
@@ -162,6 +162,75 @@ func TestCheckValidationFails(t *testing.T) {
 	assert.Contains(t, err.Error(), "missing required attribute: 'email'")
 }
 
+func TestPublishSingleItem(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.SingleItem{}))
+
+	st := models.Singleton{
+		Name:       "footer",
+		Attributes: []models.Attribute{{Name: "text", Type: "string"}},
+	}
+	require.NoError(t, db.Create(&st).Error)
+
+	created, err := storage.CreateSingleItem(&st, map[string]interface{}{"text": "(c) GoHead"})
+	require.NoError(t, err)
+	assert.Equal(t, models.ItemStatusDraft, created.Status)
+	assert.Nil(t, created.PublishedAt)
+
+	published, err := storage.PublishSingleItem("footer")
+	assert.NoError(t, err)
+	assert.True(t, published.IsPublished())
+	assert.NotNil(t, published.PublishedAt)
+
+	unpublished, err := storage.UnpublishSingleItem("footer")
+	assert.NoError(t, err)
+	assert.Equal(t, models.ItemStatusDraft, unpublished.Status)
+	assert.Nil(t, unpublished.PublishedAt)
+
+	_, err = storage.PublishSingleItem("nonexistent")
+	assert.Error(t, err)
+}
+
+func TestUpdateSingleItemVersion(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.SingleItem{}))
+
+	st := models.Singleton{
+		Name: "settings",
+		Attributes: []models.Attribute{
+			{Name: "title", Type: "string", Required: true},
+			{Name: "tagline", Type: "string"},
+		},
+	}
+	require.NoError(t, db.Create(&st).Error)
+
+	created, err := storage.CreateSingleItem(&st, map[string]interface{}{"title": "GoHead", "tagline": "Headless CMS"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.Version)
+
+	expected := 1
+	updated, err := storage.UpdateSingleItem("settings", map[string]interface{}{"title": "GoHead CMS"}, storage.UpdateOptions{
+		ExpectedVersion: &expected,
+		Partial:         true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, "GoHead CMS", updated.Data["title"])
+	assert.Equal(t, "Headless CMS", updated.Data["tagline"], "a partial update keeps the other attributes")
+
+	// A write based on the first version conflicts with the one above.
+	_, err = storage.UpdateSingleItem("settings", map[string]interface{}{"title": "Stale"}, storage.UpdateOptions{ExpectedVersion: &expected})
+	var conflict *models.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 1, conflict.Expected)
+	assert.Equal(t, 2, conflict.Current)
+
+	check, err := storage.GetSingleItemByType("settings")
+	require.NoError(t, err)
+	assert.Equal(t, 2, check.Version)
+	assert.Equal(t, "GoHead CMS", check.Data["title"])
+}
+
 // TODO
 // Additional tests can be added for relationships, pattern checks, etc.
 //
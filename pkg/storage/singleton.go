@@ -123,6 +123,16 @@ func GetSingletonByName(name string) (*models.Singleton, error) {
 	return &st, nil
 }
 
+// GetAllSingletons retrieves every single type with its attributes, ordered by name.
+func GetAllSingletons() ([]models.Singleton, error) {
+	var singletons []models.Singleton
+	if err := database.DB.Preload("Attributes").Order("name ASC").Find(&singletons).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to fetch single types")
+		return nil, fmt.Errorf("failed to fetch single types: %w", err)
+	}
+	return singletons, nil
+}
+
 // DeleteSingleton permanently deletes a single type by its ID (including attributes).
 // Note: Strapi typically doesn't expose DELETE for single types, but you can implement it if needed.
 func DeleteSingleton(SingletonID uint) error {
@@ -151,7 +161,7 @@ func DeleteSingleton(SingletonID uint) error {
 	logger.Log.WithField("Singleton", st.Name).Info("DeleteSingleton: successfully retrieved single type")
 
 	// Delete associated attributes
-	if err := tx.Where("single_type_id = ?", SingletonID).Delete(&models.Attribute{}).Error; err != nil {
+	if err := tx.Where("singleton_id = ?", SingletonID).Delete(&models.Attribute{}).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to delete attributes for single type ID '%d': %w", SingletonID, err)
 	}
@@ -181,11 +191,11 @@ func DeleteSingleton(SingletonID uint) error {
 // }
 
 // updateSingletonAttributes handles creating/updating attributes for a single type within a transaction.
-// Similar to `updateAssociatedFields` for collections, but keyed on `single_type_id`.
+// Similar to `updateAssociatedFields` for collections, but keyed on `singleton_id`.
 func updateSingletonAttributes(tx *gorm.DB, existing *models.Singleton, updatedAttributes []models.Attribute) error {
 	// Fetch existing attributes
 	var existingAttrs []models.Attribute
-	if err := tx.Where("single_type_id = ?", existing.ID).Find(&existingAttrs).Error; err != nil {
+	if err := tx.Where("singleton_id = ?", existing.ID).Find(&existingAttrs).Error; err != nil {
 		return fmt.Errorf("failed to fetch existing single type attributes: %w", err)
 	}
 
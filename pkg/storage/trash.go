@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/agent/events"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/validation"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// TaskTypePurgeTrash is the periodic task permanently deleting the items kept in the
+// trash for longer than the retention period. It runs in the ScheduleQueueName queue.
+const TaskTypePurgeTrash = "trash:purge"
+
+// ErrRestoreConflict is returned when restoring an item would break the uniqueness of
+// one of its values, since taken by another item.
+var ErrRestoreConflict = errors.New("restoring the item would duplicate a unique value")
+
+// PurgeTrashPayload is the payload of a TaskTypePurgeTrash task.
+type PurgeTrashPayload struct {
+	RetentionDays int `json:"retention_days"`
+}
+
+// NewPurgeTrashTask returns the task purging the items deleted more than retentionDays ago.
+func NewPurgeTrashTask(retentionDays int) (*asynq.Task, error) {
+	payloadBytes, err := json.Marshal(PurgeTrashPayload{RetentionDays: retentionDays})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal payload: %w", err)
+	}
+	return asynq.NewTask(TaskTypePurgeTrash, payloadBytes, asynq.Queue(ScheduleQueueName), asynq.MaxRetry(3)), nil
+}
+
+// HandlePurgeTrashTask permanently deletes the items whose retention period is over.
+func HandlePurgeTrashTask(ctx context.Context, task *asynq.Task) error {
+	var payload PurgeTrashPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid trash purge payload: %v: %w", err, asynq.SkipRetry)
+	}
+	if payload.RetentionDays <= 0 {
+		return nil
+	}
+
+	purged, err := PurgeExpiredItems(time.Now().AddDate(0, 0, -payload.RetentionDays))
+	if err != nil {
+		return err
+	}
+	logger.Log.WithField("purged_items", purged).WithField("retention_days", payload.RetentionDays).Info("Trash purged")
+	return nil
+}
+
+// GetDeletedItems lists the items of a collection in the trash, most recently deleted first.
+func GetDeletedItems(collectionID uint, page, pageSize int) ([]models.Item, int, error) {
+	var items []models.Item
+	var total int64
+
+	query := database.DB.Unscoped().Model(&models.Item{}).
+		Where("collection_id = ? AND deleted_at IS NOT NULL", collectionID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count deleted items: %w", err)
+	}
+	if err := query.Order("deleted_at DESC").Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch deleted items: %w", err)
+	}
+	return items, int(total), nil
+}
+
+// RestoreItem takes an item out of the trash. Its unique values are checked again, as
+// another item may use them by now, and its references to items deleted since are
+// dropped. References other items held to it were dropped on deletion and are not
+// restored. Its schedule is enqueued again, less the transitions whose time has passed.
+func RestoreItem(collection models.Collection, itemID uint, actor models.Actor) (*models.Item, error) {
+	var item models.Item
+	txErr := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().
+			Where("id = ? AND collection_id = ? AND deleted_at IS NOT NULL", itemID, collection.ID).
+			First(&item).Error
+		if err != nil {
+			return fmt.Errorf("deleted item with ID %d not found: %w", itemID, err)
+		}
+
+		// The item is still deleted, so it does not conflict with itself.
+		for _, attr := range collection.Attributes {
+			if value, exists := item.Data[attr.Name]; attr.Unique && exists && value != nil {
+				if err := validation.CheckFieldUniquenessTx(tx, collection.ID, attr.Name, value); err != nil {
+					return fmt.Errorf("%w: %v", ErrRestoreConflict, err)
+				}
+			}
+		}
+
+		if err := tx.Unscoped().Model(&item).Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore item: %w", err)
+		}
+		item.DeletedAt = gorm.DeletedAt{}
+		if err := models.SyncItemRelations(tx, collection, &item); err != nil {
+			return err
+		}
+		if err := restoreSchedule(tx, &item); err != nil {
+			return err
+		}
+		return RecordItemRevision(tx, &item, models.RevisionActionRestore, actor)
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	publishEvent(database.DB, events.EventTypeItemRestored, &item)
+	logger.Log.WithField("item_id", itemID).Info("Item restored successfully")
+	return &item, nil
+}
+
+// restoreSchedule enqueues the upcoming transitions of an item taken out of the trash
+// again, as those falling due while it was deleted were skipped. The transitions whose
+// time has passed are cleared.
+func restoreSchedule(tx *gorm.DB, item *models.Item) error {
+	now := time.Now()
+	schedule := models.ItemSchedule{PublishAt: item.PublishAt, UnpublishAt: item.UnpublishAt}
+	updates := map[string]any{}
+	if schedule.PublishAt != nil && !schedule.PublishAt.After(now) {
+		schedule.PublishAt = nil
+		updates["publish_at"] = nil
+	}
+	if schedule.UnpublishAt != nil && !schedule.UnpublishAt.After(now) {
+		schedule.UnpublishAt = nil
+		updates["unpublish_at"] = nil
+	}
+	if len(updates) > 0 {
+		if err := tx.Model(item).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to clear past schedule of item %d: %w", item.ID, err)
+		}
+		item.PublishAt, item.UnpublishAt = schedule.PublishAt, schedule.UnpublishAt
+	}
+	return enqueueTransitions(TransitionTargetItem, item.ID, schedule)
+}
+
+// PurgeItems permanently deletes items of a collection in the trash, with their
+// revisions. Without IDs the whole trash of the collection is emptied. It returns the
+// number of items purged.
+func PurgeItems(collectionID uint, ids []uint) (int, error) {
+	query := database.DB.Unscoped().Model(&models.Item{}).
+		Where("collection_id = ? AND deleted_at IS NOT NULL", collectionID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	var purged []uint
+	if err := query.Pluck("id", &purged).Error; err != nil {
+		return 0, fmt.Errorf("failed to list deleted items: %w", err)
+	}
+	return len(purged), purgeItems(purged)
+}
+
+// PurgeExpiredItems permanently deletes the items deleted before cutoff, in every
+// collection. It returns the number of items purged.
+func PurgeExpiredItems(cutoff time.Time) (int, error) {
+	var purged []uint
+	err := database.DB.Unscoped().Model(&models.Item{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &purged).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired items: %w", err)
+	}
+	for start := 0; start < len(purged); start += schemaMigrationBatchSize {
+		if err := purgeItems(purged[start:min(start+schemaMigrationBatchSize, len(purged))]); err != nil {
+			return start, err
+		}
+	}
+	return len(purged), nil
+}
+
+// purgeItems permanently deletes deleted items and what is kept about them.
+func purgeItems(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("item_id IN ?", ids).Delete(&models.ItemRevision{}).Error; err != nil {
+			return fmt.Errorf("failed to purge item revisions: %w", err)
+		}
+		if vectorStore != nil {
+			if err := tx.Where("item_id IN ?", ids).Delete(&models.ItemEmbedding{}).Error; err != nil {
+				return fmt.Errorf("failed to purge item embeddings: %w", err)
+			}
+		}
+		if err := tx.Unscoped().Where("id IN ? AND deleted_at IS NOT NULL", ids).Delete(&models.Item{}).Error; err != nil {
+			return fmt.Errorf("failed to purge items: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to purge items")
+		return err
+	}
+	logger.Log.WithField("item_ids", ids).Info("Items purged successfully")
+	return nil
+}
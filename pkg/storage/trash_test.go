@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTrash(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Collection{}, &models.Attribute{}, &models.Item{}, &models.ItemRelation{}, &models.ItemRevision{}, &models.Singleton{}, &models.SingleItem{}))
+
+	actor := models.SystemActor("test")
+	articles := models.Collection{Name: "articles", Attributes: []models.Attribute{
+		{Name: "title", Type: "text"},
+		{Name: "slug", Type: "text", Unique: true},
+	}}
+	require.NoError(t, db.Create(&articles).Error)
+
+	first, err := SaveItem(articles, models.JSONMap{"title": "First", "slug": "first"}, actor)
+	require.NoError(t, err)
+	second, err := SaveItem(articles, models.JSONMap{"title": "Second", "slug": "second"}, actor)
+	require.NoError(t, err)
+	require.NoError(t, DeleteItem(first.ID, actor))
+	require.NoError(t, DeleteItem(second.ID, actor))
+
+	t.Run("List deleted items", func(t *testing.T) {
+		items, total, err := GetDeletedItems(articles.ID, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, items, 2)
+		assert.Equal(t, second.ID, items[0].ID)
+		assert.True(t, items[0].DeletedAt.Valid)
+	})
+
+	t.Run("Restore item", func(t *testing.T) {
+		restored, err := RestoreItem(articles, second.ID, actor)
+		require.NoError(t, err)
+		assert.Equal(t, "second", restored.Data["slug"])
+
+		_, err = GetItemByID(articles.ID, second.ID)
+		require.NoError(t, err)
+		revisions, err := GetItemRevisions(articles.ID, second.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.RevisionActionRestore, revisions[0].Action)
+
+		_, err = RestoreItem(articles, second.ID, actor)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("Restore scheduled item", func(t *testing.T) {
+		scheduled, err := SaveItem(articles, models.JSONMap{"title": "Scheduled", "slug": "scheduled"}, actor)
+		require.NoError(t, err)
+		publishAt := time.Now().Add(time.Hour).Round(time.Second)
+		unpublishAt := publishAt.Add(24 * time.Hour)
+		_, err = ScheduleItem(articles, scheduled.ID, models.ItemSchedule{PublishAt: &publishAt, UnpublishAt: &unpublishAt})
+		require.NoError(t, err)
+		require.NoError(t, DeleteItem(scheduled.ID, actor))
+
+		// The publication fell due while the item was in the trash.
+		passed := time.Now().Add(-time.Minute)
+		require.NoError(t, db.Unscoped().Model(&models.Item{}).Where("id = ?", scheduled.ID).Update("publish_at", passed).Error)
+
+		restored, err := RestoreItem(articles, scheduled.ID, actor)
+		require.NoError(t, err)
+		assert.Nil(t, restored.PublishAt)
+		require.NotNil(t, restored.UnpublishAt)
+
+		item, err := GetItemByID(articles.ID, scheduled.ID)
+		require.NoError(t, err)
+		assert.Nil(t, item.PublishAt)
+		transitions, err := GetScheduledTransitions(time.Time{})
+		require.NoError(t, err)
+		require.Len(t, transitions, 1)
+		assert.Equal(t, scheduled.ID, transitions[0].ItemID)
+		assert.Equal(t, models.TransitionUnpublish, transitions[0].Transition)
+		assert.Equal(t, unpublishAt.Unix(), transitions[0].At.Unix())
+
+		// The transition enqueued again applies to the restored item.
+		require.NoError(t, HandleScheduledTransitionTask(context.Background(), transitionTask(t, TransitionTargetItem, scheduled.ID, models.TransitionUnpublish, unpublishAt)))
+		item, err = GetItemByID(articles.ID, scheduled.ID)
+		require.NoError(t, err)
+		assert.Nil(t, item.UnpublishAt)
+	})
+
+	t.Run("Restore conflicts with a reused unique value", func(t *testing.T) {
+		// The slug of a deleted item can be reused...
+		_, err := SaveItem(articles, models.JSONMap{"title": "New first", "slug": "first"}, actor)
+		require.NoError(t, err)
+
+		// ...and the deleted item can no longer be restored.
+		_, err = RestoreItem(articles, first.ID, actor)
+		assert.ErrorIs(t, err, ErrRestoreConflict)
+		_, err = GetItemByID(articles.ID, first.ID)
+		assert.Error(t, err, "the item stays in the trash")
+	})
+
+	t.Run("Purge items", func(t *testing.T) {
+		purged, err := PurgeItems(articles.ID, []uint{second.ID})
+		require.NoError(t, err)
+		assert.Equal(t, 0, purged, "restored items are not in the trash")
+
+		purged, err = PurgeItems(articles.ID, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		var count int64
+		require.NoError(t, db.Unscoped().Model(&models.Item{}).Where("id = ?", first.ID).Count(&count).Error)
+		assert.Zero(t, count)
+		require.NoError(t, db.Model(&models.ItemRevision{}).Where("item_id = ?", first.ID).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Purge expired items", func(t *testing.T) {
+		old, err := SaveItem(articles, models.JSONMap{"title": "Old", "slug": "old"}, actor)
+		require.NoError(t, err)
+		recent, err := SaveItem(articles, models.JSONMap{"title": "Recent", "slug": "recent"}, actor)
+		require.NoError(t, err)
+		require.NoError(t, DeleteItem(old.ID, actor))
+		require.NoError(t, DeleteItem(recent.ID, actor))
+		require.NoError(t, db.Unscoped().Model(&models.Item{}).Where("id = ?", old.ID).
+			Update("deleted_at", time.Now().AddDate(0, 0, -40)).Error)
+
+		payload, err := json.Marshal(PurgeTrashPayload{RetentionDays: 30})
+		require.NoError(t, err)
+		require.NoError(t, HandlePurgeTrashTask(context.Background(), asynq.NewTask(TaskTypePurgeTrash, payload)))
+
+		items, _, err := GetDeletedItems(articles.ID, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, recent.ID, items[0].ID)
+	})
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/database"
+	"github.com/gohead-cms/gohead/pkg/logger"
+)
+
+// SaveWebhook creates a new webhook.
+func SaveWebhook(webhook *models.Webhook) error {
+	if err := database.DB.Create(webhook).Error; err != nil {
+		logger.Log.WithError(err).WithField("webhook", webhook.Name).Error("Failed to create webhook")
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	logger.Log.WithField("webhook", webhook.Name).Info("Webhook created successfully")
+	return nil
+}
+
+// GetWebhookByName retrieves a webhook by its name.
+func GetWebhookByName(name string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := database.DB.Where("name = ?", name).First(&webhook).Error; err != nil {
+		return nil, fmt.Errorf("webhook '%s' not found: %w", name, err)
+	}
+	return &webhook, nil
+}
+
+// GetWebhookByID retrieves a webhook by its ID.
+func GetWebhookByID(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := database.DB.First(&webhook, id).Error; err != nil {
+		return nil, fmt.Errorf("webhook with ID %d not found: %w", id, err)
+	}
+	return &webhook, nil
+}
+
+// GetAllWebhooks retrieves all webhooks.
+func GetAllWebhooks() ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := database.DB.Order("id").Find(&webhooks).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to retrieve webhooks")
+		return nil, fmt.Errorf("failed to retrieve webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook saves the changes made to a webhook.
+func UpdateWebhook(webhook *models.Webhook) error {
+	if err := database.DB.Save(webhook).Error; err != nil {
+		logger.Log.WithError(err).WithField("webhook", webhook.Name).Error("Failed to update webhook")
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	logger.Log.WithField("webhook", webhook.Name).Info("Webhook updated successfully")
+	return nil
+}
+
+// DeleteWebhook deletes a webhook and its delivery log.
+func DeleteWebhook(id uint) error {
+	tx := database.DB.Begin()
+	if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	if err := tx.Delete(&models.Webhook{}, id).Error; err != nil {
+		tx.Rollback()
+		logger.Log.WithError(err).WithField("id", id).Error("Failed to delete webhook")
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit webhook deletion: %w", err)
+	}
+	logger.Log.WithField("id", id).Info("Webhook deleted successfully")
+	return nil
+}
+
+// FindWebhooksForEvent returns the enabled webhooks notified of an event of a collection.
+func FindWebhooksForEvent(collection, event string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := database.DB.Where("enabled = ?", true).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve webhooks: %w", err)
+	}
+	matching := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Matches(collection, event) {
+			matching = append(matching, webhook)
+		}
+	}
+	return matching, nil
+}
+
+// SaveWebhookDelivery creates or updates a webhook delivery.
+func SaveWebhookDelivery(delivery *models.WebhookDelivery) error {
+	if err := database.DB.Save(delivery).Error; err != nil {
+		return fmt.Errorf("failed to save webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookDelivery retrieves a delivery of a webhook by its ID.
+func GetWebhookDelivery(webhookID, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := database.DB.Where("webhook_id = ?", webhookID).First(&delivery, id).Error; err != nil {
+		return nil, fmt.Errorf("webhook delivery with ID %d not found: %w", id, err)
+	}
+	return &delivery, nil
+}
+
+// GetWebhookDeliveryByID retrieves a delivery by its ID, whatever its webhook.
+func GetWebhookDeliveryByID(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := database.DB.First(&delivery, id).Error; err != nil {
+		return nil, fmt.Errorf("webhook delivery with ID %d not found: %w", id, err)
+	}
+	return &delivery, nil
+}
+
+// GetWebhookDeliveries lists the deliveries of a webhook, newest first, optionally
+// filtered by status.
+func GetWebhookDeliveries(webhookID uint, status string, page, pageSize int) ([]models.WebhookDelivery, int, error) {
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	query := database.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch webhook deliveries: %w", err)
+	}
+	return deliveries, int(total), nil
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Webhook{}, &models.WebhookDelivery{}))
+
+	builder := models.Webhook{Name: "builder", URL: "https://example.com", Events: []string{"item:published"}, Collections: []string{"articles"}, Enabled: true}
+	indexer := models.Webhook{Name: "indexer", URL: "https://example.org", Events: []string{models.WebhookEventWildcard}, Enabled: true}
+	disabled := models.Webhook{Name: "disabled", URL: "https://example.net", Events: []string{models.WebhookEventWildcard}}
+	for _, webhook := range []*models.Webhook{&builder, &indexer, &disabled} {
+		require.NoError(t, SaveWebhook(webhook))
+	}
+
+	matching, err := FindWebhooksForEvent("articles", "item:published")
+	require.NoError(t, err)
+	require.Len(t, matching, 2)
+	assert.Equal(t, "builder", matching[0].Name)
+	assert.Equal(t, "indexer", matching[1].Name)
+
+	matching, err = FindWebhooksForEvent("pages", "item:published")
+	require.NoError(t, err)
+	require.Len(t, matching, 1)
+	assert.Equal(t, "indexer", matching[0].Name)
+
+	for i, status := range []string{models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed, models.WebhookDeliveryFailed} {
+		delivery := models.WebhookDelivery{WebhookID: builder.ID, Event: "item:published", Status: status, Attempts: i + 1}
+		require.NoError(t, SaveWebhookDelivery(&delivery))
+	}
+	deliveries, total, err := GetWebhookDeliveries(builder.ID, models.WebhookDeliveryFailed, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 3, deliveries[0].Attempts)
+
+	_, err = GetWebhookDelivery(indexer.ID, deliveries[0].ID)
+	assert.Error(t, err)
+
+	require.NoError(t, DeleteWebhook(builder.ID))
+	_, total, err = GetWebhookDeliveries(builder.ID, "", 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	_, err = GetWebhookByName("builder")
+	assert.Error(t, err)
+}
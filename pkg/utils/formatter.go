@@ -25,21 +25,49 @@ func FormatCollectionSchema(collection *models.Collection) map[string]any {
 			attrDef["target"] = "api::" + attr.Target + "." + attr.Target
 			attrDef["relation"] = attr.Relation
 		}
+		if attr.Type == "component" {
+			attrDef["component"] = attr.ComponentRef
+			attrDef["repeatable"] = attr.Repeatable
+		}
+		if attr.Type == "dynamiczone" {
+			attrDef["components"] = attr.Components
+		}
+		if len(attr.ReadRoles) > 0 {
+			attrDef["readRoles"] = attr.ReadRoles
+		}
+		if len(attr.WriteRoles) > 0 {
+			attrDef["writeRoles"] = attr.WriteRoles
+		}
+		if attr.Localized {
+			attrDef["localized"] = true
+		}
+		if attr.Searchable {
+			attrDef["searchable"] = true
+		}
+		if attr.Semantic {
+			attrDef["semantic"] = true
+		}
 		attrSchema[attr.Name] = attrDef
 	}
 
-	return map[string]any{
-		"id":  collection.ID,
-		"uid": "api::" + collection.Name + "." + collection.Name,
-		"schema": map[string]any{
-			"collectionName": collection.Name,
-			"info": map[string]any{
-				"singularName": singular,
-				"pluralName":   plural,
-				"displayName":  capitalize(collection.Name),
-			},
-			"attributes": attrSchema,
+	schema := map[string]any{
+		"collectionName": collection.Name,
+		"info": map[string]any{
+			"singularName": singular,
+			"pluralName":   plural,
+			"displayName":  capitalize(collection.Name),
 		},
+		"attributes": attrSchema,
+	}
+	if collection.IsLocalized() {
+		schema["locales"] = collection.Locales
+		schema["defaultLocale"] = collection.DefaultLocale
+	}
+
+	return map[string]any{
+		"id":     collection.ID,
+		"uid":    "api::" + collection.Name + "." + collection.Name,
+		"schema": schema,
 	}
 }
 
@@ -144,7 +172,7 @@ func FormatNestedItems(itemID uint, data models.JSONMap, collection *models.Coll
 				} else {
 					attributes[attr.Name] = val // fallback: put value directly
 				}
-			case "manyToMany":
+			case "oneToMany", "manyToMany":
 				arr := []any{}
 				if exists && val != nil {
 					switch list := val.(type) {
@@ -6,13 +6,22 @@ import (
 
 	"github.com/gohead-cms/gohead/pkg/database"
 	"github.com/gohead-cms/gohead/pkg/logger"
+
+	"gorm.io/gorm"
 )
 
-// CheckFieldUniqueness checks if a field value is unique within a collection.
+// CheckFieldUniqueness checks if a field value is unique within a collection. Deleted
+// items are ignored, so their values can be reused.
 func CheckFieldUniqueness(collectionID uint, fieldName string, value interface{}) error {
+	return CheckFieldUniquenessTx(database.DB, collectionID, fieldName, value)
+}
+
+// CheckFieldUniquenessTx is CheckFieldUniqueness within a transaction, which also sees the
+// items the transaction wrote.
+func CheckFieldUniquenessTx(tx *gorm.DB, collectionID uint, fieldName string, value interface{}) error {
 	var count int64
-	query := database.DB.Table("items").
-		Where("collection_id = ? AND data ->> ? = ?", collectionID, fieldName, value).
+	query := tx.Table("items").
+		Where("collection_id = ? AND deleted_at IS NULL AND data ->> ? = ?", collectionID, fieldName, value).
 		Count(&count)
 
 	if query.Error != nil {
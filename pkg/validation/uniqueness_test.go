@@ -33,7 +33,8 @@ func SetupTestDB() *gorm.DB {
 		CREATE TABLE items (
 			id INTEGER PRIMARY KEY,
 			collection_id INTEGER,
-			data JSON
+			data JSON,
+			deleted_at DATETIME
 		);
 	`)
 
@@ -49,6 +50,8 @@ func TestCheckFieldUniqueness(t *testing.T) {
 	testDB.Exec(`INSERT INTO items (collection_id, data) VALUES
 		(1, '{"field_name": "existing_value"}'),
 		(1, '{"field_name": "another_value"}');`)
+	testDB.Exec(`INSERT INTO items (collection_id, data, deleted_at) VALUES
+		(1, '{"field_name": "deleted_value"}', CURRENT_TIMESTAMP);`)
 
 	t.Run("Unique field value", func(t *testing.T) {
 		err := CheckFieldUniqueness(1, "field_name", "unique_value")
@@ -61,6 +64,11 @@ func TestCheckFieldUniqueness(t *testing.T) {
 		assert.Contains(t, err.Error(), "value for field 'field_name' must be unique")
 	})
 
+	t.Run("Value of a deleted item - should not conflict", func(t *testing.T) {
+		err := CheckFieldUniqueness(1, "field_name", "deleted_value")
+		assert.NoError(t, err)
+	})
+
 	t.Run("Different collection - should not conflict", func(t *testing.T) {
 		err := CheckFieldUniqueness(2, "field_name", "existing_value")
 		assert.NoError(t, err)
@@ -0,0 +1,311 @@
+// Package webhooks delivers content events to the HTTP endpoints of webhooks.
+//
+// Every delivery is a POST of a JSON payload, signed with the secret of the webhook:
+// the X-Gohead-Signature header holds "sha256=" followed by the hex-encoded
+// HMAC-SHA256 of the X-Gohead-Timestamp header, a dot and the request body.
+// Failed deliveries are retried with an exponential backoff.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/agent/events"
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/logger"
+	"github.com/gohead-cms/gohead/pkg/storage"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const (
+	// TaskTypeDeliver is the task sending a delivery to its webhook.
+	TaskTypeDeliver = "webhooks:deliver"
+	// QueueName is the Asynq queue of webhook deliveries.
+	QueueName = "webhooks"
+	// MaxRetry is the number of times a failed delivery is retried.
+	MaxRetry = 8
+
+	// Headers of the deliveries.
+	HeaderEvent     = "X-Gohead-Event"
+	HeaderDelivery  = "X-Gohead-Delivery"
+	HeaderTimestamp = "X-Gohead-Timestamp"
+	HeaderSignature = "X-Gohead-Signature"
+
+	// maxResponseBody is the size of the response bodies kept in the delivery log.
+	maxResponseBody = 4096
+	// baseRetryDelay and maxRetryDelay bound the backoff between two attempts.
+	baseRetryDelay = 30 * time.Second
+	maxRetryDelay  = 6 * time.Hour
+)
+
+// DeliveryPayload is the payload of a TaskTypeDeliver task.
+type DeliveryPayload struct {
+	DeliveryID uint `json:"delivery_id"`
+}
+
+// httpClient sends the deliveries. Endpoints have a few seconds to answer.
+var httpClient = newHTTPClient(false)
+
+// AllowPrivateNetworks lets deliveries reach loopback, private and link-local
+// addresses, such as endpoints on the host during development. They are refused by
+// default, so that webhooks cannot probe the network the workers run in.
+func AllowPrivateNetworks(allow bool) {
+	httpClient = newHTTPClient(allow)
+}
+
+// newHTTPClient returns the client of the deliveries. Unless allowPrivate, the address
+// of every connection is checked once resolved, which also covers redirects and
+// host names resolving to private addresses.
+func newHTTPClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("webhook endpoint address %s is not public", addrPort.Addr())
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		// Deliveries are sent directly, a proxy would be the address checked.
+		Transport: &http.Transport{DialContext: dialer.DialContext, ForceAttemptHTTP2: true, TLSHandshakeTimeout: 5 * time.Second},
+	}
+}
+
+// Ranges not covered by the methods of netip.Addr: the "this network" range and the
+// carrier-grade NAT one.
+var (
+	thisNetwork        = netip.MustParsePrefix("0.0.0.0/8")
+	sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+)
+
+// isPublicAddr reports whether addr is a public unicast address, rather than a
+// loopback, private, link-local (cloud metadata services among them) or unspecified
+// one.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !thisNetwork.Contains(addr) && !sharedAddressSpace.Contains(addr)
+}
+
+// Sign returns the signature of a delivery body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryDelay is the exponential backoff of failed deliveries, for the RetryDelayFunc
+// of the worker. Other tasks keep the default delays of Asynq.
+func RetryDelay(n int, err error, task *asynq.Task) time.Duration {
+	if task.Type() != TaskTypeDeliver {
+		return asynq.DefaultRetryDelayFunc(n, err, task)
+	}
+	delay := baseRetryDelay << min(n, 20)
+	return min(delay, maxRetryDelay)
+}
+
+// Dispatch records a delivery of a collection event for every webhook subscribed to it,
+// and enqueues them.
+func Dispatch(ctx context.Context, client *asynq.Client, event events.CollectionEventPayload) error {
+	webhooks, err := storage.FindWebhooksForEvent(event.CollectionName, string(event.EventType))
+	if err != nil {
+		return err
+	}
+
+	payload := models.JSONMap{
+		"event":      string(event.EventType),
+		"collection": event.CollectionName,
+		"created_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	if event.ItemID != 0 {
+		payload["item_id"] = event.ItemID
+	}
+	if event.ItemData != nil {
+		payload["data"] = event.ItemData
+	}
+
+	for _, webhook := range webhooks {
+		delivery := &models.WebhookDelivery{
+			WebhookID: webhook.ID,
+			Event:     string(event.EventType),
+			Payload:   payload,
+			Status:    models.WebhookDeliveryPending,
+		}
+		if err := storage.SaveWebhookDelivery(delivery); err != nil {
+			return err
+		}
+		if err := Enqueue(ctx, client, delivery.ID); err != nil {
+			logger.Log.WithError(err).WithField("webhook", webhook.Name).Error("Failed to enqueue webhook delivery")
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.Error = err.Error()
+			if err := storage.SaveWebhookDelivery(delivery); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Redeliver records a new delivery of the payload of a previous one and enqueues it.
+func Redeliver(ctx context.Context, client *asynq.Client, previous *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{
+		WebhookID:    previous.WebhookID,
+		Event:        previous.Event,
+		Payload:      previous.Payload,
+		Status:       models.WebhookDeliveryPending,
+		RedeliveryOf: &previous.ID,
+	}
+	if err := storage.SaveWebhookDelivery(delivery); err != nil {
+		return nil, err
+	}
+	if err := Enqueue(ctx, client, delivery.ID); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Enqueue enqueues the sending of a recorded delivery.
+func Enqueue(ctx context.Context, client *asynq.Client, deliveryID uint) error {
+	payloadBytes, err := json.Marshal(DeliveryPayload{DeliveryID: deliveryID})
+	if err != nil {
+		return fmt.Errorf("could not marshal payload: %w", err)
+	}
+	task := asynq.NewTask(TaskTypeDeliver, payloadBytes, asynq.Queue(QueueName), asynq.MaxRetry(MaxRetry))
+
+	info, err := client.EnqueueContext(ctx, task)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to enqueue webhook delivery task")
+		return fmt.Errorf("could not enqueue task: %w", err)
+	}
+	logger.Log.
+		WithField("job_id", info.ID).
+		WithField("delivery_id", deliveryID).
+		Info("Successfully enqueued webhook delivery task")
+	return nil
+}
+
+// HandleDeliveryTask sends a delivery to its webhook and records the response. It
+// returns an error, so the task is retried, unless the endpoint answered with a 2xx
+// status. The delivery is marked as failed once the retries are exhausted.
+func HandleDeliveryTask(ctx context.Context, task *asynq.Task) error {
+	var payload DeliveryPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid webhook delivery payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	delivery, err := storage.GetWebhookDeliveryByID(payload.DeliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Log.WithField("delivery_id", payload.DeliveryID).Info("Webhook delivery was deleted, skipping it")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	webhook, err := storage.GetWebhookByID(delivery.WebhookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Log.WithField("delivery_id", delivery.ID).Info("Webhook was deleted, skipping its delivery")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	deliverErr := Deliver(ctx, webhook, delivery)
+	retries, _ := asynq.GetRetryCount(ctx)
+	maxRetry, ok := asynq.GetMaxRetry(ctx)
+	switch {
+	case deliverErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+	case errors.Is(deliverErr, asynq.SkipRetry) || !ok || retries >= maxRetry:
+		delivery.Status = models.WebhookDeliveryFailed
+	default:
+		delivery.Status = models.WebhookDeliveryPending
+	}
+	if err := storage.SaveWebhookDelivery(delivery); err != nil {
+		return err
+	}
+
+	if deliverErr != nil {
+		logger.Log.
+			WithError(deliverErr).
+			WithField("webhook", webhook.Name).
+			WithField("delivery_id", delivery.ID).
+			WithField("attempts", delivery.Attempts).
+			Warn("Webhook delivery failed")
+		return deliverErr
+	}
+	logger.Log.WithField("webhook", webhook.Name).WithField("delivery_id", delivery.ID).Info("Webhook delivered")
+	return nil
+}
+
+// Deliver sends a delivery to a webhook once and records the response, or the error,
+// on the delivery. It returns an error when the endpoint did not answer with a 2xx
+// status.
+func Deliver(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %v: %w", err, asynq.SkipRetry)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v: %w", err, asynq.SkipRetry)
+	}
+
+	timestamp := time.Now().Unix()
+	for name, value := range webhook.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoHead-Webhooks")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	delivery.Attempts++
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	now := time.Now()
+	delivery.DeliveredAt = &now
+	if err != nil {
+		delivery.StatusCode = 0
+		delivery.ResponseBody = ""
+		delivery.Error = err.Error()
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	delivery.StatusCode = resp.StatusCode
+	delivery.ResponseBody = strings.ToValidUTF8(string(responseBody), "")
+	delivery.Error = ""
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		delivery.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		return fmt.Errorf("webhook endpoint answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
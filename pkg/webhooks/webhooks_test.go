@@ -0,0 +1,133 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gohead-cms/gohead/internal/models"
+	"github.com/gohead-cms/gohead/pkg/storage"
+	"github.com/gohead-cms/gohead/pkg/testutils"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDeliveryTask(t *testing.T) {
+	_, db := testutils.SetupTestServer()
+	defer testutils.CleanupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Webhook{}, &models.WebhookDelivery{}))
+	// The test endpoint listens on the loopback interface.
+	AllowPrivateNetworks(true)
+	defer AllowPrivateNetworks(false)
+
+	status := http.StatusOK
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("thanks"))
+	}))
+	defer server.Close()
+
+	webhook := models.Webhook{
+		Name:    "builder",
+		URL:     server.URL,
+		Events:  []string{models.WebhookEventWildcard},
+		Secret:  "s3cret",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		Enabled: true,
+	}
+	require.NoError(t, storage.SaveWebhook(&webhook))
+	delivery := models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		Event:     "item:published",
+		Payload:   models.JSONMap{"event": "item:published", "collection": "articles", "item_id": 7},
+		Status:    models.WebhookDeliveryPending,
+	}
+	require.NoError(t, storage.SaveWebhookDelivery(&delivery))
+	task := func() *asynq.Task {
+		payload, _ := json.Marshal(DeliveryPayload{DeliveryID: delivery.ID})
+		return asynq.NewTask(TaskTypeDeliver, payload)
+	}
+
+	require.NoError(t, HandleDeliveryTask(context.Background(), task()))
+	require.NotNil(t, received)
+	assert.Equal(t, "Bearer token", received.Header.Get("Authorization"))
+	assert.Equal(t, "item:published", received.Header.Get(HeaderEvent))
+	assert.Equal(t, strconv.FormatUint(uint64(delivery.ID), 10), received.Header.Get(HeaderDelivery))
+	timestamp, err := strconv.ParseInt(received.Header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, Sign("s3cret", timestamp, receivedBody), received.Header.Get(HeaderSignature))
+	assert.JSONEq(t, `{"event": "item:published", "collection": "articles", "item_id": 7}`, string(receivedBody))
+
+	logged, err := storage.GetWebhookDeliveryByID(delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliverySucceeded, logged.Status)
+	assert.Equal(t, http.StatusOK, logged.StatusCode)
+	assert.Equal(t, "thanks", logged.ResponseBody)
+	assert.Equal(t, 1, logged.Attempts)
+
+	// Failed attempts are recorded and returned as errors, so the task is retried.
+	status = http.StatusBadGateway
+	assert.Error(t, HandleDeliveryTask(context.Background(), task()))
+	logged, err = storage.GetWebhookDeliveryByID(delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryFailed, logged.Status)
+	assert.Equal(t, http.StatusBadGateway, logged.StatusCode)
+	assert.Equal(t, 2, logged.Attempts)
+	assert.NotEmpty(t, logged.Error)
+
+	// Deliveries of deleted webhooks are dropped.
+	require.NoError(t, storage.DeleteWebhook(webhook.ID))
+	assert.NoError(t, HandleDeliveryTask(context.Background(), task()))
+}
+
+func TestRetryDelay(t *testing.T) {
+	deliver := asynq.NewTask(TaskTypeDeliver, nil)
+	assert.Equal(t, 30*time.Second, RetryDelay(0, nil, deliver))
+	assert.Equal(t, 4*time.Minute, RetryDelay(3, nil, deliver))
+	assert.Equal(t, 6*time.Hour, RetryDelay(12, nil, deliver))
+}
+
+func TestPrivateNetworksRefused(t *testing.T) {
+	reached := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	webhook := models.Webhook{Name: "internal", URL: server.URL, Events: []string{models.WebhookEventWildcard}}
+	delivery := models.WebhookDelivery{Event: "item:published", Payload: models.JSONMap{}}
+	require.Error(t, Deliver(context.Background(), &webhook, &delivery))
+	assert.False(t, reached)
+	assert.Contains(t, delivery.Error, "is not public")
+	assert.Empty(t, delivery.ResponseBody)
+
+	for address, public := range map[string]bool{
+		"93.184.215.14":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.0.0.8":        false,
+		"172.16.4.2":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		assert.Equal(t, public, isPublicAddr(netip.MustParseAddr(address)), address)
+	}
+}